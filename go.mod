@@ -6,12 +6,20 @@ require (
 	github.com/zapscloud/golib-auth v1.0.1-0.20231117124031-5c253c2f7c88
 	github.com/zapscloud/golib-dbutils v1.1.1-0.20231016071702-b6e244391427
 	github.com/zapscloud/golib-platform v1.0.1-0.20231017073401-c864d398e548
+	github.com/zapscloud/golib-platform-service v0.0.0-20231104052444-07da4e75a984
 	github.com/zapscloud/golib-utils v1.0.1-0.20231117081529-93ad4f30cea1
 	go.mongodb.org/mongo-driver v1.12.1
+	go.opentelemetry.io/otel v1.19.0
+	go.opentelemetry.io/otel/metric v1.19.0
+	go.opentelemetry.io/otel/sdk v1.19.0
+	go.opentelemetry.io/otel/trace v1.19.0
+	golang.org/x/crypto v0.2.0
 )
 
 require (
 	github.com/andybalholm/brotli v1.0.4 // indirect
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-sql-driver/mysql v1.6.0 // indirect
 	github.com/gofiber/fiber/v2 v2.36.0 // indirect
 	github.com/gofiber/utils v1.0.1 // indirect
@@ -30,9 +38,7 @@ require (
 	github.com/youmark/pkcs8 v0.0.0-20201027041543-1326539a0a0a // indirect
 	github.com/zapscloud/golib v1.0.4 // indirect
 	github.com/zapscloud/golib-platform-repository v0.0.0-20231104045312-797a30003891 // indirect
-	github.com/zapscloud/golib-platform-service v0.0.0-20231104052444-07da4e75a984 // indirect
-	golang.org/x/crypto v0.2.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
 )
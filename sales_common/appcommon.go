@@ -12,37 +12,126 @@ const (
 	// Database Prefix
 	DbPrefix = db_common.DB_COLLECTION_PREFIX
 	// Collection Names
-	DbRegions           = DbPrefix + "sales_regions"
-	DbBanners           = DbPrefix + "sales_banners"
-	DbBrands            = DbPrefix + "sales_brands"
-	DbCatalogues        = DbPrefix + "sales_catalogues"
-	DbCategories        = DbPrefix + "sales_categories"
-	DbProducts          = DbPrefix + "sales_products"
-	DbTestimonials      = DbPrefix + "sales_testimonials"
-	DbBlogs             = DbPrefix + "sales_blogs"
-	DbCustomers         = DbPrefix + "sales_customers"
-	DbCustomerOrders    = DbPrefix + "sales_customer_orders"
-	DbCustomerCarts     = DbPrefix + "sales_customer_carts"
-	DbCustomerWishlists = DbPrefix + "sales_customer_wishlists"
-	DbCustomerReviews   = DbPrefix + "sales_customer_reviews"
-	DbPolicies          = DbPrefix + "sales_policies"
-	DbPayments          = DbPrefix + "sales_payments"
-	DbNavigations       = DbPrefix + "sales_navigations"
-	DbPreferences       = DbPrefix + "sales_preferences"
-	DbProdPreferences   = DbPrefix + "sales_prod_preferences"
-	DbPages             = DbPrefix + "sales_pages"
-	DbDealers           = DbPrefix + "sales_dealers"
-	DbOffers            = DbPrefix + "sales_offers"
-	DbMedias            = DbPrefix + "sales_medias"
-	DbQuiz              = DbPrefix + "sales_quiz"
-	DbCampaigns         = DbPrefix + "sales_campaigns"
-	DbStates            = DbPrefix + "sales_states"
-	DbRatings           = DbPrefix + "sales_ratings"
-	DbMaterialTypes     = DbPrefix + "sales_material_types"
-	DbCoupons           = DbPrefix + "sales_coupons"
-	DbCustomerTypes     = DbPrefix + "sales_customer_types"
-	DbTerritories       = DbPrefix + "sales_territories"
-	DbCallbacks         = DbPrefix + "sales_callbacks"
+	DbRegions            = DbPrefix + "sales_regions"
+	DbBanners            = DbPrefix + "sales_banners"
+	DbBrands             = DbPrefix + "sales_brands"
+	DbCatalogues         = DbPrefix + "sales_catalogues"
+	DbCategories         = DbPrefix + "sales_categories"
+	DbProducts           = DbPrefix + "sales_products"
+	DbTestimonials       = DbPrefix + "sales_testimonials"
+	DbBlogs              = DbPrefix + "sales_blogs"
+	DbCustomers          = DbPrefix + "sales_customers"
+	DbCustomerOrders     = DbPrefix + "sales_customer_orders"
+	DbCustomerCarts      = DbPrefix + "sales_customer_carts"
+	DbCustomerWishlists  = DbPrefix + "sales_customer_wishlists"
+	DbCustomerReviews    = DbPrefix + "sales_customer_reviews"
+	DbPolicies           = DbPrefix + "sales_policies"
+	DbPayments           = DbPrefix + "sales_payments"
+	DbNavigations        = DbPrefix + "sales_navigations"
+	DbPreferences        = DbPrefix + "sales_preferences"
+	DbProdPreferences    = DbPrefix + "sales_prod_preferences"
+	DbPages              = DbPrefix + "sales_pages"
+	DbDealers            = DbPrefix + "sales_dealers"
+	DbOffers             = DbPrefix + "sales_offers"
+	DbMedias             = DbPrefix + "sales_medias"
+	DbQuiz               = DbPrefix + "sales_quiz"
+	DbCampaigns          = DbPrefix + "sales_campaigns"
+	DbStates             = DbPrefix + "sales_states"
+	DbRatings            = DbPrefix + "sales_ratings"
+	DbMaterialTypes      = DbPrefix + "sales_material_types"
+	DbCoupons            = DbPrefix + "sales_coupons"
+	DbCustomerTypes      = DbPrefix + "sales_customer_types"
+	DbTerritories        = DbPrefix + "sales_territories"
+	DbCallbacks          = DbPrefix + "sales_callbacks"
+	DbMigrations         = DbPrefix + "sales_migrations"
+	DbCustomerTokens     = DbPrefix + "sales_customer_tokens"
+	DbLoginAttempts      = DbPrefix + "sales_login_attempts"
+	DbLoginEvents        = DbPrefix + "sales_login_events"
+	DbCustomerAddresses  = DbPrefix + "sales_customer_addresses"
+	DbPriceTiers         = DbPrefix + "sales_price_tiers"
+	DbLoyaltyTxns        = DbPrefix + "sales_loyalty_txns"
+	DbLoyaltyBalances    = DbPrefix + "sales_loyalty_balances"
+	DbCustomerMerges     = DbPrefix + "sales_customer_merges"
+	DbCustomerIdentities = DbPrefix + "sales_customer_identities"
+	DbCustomerSessions   = DbPrefix + "sales_customer_sessions"
+	DbCustomerConsents   = DbPrefix + "sales_customer_consents"
+	DbConsentEvents      = DbPrefix + "sales_consent_events"
+	DbCreditAccounts     = DbPrefix + "sales_credit_accounts"
+	DbCreditInvoices     = DbPrefix + "sales_credit_invoices"
+	DbProductVariants    = DbPrefix + "sales_product_variants"
+	DbCatalogueItems     = DbPrefix + "sales_catalogue_items"
+	DbCatalogueVersions  = DbPrefix + "sales_catalogue_versions"
+	DbPriceLists         = DbPrefix + "sales_price_lists"
+	DbPriceListItems     = DbPrefix + "sales_price_list_items"
+	DbInventory          = DbPrefix + "sales_inventory"
+	DbStockReservations  = DbPrefix + "sales_stock_reservations"
+	DbWarehouses         = DbPrefix + "sales_warehouses"
+)
+
+// Address types
+const (
+	ADDRESS_TYPE_SHIPPING = "shipping"
+	ADDRESS_TYPE_BILLING  = "billing"
+	ADDRESS_TYPE_BOTH     = "both" // Default
+)
+
+// Price Tier scopes, the Tier for the Product overrides the one for its Category,
+// which overrides the one for all the Products
+const (
+	PRICE_TIER_SCOPE_ALL      = "all"
+	PRICE_TIER_SCOPE_CATEGORY = "category"
+	PRICE_TIER_SCOPE_PRODUCT  = "product"
+)
+
+// Price Lists
+const (
+	// Business Preference for the currency of the product_price, used when no Price List has the Product, e.g.
+	// { "preference_id": "pricing", "default_currency": "INR" }
+	PREFERENCE_PRICING = "pricing"
+)
+
+// Inventory
+const (
+	RESERVATION_STATUS_ACTIVE    = "active"
+	RESERVATION_STATUS_RELEASED  = "released"
+	RESERVATION_STATUS_COMMITTED = "committed" // Taken from the on hand stock
+	RESERVATION_STATUS_EXPIRED   = "expired"
+	RESERVATION_STATUS_RESTOCKED = "restocked" // Committed, then put back on hand when the Order failed
+
+	// Business Preference for how long the Carts and Orders hold the stock, 0 to hold till released, e.g.
+	// { "preference_id": "inventory", "cart_reservation_minutes": 30, "order_reservation_minutes": 0, "allow_split": true }.
+	// Carts hold for 30 minutes and Orders till confirmed or failed by default. allow_split lets the Orders be
+	// fulfilled from more than one location, when the Order does not say
+	PREFERENCE_INVENTORY = "inventory"
+
+	DEFAULT_CART_RESERVATION_MINUTES = 30
+
+	// Stock locations, Inventory without location_type is the default stock of the business
+	LOCATION_TYPE_WAREHOUSE = "warehouse"
+	LOCATION_TYPE_DEALER    = "dealer"
+)
+
+// Catalogue Item types
+const (
+	CATALOGUE_ITEM_TYPE_PRODUCT  = "product"
+	CATALOGUE_ITEM_TYPE_CATEGORY = "category"
+)
+
+// Loyalty Points
+const (
+	// Transaction types, earn/refund and positive adjust add points, redeem/expire and negative adjust deduct.
+	// Points are deducted from the oldest earned first
+	LOYALTY_TXN_EARN   = "earn"   // For a delivered Order
+	LOYALTY_TXN_REDEEM = "redeem" // At checkout of an Order
+	LOYALTY_TXN_REFUND = "refund" // Redeemed points returned when the Order failed
+	LOYALTY_TXN_EXPIRE = "expire"
+	LOYALTY_TXN_ADJUST = "adjust" // By admin
+
+	// Business Preference to configure the earn and redeem rules, e.g. earn 1 point for each 10
+	// spent, redeem 1 point as 0.5 for at most 20% of the order, points expire in a year
+	// { "preference_id": "loyalty", "loyalty_enabled": true, "earn_rate": 0.1, "earn_min_order": 500,
+	//   "redeem_value": 0.5, "redeem_min_points": 100, "redeem_max_percent": 20, "points_expiry_days": 365 }
+	PREFERENCE_LOYALTY = "loyalty"
 )
 
 const (
@@ -53,6 +142,221 @@ const (
 	ORDER_STATUS_DELIVERED = "delivered"
 )
 
+// Import / Export
+const (
+	// Entities supported for Import/Export
+	DATAIO_ENTITY_PRODUCTS   = "products"
+	DATAIO_ENTITY_BRANDS     = "brands"
+	DATAIO_ENTITY_CATEGORIES = "categories"
+	DATAIO_ENTITY_DEALERS    = "dealers"
+
+	// File Formats
+	DATAIO_FORMAT_CSV   = "csv"
+	DATAIO_FORMAT_JSONL = "jsonl" // JSON Lines, one document per line
+
+	// Fields in Import/Export report
+	DATAIO_REPORT_TOTAL    = "total"
+	DATAIO_REPORT_CREATED  = "created"
+	DATAIO_REPORT_UPDATED  = "updated"
+	DATAIO_REPORT_FAILED   = "failed"
+	DATAIO_REPORT_DRY_RUN  = "dry_run"
+	DATAIO_REPORT_EXPORTED = "exported"
+	DATAIO_REPORT_ERRORS   = "errors"
+	DATAIO_REPORT_ROW      = "row"
+	DATAIO_REPORT_ID       = "id"
+	DATAIO_REPORT_ERROR    = "error"
+)
+
+// Customer Authentication
+const (
+	// Grant type and AuthData field for OTP login
+	GRANT_TYPE_OTP = "otp"
+	AUTH_OTP       = "otp"
+
+	// AuthData fields for Refresh Token grant
+	AUTH_REFRESH_TOKEN = "refresh_token"
+	AUTH_CLIENT_ID     = "client_id"
+
+	REFRESH_TOKEN_VALIDITY_DAYS = 30
+
+	// AuthData fields for the Session started by the login, device is given by the client, e.g. "Pixel 8 / Android 14"
+	AUTH_DEVICE     = "device"
+	AUTH_SESSION_ID = "session_id"
+
+	// AuthData field and values for the identifier the Customer logins with
+	AUTH_LOGIN_TYPE    = "login_type"
+	LOGIN_TYPE_LOGINID = "loginid"
+	LOGIN_TYPE_EMAIL   = "email"
+	LOGIN_TYPE_PHONE   = "phone"
+
+	// Grant type and AuthData fields for sign in with the OpenID Connect ID Token of an identity provider
+	GRANT_TYPE_ID_TOKEN    = "id_token"
+	AUTH_ID_TOKEN          = "id_token"
+	AUTH_IDENTITY_PROVIDER = "identity_provider"
+	AUTH_NONCE             = "nonce" // Required when the authorize request set the nonce, verified with the nonce claim
+
+	// Business Preference to configure the identity providers, jwks is the inline key set used instead
+	// of fetching the jwks_uri, e.g.
+	// { "preference_id": "oidc_providers", "oidc_providers": [{ "provider": "google", "issuer": "https://accounts.google.com",
+	//   "client_ids": ["<client id>"], "jwks_uri": "https://www.googleapis.com/oauth2/v3/certs" }] }
+	PREFERENCE_OIDC_PROVIDERS = "oidc_providers"
+
+	// AuthData field for the Guest cart Token, the Guest cart is merged into the Customer's cart on login
+	AUTH_GUEST_CART_TOKEN = "guest_cart_token"
+
+	// Business Preference to configure the Customer login, e.g.
+	// { "preference_id": "customer_login", "login_types": ["loginid", "email", "phone"], "phone_country_code": "91" }
+	PREFERENCE_CUSTOMER_LOGIN = "customer_login"
+
+	OTP_LENGTH           = 6
+	OTP_VALIDITY_MINUTES = 5
+	OTP_MAX_ATTEMPTS     = 5
+
+	// Failed logins, counted for each login identifier and each Customer. After LOGIN_DELAY_AFTER_ATTEMPTS
+	// failures the next attempt is delayed 1, 2, 4.. seconds up to LOGIN_DELAY_MAX_SECONDS, after
+	// LOGIN_LOCKOUT_ATTEMPTS failures the login is locked for LOGIN_LOCKOUT_MINUTES
+	LOGIN_DELAY_AFTER_ATTEMPTS = 3
+	LOGIN_DELAY_MAX_SECONDS    = 60
+	LOGIN_LOCKOUT_ATTEMPTS     = 10
+	LOGIN_LOCKOUT_MINUTES      = 15
+
+	// Email/Phone verification, Email is verified with a signed link Token and Phone with a Code.
+	// Verification can be resent after VERIFY_RESEND_SECONDS and at most VERIFY_MAX_SENDS_PER_DAY times
+	VERIFY_TOKEN_VALIDITY_HOURS  = 24
+	VERIFY_CODE_VALIDITY_MINUTES = 10
+	VERIFY_CODE_MAX_ATTEMPTS     = 5
+	VERIFY_RESEND_SECONDS        = 60
+	VERIFY_MAX_SENDS_PER_DAY     = 5
+
+	// Password reset, only the latest reset Token is valid and it can be used once.
+	// Reset can be requested again after VERIFY_RESEND_SECONDS
+	PASSWORD_RESET_VALIDITY_MINUTES = 30
+	PASSWORD_RESET_TOKEN_PURPOSE    = "password_reset" // Payload value, so other signed Tokens can not be used
+
+	// Login security event types
+	LOGIN_EVENT_LOCKED   = "locked"
+	LOGIN_EVENT_UNLOCKED = "unlocked"
+)
+
+// Guest carts
+const (
+	GUEST_CART_TOKEN_PURPOSE = "guest_cart" // Payload value, so other signed Tokens can not be used
+
+	// Business Preference to configure how the quantity is merged when the Guest and the Customer
+	// carts have the same Product, e.g. { "preference_id": "cart_merge", "cart_merge_rule": "max" }
+	PREFERENCE_CART_MERGE = "cart_merge"
+
+	CART_MERGE_RULE_SUM      = "sum" // Default, add the quantities
+	CART_MERGE_RULE_MAX      = "max" // Larger of the quantities
+	CART_MERGE_RULE_CUSTOMER = "customer"
+	CART_MERGE_RULE_GUEST    = "guest"
+
+	// Fields in Cart merge report
+	CART_MERGE_REPORT_MOVED  = "moved"  // Products only in the Guest cart
+	CART_MERGE_REPORT_MERGED = "merged" // Products in both the carts
+)
+
+// Customer duplicates and merge
+const (
+	// Fields in Duplicate Customers report
+	DUPLICATE_REPORT_MATCH_FIELD  = "match_field" // customer_email, customer_phone or customer_name
+	DUPLICATE_REPORT_MATCH_VALUE  = "match_value"
+	DUPLICATE_REPORT_CUSTOMER_IDS = "customer_ids"
+
+	// Records moved to the surviving Customer, in merge_counts of the Customer Merge
+	MERGE_COUNT_ORDERS          = "orders"
+	MERGE_COUNT_CARTS           = "carts"
+	MERGE_COUNT_WISHLISTS       = "wishlists"
+	MERGE_COUNT_REVIEWS         = "reviews"
+	MERGE_COUNT_LOYALTY_TXNS    = "loyalty_txns"
+	MERGE_COUNT_IDENTITIES      = "identities"
+	MERGE_COUNT_ADDRESSES       = "addresses"
+	MERGE_COUNT_CONSENTS        = "consents"
+	MERGE_COUNT_CONSENT_EVENTS  = "consent_events"
+	MERGE_COUNT_RESERVATIONS    = "stock_reservations"
+	MERGE_COUNT_CREDIT_INVOICES = "credit_invoices"
+
+	// Status of the Customer Merge, a merge stopped by a failure stays in progress and is resumed by
+	// the next merge to the same Customer
+	MERGE_STATUS_IN_PROGRESS = "in_progress"
+	MERGE_STATUS_COMPLETED   = "completed"
+)
+
+// Credit for B2B buyers
+const (
+	// Credit Account is for a Customer or a Dealer
+	CREDIT_PARTY_CUSTOMER = "customer"
+	CREDIT_PARTY_DEALER   = "dealer"
+
+	// Orders with this payment_mode are bought on credit, the Dealer's Account is charged if the Order
+	// has the dealer_id, else the Customer's. The Invoice is due after the payment terms of the Account
+	PAYMENT_MODE_CREDIT = "credit"
+
+	// Invoice status, void when the Order failed
+	INVOICE_STATUS_OPEN = "open"
+	INVOICE_STATUS_PAID = "paid"
+	INVOICE_STATUS_VOID = "void"
+
+	// Aging report, the due amount of the open Invoices in buckets of the days past due
+	AGING_REPORT_AS_OF  = "as_of"
+	AGING_REPORT_ROWS   = "rows"    // One for each Account, with its currency, the buckets and total
+	AGING_REPORT_TOTALS = "totals"  // Buckets and total by currency
+	AGING_CURRENT       = "current" // Not yet due
+	AGING_DAYS_1_30     = "days_1_30"
+	AGING_DAYS_31_60    = "days_31_60"
+	AGING_DAYS_61_90    = "days_61_90"
+	AGING_DAYS_OVER_90  = "days_over_90"
+	AGING_TOTAL         = "total"
+)
+
+// Marketing consent
+const (
+	// Consent status, with double opt-in the consent is pending till the Customer confirms it
+	CONSENT_STATUS_PENDING   = "pending"
+	CONSENT_STATUS_GRANTED   = "granted"
+	CONSENT_STATUS_WITHDRAWN = "withdrawn"
+
+	// Consent sources set by the service, other sources (signup, checkout, account, admin..) are given by the caller
+	CONSENT_SOURCE_CONFIRMATION = "double_opt_in"
+	CONSENT_SOURCE_UNSUBSCRIBE  = "unsubscribe_link"
+
+	// Double opt-in confirmation Token is valid for CONSENT_CONFIRM_VALIDITY_HOURS, only the latest one is valid.
+	// Unsubscribe Token never expires, so the links in the messages sent keep working
+	CONSENT_CONFIRM_VALIDITY_HOURS    = 72
+	CONSENT_CONFIRM_TOKEN_PURPOSE     = "consent_confirm"     // Payload value, so other signed Tokens can not be used
+	CONSENT_UNSUBSCRIBE_TOKEN_PURPOSE = "consent_unsubscribe" // Payload value, so other signed Tokens can not be used
+
+	// Business Preference to configure the channels needing double opt-in, none if not configured, e.g.
+	// { "preference_id": "marketing_consent", "double_opt_in": ["email", "whatsapp"] }
+	PREFERENCE_MARKETING_CONSENT = "marketing_consent"
+
+	// Fields in Campaign send report
+	CAMPAIGN_REPORT_SENT    = "sent"
+	CAMPAIGN_REPORT_FAILED  = "failed"
+	CAMPAIGN_REPORT_SKIPPED = "skipped" // Consented Customers without the email/phone
+
+	CAMPAIGN_SEND_BATCH_SIZE = 500
+
+	// Campaign is claimed as sending before the first message, so it is sent only once. Sending Campaign
+	// with no progress for CAMPAIGN_SEND_STALE_MINUTES is taken as stopped, and resumed after the last
+	// batch sent by the next SendCampaign
+	CAMPAIGN_STATUS_SENDING     = "sending"
+	CAMPAIGN_STATUS_SENT        = "sent"
+	CAMPAIGN_SEND_STALE_MINUTES = 15
+)
+
+// Migrations
+const (
+	MIGRATION_MODE_UP      = "up"
+	MIGRATION_MODE_DRY_RUN = "dry_run"
+
+	// Fields in Migration report
+	MIGRATION_REPORT_MODE    = "mode"
+	MIGRATION_REPORT_APPLIED = "applied"
+	MIGRATION_REPORT_PENDING = "pending"
+	MIGRATION_REPORT_SKIPPED = "skipped"
+)
+
 // Product Module table fields
 const (
 	// Common fields for all tables
@@ -66,6 +370,9 @@ const (
 	FLD_REGION_PINCODE_FROM = "pincode_from"
 	FLD_REGION_PINCODE_TO   = "pincode_to"
 
+	// Pincodes are numeric of fixed length, so the ranges stored as strings compare the same as numbers
+	PINCODE_LENGTH = 6
+
 	// Fields for Banner
 	FLD_BANNER_ID   = "banner_id"
 	FLD_BANNER_NAME = "banner_name"
@@ -81,13 +388,108 @@ const (
 	FLD_CATALOGUE_ID   = "catalogue_id"
 	FLD_CATALOGUE_NAME = "catalogue_name"
 
+	// Fields for Price List, prices in the currency for the Customer type and/or Region, empty for all, valid from
+	// valid_from and until valid_to if given. The most specific Price List having the Product wins, then the
+	// one with higher price_list_priority, then the one valid from later
+	FLD_PRICE_LIST_ID       = "price_list_id"
+	FLD_PRICE_LIST_NAME     = "price_list_name"
+	FLD_PRICE_LIST_PRIORITY = "price_list_priority"
+	FLD_CURRENCY            = "currency" // ISO 4217 code
+	FLD_VALID_FROM          = "valid_from"
+	FLD_VALID_TO            = "valid_to"
+	FLD_DEFAULT_CURRENCY    = "default_currency"
+
+	// Fields for Inventory of the Product, or of its Variant if variant_id given. Products without Inventory
+	// are not tracked, so are never short of stock. stock_available is on hand less reserved, set in Get
+	FLD_INVENTORY_ID    = "inventory_id"
+	FLD_STOCK_ON_HAND   = "stock_on_hand"
+	FLD_STOCK_RESERVED  = "stock_reserved"
+	FLD_STOCK_AVAILABLE = "stock_available"
+	FLD_STOCK_LOCATIONS = "stock_locations" // Inventory of each location, in the stock of the Product
+
+	// Fields for the location of the Inventory, a Warehouse or a Dealer. Locations have the address_pincode
+	// and the sales_region_id, resolved from the pincode if not given, to source the Orders from the nearest
+	FLD_LOCATION_TYPE = "location_type"
+	FLD_LOCATION_ID   = "location_id"
+
+	// Fields for Stock Reservation, held by the Cart with cart_id or the Order with customer_order_id. The
+	// Cart and each of the order_items have the reservation_id
+	FLD_RESERVATION_ID         = "reservation_id"
+	FLD_RESERVATION_STATUS     = "reservation_status"
+	FLD_RESERVATION_EXPIRES_AT = "reservation_expires_at"
+
+	FLD_CART_RESERVATION_MINUTES  = "cart_reservation_minutes"
+	FLD_ORDER_RESERVATION_MINUTES = "order_reservation_minutes"
+
+	// Fields for sourcing the Orders, each of the order_items has the allocations of its quantity to the
+	// locations, with location_type, location_id, inventory_id, quantity and reservation_id
+	FLD_ALLOW_SPLIT      = "allow_split"
+	FLD_ITEM_ALLOCATIONS = "allocations"
+
+	// Fields for Price List Item, the Decimal128 price of the Product, or of its Variant if variant_id given
+	FLD_PRICE_LIST_ITEM_ID = "price_list_item_id"
+	FLD_PRICE_LIST_PRICE   = "price_list_price"
+
+	// Fields for the Catalogue publishing, published_version is the catalogue_version read by the storefronts
+	FLD_CATALOGUE_PUBLISHED_VERSION = "published_version"
+	FLD_CATALOGUE_PUBLISHED_AT      = "published_at"
+
+	// Fields for Catalogue Item, a Product or a Category in the draft of the Catalogue. The item has the
+	// product_id or the category_id as per the catalogue_item_type
+	FLD_CATALOGUE_ITEM_ID       = "catalogue_item_id"
+	FLD_CATALOGUE_ITEM_TYPE     = "catalogue_item_type"
+	FLD_CATALOGUE_ITEM_POSITION = "catalogue_item_position"
+
+	// Fields for Catalogue Version, the immutable snapshot of the Catalogue and its items when published.
+	// Each of the catalogue_items has the Product or Category in catalogue_item_data, and the Category items
+	// have the Products of the Category and its sub Categories in catalogue_item_products
+	FLD_CATALOGUE_VERSION_ID    = "catalogue_version_id"
+	FLD_CATALOGUE_VERSION       = "catalogue_version"
+	FLD_CATALOGUE_DATA          = "catalogue_data"
+	FLD_CATALOGUE_ITEMS         = "catalogue_items"
+	FLD_CATALOGUE_ITEM_DATA     = "catalogue_item_data"
+	FLD_CATALOGUE_ITEM_PRODUCTS = "catalogue_item_products"
+
 	// Fields for Category Table
 	FLD_CATEGORY_ID   = "category_id"
 	FLD_CATEGORY_NAME = "category_name"
 
+	// Fields for Category tree, category_ancestors are the ids from the root to the parent and
+	// category_path is the materialized path of the ids e.g. "/catg_1/catg_2/catg_3", both set by the service.
+	// category_children is set in the tree returned by GetTree
+	FLD_CATEGORY_PARENT_ID = "parent_category_id"
+	FLD_CATEGORY_ANCESTORS = "category_ancestors"
+	FLD_CATEGORY_PATH      = "category_path"
+	FLD_CATEGORY_CHILDREN  = "category_children"
+
 	// Fields for Product Table
-	FLD_PRODUCT_ID   = "product_id"
-	FLD_PRODUCT_NAME = "product_name"
+	FLD_PRODUCT_ID    = "product_id"
+	FLD_PRODUCT_NAME  = "product_name"
+	FLD_PRODUCT_PRICE = "product_price" // Base price, before the Price Tier of the Customer
+
+	// Option axes of the Product having Variants, e.g.
+	// "product_options": [{ "option_name": "size", "option_values": ["S", "M", "L"] }, { "option_name": "colour", "option_values": ["Red", "Blue"] }]
+	FLD_PRODUCT_OPTIONS  = "product_options"
+	FLD_OPTION_NAME      = "option_name"
+	FLD_OPTION_VALUES    = "option_values"
+	FLD_PRODUCT_VARIANTS = "product_variants" // Variants of the Product in Get and List
+
+	// Fields for Product Variant, the SKU sold for one value of each option axis of the Product, e.g.
+	// "variant_options": { "size": "M", "colour": "Red" }. Price is the product_price if not given.
+	// variant_id and variant_sku are also set in the Cart/Order items. Stock of the Variant is in the
+	// Inventory with the variant_id
+	FLD_VARIANT_ID      = "variant_id"
+	FLD_VARIANT_SKU     = "variant_sku"
+	FLD_VARIANT_OPTIONS = "variant_options"
+	FLD_VARIANT_PRICE   = "variant_price"
+	FLD_VARIANT_IMAGES  = "variant_images"
+	FLD_VARIANT_BARCODE = "variant_barcode"
+
+	// Fields for the priced Product in Cart/Order
+	FLD_QUANTITY   = "quantity"
+	FLD_BASE_PRICE = "base_price"
+	FLD_UNIT_PRICE = "unit_price"
+	FLD_LINE_TOTAL = "line_total"
 
 	// Fields for Testimonial
 	FLD_TESTIMONIAL_ID   = "testimonial_id"
@@ -103,14 +505,166 @@ const (
 	FLD_CUSTOMER_ORDER_NAME   = "customer_order_name"
 	FLD_CUSTOMER_ORDER_STATUS = "order_status"
 
+	FLD_ORDER_ITEMS = "order_items" // Products in the Order, with product_id, variant_id (for the Products having options) and quantity. reservation_id is set, or the allocations of the quantity to the locations, if the stock is tracked
+	FLD_ORDER_TOTAL = "order_total"
+
+	FLD_ORDER_REDEEM_POINTS  = "redeem_points"  // Loyalty points to redeem at checkout
+	FLD_ORDER_REDEEMED_VALUE = "redeemed_value" // Amount off the Order for the redeemed points
+	FLD_ORDER_PAYABLE        = "order_payable"  // order_total less the redeemed value
+	FLD_ORDER_PAYMENT_MODE   = "payment_mode"   // credit, others are paid at checkout
+
+	// Customer Order addresses, the Address is copied to the Order so later changes do not affect it
+	FLD_ORDER_SHIPPING_ADDRESS_ID = "shipping_address_id"
+	FLD_ORDER_SHIPPING_ADDRESS    = "shipping_address"
+	FLD_ORDER_BILLING_ADDRESS_ID  = "billing_address_id"
+	FLD_ORDER_BILLING_ADDRESS     = "billing_address"
+
 	// Fields for Customer Table
 	FLD_CUSTOMER_ID       = "customer_id"
 	FLD_CUSTOMER_LOGIN_ID = "customer_loginid"
 	FLD_CUSTOMER_PASSWORD = "customer_password"
 	FLD_CUSTOMER_OTP      = "customer_otp"
+	FLD_CUSTOMER_EMAIL    = "customer_email"
+	FLD_CUSTOMER_PHONE    = "customer_phone"
+
+	FLD_CUSTOMER_NAME        = "customer_name"
+	FLD_CUSTOMER_MERGED_INTO = "customer_merged_into" // Surviving Customer, the merged Customer is deleted
+
+	FLD_CUSTOMER_OTP_EXPIRY   = "customer_otp_expiry"
+	FLD_CUSTOMER_OTP_ATTEMPTS = "customer_otp_attempts"
+	FLD_CUSTOMER_OTP_SENT_AT  = "customer_otp_sent_at"
+	FLD_CUSTOMER_OTP_SENDS    = "customer_otp_sends" // OTPs sent since the first send of the day
+
+	// Fields for Customer Password reset
+	FLD_CUSTOMER_RESET_CODE    = "customer_reset_code" // Hash of the Token nonce
+	FLD_CUSTOMER_RESET_EXPIRY  = "customer_reset_expiry"
+	FLD_CUSTOMER_RESET_SENT_AT = "customer_reset_sent_at"
+
+	// Fields for Customer Email/Phone verification
+	FLD_CUSTOMER_EMAIL_VERIFIED        = "customer_email_verified"
+	FLD_CUSTOMER_EMAIL_VERIFY_CODE     = "customer_email_verify_code" // Hash of the Token nonce, only the latest Token is valid
+	FLD_CUSTOMER_EMAIL_VERIFY_EXPIRY   = "customer_email_verify_expiry"
+	FLD_CUSTOMER_EMAIL_VERIFY_ATTEMPTS = "customer_email_verify_attempts"
+	FLD_CUSTOMER_EMAIL_VERIFY_SENT_AT  = "customer_email_verify_sent_at"
+	FLD_CUSTOMER_EMAIL_VERIFY_SENDS    = "customer_email_verify_sends" // Sends in the last day
+	FLD_CUSTOMER_PHONE_VERIFIED        = "customer_phone_verified"
+	FLD_CUSTOMER_PHONE_VERIFY_CODE     = "customer_phone_verify_code" // Hash of the Code
+	FLD_CUSTOMER_PHONE_VERIFY_EXPIRY   = "customer_phone_verify_expiry"
+	FLD_CUSTOMER_PHONE_VERIFY_ATTEMPTS = "customer_phone_verify_attempts"
+	FLD_CUSTOMER_PHONE_VERIFY_SENT_AT  = "customer_phone_verify_sent_at"
+	FLD_CUSTOMER_PHONE_VERIFY_SENDS    = "customer_phone_verify_sends"
+
+	// Fields for Customer Refresh Token
+	FLD_TOKEN_ID          = "token_id"
+	FLD_TOKEN_FAMILY_ID   = "token_family_id" // All the Tokens rotated from the same login
+	FLD_TOKEN_HASH        = "token_hash"
+	FLD_TOKEN_CLIENT_ID   = "token_client_id"
+	FLD_TOKEN_EXPIRES_AT  = "token_expires_at"
+	FLD_TOKEN_IS_USED     = "token_is_used"
+	FLD_TOKEN_IS_REVOKED  = "token_is_revoked"
+	FLD_TOKEN_REPLACED_BY = "token_replaced_by"
+
+	// Fields for Customer Session, the session_id is the token_family_id of the Refresh Tokens of the login
+	FLD_SESSION_ID           = "session_id"
+	FLD_SESSION_CLIENT_ID    = "session_client_id"
+	FLD_SESSION_DEVICE       = "session_device"
+	FLD_SESSION_LAST_USED_AT = "session_last_used_at"
+	FLD_SESSION_IS_REVOKED   = "session_is_revoked"
+	FLD_SESSION_REVOKED_AT   = "session_revoked_at"
+
+	// Fields for Failed Login Attempts
+	FLD_LOGIN_ATTEMPT_ID      = "login_attempt_id"
+	FLD_LOGIN_KEY             = "login_key" // Customer field used to login
+	FLD_LOGIN_VALUE           = "login_value"
+	FLD_LOGIN_FAILED_ATTEMPTS = "failed_attempts"
+	FLD_LOGIN_LAST_FAILED_AT  = "last_failed_at"
+	FLD_LOGIN_LOCKED_UNTIL    = "locked_until"
+
+	// Fields for Login Security Events
+	FLD_LOGIN_EVENT_ID   = "login_event_id"
+	FLD_LOGIN_EVENT_TYPE = "login_event_type"
+
+	// Fields for Customer Identity
+	FLD_IDENTITY_ID       = "identity_id"
+	FLD_IDENTITY_PROVIDER = "identity_provider"
+	FLD_IDENTITY_SUBJECT  = "identity_subject" // sub claim, unique for the provider
+	FLD_IDENTITY_EMAIL    = "identity_email"
+
+	// Fields for Marketing Consent, consent_status/consent_source are also in the Consent Events
+	FLD_CONSENT_ID           = "consent_id"
+	FLD_CONSENT_CHANNEL      = "consent_channel" // email, sms or whatsapp
+	FLD_CONSENT_STATUS       = "consent_status"
+	FLD_CONSENT_SOURCE       = "consent_source"
+	FLD_CONSENT_REQUESTED_AT = "consent_requested_at" // Double opt-in confirmation sent
+	FLD_CONSENT_GRANTED_AT   = "consent_granted_at"
+	FLD_CONSENT_WITHDRAWN_AT = "consent_withdrawn_at"
+	FLD_CONSENT_CONFIRM_HASH = "consent_confirm_hash"
+	FLD_CONSENT_EVENT_ID     = "consent_event_id"
+	FLD_CONSENT_DOUBLE_OPTIN = "double_opt_in" // Preference field
+
+	// Fields for Credit Account
+	FLD_CREDIT_ACCOUNT_ID  = "credit_account_id"
+	FLD_CREDIT_PARTY_TYPE  = "party_type"         // customer or dealer
+	FLD_CREDIT_PARTY_ID    = "party_id"           // customer_id or dealer_id
+	FLD_CREDIT_LIMIT       = "credit_limit"       // In the currency of the Account, also of its Invoices
+	FLD_CREDIT_TERMS_DAYS  = "payment_terms_days" // Net days, Invoices are due after these days
+	FLD_CREDIT_OUTSTANDING = "outstanding_balance"
+
+	// Fields for Credit Invoice, invoice_id and invoice_due_at are also set in the Order
+	FLD_INVOICE_ID             = "invoice_id"
+	FLD_INVOICE_AMOUNT         = "invoice_amount"
+	FLD_INVOICE_PAID           = "invoice_paid"
+	FLD_INVOICE_STATUS         = "invoice_status"
+	FLD_INVOICE_DUE_AT         = "invoice_due_at"
+	FLD_INVOICE_PAID_AT        = "invoice_paid_at"
+	FLD_INVOICE_PAYMENTS       = "invoice_payments" // Each with payment_amount, payment_reference and paid_at
+	FLD_INVOICE_PAYMENT_AMOUNT = "payment_amount"
+	FLD_INVOICE_PAYMENT_REF    = "payment_reference"
+
+	// Fields for Customer Merge
+	FLD_CUSTOMER_MERGE_ID   = "customer_merge_id"
+	FLD_MERGED_CUSTOMER_IDS = "merged_customer_ids"
+	FLD_MERGE_COUNTS        = "merge_counts"
+	FLD_MERGE_STATUS        = "merge_status"
+	FLD_MERGE_DONE_IDS      = "merge_done_customer_ids" // Merged Customers whose records are moved
+
+	// Fields for Cart
+	FLD_CART_GUEST_TOKEN = "guest_cart_token" // Service props for the Guest cart, instead of customer_id
+	FLD_CART_IS_GUEST    = "is_guest_cart"
+	FLD_CART_MERGE_RULE  = "cart_merge_rule"
+
+	// Fields for Customer Address
+	FLD_ADDRESS_ID                  = "address_id"
+	FLD_ADDRESS_LABEL               = "address_label" // e.g. Home, Office
+	FLD_ADDRESS_TYPE                = "address_type"
+	FLD_ADDRESS_PINCODE             = "address_pincode"
+	FLD_ADDRESS_IS_DEFAULT_SHIPPING = "is_default_shipping"
+	FLD_ADDRESS_IS_DEFAULT_BILLING  = "is_default_billing"
 
 	// Field for Customer Type Table
-	FLD_CUSTOMER_TYPE_ID = "customertype_id"
+	FLD_CUSTOMER_TYPE_ID = "customer_type_id" // Was customertype_id, renamed by the migration 20231206_01
+
+	// Fields for Price Tier
+	FLD_PRICE_TIER_ID       = "price_tier_id"
+	FLD_PRICE_TIER_NAME     = "price_tier_name"
+	FLD_PRICE_TIER_SCOPE    = "price_tier_scope"
+	FLD_PRICE_TIER_DISCOUNT = "price_tier_discount" // Percentage off the base price
+	FLD_PRICE_TIER_PRICE    = "price_tier_price"    // Fixed price (Decimal128), only for Product scope
+
+	// Fields for Loyalty Transaction
+	FLD_LOYALTY_TXN_ID     = "loyalty_txn_id"
+	FLD_LOYALTY_TXN_TYPE   = "loyalty_txn_type"
+	FLD_LOYALTY_POINTS     = "loyalty_points" // Signed, negative for deductions
+	FLD_LOYALTY_EXPIRES_AT = "loyalty_expires_at"
+	FLD_LOYALTY_REF_TXN_ID = "loyalty_ref_txn_id" // Earn transaction the expire is for
+	FLD_LOYALTY_REMARKS    = "loyalty_remarks"
+
+	// Fields for Loyalty Balance
+	FLD_LOYALTY_POINTS_BALANCE  = "points_balance"
+	FLD_LOYALTY_POINTS_EARNED   = "points_earned"
+	FLD_LOYALTY_POINTS_REDEEMED = "points_redeemed"
+	FLD_LOYALTY_POINTS_EXPIRED  = "points_expired"
+	FLD_LOYALTY_POINTS_ADJUSTED = "points_adjusted"
 
 	// Fields for Order
 	FLD_ORDER_ID   = "order_id"
@@ -133,6 +687,27 @@ const (
 	FLD_PREFERENCE_ID   = "preference_id"
 	FLD_PREFERENCE_NAME = "preference_name"
 
+	// Fields in Customer Login Preference
+	FLD_LOGIN_TYPES              = "login_types"
+	FLD_LOGIN_PHONE_COUNTRY_CODE = "phone_country_code"
+
+	// Fields in Loyalty Preference
+	FLD_LOYALTY_ENABLED            = "loyalty_enabled"
+	FLD_LOYALTY_EARN_RATE          = "earn_rate" // Points for each unit of the order_total
+	FLD_LOYALTY_EARN_MIN_ORDER     = "earn_min_order"
+	FLD_LOYALTY_REDEEM_VALUE       = "redeem_value" // Amount for each point
+	FLD_LOYALTY_REDEEM_MIN_POINTS  = "redeem_min_points"
+	FLD_LOYALTY_REDEEM_MAX_PERCENT = "redeem_max_percent" // Of the order_total
+	FLD_LOYALTY_EXPIRY_DAYS        = "points_expiry_days" // 0 for never
+
+	// Fields in OIDC Providers Preference
+	FLD_OIDC_PROVIDERS  = "oidc_providers"
+	FLD_OIDC_PROVIDER   = "provider"
+	FLD_OIDC_ISSUER     = "issuer"
+	FLD_OIDC_CLIENT_IDS = "client_ids"
+	FLD_OIDC_JWKS_URI   = "jwks_uri"
+	FLD_OIDC_JWKS       = "jwks"
+
 	// Fields for Product Preference
 	FLD_PROD_PREFERENCE_ID   = "prod_preference_id"
 	FLD_PROD_PREFERENCE_NAME = "prod_preference_name"
@@ -144,6 +719,10 @@ const (
 	FLD_DEALER_ID   = "dealer_id"
 	FLD_DEALER_NAME = "dealer_name"
 
+	// Fields for Warehouse
+	FLD_WAREHOUSE_ID   = "warehouse_id"
+	FLD_WAREHOUSE_NAME = "warehouse_name"
+
 	// Fields for Review/Feedback
 	FLD_REVIEW_ID = "review_id"
 
@@ -160,13 +739,22 @@ const (
 	FLD_QUIZ_ID = "quiz_id"
 
 	// Field For Callback
-	FLD_CALLBACK_ID = "callback_id"
+	FLD_CALLBACK_ID  = "callback_id"
 	FLD_IS_FULFILLED = "is_fulfilled"
 
 	// Fields for Campaign
 	FLD_CAMPAIGN_ID   = "campaign_id"
 	FLD_CAMPAIGN_NAME = "campaign_name"
 
+	FLD_CAMPAIGN_CHANNEL     = "campaign_channel" // email, sms or whatsapp
+	FLD_CAMPAIGN_SUBJECT     = "campaign_subject"
+	FLD_CAMPAIGN_BODY        = "campaign_body"
+	FLD_CAMPAIGN_SENT_AT     = "campaign_sent_at"
+	FLD_CAMPAIGN_SEND_REPORT = "campaign_send_report"
+	FLD_CAMPAIGN_STATUS      = "campaign_status"      // sending or sent
+	FLD_CAMPAIGN_SEND_CURSOR = "campaign_send_cursor" // Last consent_id of the batches sent
+	FLD_CAMPAIGN_SEND_BEAT   = "campaign_send_beat_at"
+
 	// Fields for States
 	FLD_STATE_ID           = "sales_state_id"
 	FLD_STATE_NAME         = "sales_state_name"
@@ -189,6 +777,13 @@ const (
 	// Fields for Territory
 	FLD_TERRITORY_ID   = "territory_id"
 	FLD_TERRITORY_NAME = "territory_name"
+
+	// Fields for Migrations
+	FLD_MIGRATION_ID          = "migration_id"
+	FLD_MIGRATION_DESCRIPTION = "migration_description"
+	FLD_MIGRATION_COLLECTION  = "migration_collection"
+	FLD_MIGRATION_AFFECTED    = "migration_affected"
+	FLD_MIGRATION_APPLIED_AT  = "migration_applied_at"
 )
 
 func init() {
@@ -205,3 +800,63 @@ func GetServiceModuleCode() string {
 //
 //
 // db.zc_sales_region.createIndex({"sales_region_pincodes.pincode_from": 1}, {"sales_region_pincodes.pincode_to": 1})
+//
+// db.zc_sales_migrations.createIndex({"migration_id": 1}, {unique: true})
+//
+// db.zc_sales_customer_tokens.createIndex({"token_id": 1}, {unique: true})
+// db.zc_sales_customer_tokens.createIndex({"token_family_id": 1})
+// db.zc_sales_customer_sessions.createIndex({"session_id": 1}, {unique: true})
+// db.zc_sales_customer_sessions.createIndex({"business_id": 1, "customer_id": 1, "session_is_revoked": 1})
+//
+// db.zc_sales_customers.createIndex({"business_id": 1, "customer_loginid": 1}, {unique: true, partialFilterExpression: {"is_deleted": false, "customer_loginid": {$gt: ""}}})
+// db.zc_sales_customers.createIndex({"business_id": 1, "customer_email": 1}, {unique: true, partialFilterExpression: {"is_deleted": false, "customer_email": {$gt: ""}}})
+// db.zc_sales_customers.createIndex({"business_id": 1, "customer_phone": 1}, {unique: true, partialFilterExpression: {"is_deleted": false, "customer_phone": {$gt: ""}}})
+//
+// db.zc_sales_login_attempts.createIndex({"business_id": 1, "login_attempt_id": 1}, {unique: true})
+// db.zc_sales_login_events.createIndex({"business_id": 1, "created_at": -1})
+//
+// db.zc_sales_customer_addresses.createIndex({"business_id": 1, "customer_id": 1})
+//
+// db.zc_sales_customer_carts.createIndex({"business_id": 1, "customer_id": 1, "product_id": 1})
+//
+// db.zc_sales_price_tiers.createIndex({"business_id": 1, "customer_type_id": 1})
+//
+// db.zc_sales_loyalty_txns.createIndex({"business_id": 1, "customer_id": 1, "created_at": 1})
+// Created by the migration 20231201_01_loyalty_txns_order_index:
+// db.zc_sales_loyalty_txns.createIndex({"business_id": 1, "customer_order_id": 1, "loyalty_txn_type": 1}, {unique: true, partialFilterExpression: {"customer_order_id": {$gt: ""}}})
+// db.zc_sales_loyalty_balances.createIndex({"business_id": 1, "customer_id": 1}, {unique: true})
+//
+// db.zc_sales_customer_merges.createIndex({"business_id": 1, "customer_id": 1})
+//
+// Created by the migration 20231207_06_customer_identities_unique:
+// db.zc_sales_customer_identities.createIndex({"business_id": 1, "identity_provider": 1, "identity_subject": 1}, {unique: true, partialFilterExpression: {"is_deleted": false}})
+// db.zc_sales_customer_identities.createIndex({"business_id": 1, "customer_id": 1})
+//
+// db.zc_sales_customer_consents.createIndex({"business_id": 1, "customer_id": 1, "consent_channel": 1}, {unique: true, partialFilterExpression: {"is_deleted": false}})
+// db.zc_sales_customer_consents.createIndex({"business_id": 1, "consent_channel": 1, "consent_status": 1})
+// db.zc_sales_consent_events.createIndex({"business_id": 1, "customer_id": 1, "created_at": 1})
+//
+// db.zc_sales_credit_accounts.createIndex({"business_id": 1, "party_type": 1, "party_id": 1}, {unique: true, partialFilterExpression: {"is_deleted": false}})
+// db.zc_sales_credit_invoices.createIndex({"business_id": 1, "credit_account_id": 1, "invoice_status": 1, "invoice_due_at": 1})
+// db.zc_sales_credit_invoices.createIndex({"business_id": 1, "customer_order_id": 1}, {unique: true})
+//
+// db.zc_sales_categories.createIndex({"business_id": 1, "parent_category_id": 1})
+// db.zc_sales_categories.createIndex({"business_id": 1, "category_ancestors": 1})
+// db.zc_sales_categories.createIndex({"business_id": 1, "category_path": 1})
+//
+// db.zc_sales_catalogue_items.createIndex({"business_id": 1, "catalogue_id": 1, "catalogue_item_position": 1})
+// db.zc_sales_catalogue_versions.createIndex({"business_id": 1, "catalogue_id": 1, "catalogue_version": 1}, {unique: true})
+//
+// db.zc_sales_price_lists.createIndex({"business_id": 1, "currency": 1, "valid_from": 1})
+// db.zc_sales_price_list_items.createIndex({"business_id": 1, "product_id": 1, "price_list_id": 1, "variant_id": 1}, {unique: true, partialFilterExpression: {"is_deleted": false}})
+//
+// db.zc_sales_inventory.createIndex({"business_id": 1, "product_id": 1, "variant_id": 1, "location_type": 1, "location_id": 1}, {unique: true, partialFilterExpression: {"is_deleted": false}})
+// db.zc_sales_stock_reservations.createIndex({"business_id": 1, "reservation_status": 1, "reservation_expires_at": 1})
+// db.zc_sales_stock_reservations.createIndex({"business_id": 1, "customer_id": 1, "reservation_status": 1})
+// db.zc_sales_inventory.createIndex({"business_id": 1, "location_type": 1, "location_id": 1})
+// db.zc_sales_warehouses.createIndex({"business_id": 1, "sales_region_id": 1})
+//
+// db.zc_sales_product_variants.createIndex({"business_id": 1, "product_id": 1})
+// Created by the migration 20231205_01_product_variants_unique:
+// db.zc_sales_product_variants.createIndex({"business_id": 1, "variant_sku": 1}, {unique: true, partialFilterExpression: {"is_deleted": false, "variant_sku": {$gt: ""}}})
+// db.zc_sales_product_variants.createIndex({"business_id": 1, "variant_barcode": 1}, {unique: true, partialFilterExpression: {"is_deleted": false, "variant_barcode": {$gt: ""}}})
@@ -0,0 +1,188 @@
+package sales_common
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetMemberDataTime - Get the time value, which could be time.Time when set by the code,
+// primitive.DateTime when read back from MongoDB or RFC3339 string when parsed from JSON
+func GetMemberDataTime(data utils.Map, memberName string) (time.Time, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return time.Time{}, err
+	}
+
+	switch value := dataVal.(type) {
+	case time.Time:
+		return value, nil
+	case primitive.DateTime:
+		return value.Time(), nil
+	case string:
+		if timeVal, err := time.Parse(time.RFC3339, value); err == nil {
+			return timeVal, nil
+		}
+	}
+
+	err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be a datetime"}
+	return time.Time{}, err
+}
+
+// GetMemberDataStrArray - Get the string array value, which could be []string when set by the code
+// or primitive.A when read back from MongoDB
+func GetMemberDataStrArray(data utils.Map, memberName string) ([]string, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return nil, err
+	}
+
+	var values []interface{}
+	switch value := dataVal.(type) {
+	case []string:
+		return value, nil
+	case primitive.A:
+		values = value
+	case []interface{}:
+		values = value
+	default:
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be an array of strings"}
+		return nil, err
+	}
+
+	result := []string{}
+	for _, value := range values {
+		strValue, ok := value.(string)
+		if !ok {
+			err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be an array of strings"}
+			return nil, err
+		}
+		result = append(result, strValue)
+	}
+	return result, nil
+}
+
+// GetMemberDataFloat - Get the numeric value as float64, it could be stored as int, float or Decimal128
+func GetMemberDataFloat(data utils.Map, memberName string) (float64, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return 0, err
+	}
+
+	switch value := dataVal.(type) {
+	case float64:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	case int32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case primitive.Decimal128:
+		// Money values priced in decimal
+		if ratVal, err := DecimalToRat(value); err == nil {
+			floatVal, _ := ratVal.Float64()
+			return floatVal, nil
+		}
+	}
+
+	err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be a number"}
+	return 0, err
+}
+
+// GetMemberDataMapArray - Get the array of documents, which could be []utils.Map when set by the code,
+// []interface{} when parsed from JSON or primitive.A when read back from MongoDB
+func GetMemberDataMapArray(data utils.Map, memberName string) ([]utils.Map, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return nil, err
+	}
+
+	var values []interface{}
+	switch value := dataVal.(type) {
+	case []utils.Map:
+		return value, nil
+	case primitive.A:
+		values = value
+	case []interface{}:
+		values = value
+	default:
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be an array of objects"}
+		return nil, err
+	}
+
+	result := []utils.Map{}
+	for _, value := range values {
+		switch item := value.(type) {
+		case utils.Map:
+			result = append(result, item)
+		case map[string]interface{}:
+			result = append(result, item)
+		case primitive.M:
+			result = append(result, utils.Map(item))
+		case primitive.D:
+			result = append(result, utils.Map(item.Map()))
+		default:
+			err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be an array of objects"}
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// GetMemberDataMap - Get the document, which could be utils.Map when set by the code, map[string]interface{}
+// when parsed from JSON or primitive.M/primitive.D when read back from MongoDB
+func GetMemberDataMap(data utils.Map, memberName string) (utils.Map, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return nil, err
+	}
+
+	switch value := dataVal.(type) {
+	case utils.Map:
+		return value, nil
+	case map[string]interface{}:
+		return value, nil
+	case primitive.M:
+		return utils.Map(value), nil
+	case primitive.D:
+		return utils.Map(value.Map()), nil
+	}
+
+	err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be an object"}
+	return nil, err
+}
+
+// ValidatePincode - Verify the pincode is PINCODE_LENGTH digits
+func ValidatePincode(pincode string) error {
+	if len(pincode) != PINCODE_LENGTH || !phoneDigits.MatchString(pincode) {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Pincode", ErrorDetail: "Pincode should be " + strconv.Itoa(PINCODE_LENGTH) + " digits"}
+		return err
+	}
+	return nil
+}
+
+// BuildFilter - Build the filter string of the DAO List/Find from the field values. The values are marshalled
+// as Extended JSON, so a value with quotes or operators can not change the filter
+func BuildFilter(fields utils.Map) (string, error) {
+	filter, err := bson.MarshalExtJSON(fields, true, false)
+	if err != nil {
+		return "", err
+	}
+	return string(filter), nil
+}
@@ -0,0 +1,41 @@
+package sales_common
+
+import (
+	"testing"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBuildFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields utils.Map
+	}{
+		{"plain", utils.Map{FLD_CUSTOMER_TYPE_ID: "retail"}},
+		{"quotes", utils.Map{FLD_CUSTOMER_TYPE_ID: `retail", "$where": "1`}},
+		{"operator", utils.Map{FLD_PRICE_LIST_ID: utils.Map{"$in": []string{"pl1", `pl2"]}`}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := BuildFilter(tt.fields)
+			if err != nil {
+				t.Fatalf("BuildFilter() error = %v", err)
+			}
+
+			// Parsed the same way as the Mongo DAOs
+			var got bson.M
+			if err := bson.UnmarshalExtJSON([]byte(filter), true, &got); err != nil {
+				t.Fatalf("filter %s is invalid: %v", filter, err)
+			}
+			if len(got) != len(tt.fields) {
+				t.Errorf("filter %s has %d fields, want %d", filter, len(got), len(tt.fields))
+			}
+			for key := range tt.fields {
+				if _, ok := got[key]; !ok {
+					t.Errorf("filter %s has no %s", filter, key)
+				}
+			}
+		})
+	}
+}
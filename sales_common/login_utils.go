@@ -0,0 +1,109 @@
+package sales_common
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// Login identifiers
+//
+// Customer can login with LoginId, Email or Phone, the identifiers are stored normalized so the
+// same login typed differently matches the same Customer. LoginId and Email are lower-cased, Phone
+// is stored in E.164 format "+<country code><number>" without spaces or separators.
+var (
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+	phoneDigits     = regexp.MustCompile(`^[0-9]+$`)
+	phoneLike       = regexp.MustCompile(`^\+?[0-9 ()\-.]+$`)
+)
+
+// NormalizeLogin - Normalize the login identifier for the login type
+func NormalizeLogin(loginType string, login string, defaultCountryCode string) (string, error) {
+	switch loginType {
+	case LOGIN_TYPE_LOGINID:
+		return NormalizeLoginId(login)
+	case LOGIN_TYPE_EMAIL:
+		return NormalizeEmail(login)
+	case LOGIN_TYPE_PHONE:
+		return NormalizePhone(login, defaultCountryCode)
+	}
+
+	err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Login Type", ErrorDetail: "Login type should be loginid, email or phone"}
+	return "", err
+}
+
+// DetectLoginType - Detect the login type from the identifier entered, among the enabled login types.
+// A numeric identifier is a Phone only when Phone login is enabled, otherwise it is taken as LoginId
+func DetectLoginType(login string, loginTypes []string) string {
+	login = strings.TrimSpace(login)
+
+	candidates := []string{}
+	if strings.Contains(login, "@") {
+		candidates = append(candidates, LOGIN_TYPE_EMAIL)
+	} else if phoneLike.MatchString(login) {
+		candidates = append(candidates, LOGIN_TYPE_PHONE)
+	}
+	candidates = append(candidates, LOGIN_TYPE_LOGINID)
+
+	for _, candidate := range candidates {
+		for _, loginType := range loginTypes {
+			if loginType == candidate {
+				return candidate
+			}
+		}
+	}
+	// None enabled, report the detected type
+	return candidates[0]
+}
+
+// NormalizeLoginId - Trim and lower-case the LoginId
+func NormalizeLoginId(loginId string) (string, error) {
+	loginId = strings.ToLower(strings.TrimSpace(loginId))
+	if len(loginId) == 0 {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid LoginId", ErrorDetail: "LoginId should not be empty"}
+		return "", err
+	}
+	return loginId, nil
+}
+
+// NormalizeEmail - Trim and lower-case the Email, also verify it looks like an Email
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	local, domain, found := strings.Cut(email, "@")
+	if !found || len(local) == 0 || strings.Contains(domain, "@") ||
+		!strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Email", ErrorDetail: "Given Email " + email + " is not valid"}
+		return "", err
+	}
+	return email, nil
+}
+
+// NormalizePhone - Convert the Phone to E.164 format, defaultCountryCode (digits only, e.g. "91")
+// is prefixed when the Phone is given without country code
+func NormalizePhone(phone string, defaultCountryCode string) (string, error) {
+	errInvalid := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Phone", ErrorDetail: "Given Phone " + phone + " is not valid"}
+
+	number := phoneSeparators.Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(number, "+") {
+		number = number[1:]
+	} else if strings.HasPrefix(number, "00") {
+		// International call prefix
+		number = number[2:]
+	} else {
+		countryCode := strings.TrimPrefix(strings.TrimSpace(defaultCountryCode), "+")
+		if len(countryCode) == 0 {
+			errInvalid.ErrorDetail = "Phone " + phone + " should be given with country code"
+			return "", errInvalid
+		}
+		// Drop the national trunk prefix
+		number = countryCode + strings.TrimLeft(number, "0")
+	}
+
+	// E.164 allows at most 15 digits, country code never starts with 0
+	if !phoneDigits.MatchString(number) || strings.HasPrefix(number, "0") || len(number) < 8 || len(number) > 15 {
+		return "", errInvalid
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,30 @@
+package sales_common
+
+import "testing"
+
+func TestDetectLoginType(t *testing.T) {
+	all := []string{LOGIN_TYPE_LOGINID, LOGIN_TYPE_EMAIL, LOGIN_TYPE_PHONE}
+
+	tests := []struct {
+		name       string
+		login      string
+		loginTypes []string
+		want       string
+	}{
+		{"email", "John@Example.com", all, LOGIN_TYPE_EMAIL},
+		{"phone", "+91 98765-43210", all, LOGIN_TYPE_PHONE},
+		{"loginid", "john.doe", all, LOGIN_TYPE_LOGINID},
+		{"numeric loginid without phone login", "1001", []string{LOGIN_TYPE_LOGINID}, LOGIN_TYPE_LOGINID},
+		{"numeric with phone login", "9876543210", []string{LOGIN_TYPE_LOGINID, LOGIN_TYPE_PHONE}, LOGIN_TYPE_PHONE},
+		{"email without email login", "john@example.com", []string{LOGIN_TYPE_LOGINID}, LOGIN_TYPE_LOGINID},
+		{"phone only enabled", "john.doe", []string{LOGIN_TYPE_PHONE}, LOGIN_TYPE_LOGINID},
+		{"nothing enabled", "9876543210", nil, LOGIN_TYPE_PHONE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLoginType(tt.login, tt.loginTypes); got != tt.want {
+				t.Errorf("DetectLoginType(%q, %v) = %q, want %q", tt.login, tt.loginTypes, got, tt.want)
+			}
+		})
+	}
+}
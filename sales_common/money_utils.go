@@ -0,0 +1,124 @@
+package sales_common
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Money values are stored as primitive.Decimal128 and computed as big.Rat, so no value is lost to float64
+
+// Currencies having other than 2 decimals in their minor unit
+var currencyScales = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// IsValidCurrency - Whether the currency is an ISO 4217 code, 3 upper case letters
+func IsValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, ch := range currency {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrencyScale - Decimals of the minor unit of the currency
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[currency]; ok {
+		return scale
+	}
+	return 2
+}
+
+// GetMemberDataDecimal - Get the decimal value, which could be primitive.Decimal128 when set by the code or read
+// back from MongoDB, a string or a number when parsed from JSON
+func GetMemberDataDecimal(data utils.Map, memberName string) (primitive.Decimal128, error) {
+
+	dataVal, dataOk := data[memberName]
+	if !dataOk {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Missing Data", ErrorDetail: memberName + " value should be sent"}
+		return primitive.Decimal128{}, err
+	}
+
+	var strVal string
+	switch value := dataVal.(type) {
+	case primitive.Decimal128:
+		return value, nil
+	case string:
+		strVal = strings.TrimSpace(value)
+	case float64:
+		strVal = strconv.FormatFloat(value, 'f', -1, 64)
+	case float32:
+		strVal = strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case int:
+		strVal = strconv.FormatInt(int64(value), 10)
+	case int32:
+		strVal = strconv.FormatInt(int64(value), 10)
+	case int64:
+		strVal = strconv.FormatInt(value, 10)
+	}
+
+	decVal, err := primitive.ParseDecimal128(strVal)
+	if err != nil || decVal.IsNaN() || decVal.IsInf() != 0 {
+		err := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Datatype", ErrorDetail: memberName + " value should be a decimal"}
+		return primitive.Decimal128{}, err
+	}
+	return decVal, nil
+}
+
+// DecimalToRat - Convert the decimal to big.Rat for computing
+func DecimalToRat(value primitive.Decimal128) (*big.Rat, error) {
+	coefficient, exp, err := value.BigInt()
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Rat).SetInt(coefficient)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil))
+	if exp >= 0 {
+		return result.Mul(result, scale), nil
+	}
+	return result.Quo(result, scale), nil
+}
+
+// RatToDecimal - Convert the big.Rat to decimal, rounded half away from zero to the given decimals
+func RatToDecimal(value *big.Rat, scale int) primitive.Decimal128 {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+
+	// Round the quotient by adding half of the denominator to the remainder
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+	}
+
+	result, _ := primitive.ParseDecimal128FromBigInt(quotient, -scale)
+	return result
+}
+
+// HasScale - Whether the value has no more decimals than the given decimals
+func HasScale(value *big.Rat, scale int) bool {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	return scaled.IsInt()
+}
+
+// FloatToDecimal - Convert the float64 price of the Products to decimal with the given decimals
+func FloatToDecimal(value float64, scale int) primitive.Decimal128 {
+	ratVal, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return RatToDecimal(ratVal, scale)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,134 @@
+package sales_common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func mustRat(t *testing.T, value string) *big.Rat {
+	ratVal, ok := new(big.Rat).SetString(value)
+	if !ok {
+		t.Fatalf("invalid rat %s", value)
+	}
+	return ratVal
+}
+
+func TestRatToDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		scale int
+		want  string
+	}{
+		{"12.5", 0, "13"},
+		{"12.4999", 0, "12"},
+		{"-12.5", 0, "-13"},
+		{"1/3", 2, "0.33"},
+		{"2/3", 2, "0.67"},
+		{"0.005", 2, "0.01"},
+		{"0.0049", 2, "0.00"},
+		{"-0.005", 2, "-0.01"},
+		{"10", 2, "10.00"},
+		{"1.0005", 3, "1.001"},
+		{"-1.0004", 3, "-1.000"},
+		{"2/3", 3, "0.667"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := RatToDecimal(mustRat(t, tt.value), tt.scale).String(); got != tt.want {
+				t.Errorf("RatToDecimal(%s, %d) = %s, want %s", tt.value, tt.scale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasScale(t *testing.T) {
+	tests := []struct {
+		value string
+		scale int
+		want  bool
+	}{
+		{"12", 0, true},
+		{"12.5", 0, false},
+		{"12.50", 2, true},
+		{"12.505", 2, false},
+		{"1/3", 3, false},
+		{"-1.125", 3, true},
+		{"1.0001", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := HasScale(mustRat(t, tt.value), tt.scale); got != tt.want {
+				t.Errorf("HasScale(%s, %d) = %v, want %v", tt.value, tt.scale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalToRat(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"12.50", "25/2"},
+		{"0.001", "1/1000"},
+		{"-3.3", "-33/10"},
+		{"1E+3", "1000"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			decVal, err := primitive.ParseDecimal128(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecimalToRat(decVal)
+			if err != nil {
+				t.Fatalf("DecimalToRat(%s) error = %v", tt.value, err)
+			}
+			if got.Cmp(mustRat(t, tt.want)) != 0 {
+				t.Errorf("DecimalToRat(%s) = %s, want %s", tt.value, got.RatString(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMemberDataDecimal(t *testing.T) {
+	decVal, _ := primitive.ParseDecimal128("4.20")
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{"decimal", decVal, "4.20", false},
+		{"string", " 4.20 ", "4.20", false},
+		{"float", 0.1, "0.1", false},
+		{"int", 7, "7", false},
+		{"invalid", "ten", "", true},
+		{"nan", "NaN", "", true},
+		{"bool", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetMemberDataDecimal(utils.Map{FLD_UNIT_PRICE: tt.value}, FLD_UNIT_PRICE)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMemberDataDecimal() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("GetMemberDataDecimal() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyScale(t *testing.T) {
+	for currency, want := range map[string]int{"JPY": 0, "INR": 2, "USD": 2, "KWD": 3, "": 2} {
+		if got := CurrencyScale(currency); got != want {
+			t.Errorf("CurrencyScale(%q) = %d, want %d", currency, got, want)
+		}
+	}
+}
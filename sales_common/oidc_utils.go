@@ -0,0 +1,275 @@
+package sales_common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// OpenID Connect ID Tokens
+//
+// ID Token of the identity provider (Google, Apple..) is a JWT signed with RS256 or ES256. The signature
+// is verified with the provider's JSON Web Key Set, given inline in the provider configuration or fetched
+// from its jwks_uri. Fetched key sets are cached for OIDC_JWKS_CACHE_MINUTES and fetched again when the
+// Token is signed with an unknown key, as providers rotate the keys. Unknown keys fetch the key set again
+// at most once in OIDC_JWKS_REFETCH_SECONDS, so Tokens with made up keys can not flood the provider.
+const (
+	OIDC_JWKS_CACHE_MINUTES   = 60
+	OIDC_JWKS_REFETCH_SECONDS = 60
+	OIDC_CLOCK_SKEW_SECONDS   = 60
+)
+
+// OIDCProvider - Identity provider accepted for the business
+type OIDCProvider struct {
+	Provider  string   // Name used in AuthData, e.g. google
+	Issuer    string   // iss claim
+	ClientIds []string // aud claim should be one of these
+	JWKSUri   string   // Key set is fetched from here if JWKS is empty
+	JWKS      []byte   // Inline key set, e.g. for testing with a local key
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jwksCacheEntry struct {
+	keySet    []byte
+	fetchedAt time.Time
+}
+
+var (
+	jwksMutex  sync.Mutex
+	jwksCache  = map[string]jwksCacheEntry{}
+	jwksClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// VerifyIDToken - Verify the signature, issuer, audience and validity of the ID Token and return its claims.
+// The nonce must be given when the ID Token has the nonce claim, i.e. the authorize request set one, so the
+// ID Token cannot be replayed without the nonce
+func VerifyIDToken(idToken string, provider OIDCProvider, nonce string) (utils.Map, error) {
+	errInvalid := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Invalid ID Token", ErrorDetail: "Given ID Token is not valid"}
+
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errInvalid
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeJWTPart(parts[0], &header)
+	if err != nil {
+		return nil, errInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	key, err := getJSONWebKey(provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature) {
+		return nil, errInvalid
+	}
+
+	claims := utils.Map{}
+	err = decodeJWTPart(parts[1], &claims)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if issuer != provider.Issuer || !hasAudience(claims["aud"], provider.ClientIds) {
+		return nil, errInvalid
+	}
+
+	now := time.Now().Unix()
+	expiry, _ := claims["exp"].(float64)
+	if int64(expiry)+OIDC_CLOCK_SKEW_SECONDS < now {
+		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Expired ID Token", ErrorDetail: "Given ID Token is expired"}
+		return nil, err
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && int64(notBefore)-OIDC_CLOCK_SKEW_SECONDS > now {
+		return nil, errInvalid
+	}
+	claimNonce, hasNonce := claims["nonce"]
+	if (hasNonce || len(nonce) > 0) && claimNonce != nonce {
+		return nil, errInvalid
+	}
+
+	return claims, nil
+}
+
+// IsClaimTrue - Whether the boolean claim is true, Apple sends them as strings
+func IsClaimTrue(claims utils.Map, claim string) bool {
+	switch value := claims[claim].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+func decodeJWTPart(part string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func hasAudience(aud interface{}, clientIds []string) bool {
+	audiences := []string{}
+	switch value := aud.(type) {
+	case string:
+		audiences = append(audiences, value)
+	case []interface{}:
+		for _, item := range value {
+			if audience, ok := item.(string); ok {
+				audiences = append(audiences, audience)
+			}
+		}
+	}
+
+	for _, audience := range audiences {
+		for _, clientId := range clientIds {
+			if audience == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getJSONWebKey - Find the key in the provider's key set, fetches the key set again if not found
+func getJSONWebKey(provider OIDCProvider, kid string) (jsonWebKey, error) {
+	errKey := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Invalid ID Token", ErrorDetail: "ID Token is not signed with a key of the provider"}
+
+	keySet := provider.JWKS
+	fetched := false
+	if len(keySet) == 0 {
+		var err error
+		keySet, fetched, err = fetchJWKS(provider.JWKSUri, false)
+		if err != nil {
+			return jsonWebKey{}, err
+		}
+	}
+
+	key, found := findJSONWebKey(keySet, kid)
+	if !found && len(provider.JWKS) == 0 && !fetched {
+		keySet, _, err := fetchJWKS(provider.JWKSUri, true)
+		if err != nil {
+			return jsonWebKey{}, err
+		}
+		key, found = findJSONWebKey(keySet, kid)
+	}
+	if !found {
+		return jsonWebKey{}, errKey
+	}
+	return key, nil
+}
+
+func findJSONWebKey(keySet []byte, kid string) (jsonWebKey, bool) {
+	var jwks jsonWebKeySet
+	if err := json.Unmarshal(keySet, &jwks); err != nil {
+		return jsonWebKey{}, false
+	}
+	for _, key := range jwks.Keys {
+		if key.Kid == kid || (len(kid) == 0 && len(jwks.Keys) == 1) {
+			return key, true
+		}
+	}
+	return jsonWebKey{}, false
+}
+
+// fetchJWKS - Get the key set from the cache or the uri, returns whether it was fetched now. The lock is not
+// held while fetching, so a slow provider does not hold up the other logins
+func fetchJWKS(uri string, refresh bool) ([]byte, bool, error) {
+	jwksMutex.Lock()
+	entry, ok := jwksCache[uri]
+	jwksMutex.Unlock()
+
+	if ok {
+		age := time.Since(entry.fetchedAt)
+		if age < OIDC_JWKS_CACHE_MINUTES*time.Minute && (!refresh || age < OIDC_JWKS_REFETCH_SECONDS*time.Second) {
+			return entry.keySet, false, nil
+		}
+	}
+
+	errFetch := &utils.AppError{ErrorStatus: 503, ErrorMsg: "Key Set not available", ErrorDetail: "Keys of the identity provider could not be fetched"}
+	if len(uri) == 0 {
+		return nil, false, errFetch
+	}
+	resp, err := jwksClient.Get(uri)
+	if err != nil {
+		return nil, false, errFetch
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, errFetch
+	}
+	keySet, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, false, errFetch
+	}
+
+	jwksMutex.Lock()
+	jwksCache[uri] = jwksCacheEntry{keySet: keySet, fetchedAt: time.Now()}
+	jwksMutex.Unlock()
+	return keySet, true, nil
+}
+
+// verifyJWTSignature - Verify the RS256 or ES256 signature, other algorithms are rejected
+func verifyJWTSignature(alg string, key jsonWebKey, signingInput string, signature []byte) bool {
+	hash := sha256.Sum256([]byte(signingInput))
+
+	switch {
+	case alg == "RS256" && key.Kty == "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(key.N)
+		e, errE := base64.RawURLEncoding.DecodeString(key.E)
+		if errN != nil || errE != nil {
+			return false
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature) == nil
+
+	case alg == "ES256" && key.Kty == "EC" && key.Crv == "P-256":
+		x, errX := base64.RawURLEncoding.DecodeString(key.X)
+		y, errY := base64.RawURLEncoding.DecodeString(key.Y)
+		if errX != nil || errY != nil || len(signature) != 64 {
+			return false
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(publicKey, hash[:], r, s)
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package sales_common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+const testIssuer = "https://accounts.example.com"
+
+type testSigner struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{rsaKey: rsaKey, ecKey: ecKey}
+}
+
+func (s testSigner) jwks(t *testing.T) []byte {
+	b64 := base64.RawURLEncoding.EncodeToString
+	keySet := jsonWebKeySet{Keys: []jsonWebKey{
+		{Kty: "RSA", Kid: "rsa1", Alg: "RS256", N: b64(s.rsaKey.N.Bytes()), E: b64(big.NewInt(int64(s.rsaKey.E)).Bytes())},
+		{Kty: "EC", Kid: "ec1", Alg: "ES256", Crv: "P-256", X: b64(s.ecKey.X.FillBytes(make([]byte, 32))), Y: b64(s.ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	data, err := json.Marshal(keySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (s testSigner) sign(t *testing.T, alg string, kid string, claims utils.Map) string {
+	header, _ := json.Marshal(utils.Map{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case "ES256":
+		r, sv, err := ecdsa.Sign(rand.Reader, s.ecKey, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), sv.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testClaims(overrides utils.Map) utils.Map {
+	now := time.Now().Unix()
+	claims := utils.Map{
+		"iss":   testIssuer,
+		"aud":   "client-1",
+		"sub":   "user-1",
+		"iat":   now,
+		"exp":   now + 600,
+		"nonce": "n-1",
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	signer := newTestSigner(t)
+	provider := OIDCProvider{Provider: "example", Issuer: testIssuer, ClientIds: []string{"client-1", "client-2"}, JWKS: signer.jwks(t)}
+	now := time.Now().Unix()
+
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		payload, _ := json.Marshal(testClaims(utils.Map{"sub": "user-2"}))
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr string
+	}{
+		{"rs256", signer.sign(t, "RS256", "rsa1", testClaims(nil)), "n-1", ""},
+		{"es256", signer.sign(t, "ES256", "ec1", testClaims(nil)), "n-1", ""},
+		{"no nonce requested", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"nonce": nil})), "", ""},
+		{"audience list", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"aud": []string{"other", "client-2"}})), "n-1", ""},
+		{"within clock skew", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"exp": now - OIDC_CLOCK_SKEW_SECONDS/2})), "n-1", ""},
+		{"malformed", "not-a-token", "", "Invalid ID Token"},
+		{"tampered payload", tamper(signer.sign(t, "RS256", "rsa1", testClaims(nil))), "", "Invalid ID Token"},
+		{"alg none", signer.sign(t, "none", "rsa1", testClaims(nil)), "", "Invalid ID Token"},
+		{"alg of other key type", signer.sign(t, "RS256", "ec1", testClaims(nil)), "", "Invalid ID Token"},
+		{"unknown kid", signer.sign(t, "RS256", "rsa2", testClaims(nil)), "", "Invalid ID Token"},
+		{"wrong issuer", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"iss": "https://evil.example.com"})), "", "Invalid ID Token"},
+		{"wrong audience", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"aud": "client-3"})), "", "Invalid ID Token"},
+		{"no audience", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"aud": nil})), "", "Invalid ID Token"},
+		{"expired", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"exp": now - 2*OIDC_CLOCK_SKEW_SECONDS})), "", "Expired ID Token"},
+		{"no expiry", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"exp": nil})), "", "Expired ID Token"},
+		{"not yet valid", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"nbf": now + 2*OIDC_CLOCK_SKEW_SECONDS})), "", "Invalid ID Token"},
+		{"wrong nonce", signer.sign(t, "RS256", "rsa1", testClaims(nil)), "n-2", "Invalid ID Token"},
+		{"missing nonce", signer.sign(t, "RS256", "rsa1", testClaims(utils.Map{"nonce": nil})), "n-1", "Invalid ID Token"},
+		{"nonce not given", signer.sign(t, "RS256", "rsa1", testClaims(nil)), "", "Invalid ID Token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := VerifyIDToken(tt.token, provider, tt.nonce)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("VerifyIDToken() error = %v", err)
+				}
+				if claims["sub"] != "user-1" {
+					t.Errorf("VerifyIDToken() sub = %v, want user-1", claims["sub"])
+				}
+				return
+			}
+			appErr, ok := err.(*utils.AppError)
+			if !ok {
+				t.Fatalf("VerifyIDToken() error = %v, want %q", err, tt.wantErr)
+			}
+			if appErr.ErrorMsg != tt.wantErr || appErr.ErrorStatus != 401 {
+				t.Errorf("VerifyIDToken() error = %d %q, want 401 %q", appErr.ErrorStatus, appErr.ErrorMsg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenFetchesKeys(t *testing.T) {
+	signer := newTestSigner(t)
+	keySet := signer.jwks(t)
+
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(keySet)
+	}))
+	defer server.Close()
+
+	provider := OIDCProvider{Provider: "example", Issuer: testIssuer, ClientIds: []string{"client-1"}, JWKSUri: server.URL}
+
+	if _, err := VerifyIDToken(signer.sign(t, "RS256", "rsa1", testClaims(nil)), provider, "n-1"); err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if _, err := VerifyIDToken(signer.sign(t, "ES256", "ec1", testClaims(nil)), provider, "n-1"); err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("key set fetched %d times, want 1 from the cache", got)
+	}
+
+	// Unknown keys do not fetch the key set again within OIDC_JWKS_REFETCH_SECONDS
+	for i := 0; i < 5; i++ {
+		if _, err := VerifyIDToken(signer.sign(t, "RS256", "rsa2", testClaims(nil)), provider, ""); err == nil {
+			t.Fatal("VerifyIDToken() with unknown key, want error")
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("key set fetched %d times for unknown keys, want 1", got)
+	}
+
+	// Once the refetch interval is over, an unknown key fetches the rotated key set
+	jwksMutex.Lock()
+	entry := jwksCache[server.URL]
+	entry.fetchedAt = entry.fetchedAt.Add(-OIDC_JWKS_REFETCH_SECONDS * time.Second)
+	jwksCache[server.URL] = entry
+	jwksMutex.Unlock()
+
+	if _, err := VerifyIDToken(signer.sign(t, "RS256", "rsa2", testClaims(nil)), provider, ""); err == nil {
+		t.Fatal("VerifyIDToken() with unknown key, want error")
+	}
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Errorf("key set fetched %d times after the refetch interval, want 2", got)
+	}
+}
@@ -0,0 +1,68 @@
+package sales_common
+
+import (
+	"crypto/subtle"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing
+//
+// Passwords are stored in bcrypt modular crypt format "$2a$<cost>$<salt+hash>", the prefix carries
+// the algorithm version and cost, so the hash can be upgraded later without breaking old ones.
+// Hashes created by earlier versions are unsalted SHA-256 hex strings (utils.SHA), these are still
+// verified and reported as NeedsRehash so that they get replaced on the next successful login.
+const (
+	PASSWORD_HASH_COST = 12
+)
+
+var legacySHAHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// VerifyDummyPassword - Verify the password against the hash of a random password, when the login is not found,
+// so that the unknown login takes as long as a wrong password
+func VerifyDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword(utils.GenerateUniqueId("pwd"))
+	})
+	VerifyPassword(dummyHash, password)
+}
+
+// HashPassword - Hash the password with the current algorithm
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PASSWORD_HASH_COST)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerifyPassword - Verify the password against the stored hash, needsRehash is true when the
+// password matches but the stored hash is not in the current format
+func VerifyPassword(hash string, password string) (matched bool, needsRehash bool) {
+
+	if legacySHAHash.MatchString(hash) {
+		matched = subtle.ConstantTimeCompare([]byte(hash), []byte(utils.SHA(password))) == 1
+		return matched, matched
+	}
+
+	if !strings.HasPrefix(hash, "$2") {
+		// Unknown format
+		return false, false
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, err != nil || cost < PASSWORD_HASH_COST
+}
@@ -0,0 +1,86 @@
+package sales_common
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// Signed Tokens
+//
+// Token is "<base64 payload>.<base64 HMAC-SHA256 of payload>", the payload values are joined with "|".
+// The Token is not encrypted, so the payload must not carry secrets. The signing key is set by the
+// application on startup with SetTokenSigningKey and should be the same on all the instances.
+var (
+	tokenMutex      sync.RWMutex
+	tokenSigningKey []byte
+)
+
+const tokenPayloadSeparator = "|"
+
+// SetTokenSigningKey - Set the key to sign the Tokens, at least 32 bytes is recommended
+func SetTokenSigningKey(key []byte) {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+	tokenSigningKey = append([]byte{}, key...)
+}
+
+// SignToken - Create the signed Token for the payload values
+func SignToken(values ...string) (string, error) {
+	key, err := getTokenSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	payload := []byte(strings.Join(values, tokenPayloadSeparator))
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ParseSignedToken - Verify the signature of the Token and return the payload values
+func ParseSignedToken(token string) ([]string, error) {
+	errInvalid := &utils.AppError{ErrorStatus: 400, ErrorMsg: "Invalid Token", ErrorDetail: "Given Token is not valid"}
+
+	key, err := getTokenSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encPayload, encSignature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, errInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return nil, errInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encSignature)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errInvalid
+	}
+
+	return strings.Split(string(payload), tokenPayloadSeparator), nil
+}
+
+func getTokenSigningKey() ([]byte, error) {
+	tokenMutex.RLock()
+	defer tokenMutex.RUnlock()
+
+	if len(tokenSigningKey) == 0 {
+		err := &utils.AppError{ErrorStatus: 500, ErrorMsg: "Signing key not configured", ErrorDetail: "Call sales_common.SetTokenSigningKey to sign the Tokens"}
+		return nil, err
+	}
+	return tokenSigningKey, nil
+}
@@ -0,0 +1,63 @@
+package sales_migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// Database in which the Migration collection exist
+const (
+	DATABASE_REGION = "region" // Default, most of the sales collections
+	DATABASE_MAIN   = "main"
+)
+
+// Migration - A single reshape of documents in a collection across all the businesses.
+//
+// Migrations are applied in the order of Id and recorded in sales_migrations once applied,
+// so the Id must never be changed or reused. The Filter must select only the documents
+// which still need the change, so that re-running a Migration is harmless.
+type Migration struct {
+	Id          string // Sortable unique id, e.g. "20231120_01_customer_type_id"
+	Description string
+	Collection  string
+	Database    string // DATABASE_REGION or DATABASE_MAIN, default DATABASE_REGION
+
+	// Filter and Update are applied with UpdateMany
+	Filter utils.Map
+	Update utils.Map
+	// Pipeline - Optional, aggregation pipeline applied with the Filter instead of Update, for the values
+	// computed from the document itself
+	Pipeline []utils.Map
+
+	// Apply - Optional, used instead of Filter/Update for reshapes which need more than one update.
+	// It must return the number of documents changed, or to be changed when dryRun is true
+	Apply func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error)
+}
+
+var registeredMigrations = map[string]Migration{}
+
+// Register - Register the Migration, usually from init()
+func Register(migrations ...Migration) {
+	for _, m := range migrations {
+		if _, exist := registeredMigrations[m.Id]; exist {
+			panic(fmt.Sprintf("sales_migrations: Migration %s registered twice", m.Id))
+		}
+		registeredMigrations[m.Id] = m
+	}
+}
+
+// GetMigrations - Get all the registered Migrations in the order to be applied
+func GetMigrations() []Migration {
+	migrations := make([]Migration, 0, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Id < migrations[j].Id
+	})
+	return migrations
+}
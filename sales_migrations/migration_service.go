@@ -0,0 +1,244 @@
+package sales_migrations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// MigrationService - Apply the registered Migrations.
+//
+// Migrations run on the databases of the given business (main and region) without business filter,
+// so all the businesses sharing those databases are migrated together. Applied Migrations are
+// recorded in the same database, hence running it for another business of the same region skips them.
+type MigrationService interface {
+	// List - List all the Migrations with the applied details if applied already
+	List() ([]utils.Map, error)
+	// Run - Run the pending Migrations in MIGRATION_MODE_UP or MIGRATION_MODE_DRY_RUN
+	Run(mode string) (utils.Map, error)
+
+	EndService()
+}
+
+type migrationBaseService struct {
+	db_utils.DatabaseService
+	dbRegion           db_utils.DatabaseService
+	daoMigrationMain   sales_repository.MigrationDao
+	daoMigrationRegion sales_repository.MigrationDao
+	daoBusiness        platform_repository.BusinessDao
+	child              MigrationService
+	businessId         string
+	ctx                context.Context
+}
+
+// NewMigrationService - Construct Migration Service
+func NewMigrationService(props utils.Map) (MigrationService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("MigrationService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := migrationBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// EndService - Close all the services
+func (p *migrationBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *migrationBaseService) initializeService() {
+	log.Printf("MigrationService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoMigrationMain = sales_repository.NewMigrationDao(p.GetClient())
+	p.daoMigrationRegion = sales_repository.NewMigrationDao(p.dbRegion.GetClient())
+}
+
+// List - List all the Migrations with the applied details if applied already
+func (p *migrationBaseService) List() ([]utils.Map, error) {
+
+	log.Println("MigrationService::List - Begin")
+
+	applied, err := p.getApplied()
+	if err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, m := range GetMigrations() {
+		data, isApplied := applied[m.Database][m.Id]
+		if !isApplied {
+			data = migrationData(m)
+		}
+		listdata = append(listdata, data)
+	}
+
+	log.Println("MigrationService::List - End ", len(listdata))
+	return listdata, nil
+}
+
+// Run - Run the pending Migrations in MIGRATION_MODE_UP or MIGRATION_MODE_DRY_RUN
+func (p *migrationBaseService) Run(mode string) (utils.Map, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Println("MigrationService::Run - Begin ", mode)
+
+	if mode != sales_common.MIGRATION_MODE_UP && mode != sales_common.MIGRATION_MODE_DRY_RUN {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "02",
+			ErrorMsg:    "Invalid Mode",
+			ErrorDetail: "Migration mode should be up or dry_run"}
+		return nil, err
+	}
+	dryRun := mode == sales_common.MIGRATION_MODE_DRY_RUN
+
+	applied, err := p.getApplied()
+	if err != nil {
+		return nil, err
+	}
+
+	listApplied := []utils.Map{}
+	listPending := []utils.Map{}
+	listSkipped := []utils.Map{}
+	getReport := func() utils.Map {
+		return utils.Map{
+			sales_common.MIGRATION_REPORT_MODE:    mode,
+			sales_common.MIGRATION_REPORT_APPLIED: listApplied,
+			sales_common.MIGRATION_REPORT_PENDING: listPending,
+			sales_common.MIGRATION_REPORT_SKIPPED: listSkipped,
+		}
+	}
+
+	for _, m := range GetMigrations() {
+		if data, isApplied := applied[m.Database][m.Id]; isApplied {
+			listSkipped = append(listSkipped, data)
+			continue
+		}
+
+		data := migrationData(m)
+		affected, err := p.applyMigration(m, dryRun)
+		if err != nil {
+			// Stop here, the later Migrations may depend on this one
+			log.Println("MigrationService::Run - Failed ", m.Id, err)
+			return getReport(), err
+		}
+		data[sales_common.FLD_MIGRATION_AFFECTED] = affected
+
+		if dryRun {
+			listPending = append(listPending, data)
+			continue
+		}
+
+		data[sales_common.FLD_MIGRATION_APPLIED_AT] = time.Now()
+		data, err = p.getDao(m.Database).Create(p.ctx, data)
+		if err != nil {
+			return getReport(), err
+		}
+		listApplied = append(listApplied, data)
+	}
+
+	log.Println("MigrationService::Run - End ", len(listApplied), len(listPending), len(listSkipped))
+	return getReport(), nil
+}
+
+func (p *migrationBaseService) applyMigration(m Migration, dryRun bool) (int64, error) {
+	dao := p.getDao(m.Database)
+
+	if m.Apply != nil {
+		return m.Apply(p.ctx, dao, dryRun)
+	}
+
+	if dryRun {
+		return dao.CountDocuments(p.ctx, m.Collection, m.Filter)
+	}
+	if len(m.Pipeline) > 0 {
+		return dao.UpdateMany(p.ctx, m.Collection, m.Filter, m.Pipeline)
+	}
+	return dao.UpdateMany(p.ctx, m.Collection, m.Filter, m.Update)
+}
+
+// getApplied - Get the applied Migrations for each Database
+func (p *migrationBaseService) getApplied() (map[string]map[string]utils.Map, error) {
+	applied := map[string]map[string]utils.Map{}
+
+	for _, database := range []string{DATABASE_MAIN, DATABASE_REGION} {
+		records, err := p.getDao(database).List(p.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[database] = map[string]utils.Map{}
+		for _, record := range records {
+			migrationId, _ := utils.GetMemberDataStr(record, sales_common.FLD_MIGRATION_ID)
+			applied[database][migrationId] = record
+		}
+	}
+	// Migration with empty Database belongs to the region
+	applied[""] = applied[DATABASE_REGION]
+
+	return applied, nil
+}
+
+func (p *migrationBaseService) getDao(database string) sales_repository.MigrationDao {
+	if database == DATABASE_MAIN {
+		return p.daoMigrationMain
+	}
+	return p.daoMigrationRegion
+}
+
+func migrationData(m Migration) utils.Map {
+	return utils.Map{
+		sales_common.FLD_MIGRATION_ID:          m.Id,
+		sales_common.FLD_MIGRATION_DESCRIPTION: m.Description,
+		sales_common.FLD_MIGRATION_COLLECTION:  m.Collection,
+	}
+}
+
+func (p *migrationBaseService) errorReturn(err error) (MigrationService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -0,0 +1,258 @@
+package sales_migrations
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// fakeMigrationDao - Collections of a database in memory, filters support equality and $exists, updates support
+// $set and $rename. Pipelines are counted but not applied
+type fakeMigrationDao struct {
+	sales_repository.MigrationDao
+	collections map[string][]utils.Map
+	records     []utils.Map
+	updates     int
+}
+
+func newFakeMigrationDao() *fakeMigrationDao {
+	return &fakeMigrationDao{collections: map[string][]utils.Map{}}
+}
+
+func (f *fakeMigrationDao) List(ctx context.Context) ([]utils.Map, error) {
+	return f.records, nil
+}
+
+func (f *fakeMigrationDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	f.records = append(f.records, indata)
+	return indata, nil
+}
+
+func (f *fakeMigrationDao) CountDocuments(ctx context.Context, collection string, filter utils.Map) (int64, error) {
+	var count int64
+	for _, doc := range f.collections[collection] {
+		if matchDoc(doc, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeMigrationDao) UpdateMany(ctx context.Context, collection string, filter utils.Map, update interface{}) (int64, error) {
+	f.updates++
+	var count int64
+	for _, doc := range f.collections[collection] {
+		if !matchDoc(doc, filter) {
+			continue
+		}
+		count++
+		operators, ok := update.(utils.Map)
+		if !ok {
+			continue
+		}
+		if fields, ok := operators["$set"].(utils.Map); ok {
+			for key, value := range fields {
+				doc[key] = value
+			}
+		}
+		if fields, ok := operators["$rename"].(utils.Map); ok {
+			for fromKey, toKey := range fields {
+				if value, exist := doc[fromKey]; exist {
+					doc[toKey.(string)] = value
+					delete(doc, fromKey)
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeMigrationDao) CreateIndex(ctx context.Context, collection string, keys []string, unique bool, partialFilter utils.Map) (string, error) {
+	return collection + "_index", nil
+}
+
+func matchDoc(doc utils.Map, filter utils.Map) bool {
+	for key, cond := range filter {
+		value, exist := doc[key]
+		if operators, ok := cond.(utils.Map); ok {
+			for operator, operand := range operators {
+				if operator != "$exists" || operand.(bool) != exist {
+					return false
+				}
+			}
+		} else if !exist || value != cond {
+			return false
+		}
+	}
+	return true
+}
+
+func newTestMigrationService() (*migrationBaseService, *fakeMigrationDao, *fakeMigrationDao) {
+	daoMain, daoRegion := newFakeMigrationDao(), newFakeMigrationDao()
+	daoRegion.collections[sales_common.DbCustomers] = []utils.Map{
+		{sales_common.FLD_CUSTOMER_ID: "cust1", "customertype_id": "retail", "is_deleted": false},
+		{sales_common.FLD_CUSTOMER_ID: "cust2", "customertype_id": "dealer"},
+		{sales_common.FLD_CUSTOMER_ID: "cust3", sales_common.FLD_CUSTOMER_TYPE_ID: "retail", "is_deleted": false},
+	}
+	p := &migrationBaseService{daoMigrationMain: daoMain, daoMigrationRegion: daoRegion, ctx: context.Background()}
+	return p, daoMain, daoRegion
+}
+
+// reportAffected - Affected count of the Migrations in the report list by Id
+func reportAffected(report utils.Map, list string) map[string]int64 {
+	result := map[string]int64{}
+	for _, data := range report[list].([]utils.Map) {
+		migrationId, _ := utils.GetMemberDataStr(data, sales_common.FLD_MIGRATION_ID)
+		affected, _ := data[sales_common.FLD_MIGRATION_AFFECTED].(int64)
+		result[migrationId] = affected
+	}
+	return result
+}
+
+func TestRunDryRun(t *testing.T) {
+	p, daoMain, daoRegion := newTestMigrationService()
+
+	report, err := p.Run(sales_common.MIGRATION_MODE_DRY_RUN)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	pending := reportAffected(report, sales_common.MIGRATION_REPORT_PENDING)
+	if len(pending) != len(GetMigrations()) {
+		t.Errorf("Run() pending %d Migrations, want %d", len(pending), len(GetMigrations()))
+	}
+	for migrationId, want := range map[string]int64{
+		"20231120_01_is_deleted_" + sales_common.DbCustomers:                  1,
+		"20231206_01_rename_customer_type_id_" + sales_common.DbCustomers:     2,
+		"20231206_01_rename_customer_type_id_" + sales_common.DbCustomerTypes: 0,
+	} {
+		if got := pending[migrationId]; got != want {
+			t.Errorf("Run() %s affected = %d, want %d", migrationId, got, want)
+		}
+	}
+
+	if daoMain.updates+daoRegion.updates != 0 || len(daoMain.records)+len(daoRegion.records) != 0 {
+		t.Errorf("Run() in dry run updated %d times and recorded %d Migrations, want none", daoMain.updates+daoRegion.updates, len(daoMain.records)+len(daoRegion.records))
+	}
+	if _, ok := daoRegion.collections[sales_common.DbCustomers][0]["customertype_id"]; !ok {
+		t.Error("Run() in dry run renamed the field")
+	}
+}
+
+func TestRunUpOnce(t *testing.T) {
+	p, daoMain, daoRegion := newTestMigrationService()
+
+	report, err := p.Run(sales_common.MIGRATION_MODE_UP)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	applied := reportAffected(report, sales_common.MIGRATION_REPORT_APPLIED)
+	if len(applied) != len(GetMigrations()) {
+		t.Errorf("Run() applied %d Migrations, want %d", len(applied), len(GetMigrations()))
+	}
+	if got := applied["20231206_01_rename_customer_type_id_"+sales_common.DbCustomers]; got != 2 {
+		t.Errorf("Run() renamed %d Customers, want 2", got)
+	}
+
+	for _, doc := range daoRegion.collections[sales_common.DbCustomers] {
+		if _, ok := doc["customertype_id"]; ok {
+			t.Errorf("Customer %v still has customertype_id", doc[sales_common.FLD_CUSTOMER_ID])
+		}
+		if _, ok := doc[sales_common.FLD_CUSTOMER_TYPE_ID]; !ok {
+			t.Errorf("Customer %v has no %s", doc[sales_common.FLD_CUSTOMER_ID], sales_common.FLD_CUSTOMER_TYPE_ID)
+		}
+		if doc["is_deleted"] != false {
+			t.Errorf("Customer %v is_deleted = %v, want false", doc[sales_common.FLD_CUSTOMER_ID], doc["is_deleted"])
+		}
+	}
+	if got := daoRegion.collections[sales_common.DbCustomers][1][sales_common.FLD_CUSTOMER_TYPE_ID]; got != "dealer" {
+		t.Errorf("Customer cust2 %s = %v, want dealer", sales_common.FLD_CUSTOMER_TYPE_ID, got)
+	}
+
+	// Recorded in the database of each Migration
+	for _, m := range GetMigrations() {
+		records := daoRegion.records
+		if m.Database == DATABASE_MAIN {
+			records = daoMain.records
+		}
+		found := false
+		for _, record := range records {
+			found = found || record[sales_common.FLD_MIGRATION_ID] == m.Id
+		}
+		if !found {
+			t.Errorf("Migration %s is not recorded in the %s database", m.Id, m.Database)
+		}
+	}
+
+	// Applied Migrations are skipped when run again
+	updates := daoMain.updates + daoRegion.updates
+	report, err = p.Run(sales_common.MIGRATION_MODE_UP)
+	if err != nil {
+		t.Fatalf("Run() again error = %v", err)
+	}
+	if got := len(report[sales_common.MIGRATION_REPORT_SKIPPED].([]utils.Map)); got != len(GetMigrations()) {
+		t.Errorf("Run() again skipped %d Migrations, want %d", got, len(GetMigrations()))
+	}
+	if got := len(report[sales_common.MIGRATION_REPORT_APPLIED].([]utils.Map)); got != 0 {
+		t.Errorf("Run() again applied %d Migrations, want 0", got)
+	}
+	if daoMain.updates+daoRegion.updates != updates {
+		t.Error("Run() again updated the documents")
+	}
+}
+
+func TestRenameMigrationIdempotent(t *testing.T) {
+	m := renameMigration("test_rename", sales_common.DbCustomers, DATABASE_REGION, "customertype_id", sales_common.FLD_CUSTOMER_TYPE_ID)
+	dao := newFakeMigrationDao()
+	dao.collections[sales_common.DbCustomers] = []utils.Map{
+		{"customertype_id": "retail"},
+		// Both names, the new one is kept
+		{"customertype_id": "retail", sales_common.FLD_CUSTOMER_TYPE_ID: "dealer"},
+	}
+
+	for i, want := range []int64{1, 0} {
+		count, err := dao.UpdateMany(context.Background(), m.Collection, m.Filter, m.Update)
+		if err != nil || count != want {
+			t.Errorf("apply %d = %d, %v, want %d", i+1, count, err, want)
+		}
+	}
+	if got := dao.collections[sales_common.DbCustomers][1][sales_common.FLD_CUSTOMER_TYPE_ID]; got != "dealer" {
+		t.Errorf("%s = %v, want the new value dealer kept", sales_common.FLD_CUSTOMER_TYPE_ID, got)
+	}
+}
+
+func TestRunInvalidMode(t *testing.T) {
+	p, _, _ := newTestMigrationService()
+	if _, err := p.Run("down"); err == nil {
+		t.Error("Run(down) want error")
+	}
+}
+
+func TestNormalizePhoneFilter(t *testing.T) {
+	var filter utils.Map
+	for _, m := range GetMigrations() {
+		if m.Id == "20231207_04_normalize_"+sales_common.FLD_CUSTOMER_PHONE {
+			filter = m.Filter
+		}
+	}
+	if filter == nil {
+		t.Fatal("phone normalization Migration is not registered")
+	}
+	pattern := regexp.MustCompile(filter[sales_common.FLD_CUSTOMER_PHONE].(utils.Map)["$regex"].(string))
+
+	for phone, want := range map[string]bool{
+		"+91 98765 43210":   true,
+		"+1 (415) 555-0100": true,
+		"00919876543210":    true,
+		"+919876543210":     false, // Normalized
+		"98765 43210":       false, // Needs the country code of the business
+	} {
+		if got := pattern.MatchString(phone); got != want {
+			t.Errorf("filter matches %q = %v, want %v", phone, got, want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package sales_migrations
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+//
+// Migrations shipped with golib-sales, add the new ones at the end with a greater Id
+//
+
+func init() {
+	// Documents created without is_deleted flag are not visible to List/Get/Find
+	// since all the DAOs filter on is_deleted = false
+	regionCollections := []string{
+		sales_common.DbRegions, sales_common.DbBanners, sales_common.DbBrands,
+		sales_common.DbCatalogues, sales_common.DbCategories, sales_common.DbProducts,
+		sales_common.DbTestimonials, sales_common.DbBlogs, sales_common.DbCustomers,
+		sales_common.DbCustomerCarts, sales_common.DbPolicies, sales_common.DbPayments,
+		sales_common.DbNavigations, sales_common.DbPreferences, sales_common.DbProdPreferences,
+		sales_common.DbPages, sales_common.DbDealers, sales_common.DbOffers,
+		sales_common.DbMedias, sales_common.DbCampaigns, sales_common.DbStates,
+		sales_common.DbRatings, sales_common.DbMaterialTypes, sales_common.DbCoupons,
+		sales_common.DbCustomerTypes,
+	}
+	mainCollections := []string{
+		sales_common.DbCustomerOrders, sales_common.DbCustomerWishlists,
+		sales_common.DbCustomerReviews, sales_common.DbQuiz, sales_common.DbCallbacks,
+	}
+
+	for _, collection := range regionCollections {
+		Register(isDeletedMigration(collection, DATABASE_REGION))
+	}
+	for _, collection := range mainCollections {
+		Register(isDeletedMigration(collection, DATABASE_MAIN))
+	}
+
+	// LoyaltyLedger upserts the earn and refund transactions of an Order, the unique index keeps a
+	// parallel upsert from adding the second one
+	Register(Migration{
+		Id:          "20231201_01_loyalty_txns_order_index",
+		Description: "Create the unique index of the Order transactions of each type",
+		Collection:  sales_common.DbLoyaltyTxns,
+		Database:    DATABASE_REGION,
+		Apply: func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error) {
+			if dryRun {
+				return 0, nil
+			}
+			keys := []string{sales_common.FLD_BUSINESS_ID, sales_common.FLD_CUSTOMER_ORDER_ID, sales_common.FLD_LOYALTY_TXN_TYPE}
+			partialFilter := utils.Map{sales_common.FLD_CUSTOMER_ORDER_ID: utils.Map{"$gt": ""}}
+			_, err := dao.CreateIndex(ctx, sales_common.DbLoyaltyTxns, keys, true, partialFilter)
+			return 0, err
+		},
+	})
+
+	// SKU and Barcode of the Variants are checked before the write, the unique indexes keep the Variants
+	// written in parallel from getting the same one
+	Register(Migration{
+		Id:          "20231205_01_product_variants_unique",
+		Description: "Create the unique indexes of the Variant SKU and Barcode",
+		Collection:  sales_common.DbProductVariants,
+		Database:    DATABASE_REGION,
+		Apply: func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error) {
+			if dryRun {
+				return 0, nil
+			}
+			for _, field := range []string{sales_common.FLD_VARIANT_SKU, sales_common.FLD_VARIANT_BARCODE} {
+				keys := []string{sales_common.FLD_BUSINESS_ID, field}
+				partialFilter := utils.Map{db_common.FLD_IS_DELETED: false, field: utils.Map{"$gt": ""}}
+				_, err := dao.CreateIndex(ctx, sales_common.DbProductVariants, keys, true, partialFilter)
+				if err != nil {
+					return 0, err
+				}
+			}
+			return 0, nil
+		},
+	})
+
+	// Field names of sales_common follow <entity>_<field>, the customer type was stored as customertype_id
+	for _, collection := range []string{sales_common.DbCustomerTypes, sales_common.DbCustomers, sales_common.DbPriceTiers, sales_common.DbPriceLists} {
+		Register(renameMigration("20231206_01_rename_customer_type_id_"+collection, collection, DATABASE_REGION, "customertype_id", sales_common.FLD_CUSTOMER_TYPE_ID))
+	}
+
+	// CreditLedger stored the amounts as double rounded to 2 decimals, they are Decimal128 now
+	Register(decimalMigration("20231207_01_credit_decimal_"+sales_common.DbCreditAccounts, sales_common.DbCreditAccounts,
+		sales_common.FLD_CREDIT_LIMIT, sales_common.FLD_CREDIT_OUTSTANDING))
+	Register(decimalMigration("20231207_01_credit_decimal_"+sales_common.DbCreditInvoices, sales_common.DbCreditInvoices,
+		sales_common.FLD_INVOICE_AMOUNT, sales_common.FLD_INVOICE_PAID))
+	Register(Migration{
+		Id:          "20231207_02_credit_decimal_" + sales_common.FLD_INVOICE_PAYMENTS,
+		Description: "Convert the " + sales_common.FLD_INVOICE_PAYMENT_AMOUNT + " of the payments stored as number to decimal",
+		Collection:  sales_common.DbCreditInvoices,
+		Database:    DATABASE_REGION,
+		Filter:      utils.Map{sales_common.FLD_INVOICE_PAYMENTS + "." + sales_common.FLD_INVOICE_PAYMENT_AMOUNT: utils.Map{"$type": numberTypes}},
+		Pipeline: []utils.Map{{"$set": utils.Map{sales_common.FLD_INVOICE_PAYMENTS: utils.Map{"$map": utils.Map{
+			"input": "$" + sales_common.FLD_INVOICE_PAYMENTS,
+			"as":    "payment",
+			"in": utils.Map{"$mergeObjects": []interface{}{"$$payment", utils.Map{
+				sales_common.FLD_INVOICE_PAYMENT_AMOUNT: decimalOf("$$payment." + sales_common.FLD_INVOICE_PAYMENT_AMOUNT)}}},
+		}}}}},
+	})
+
+	// Publish takes the next version after the latest one of the Catalogue, the unique index fails the publish
+	// of the same version in parallel
+	Register(Migration{
+		Id:          "20231207_03_catalogue_versions_unique",
+		Description: "Create the unique index of the Catalogue versions",
+		Collection:  sales_common.DbCatalogueVersions,
+		Database:    DATABASE_REGION,
+		Apply: func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error) {
+			if dryRun {
+				return 0, nil
+			}
+			keys := []string{sales_common.FLD_BUSINESS_ID, sales_common.FLD_CATALOGUE_ID, sales_common.FLD_CATALOGUE_VERSION}
+			_, err := dao.CreateIndex(ctx, sales_common.DbCatalogueVersions, keys, true, nil)
+			return 0, err
+		},
+	})
+
+	// LoginId and Email are stored trimmed and lower-cased, Phone in E.164. Phones stored without the country
+	// code need the country code of the business, they are normalized when the Customer is updated
+	for _, field := range []string{sales_common.FLD_CUSTOMER_LOGIN_ID, sales_common.FLD_CUSTOMER_EMAIL} {
+		Register(lowerCaseMigration("20231207_04_normalize_"+field, sales_common.DbCustomers, field))
+	}
+	Register(Migration{
+		Id:          "20231207_04_normalize_" + sales_common.FLD_CUSTOMER_PHONE,
+		Description: "Remove the separators from the Phones with country code",
+		Collection:  sales_common.DbCustomers,
+		Database:    DATABASE_REGION,
+		Filter:      utils.Map{sales_common.FLD_CUSTOMER_PHONE: utils.Map{"$regex": `^(00|\+.*[ ()\-.])`}},
+		Pipeline:    phonePipeline(sales_common.FLD_CUSTOMER_PHONE),
+	})
+
+	// Logins are checked before the write, the unique indexes keep the Customers written in parallel from
+	// getting the same login. Fails when two Customers have the same login, see the duplicates report
+	Register(Migration{
+		Id:          "20231207_05_customer_logins_unique",
+		Description: "Create the unique indexes of the Customer LoginId, Email and Phone",
+		Collection:  sales_common.DbCustomers,
+		Database:    DATABASE_REGION,
+		Apply: func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error) {
+			if dryRun {
+				return 0, nil
+			}
+			for _, field := range []string{sales_common.FLD_CUSTOMER_LOGIN_ID, sales_common.FLD_CUSTOMER_EMAIL, sales_common.FLD_CUSTOMER_PHONE} {
+				keys := []string{sales_common.FLD_BUSINESS_ID, field}
+				partialFilter := utils.Map{db_common.FLD_IS_DELETED: false, field: utils.Map{"$gt": ""}}
+				_, err := dao.CreateIndex(ctx, sales_common.DbCustomers, keys, true, partialFilter)
+				if err != nil {
+					return 0, err
+				}
+			}
+			return 0, nil
+		},
+	})
+
+	// Two sign ins with the same ID Token at the same time should not link the identity twice
+	Register(Migration{
+		Id:          "20231207_06_customer_identities_unique",
+		Description: "Create the unique index of the Customer identities",
+		Collection:  sales_common.DbCustomerIdentities,
+		Database:    DATABASE_REGION,
+		Apply: func(ctx context.Context, dao sales_repository.MigrationDao, dryRun bool) (int64, error) {
+			if dryRun {
+				return 0, nil
+			}
+			keys := []string{sales_common.FLD_BUSINESS_ID, sales_common.FLD_IDENTITY_PROVIDER, sales_common.FLD_IDENTITY_SUBJECT}
+			partialFilter := utils.Map{db_common.FLD_IS_DELETED: false}
+			_, err := dao.CreateIndex(ctx, sales_common.DbCustomerIdentities, keys, true, partialFilter)
+			return 0, err
+		},
+	})
+}
+
+// numberTypes - BSON types of the amounts stored as number instead of decimal
+var numberTypes = []string{"double", "int", "long"}
+
+func isDeletedMigration(collection string, database string) Migration {
+	return Migration{
+		Id:          "20231120_01_is_deleted_" + collection,
+		Description: "Set is_deleted to false where it is missing",
+		Collection:  collection,
+		Database:    database,
+		Filter:      utils.Map{db_common.FLD_IS_DELETED: utils.Map{"$exists": false}},
+		Update:      utils.Map{"$set": utils.Map{db_common.FLD_IS_DELETED: false}},
+	}
+}
+
+// decimalMigration - Convert the fields stored as number to decimal rounded to 2 decimals, the fields
+// already decimal are kept
+func decimalMigration(id string, collection string, fields ...string) Migration {
+	filters := []utils.Map{}
+	values := utils.Map{}
+	for _, field := range fields {
+		filters = append(filters, utils.Map{field: utils.Map{"$type": numberTypes}})
+		values[field] = decimalOf("$" + field)
+	}
+	return Migration{
+		Id:          id,
+		Description: "Convert the amounts stored as number to decimal",
+		Collection:  collection,
+		Database:    DATABASE_REGION,
+		Filter:      utils.Map{"$or": filters},
+		Pipeline:    []utils.Map{{"$set": values}},
+	}
+}
+
+// decimalOf - Aggregation expression of the number as decimal rounded to 2 decimals, any other value is kept
+func decimalOf(expression string) utils.Map {
+	return utils.Map{"$cond": []interface{}{
+		utils.Map{"$in": []interface{}{utils.Map{"$type": expression}, numberTypes}},
+		utils.Map{"$round": []interface{}{utils.Map{"$toDecimal": expression}, 2}},
+		expression}}
+}
+
+// lowerCaseMigration - Trim and lower-case the string field where it is not yet
+func lowerCaseMigration(id string, collection string, field string) Migration {
+	normalized := utils.Map{"$toLower": utils.Map{"$trim": utils.Map{"input": "$" + field}}}
+	return Migration{
+		Id:          id,
+		Description: "Trim and lower-case " + field,
+		Collection:  collection,
+		Database:    DATABASE_REGION,
+		Filter: utils.Map{field: utils.Map{"$type": "string"},
+			"$expr": utils.Map{"$ne": []interface{}{"$" + field, normalized}}},
+		Pipeline: []utils.Map{{"$set": utils.Map{field: normalized}}},
+	}
+}
+
+// phonePipeline - Remove the separators from the Phone and replace the international call prefix 00 with +
+func phonePipeline(field string) []utils.Map {
+	var phone interface{} = "$" + field
+	for _, separator := range []string{" ", "-", "(", ")", "."} {
+		phone = utils.Map{"$replaceAll": utils.Map{"input": phone, "find": separator, "replacement": ""}}
+	}
+	return []utils.Map{
+		{"$set": utils.Map{field: phone}},
+		{"$set": utils.Map{field: utils.Map{"$cond": []interface{}{
+			utils.Map{"$eq": []interface{}{utils.Map{"$substrCP": []interface{}{"$" + field, 0, 2}}, "00"}},
+			utils.Map{"$concat": []interface{}{"+", utils.Map{"$substrCP": []interface{}{"$" + field, 2, utils.Map{"$strLenCP": "$" + field}}}}},
+			"$" + field}}}},
+	}
+}
+
+// renameMigration - Rename the field in the documents still having the old name, the documents having both names
+// keep the new one
+func renameMigration(id string, collection string, database string, fromField string, toField string) Migration {
+	return Migration{
+		Id:          id,
+		Description: "Rename " + fromField + " to " + toField,
+		Collection:  collection,
+		Database:    database,
+		Filter:      utils.Map{fromField: utils.Map{"$exists": true}, toField: utils.Map{"$exists": false}},
+		Update:      utils.Map{"$rename": utils.Map{fromField: toField}},
+	}
+}
@@ -0,0 +1,40 @@
+package sales_notifier
+
+import "sync"
+
+// FakeSender - Sender which keeps the Messages in memory, for local development and tests
+type FakeSender struct {
+	mutex    sync.Mutex
+	messages []Message
+	Err      error // Returned from Send when set
+}
+
+// Send - Keep the Message
+func (f *FakeSender) Send(msg Message) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages - Get the Messages sent so far
+func (f *FakeSender) Messages() []Message {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]Message{}, f.messages...)
+}
+
+// LastMessage - Get the last Message sent to the given address
+func (f *FakeSender) LastMessage(to string) (Message, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,79 @@
+package sales_notifier
+
+import (
+	"log"
+	"sync"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// Channels
+const (
+	CHANNEL_SMS      = "sms"
+	CHANNEL_EMAIL    = "email"
+	CHANNEL_WHATSAPP = "whatsapp" // To is the phone number
+)
+
+// Purpose of the Message, so that Sender can pick the template
+const (
+	PURPOSE_OTP    = "otp"
+	PURPOSE_VERIFY = "verify" // Email/Phone verification, Data has the token or code
+
+	PURPOSE_PASSWORD_RESET = "password_reset" // Data has the token
+
+	PURPOSE_CONSENT_CONFIRM = "consent_confirm" // Double opt-in for marketing, Data has the token
+	PURPOSE_CAMPAIGN        = "campaign"        // Marketing, Data has the unsubscribe token and campaign_id
+)
+
+// Keys in Message Data
+const (
+	DATA_TOKEN = "token"
+	DATA_CODE  = "code"
+)
+
+// Message - Message to be delivered to the customer
+type Message struct {
+	Channel    string // CHANNEL_SMS, CHANNEL_EMAIL or CHANNEL_WHATSAPP
+	To         string // Phone number or Email address
+	Purpose    string
+	BusinessId string
+	Subject    string
+	Body       string
+	Data       utils.Map // Values used in the Body, e.g. the OTP, for template based Senders
+}
+
+// Sender - Deliver the Message through SMS/Email gateway
+type Sender interface {
+	Send(msg Message) error
+}
+
+var (
+	mutex  sync.RWMutex
+	sender Sender
+)
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+// SetSender - Set the Sender used by the sales services
+func SetSender(s Sender) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	sender = s
+}
+
+// Send - Send the Message with the configured Sender
+func Send(msg Message) error {
+	mutex.RLock()
+	s := sender
+	mutex.RUnlock()
+
+	if s == nil {
+		err := &utils.AppError{ErrorStatus: 500, ErrorMsg: "Sender not configured", ErrorDetail: "Call sales_notifier.SetSender to deliver " + msg.Channel + " messages"}
+		return err
+	}
+
+	log.Println("Notifier::Send ", msg.Channel, msg.Purpose, msg.To)
+	return s.Send(msg)
+}
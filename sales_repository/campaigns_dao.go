@@ -1,6 +1,9 @@
 package sales_repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
 	"github.com/zapscloud/golib-utils/utils"
@@ -11,17 +14,21 @@ type CampaignDao interface {
 	// InitializeDao
 	InitializeDao(client utils.Map, businessId string)
 	//List - List all Collections
-	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
 	// Get - Get by code
-	Get(campaignId string) (utils.Map, error)
+	Get(ctx context.Context, campaignId string) (utils.Map, error)
 	// Find - Find by filter
-	Find(filter string) (utils.Map, error)
+	Find(ctx context.Context, filter string) (utils.Map, error)
 	// Create - Create Collection
-	Create(indata utils.Map) (utils.Map, error)
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
 	// Update - Update Collection
-	Update(campaignId string, indata utils.Map) (utils.Map, error)
+	Update(ctx context.Context, campaignId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Collection
-	Delete(campaignId string) (int64, error)
+	Delete(ctx context.Context, campaignId string) (int64, error)
+
+	// ClaimSend - Set the Campaign as sending only if it is not sent and not being sent since staleBefore,
+	// else mongo.ErrNoDocuments is returned
+	ClaimSend(ctx context.Context, campaignId string, staleBefore time.Time, indata utils.Map) (utils.Map, error)
 }
 
 // NewCampaign - Contruct Business Media Dao
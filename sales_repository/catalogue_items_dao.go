@@ -0,0 +1,52 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CatalogueItemDao - Catalogue Item DAO Repository, the Products and Categories in the draft of the Catalogues
+type CatalogueItemDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, itemId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, itemId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, itemId string) (int64, error)
+}
+
+// NewCatalogueItemDao - Contruct Business CatalogueItem Dao
+func NewCatalogueItemDao(client utils.Map, business_id string) CatalogueItemDao {
+	var daoCatalogueItem CatalogueItemDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoCatalogueItem = &mongodb_repository.CatalogueItemMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoCatalogueItem != nil {
+		// Initialize the Dao
+		daoCatalogueItem.InitializeDao(client, business_id)
+	}
+
+	return daoCatalogueItem
+}
@@ -0,0 +1,48 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CatalogueVersionDao - Catalogue Version DAO Repository, the published snapshots of the Catalogues are only appended
+type CatalogueVersionDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, versionId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+}
+
+// NewCatalogueVersionDao - Contruct Business CatalogueVersion Dao
+func NewCatalogueVersionDao(client utils.Map, business_id string) CatalogueVersionDao {
+	var daoCatalogueVersion CatalogueVersionDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoCatalogueVersion = &mongodb_repository.CatalogueVersionMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoCatalogueVersion != nil {
+		// Initialize the Dao
+		daoCatalogueVersion.InitializeDao(client, business_id)
+	}
+
+	return daoCatalogueVersion
+}
@@ -1,6 +1,8 @@
 package sales_repository
 
 import (
+	"context"
+
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
 	"github.com/zapscloud/golib-utils/utils"
@@ -11,17 +13,19 @@ type CategoryDao interface {
 	// InitializeDao
 	InitializeDao(client utils.Map, businessId string)
 	//List - List all Collections
-	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
 	// Find - Find by code
-	Get(categoryid string) (utils.Map, error)
+	Get(ctx context.Context, categoryid string) (utils.Map, error)
 	// Find - Find by filter
-	Find(filter string) (utils.Map, error)
+	Find(ctx context.Context, filter string) (utils.Map, error)
 	// Create - Create Collection
-	Create(indata utils.Map) (utils.Map, error)
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
 	// Update - Update Collection
-	Update(categoriId string, indata utils.Map) (utils.Map, error)
+	Update(ctx context.Context, categoriId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Collection
-	Delete(categoryId string) (int64, error)
+	Delete(ctx context.Context, categoryId string) (int64, error)
+	// MoveSubtree - Move the Category and its sub Categories under the new ancestors in one update
+	MoveSubtree(ctx context.Context, categoryId string, parentId string, ancestors []string) (int64, error)
 }
 
 // NewCategoryDao - Contruct Business Category Dao
@@ -0,0 +1,57 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreditAccountDao - Credit Account DAO Repository, credit limit and outstanding of a Customer or Dealer
+type CreditAccountDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, creditAccountId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, creditAccountId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, creditAccountId string) (int64, error)
+
+	// ApplyOutstanding - Add the amount to the outstanding balance, positive amount is added only if the
+	// outstanding stays within the credit limit
+	ApplyOutstanding(ctx context.Context, creditAccountId string, amount primitive.Decimal128) (utils.Map, error)
+}
+
+// NewCreditAccountDao - Contruct Business CreditAccount Dao
+func NewCreditAccountDao(client utils.Map, business_id string) CreditAccountDao {
+	var daoCreditAccount CreditAccountDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoCreditAccount = &mongodb_repository.CreditAccountMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoCreditAccount != nil {
+		// Initialize the Dao
+		daoCreditAccount.InitializeDao(client, business_id)
+	}
+
+	return daoCreditAccount
+}
@@ -0,0 +1,61 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreditInvoiceDao - Invoice DAO Repository, invoices of the Orders bought on credit
+type CreditInvoiceDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, invoiceId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, invoiceId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, invoiceId string) (int64, error)
+
+	// ApplyPayment - Add the payment to the open Invoice only if the paid amount stays within the invoice amount
+	ApplyPayment(ctx context.Context, invoiceId string, amount primitive.Decimal128, payment utils.Map) (utils.Map, error)
+	// VoidByOrder - Void the open Invoice of the Order and return it as it was before, mongo.ErrNoDocuments if
+	// the Order has no open Invoice. So the Invoice is voided only once
+	VoidByOrder(ctx context.Context, custOrderId string) (utils.Map, error)
+	// ReassignAccount - Move all the Invoices of the Account to the other Account of the party
+	ReassignAccount(ctx context.Context, fromAccountId string, toAccountId string, toPartyId string) (int64, error)
+}
+
+// NewCreditInvoiceDao - Contruct Business CreditInvoice Dao
+func NewCreditInvoiceDao(client utils.Map, business_id string) CreditInvoiceDao {
+	var daoCreditInvoice CreditInvoiceDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoCreditInvoice = &mongodb_repository.CreditInvoiceMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoCreditInvoice != nil {
+		// Initialize the Dao
+		daoCreditInvoice.InitializeDao(client, business_id)
+	}
+
+	return daoCreditInvoice
+}
@@ -0,0 +1,47 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerMergeDao - Customer Merge DAO Repository, merges are only appended and updated till completed
+type CustomerMergeDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, customerMergeId string, indata utils.Map) (utils.Map, error)
+}
+
+// NewCustomerMergeDao - Contruct Business CustomerMerge Dao
+func NewCustomerMergeDao(client utils.Map, business_id string) CustomerMergeDao {
+	var daoCustomerMerge CustomerMergeDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoCustomerMerge = &mongodb_repository.CustomerMergeMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoCustomerMerge != nil {
+		// Initialize the Dao
+		daoCustomerMerge.InitializeDao(client, business_id)
+	}
+
+	return daoCustomerMerge
+}
@@ -0,0 +1,58 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerAddressDao - Customer Address DAO Repository
+type CustomerAddressDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, addressId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, addressId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, addressId string) (int64, error)
+
+	// ClearDefault - Set the default flag to false in all the addresses of the Customer except the given one
+	ClearDefault(ctx context.Context, defaultField string, exceptAddressId string) (int64, error)
+	// ReassignCustomer - Move all the records of the Customer to the other Customer, the moved addresses are
+	// not the default of the other Customer
+	ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
+}
+
+// NewCustomerAddressDao - Contruct Business Address Dao
+func NewCustomerAddressDao(client utils.Map, businessId string, customerId string) CustomerAddressDao {
+	var daoAddress CustomerAddressDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoAddress = &customer_mongodb_repository.CustomerAddressMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoAddress != nil {
+		// Initialize the Dao
+		daoAddress.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoAddress
+}
@@ -0,0 +1,50 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerConsentEventDao - Consent History DAO Repository, events are only appended
+type CustomerConsentEventDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, eventId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// ReassignCustomer - Move all the records of the Customer to the other Customer
+	ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
+}
+
+// NewCustomerConsentEventDao - Contruct Business ConsentEvent Dao
+func NewCustomerConsentEventDao(client utils.Map, businessId string, customerId string) CustomerConsentEventDao {
+	var daoConsentEvent CustomerConsentEventDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoConsentEvent = &customer_mongodb_repository.CustomerConsentEventMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoConsentEvent != nil {
+		// Initialize the Dao
+		daoConsentEvent.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoConsentEvent
+}
@@ -0,0 +1,52 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerConsentDao - Marketing Consent DAO Repository, one record for each Customer and channel
+type CustomerConsentDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, consentId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, consentId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, consentId string) (int64, error)
+}
+
+// NewCustomerConsentDao - Contruct Business Consent Dao
+func NewCustomerConsentDao(client utils.Map, businessId string, customerId string) CustomerConsentDao {
+	var daoConsent CustomerConsentDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoConsent = &customer_mongodb_repository.CustomerConsentMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoConsent != nil {
+		// Initialize the Dao
+		daoConsent.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoConsent
+}
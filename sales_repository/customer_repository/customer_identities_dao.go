@@ -0,0 +1,54 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerIdentityDao - External Identity DAO Repository, identities of the providers linked to the Customer
+type CustomerIdentityDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, identityId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, identityId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, identityId string) (int64, error)
+	// ReassignCustomer - Move all the records of the Customer to the other Customer
+	ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
+}
+
+// NewCustomerIdentityDao - Contruct Business Identity Dao
+func NewCustomerIdentityDao(client utils.Map, businessId string, customerId string) CustomerIdentityDao {
+	var daoIdentity CustomerIdentityDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoIdentity = &customer_mongodb_repository.CustomerIdentityMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoIdentity != nil {
+		// Initialize the Dao
+		daoIdentity.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoIdentity
+}
@@ -0,0 +1,53 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerLoyaltyTxnDao - Loyalty Points Transaction DAO Repository, transactions are only appended
+type CustomerLoyaltyTxnDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, txnId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// CreateForOrder - Create the transaction unless the Order already has one of the same type, returns
+	// the existing one then
+	CreateForOrder(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// ReassignCustomer - Move all the records of the Customer to the other Customer
+	ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
+}
+
+// NewCustomerLoyaltyTxnDao - Contruct Business LoyaltyTxn Dao
+func NewCustomerLoyaltyTxnDao(client utils.Map, businessId string, customerId string) CustomerLoyaltyTxnDao {
+	var daoLoyaltyTxn CustomerLoyaltyTxnDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoLoyaltyTxn = &customer_mongodb_repository.CustomerLoyaltyTxnMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoLoyaltyTxn != nil {
+		// Initialize the Dao
+		daoLoyaltyTxn.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoLoyaltyTxn
+}
@@ -0,0 +1,59 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerSessionDao - Session DAO Repository
+type CustomerSessionDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, sessionId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, sessionId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, sessionId string) (int64, error)
+
+	// Touch - Set the last used time of the Session only if it is not revoked, returns false otherwise
+	Touch(ctx context.Context, sessionId string) (bool, error)
+	// Revoke - Revoke the Session
+	Revoke(ctx context.Context, sessionId string) (int64, error)
+	// RevokeByCustomer - Revoke all the Sessions of the Customer
+	RevokeByCustomer(ctx context.Context, customerId string) (int64, error)
+}
+
+// NewCustomerSessionDao - Contruct Customer Session Dao
+func NewCustomerSessionDao(client utils.Map, businessId string, customerId string) CustomerSessionDao {
+	var daoSession CustomerSessionDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoSession = &customer_mongodb_repository.CustomerSessionMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoSession != nil {
+		// Initialize the Dao
+		daoSession.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoSession
+}
@@ -0,0 +1,59 @@
+package customer_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerTokenDao - Refresh Token DAO Repository
+type CustomerTokenDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string, customerId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, tokenId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, tokenId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, tokenId string) (int64, error)
+
+	// MarkUsed - Mark the Token as used only if it is neither used nor revoked, returns false otherwise
+	MarkUsed(ctx context.Context, tokenId string, replacedBy string) (bool, error)
+	// RevokeFamily - Revoke all the Tokens of the family
+	RevokeFamily(ctx context.Context, familyId string) (int64, error)
+	// RevokeByCustomer - Revoke all the Tokens of the Customer
+	RevokeByCustomer(ctx context.Context, customerId string) (int64, error)
+}
+
+// NewCustomerTokenDao - Contruct Business Token Dao
+func NewCustomerTokenDao(client utils.Map, businessId string, customerId string) CustomerTokenDao {
+	var daoToken CustomerTokenDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoToken = &customer_mongodb_repository.CustomerTokenMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoToken != nil {
+		// Initialize the Dao
+		daoToken.InitializeDao(client, businessId, customerId)
+	}
+
+	return daoToken
+}
@@ -1,6 +1,8 @@
 package customer_repository
 
 import (
+	"context"
+
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository/customer_mongodb_repository"
 	"github.com/zapscloud/golib-utils/utils"
@@ -11,17 +13,19 @@ type CustomerWishlistDao interface {
 	// InitializeDao
 	InitializeDao(client utils.Map, businessId string, customerId string)
 	//List - List all Collections
-	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
 	// Get - Get by code
-	Get(wishlistId string) (utils.Map, error)
+	Get(ctx context.Context, wishlistId string) (utils.Map, error)
 	// Find - Find by filter
-	Find(filter string) (utils.Map, error)
+	Find(ctx context.Context, filter string) (utils.Map, error)
 	// Create - Create Collection
-	Create(indata utils.Map) (utils.Map, error)
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
 	// Update - Update Collection
-	Update(wishlistId string, indata utils.Map) (utils.Map, error)
+	Update(ctx context.Context, wishlistId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Collection
-	Delete(wishlistId string) (int64, error)
+	Delete(ctx context.Context, wishlistId string) (int64, error)
+	// ReassignCustomer - Move all the records of the Customer to the other Customer
+	ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
 }
 
 // NewCustomerWishlistDao - Contruct Business CustomerWishlist Dao
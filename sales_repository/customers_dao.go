@@ -1,6 +1,8 @@
 package sales_repository
 
 import (
+	"context"
+
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
 
@@ -12,20 +14,29 @@ type CustomerDao interface {
 	// InitializeDao
 	InitializeDao(client utils.Map, businessId string)
 	//List - List all Collections
-	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
 	// Get - Get by code
-	Get(customerId string) (utils.Map, error)
+	Get(ctx context.Context, customerId string) (utils.Map, error)
 	// Find - Find by filter
-	Find(filter string) (utils.Map, error)
+	Find(ctx context.Context, filter string) (utils.Map, error)
 	// Create - Create Collection
-	Create(indata utils.Map) (utils.Map, error)
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
 	// Update - Update Collection
-	Update(customerId string, indata utils.Map) (utils.Map, error)
+	Update(ctx context.Context, customerId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Collection
-	Delete(customerId string) (int64, error)
+	Delete(ctx context.Context, customerId string) (int64, error)
 
-	// Authenticate
-	Authenticate(auth_key string, auth_login string, auth_pwd string) (utils.Map, error)
+	// GetByLogin - Get by login key along with the password hash, for authentication
+	GetByLogin(ctx context.Context, auth_key string, auth_login string) (utils.Map, error)
+	// IncrementAttempts - Add one to the attempts field only while it is below maxAttempts, returns the Customer
+	// along with the secrets, mongo.ErrNoDocuments if the attempts are used up
+	IncrementAttempts(ctx context.Context, customerId string, attemptsField string, maxAttempts int) (utils.Map, error)
+	// ConsumeResetCode - Clear the Password reset code only if it is still the given one, returns the Customer,
+	// mongo.ErrNoDocuments if the code is used already or replaced
+	ConsumeResetCode(ctx context.Context, customerId string, resetCode string) (utils.Map, error)
+	// FindDuplicates - Groups of the Customers with the same value of the field, ignoring the case and the
+	// surrounding spaces
+	FindDuplicates(ctx context.Context, field string) ([]utils.Map, error)
 }
 
 // NewCustomerDao - Contruct Business Customer Dao
@@ -0,0 +1,55 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// InventoryDao - Inventory DAO Repository, the on hand and reserved stock of the Products and Variants
+type InventoryDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, inventoryId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, inventoryId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, inventoryId string) (int64, error)
+	// ApplyStock - Add to the on hand and reserved stock atomically, only if both stay non negative and the
+	// reserved stays within the on hand
+	ApplyStock(ctx context.Context, inventoryId string, onHand float64, reserved float64) (utils.Map, error)
+}
+
+// NewInventoryDao - Contruct Business Inventory Dao
+func NewInventoryDao(client utils.Map, business_id string) InventoryDao {
+	var daoInventory InventoryDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoInventory = &mongodb_repository.InventoryMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoInventory != nil {
+		// Initialize the Dao
+		daoInventory.InitializeDao(client, business_id)
+	}
+
+	return daoInventory
+}
@@ -0,0 +1,49 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// LoginAttemptDao - Failed Login Attempt DAO Repository
+type LoginAttemptDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	// Get - Get by code
+	Get(ctx context.Context, attemptId string) (utils.Map, error)
+	// RecordFailure - Increment the failed attempts and set the indata values, creates the record if not exist
+	RecordFailure(ctx context.Context, attemptId string, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, attemptId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, attemptId string) (int64, error)
+}
+
+// NewLoginAttemptDao - Contruct Business LoginAttempt Dao
+func NewLoginAttemptDao(client utils.Map, business_id string) LoginAttemptDao {
+	var daoLoginAttempt LoginAttemptDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoLoginAttempt = &mongodb_repository.LoginAttemptMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoLoginAttempt != nil {
+		// Initialize the Dao
+		daoLoginAttempt.InitializeDao(client, business_id)
+	}
+
+	return daoLoginAttempt
+}
@@ -0,0 +1,45 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// LoginEventDao - Login Security Event DAO Repository, events are only appended
+type LoginEventDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+}
+
+// NewLoginEventDao - Contruct Business LoginEvent Dao
+func NewLoginEventDao(client utils.Map, business_id string) LoginEventDao {
+	var daoLoginEvent LoginEventDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoLoginEvent = &mongodb_repository.LoginEventMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoLoginEvent != nil {
+		// Initialize the Dao
+		daoLoginEvent.InitializeDao(client, business_id)
+	}
+
+	return daoLoginEvent
+}
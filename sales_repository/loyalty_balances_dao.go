@@ -0,0 +1,50 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// LoyaltyBalanceDao - Loyalty Points Balance DAO Repository, the balance of each Customer projected
+// from the Loyalty transactions
+type LoyaltyBalanceDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by CustomerId
+	Get(ctx context.Context, customerId string) (utils.Map, error)
+	// Apply - Add the points to the balance and the counters, points are deducted only if the balance has enough
+	Apply(ctx context.Context, customerId string, points int64, counters utils.Map) (utils.Map, error)
+	// Save - Replace the balance and the counters, creates the record if not exist
+	Save(ctx context.Context, customerId string, indata utils.Map) (utils.Map, error)
+}
+
+// NewLoyaltyBalanceDao - Contruct Business LoyaltyBalance Dao
+func NewLoyaltyBalanceDao(client utils.Map, business_id string) LoyaltyBalanceDao {
+	var daoLoyaltyBalance LoyaltyBalanceDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoLoyaltyBalance = &mongodb_repository.LoyaltyBalanceMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoLoyaltyBalance != nil {
+		// Initialize the Dao
+		daoLoyaltyBalance.InitializeDao(client, business_id)
+	}
+
+	return daoLoyaltyBalance
+}
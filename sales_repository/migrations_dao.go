@@ -0,0 +1,56 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// MigrationDao - Migration DAO Repository
+type MigrationDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map)
+	// List - List all applied Migrations
+	List(ctx context.Context) ([]utils.Map, error)
+	// Get - Get applied Migration by id
+	Get(ctx context.Context, migrationId string) (utils.Map, error)
+	// Create - Record the applied Migration
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+
+	// CountDocuments - Count the documents of any collection for all businesses
+	CountDocuments(ctx context.Context, collection string, filter utils.Map) (int64, error)
+	// UpdateMany - Update the documents of any collection for all businesses, the update is a document of update
+	// operators or an aggregation pipeline
+	UpdateMany(ctx context.Context, collection string, filter utils.Map, update interface{}) (int64, error)
+	// CreateIndex - Create the ascending index on the keys of any collection, only on the documents matching
+	// the partialFilter if given. Returns the name of the index
+	CreateIndex(ctx context.Context, collection string, keys []string, unique bool, partialFilter utils.Map) (string, error)
+}
+
+// NewMigrationDao - Contruct Migration Dao
+func NewMigrationDao(client utils.Map) MigrationDao {
+	var daoMigration MigrationDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoMigration = &mongodb_repository.MigrationMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoMigration != nil {
+		// Initialize the Dao
+		daoMigration.InitializeDao(client)
+	}
+
+	return daoMigration
+}
@@ -1,12 +1,14 @@
 package customer_mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -31,12 +33,12 @@ func (p *CustomerCartMongoDBDao) InitializeDao(client utils.Map, businessId stri
 }
 
 // List - List all Collections
-func (t *CustomerCartMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CustomerCartMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomerCarts)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +86,16 @@ func (t *CustomerCartMongoDBDao) List(filter string, sort string, skip int64, li
 	}
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +108,9 @@ func (t *CustomerCartMongoDBDao) List(filter string, sort string, skip int64, li
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +123,9 @@ func (t *CustomerCartMongoDBDao) List(filter string, sort string, skip int64, li
 	if len(t.customerId) > 0 {
 		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
 	}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -135,13 +143,13 @@ func (t *CustomerCartMongoDBDao) List(filter string, sort string, skip int64, li
 }
 
 // Get - Get by code
-func (t *CustomerCartMongoDBDao) Get(cartId string) (utils.Map, error) {
+func (t *CustomerCartMongoDBDao) Get(ctx context.Context, cartId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CustomerCartMongoDBDao::Get:: Begin ", cartId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CART_ID, Value: cartId}, {}}
@@ -156,7 +164,9 @@ func (t *CustomerCartMongoDBDao) Get(cartId string) (utils.Map, error) {
 	}
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -175,13 +185,13 @@ func (t *CustomerCartMongoDBDao) Get(cartId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CustomerCartMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CustomerCartMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CartDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerCarts)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -199,7 +209,9 @@ func (p *CustomerCartMongoDBDao) Find(filter string) (utils.Map, error) {
 	}
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -218,11 +230,11 @@ func (p *CustomerCartMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CustomerCartMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CustomerCartMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Cart Save - Begin", indata)
 	//Sales Cart
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -230,7 +242,9 @@ func (t *CustomerCartMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -239,16 +253,16 @@ func (t *CustomerCartMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_CART_ID])
 
-	return t.Get(indata[sales_common.FLD_CART_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_CART_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CustomerCartMongoDBDao) Update(cartId string, indata utils.Map) (utils.Map, error) {
+func (t *CustomerCartMongoDBDao) Update(ctx context.Context, cartId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Cart
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -257,23 +271,25 @@ func (t *CustomerCartMongoDBDao) Update(cartId string, indata utils.Map) (utils.
 	log.Printf("Update - Values %v", indata)
 
 	filterCart := bson.D{{Key: sales_common.FLD_CART_ID, Value: cartId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCart, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCart, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(cartId)
+	return t.Get(ctx, cartId)
 }
 
 // Delete - Delete Collection
-func (t *CustomerCartMongoDBDao) Delete(cartId string) (int64, error) {
+func (t *CustomerCartMongoDBDao) Delete(ctx context.Context, cartId string) (int64, error) {
 
 	log.Println("CustomerCartMongoDBDao::Delete - Begin ", cartId)
 
 	// Sales Cart
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
 	if err != nil {
 		return 0, err
 	}
@@ -284,7 +300,9 @@ func (t *CustomerCartMongoDBDao) Delete(cartId string) (int64, error) {
 	})
 
 	filterCart := bson.D{{Key: sales_common.FLD_CART_ID, Value: cartId}}
-	resCart, err := collection.DeleteOne(ctx, filterCart, optsCart)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "DeleteOne", t.businessId)
+	resCart, err := collection.DeleteOne(dbCtx, filterCart, optsCart)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -292,3 +310,29 @@ func (t *CustomerCartMongoDBDao) Delete(cartId string) (int64, error) {
 	log.Printf("CustomerCartMongoDBDao::Delete - End deleted %v documents\n", resCart.DeletedCount)
 	return resCart.DeletedCount, nil
 }
+
+// ReassignCustomer - Move all the records of the Customer to the other Customer
+func (t *CustomerCartMongoDBDao) ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error) {
+
+	log.Println("CustomerCartMongoDBDao::ReassignCustomer - Begin ", t.customerId, toCustomerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerCarts)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_CUSTOMER_ID: toCustomerId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerCarts, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerCartMongoDBDao::ReassignCustomer - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
@@ -0,0 +1,340 @@
+package customer_mongodb_repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CustomerLoyaltyTxnMongoDBDao - LoyaltyTxn DAO Repository
+type CustomerLoyaltyTxnMongoDBDao struct {
+	client     utils.Map
+	businessId string
+	customerId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *CustomerLoyaltyTxnMongoDBDao) InitializeDao(client utils.Map, businessId string, customerId string) {
+	log.Println("Initialize LoyaltyTxn Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+	p.customerId = customerId
+}
+
+// List - List all Collections
+func (t *CustomerLoyaltyTxnMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbLoyaltyTxns)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyTxns)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		filterdoc = append(filterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (t *CustomerLoyaltyTxnMongoDBDao) Get(ctx context.Context, txnId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("CustomerLoyaltyTxnMongoDBDao::Get:: Begin ", txnId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyTxns)
+	log.Println("Get:: Got Collection ")
+
+	filter := bson.D{{Key: sales_common.FLD_LOYALTY_TXN_ID, Value: txnId}, {}}
+
+	filter = append(filter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		filter = append(filter, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("Business CustomerLoyaltyTxnMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Find - Find by Filter
+func (p *CustomerLoyaltyTxnMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	// Find a single document
+	var result utils.Map
+
+	log.Println("LoyaltyTxnDBDao::Find:: Begin ", filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoyaltyTxns)
+	log.Println("Find:: Got Collection ", err)
+
+	bfilter := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(filter), true, &bfilter)
+	if err != nil {
+		fmt.Println("Error on filter Unmarshal", err)
+	}
+	bfilter = append(bfilter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(p.customerId) > 0 {
+		bfilter = append(bfilter, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: p.customerId})
+	}
+
+	log.Println("Find:: Got filter ", bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Find:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("LoyaltyTxnDBDao::Find:: End Found a single document: \n", err)
+	return result, nil
+}
+
+// Create - Create Collection
+func (t *CustomerLoyaltyTxnMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("LoyaltyTxn Save - Begin", indata)
+	//Sales LoyaltyTxn
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyTxns)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_LOYALTY_TXN_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_LOYALTY_TXN_ID].(string))
+}
+
+// CreateForOrder - Create the transaction unless the Order already has one of the same type, returns the
+// existing one then. Upserted in a single call, so the same transaction is never added twice
+func (t *CustomerLoyaltyTxnMongoDBDao) CreateForOrder(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CustomerLoyaltyTxnMongoDBDao::CreateForOrder - Begin ", indata[sales_common.FLD_CUSTOMER_ORDER_ID], indata[sales_common.FLD_LOYALTY_TXN_TYPE])
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyTxns)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: indata[sales_common.FLD_CUSTOMER_ORDER_ID]},
+		{Key: sales_common.FLD_LOYALTY_TXN_TYPE, Value: indata[sales_common.FLD_LOYALTY_TXN_TYPE]},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	// Fields of the filter are set by the upsert itself
+	insertdata := utils.Map{}
+	for key, value := range indata {
+		insertdata[key] = value
+	}
+	insertdata = db_common.AmendFldsforCreate(insertdata)
+	for _, field := range filter {
+		delete(insertdata, field.Key)
+	}
+	update := bson.D{{Key: "$setOnInsert", Value: insertdata}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "FindOneAndUpdate", t.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if mongo.IsDuplicateKeyError(singleResult.Err()) {
+		// Inserted by a parallel call between the match and the insert
+		_, span = sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "FindOne", t.businessId)
+		singleResult = collection.FindOne(dbCtx, filter)
+		span.End(singleResult.Err())
+	}
+	if singleResult.Err() != nil {
+		log.Println("CreateForOrder:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CustomerLoyaltyTxnMongoDBDao::CreateForOrder - End ", result[sales_common.FLD_LOYALTY_TXN_ID])
+	return result, nil
+}
+
+// ReassignCustomer - Move all the records of the Customer to the other Customer
+func (t *CustomerLoyaltyTxnMongoDBDao) ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error) {
+
+	log.Println("CustomerLoyaltyTxnMongoDBDao::ReassignCustomer - Begin ", t.customerId, toCustomerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyTxns)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_CUSTOMER_ID: toCustomerId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyTxns, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerLoyaltyTxnMongoDBDao::ReassignCustomer - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
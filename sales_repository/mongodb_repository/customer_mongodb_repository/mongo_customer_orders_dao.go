@@ -1,12 +1,14 @@
 package customer_mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -31,12 +33,12 @@ func (p *CustomerOrderMongoDBDao) InitializeDao(client utils.Map, businessId str
 }
 
 // List - List all Collections
-func (t *CustomerOrderMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CustomerOrderMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomerOrders)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +86,16 @@ func (t *CustomerOrderMongoDBDao) List(filter string, sort string, skip int64, l
 	}
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +108,9 @@ func (t *CustomerOrderMongoDBDao) List(filter string, sort string, skip int64, l
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +123,9 @@ func (t *CustomerOrderMongoDBDao) List(filter string, sort string, skip int64, l
 	if len(t.customerId) > 0 {
 		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
 	}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -135,13 +143,13 @@ func (t *CustomerOrderMongoDBDao) List(filter string, sort string, skip int64, l
 }
 
 // Get - Get by code
-func (t *CustomerOrderMongoDBDao) Get(customerorderId string) (utils.Map, error) {
+func (t *CustomerOrderMongoDBDao) Get(ctx context.Context, customerorderId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CustomerOrderMongoDBDao::Get:: Begin ", customerorderId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: customerorderId}, {}}
@@ -156,7 +164,9 @@ func (t *CustomerOrderMongoDBDao) Get(customerorderId string) (utils.Map, error)
 	}
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -175,13 +185,13 @@ func (t *CustomerOrderMongoDBDao) Get(customerorderId string) (utils.Map, error)
 }
 
 // Find - Find by Filter
-func (p *CustomerOrderMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CustomerOrderMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CustomerOrderDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerOrders)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -199,7 +209,9 @@ func (p *CustomerOrderMongoDBDao) Find(filter string) (utils.Map, error) {
 	}
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -218,11 +230,11 @@ func (p *CustomerOrderMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CustomerOrderMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CustomerOrderMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("CustomerOrder Save - Begin", indata)
 	//Sales CustomerOrder
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -230,7 +242,9 @@ func (t *CustomerOrderMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -239,16 +253,16 @@ func (t *CustomerOrderMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_CUSTOMER_ORDER_ID])
 
-	return t.Get(indata[sales_common.FLD_CUSTOMER_ORDER_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_CUSTOMER_ORDER_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CustomerOrderMongoDBDao) Update(customerorderId string, indata utils.Map) (utils.Map, error) {
+func (t *CustomerOrderMongoDBDao) Update(ctx context.Context, customerorderId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales CustomerOrder
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -257,23 +271,75 @@ func (t *CustomerOrderMongoDBDao) Update(customerorderId string, indata utils.Ma
 	log.Printf("Update - Values %v", indata)
 
 	filterCustomerOrder := bson.D{{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: customerorderId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCustomerOrder, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCustomerOrder, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(customerorderId)
+	return t.Get(ctx, customerorderId)
+}
+
+// Transition - Update the Order only if its order_status is still fromStatus, empty for the Orders without
+// status. Returns mongo.ErrNoDocuments if the status was changed in between
+func (t *CustomerOrderMongoDBDao) Transition(ctx context.Context, customerorderId string, fromStatus string, indata utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CustomerOrderMongoDBDao::Transition - Begin ", customerorderId, fromStatus, indata[sales_common.FLD_CUSTOMER_ORDER_STATUS])
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	if err != nil {
+		return result, err
+	}
+
+	var filterStatus interface{} = fromStatus
+	if len(fromStatus) == 0 {
+		filterStatus = bson.D{{Key: "$in", Value: bson.A{nil, ""}}}
+	}
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: customerorderId},
+		{Key: sales_common.FLD_CUSTOMER_ORDER_STATUS, Value: filterStatus},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		filter = append(filter, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(indata)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "FindOneAndUpdate", t.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Transition:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CustomerOrderMongoDBDao::Transition - End ", result[sales_common.FLD_CUSTOMER_ORDER_STATUS])
+	return result, nil
 }
 
 // Delete - Delete Collection
-func (t *CustomerOrderMongoDBDao) Delete(customerorderId string) (int64, error) {
+func (t *CustomerOrderMongoDBDao) Delete(ctx context.Context, customerorderId string) (int64, error) {
 
 	log.Println("CustomerOrderMongoDBDao::Delete - Begin ", customerorderId)
 
 	// Sales CustomerOrder
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
 	if err != nil {
 		return 0, err
 	}
@@ -284,7 +350,9 @@ func (t *CustomerOrderMongoDBDao) Delete(customerorderId string) (int64, error)
 	})
 
 	filterCustomerOrder := bson.D{{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: customerorderId}}
-	resCustomerOrder, err := collection.DeleteOne(ctx, filterCustomerOrder, optsCustomerOrder)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "DeleteOne", t.businessId)
+	resCustomerOrder, err := collection.DeleteOne(dbCtx, filterCustomerOrder, optsCustomerOrder)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -292,3 +360,29 @@ func (t *CustomerOrderMongoDBDao) Delete(customerorderId string) (int64, error)
 	log.Printf("CustomerOrderMongoDBDao::Delete - End deleted %v documents\n", resCustomerOrder.DeletedCount)
 	return resCustomerOrder.DeletedCount, nil
 }
+
+// ReassignCustomer - Move all the records of the Customer to the other Customer
+func (t *CustomerOrderMongoDBDao) ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error) {
+
+	log.Println("CustomerOrderMongoDBDao::ReassignCustomer - Begin ", t.customerId, toCustomerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerOrders)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_CUSTOMER_ID: toCustomerId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerOrders, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerOrderMongoDBDao::ReassignCustomer - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
@@ -1,12 +1,14 @@
 package customer_mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -31,12 +33,12 @@ func (p *CustomerReviewMongoDBDao) InitializeDao(client utils.Map, businessId st
 }
 
 // List - List all Collections
-func (t *CustomerReviewMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CustomerReviewMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomerReviews)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +86,16 @@ func (t *CustomerReviewMongoDBDao) List(filter string, sort string, skip int64,
 	}
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +108,9 @@ func (t *CustomerReviewMongoDBDao) List(filter string, sort string, skip int64,
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +124,9 @@ func (t *CustomerReviewMongoDBDao) List(filter string, sort string, skip int64,
 		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
 	}
 
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -136,13 +144,13 @@ func (t *CustomerReviewMongoDBDao) List(filter string, sort string, skip int64,
 }
 
 // Get - Get by code
-func (p *CustomerReviewMongoDBDao) Get(reviewId string) (utils.Map, error) {
+func (p *CustomerReviewMongoDBDao) Get(ctx context.Context, reviewId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CustomerReviewMongoDBDao::Get:: Begin ", reviewId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerReviews)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_REVIEW_ID, Value: reviewId}, {}}
@@ -157,7 +165,9 @@ func (p *CustomerReviewMongoDBDao) Get(reviewId string) (utils.Map, error) {
 	}
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -176,13 +186,13 @@ func (p *CustomerReviewMongoDBDao) Get(reviewId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CustomerReviewMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CustomerReviewMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CustomerReviewDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerReviews)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -200,7 +210,9 @@ func (p *CustomerReviewMongoDBDao) Find(filter string) (utils.Map, error) {
 	}
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -219,11 +231,11 @@ func (p *CustomerReviewMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CustomerReviewMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CustomerReviewMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("CustomerReview Save - Begin", indata)
 	//Business_review
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -231,7 +243,9 @@ func (t *CustomerReviewMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -240,16 +254,16 @@ func (t *CustomerReviewMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_REVIEW_ID])
 
-	return t.Get(indata[sales_common.FLD_REVIEW_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_REVIEW_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CustomerReviewMongoDBDao) Update(reviewId string, indata utils.Map) (utils.Map, error) {
+func (t *CustomerReviewMongoDBDao) Update(ctx context.Context, reviewId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//review
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -258,23 +272,25 @@ func (t *CustomerReviewMongoDBDao) Update(reviewId string, indata utils.Map) (ut
 	log.Printf("Update - Values %v", indata)
 
 	filterCustomerReview := bson.D{{Key: sales_common.FLD_REVIEW_ID, Value: reviewId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCustomerReview, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCustomerReview, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(reviewId)
+	return t.Get(ctx, reviewId)
 }
 
 // Delete - Delete Collection
-func (t *CustomerReviewMongoDBDao) Delete(reviewId string) (int64, error) {
+func (t *CustomerReviewMongoDBDao) Delete(ctx context.Context, reviewId string) (int64, error) {
 
 	log.Println("CustomerReviewMongoDBDao::Delete - Begin ", reviewId)
 
 	//BusinessCustomerReview
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
 	if err != nil {
 		return 0, err
 	}
@@ -285,7 +301,9 @@ func (t *CustomerReviewMongoDBDao) Delete(reviewId string) (int64, error) {
 	})
 
 	filterCustomerReview := bson.D{{Key: sales_common.FLD_REVIEW_ID, Value: reviewId}}
-	resCustomerReview, err := collection.DeleteOne(ctx, filterCustomerReview, optsCustomerReview)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "DeleteOne", t.businessId)
+	resCustomerReview, err := collection.DeleteOne(dbCtx, filterCustomerReview, optsCustomerReview)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -293,3 +311,29 @@ func (t *CustomerReviewMongoDBDao) Delete(reviewId string) (int64, error) {
 	log.Printf("CustomerReviewMongoDBDao::Delete - End deleted %v documents\n", resCustomerReview.DeletedCount)
 	return resCustomerReview.DeletedCount, nil
 }
+
+// ReassignCustomer - Move all the records of the Customer to the other Customer
+func (t *CustomerReviewMongoDBDao) ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error) {
+
+	log.Println("CustomerReviewMongoDBDao::ReassignCustomer - Begin ", t.customerId, toCustomerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerReviews)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_CUSTOMER_ID: toCustomerId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerReviews, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerReviewMongoDBDao::ReassignCustomer - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
@@ -0,0 +1,392 @@
+package customer_mongodb_repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CustomerTokenMongoDBDao - Token DAO Repository
+type CustomerTokenMongoDBDao struct {
+	client     utils.Map
+	businessId string
+	customerId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *CustomerTokenMongoDBDao) InitializeDao(client utils.Map, businessId string, customerId string) {
+	log.Println("Initialize Token Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+	p.customerId = customerId
+}
+
+// List - List all Collections
+func (t *CustomerTokenMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomerTokens)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		filterdoc = append(filterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (t *CustomerTokenMongoDBDao) Get(ctx context.Context, tokenId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("CustomerTokenMongoDBDao::Get:: Begin ", tokenId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	log.Println("Get:: Got Collection ")
+
+	filter := bson.D{{Key: sales_common.FLD_TOKEN_ID, Value: tokenId}, {}}
+
+	filter = append(filter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(t.customerId) > 0 {
+		filter = append(filter, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
+	}
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("Business CustomerTokenMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Find - Find by Filter
+func (p *CustomerTokenMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	// Find a single document
+	var result utils.Map
+
+	log.Println("TokenDBDao::Find:: Begin ", filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerTokens)
+	log.Println("Find:: Got Collection ", err)
+
+	bfilter := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(filter), true, &bfilter)
+	if err != nil {
+		fmt.Println("Error on filter Unmarshal", err)
+	}
+	bfilter = append(bfilter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	// Append customerId as filter if it available
+	if len(p.customerId) > 0 {
+		bfilter = append(bfilter, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: p.customerId})
+	}
+
+	log.Println("Find:: Got filter ", bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Find:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("TokenDBDao::Find:: End Found a single document: \n", err)
+	return result, nil
+}
+
+// Create - Create Collection
+func (t *CustomerTokenMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Token Save - Begin", indata)
+	//Sales Token
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_TOKEN_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_TOKEN_ID].(string))
+}
+
+// Update - Update Collection
+func (t *CustomerTokenMongoDBDao) Update(ctx context.Context, tokenId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Update - Begin")
+
+	//Sales Token
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+	log.Printf("Update - Values %v", indata)
+
+	filterToken := bson.D{{Key: sales_common.FLD_TOKEN_ID, Value: tokenId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterToken, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Update a single document: ", updateResult1.ModifiedCount)
+
+	log.Println("Update - End")
+	return t.Get(ctx, tokenId)
+}
+
+// Delete - Delete Collection
+func (t *CustomerTokenMongoDBDao) Delete(ctx context.Context, tokenId string) (int64, error) {
+
+	log.Println("CustomerTokenMongoDBDao::Delete - Begin ", tokenId)
+
+	// Sales Token
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		return 0, err
+	}
+	optsToken := options.Delete().SetCollation(&options.Collation{
+		Locale:    db_common.LOCALE,
+		Strength:  1,
+		CaseLevel: false,
+	})
+
+	filterToken := bson.D{{Key: sales_common.FLD_TOKEN_ID, Value: tokenId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "DeleteOne", t.businessId)
+	resToken, err := collection.DeleteOne(dbCtx, filterToken, optsToken)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in delete ", err)
+		return 0, err
+	}
+	log.Printf("CustomerTokenMongoDBDao::Delete - End deleted %v documents\n", resToken.DeletedCount)
+	return resToken.DeletedCount, nil
+}
+
+// MarkUsed - Mark the Token as used only if it is neither used nor revoked, returns false otherwise
+func (t *CustomerTokenMongoDBDao) MarkUsed(ctx context.Context, tokenId string, replacedBy string) (bool, error) {
+
+	log.Println("CustomerTokenMongoDBDao::MarkUsed - Begin ", tokenId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		return false, err
+	}
+
+	// Conditional update, so only one of the parallel refresh requests can use the Token
+	filterToken := bson.D{
+		{Key: sales_common.FLD_TOKEN_ID, Value: tokenId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: sales_common.FLD_TOKEN_IS_USED, Value: false},
+		{Key: sales_common.FLD_TOKEN_IS_REVOKED, Value: false}}
+	updateToken := bson.D{{Key: "$set", Value: bson.D{
+		{Key: sales_common.FLD_TOKEN_IS_USED, Value: true},
+		{Key: sales_common.FLD_TOKEN_REPLACED_BY, Value: replacedBy},
+		{Key: db_common.FLD_UPDATED_AT, Value: time.Now()}}}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "UpdateOne", t.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filterToken, updateToken)
+	span.End(err)
+	if err != nil {
+		return false, err
+	}
+
+	log.Println("CustomerTokenMongoDBDao::MarkUsed - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount == 1, nil
+}
+
+// RevokeFamily - Revoke all the Tokens of the family
+func (t *CustomerTokenMongoDBDao) RevokeFamily(ctx context.Context, familyId string) (int64, error) {
+
+	log.Println("CustomerTokenMongoDBDao::RevokeFamily - Begin ", familyId)
+
+	filterToken := bson.D{
+		{Key: sales_common.FLD_TOKEN_FAMILY_ID, Value: familyId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+
+	return t.revoke(ctx, filterToken)
+}
+
+// RevokeByCustomer - Revoke all the Tokens of the Customer
+func (t *CustomerTokenMongoDBDao) RevokeByCustomer(ctx context.Context, customerId string) (int64, error) {
+
+	log.Println("CustomerTokenMongoDBDao::RevokeByCustomer - Begin ", customerId)
+
+	filterToken := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+
+	return t.revoke(ctx, filterToken)
+}
+
+func (t *CustomerTokenMongoDBDao) revoke(ctx context.Context, filterToken bson.D) (int64, error) {
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	filterToken = append(filterToken, bson.E{Key: sales_common.FLD_TOKEN_IS_REVOKED, Value: false})
+	updateToken := bson.D{{Key: "$set", Value: bson.D{
+		{Key: sales_common.FLD_TOKEN_IS_REVOKED, Value: true},
+		{Key: db_common.FLD_UPDATED_AT, Value: time.Now()}}}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerTokens, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filterToken, updateToken)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerTokenMongoDBDao::revoke - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
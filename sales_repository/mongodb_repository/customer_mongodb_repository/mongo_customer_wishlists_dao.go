@@ -1,12 +1,14 @@
 package customer_mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -31,12 +33,12 @@ func (p *CustomerWishlistMongoDBDao) InitializeDao(client utils.Map, businessId
 }
 
 // List - List all Collections
-func (t *CustomerWishlistMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CustomerWishlistMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomerWishlists)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +86,16 @@ func (t *CustomerWishlistMongoDBDao) List(filter string, sort string, skip int64
 	}
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +108,9 @@ func (t *CustomerWishlistMongoDBDao) List(filter string, sort string, skip int64
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +124,9 @@ func (t *CustomerWishlistMongoDBDao) List(filter string, sort string, skip int64
 		basefilterdoc = append(basefilterdoc, bson.E{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId})
 	}
 
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -136,13 +144,13 @@ func (t *CustomerWishlistMongoDBDao) List(filter string, sort string, skip int64
 }
 
 // Get - Get by code
-func (p *CustomerWishlistMongoDBDao) Get(wishlistId string) (utils.Map, error) {
+func (p *CustomerWishlistMongoDBDao) Get(ctx context.Context, wishlistId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CustomerWishlistMongoDBDao::Get:: Begin ", wishlistId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerWishlists)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_WISHLIST_ID, Value: wishlistId}, {}}
@@ -157,7 +165,9 @@ func (p *CustomerWishlistMongoDBDao) Get(wishlistId string) (utils.Map, error) {
 	}
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -176,13 +186,13 @@ func (p *CustomerWishlistMongoDBDao) Get(wishlistId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CustomerWishlistMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CustomerWishlistMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CustomerWishlistDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomerWishlists)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -200,7 +210,9 @@ func (p *CustomerWishlistMongoDBDao) Find(filter string) (utils.Map, error) {
 	}
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -219,11 +231,11 @@ func (p *CustomerWishlistMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CustomerWishlistMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CustomerWishlistMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("CustomerWishlist Save - Begin", indata)
 	//Business_wishlist
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -231,7 +243,9 @@ func (t *CustomerWishlistMongoDBDao) Create(indata utils.Map) (utils.Map, error)
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -240,16 +254,16 @@ func (t *CustomerWishlistMongoDBDao) Create(indata utils.Map) (utils.Map, error)
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_WISHLIST_ID])
 
-	return t.Get(indata[sales_common.FLD_WISHLIST_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_WISHLIST_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CustomerWishlistMongoDBDao) Update(wishlistId string, indata utils.Map) (utils.Map, error) {
+func (t *CustomerWishlistMongoDBDao) Update(ctx context.Context, wishlistId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//wishlist
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -258,23 +272,25 @@ func (t *CustomerWishlistMongoDBDao) Update(wishlistId string, indata utils.Map)
 	log.Printf("Update - Values %v", indata)
 
 	filterCustomerWishlist := bson.D{{Key: sales_common.FLD_WISHLIST_ID, Value: wishlistId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCustomerWishlist, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCustomerWishlist, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(wishlistId)
+	return t.Get(ctx, wishlistId)
 }
 
 // Delete - Delete Collection
-func (t *CustomerWishlistMongoDBDao) Delete(wishlistId string) (int64, error) {
+func (t *CustomerWishlistMongoDBDao) Delete(ctx context.Context, wishlistId string) (int64, error) {
 
 	log.Println("CustomerWishlistMongoDBDao::Delete - Begin ", wishlistId)
 
 	//BusinessCustomerWishlist
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
 	if err != nil {
 		return 0, err
 	}
@@ -285,7 +301,9 @@ func (t *CustomerWishlistMongoDBDao) Delete(wishlistId string) (int64, error) {
 	})
 
 	filterCustomerWishlist := bson.D{{Key: sales_common.FLD_WISHLIST_ID, Value: wishlistId}}
-	resCustomerWishlist, err := collection.DeleteOne(ctx, filterCustomerWishlist, optsCustomerWishlist)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "DeleteOne", t.businessId)
+	resCustomerWishlist, err := collection.DeleteOne(dbCtx, filterCustomerWishlist, optsCustomerWishlist)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -293,3 +311,29 @@ func (t *CustomerWishlistMongoDBDao) Delete(wishlistId string) (int64, error) {
 	log.Printf("CustomerWishlistMongoDBDao::Delete - End deleted %v documents\n", resCustomerWishlist.DeletedCount)
 	return resCustomerWishlist.DeletedCount, nil
 }
+
+// ReassignCustomer - Move all the records of the Customer to the other Customer
+func (t *CustomerWishlistMongoDBDao) ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error) {
+
+	log.Println("CustomerWishlistMongoDBDao::ReassignCustomer - Begin ", t.customerId, toCustomerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomerWishlists)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: t.customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_CUSTOMER_ID: toCustomerId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomerWishlists, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CustomerWishlistMongoDBDao::ReassignCustomer - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
@@ -1,12 +1,15 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +32,12 @@ func (p *CampaignMongoDBDao) InitializeDao(client utils.Map, businessId string)
 }
 
 // List - List all Collections
-func (t *CampaignMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CampaignMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCampaigns)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +80,16 @@ func (t *CampaignMongoDBDao) List(filter string, sort string, skip int64, limit
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +102,9 @@ func (t *CampaignMongoDBDao) List(filter string, sort string, skip int64, limit
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +112,9 @@ func (t *CampaignMongoDBDao) List(filter string, sort string, skip int64, limit
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +132,13 @@ func (t *CampaignMongoDBDao) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Get by code
-func (p *CampaignMongoDBDao) Get(CampaignId string) (utils.Map, error) {
+func (p *CampaignMongoDBDao) Get(ctx context.Context, CampaignId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CampaignMongoDBDao::Get:: Begin ", CampaignId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCampaigns)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CAMPAIGN_ID, Value: CampaignId}, {}}
@@ -139,7 +148,9 @@ func (p *CampaignMongoDBDao) Get(CampaignId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +169,13 @@ func (p *CampaignMongoDBDao) Get(CampaignId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CampaignMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CampaignMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CampaignDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCampaigns)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +188,9 @@ func (p *CampaignMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +209,11 @@ func (p *CampaignMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CampaignMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CampaignMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Campaign Save - Begin", indata)
 	//Business_Campaign
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +221,9 @@ func (t *CampaignMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +232,16 @@ func (t *CampaignMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_CAMPAIGN_ID])
 
-	return t.Get(indata[sales_common.FLD_CAMPAIGN_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_CAMPAIGN_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CampaignMongoDBDao) Update(CampaignId string, indata utils.Map) (utils.Map, error) {
+func (t *CampaignMongoDBDao) Update(ctx context.Context, CampaignId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Campaign
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +250,25 @@ func (t *CampaignMongoDBDao) Update(CampaignId string, indata utils.Map) (utils.
 	log.Printf("Update - Values %v", indata)
 
 	filterCampaign := bson.D{{Key: sales_common.FLD_CAMPAIGN_ID, Value: CampaignId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCampaign, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCampaign, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(CampaignId)
+	return t.Get(ctx, CampaignId)
 }
 
 // Delete - Delete Collection
-func (t *CampaignMongoDBDao) Delete(CampaignId string) (int64, error) {
+func (t *CampaignMongoDBDao) Delete(ctx context.Context, CampaignId string) (int64, error) {
 
 	log.Println("CampaignMongoDBDao::Delete - Begin ", CampaignId)
 
 	//BusinessCampaign
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +279,9 @@ func (t *CampaignMongoDBDao) Delete(CampaignId string) (int64, error) {
 	})
 
 	filterCampaign := bson.D{{Key: sales_common.FLD_CAMPAIGN_ID, Value: CampaignId}}
-	resCampaign, err := collection.DeleteOne(ctx, filterCampaign, optsCampaign)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "DeleteOne", t.businessId)
+	resCampaign, err := collection.DeleteOne(dbCtx, filterCampaign, optsCampaign)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -270,3 +289,47 @@ func (t *CampaignMongoDBDao) Delete(CampaignId string) (int64, error) {
 	log.Printf("CampaignMongoDBDao::Delete - End deleted %v documents\n", resCampaign.DeletedCount)
 	return resCampaign.DeletedCount, nil
 }
+
+// ClaimSend - Set the Campaign as sending only if it is not sent and not being sent since staleBefore, else
+// mongo.ErrNoDocuments is returned. So only one SendCampaign sends the Campaign
+func (t *CampaignMongoDBDao) ClaimSend(ctx context.Context, campaignId string, staleBefore time.Time, indata utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CampaignMongoDBDao::ClaimSend - Begin ", campaignId, staleBefore)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCampaigns)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CAMPAIGN_ID, Value: campaignId},
+		{Key: sales_common.FLD_CAMPAIGN_SENT_AT, Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: sales_common.FLD_CAMPAIGN_STATUS, Value: bson.D{{Key: "$ne", Value: sales_common.CAMPAIGN_STATUS_SENDING}}}},
+			bson.D{{Key: sales_common.FLD_CAMPAIGN_SEND_BEAT, Value: bson.D{{Key: "$lt", Value: staleBefore}}}}}},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(indata)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCampaigns, "FindOneAndUpdate", t.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("ClaimSend:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CampaignMongoDBDao::ClaimSend - End ", result[sales_common.FLD_CAMPAIGN_STATUS])
+	return result, nil
+}
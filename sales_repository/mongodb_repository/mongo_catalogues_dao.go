@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *CatalogueMongoDBDao) InitializeDao(client utils.Map, businessId string)
 }
 
 // List - List all Collections
-func (t *CatalogueMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CatalogueMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCatalogues)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *CatalogueMongoDBDao) List(filter string, sort string, skip int64, limit
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -96,7 +100,9 @@ func (t *CatalogueMongoDBDao) List(filter string, sort string, skip int64, limit
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +110,9 @@ func (t *CatalogueMongoDBDao) List(filter string, sort string, skip int64, limit
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -122,13 +130,13 @@ func (t *CatalogueMongoDBDao) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Get by code
-func (p *CatalogueMongoDBDao) Get(catalogueId string) (utils.Map, error) {
+func (p *CatalogueMongoDBDao) Get(ctx context.Context, catalogueId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CatalogueMongoDBDao::Get:: Begin ", catalogueId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCatalogues)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CATALOGUE_ID, Value: catalogueId}, {}}
@@ -139,7 +147,9 @@ func (p *CatalogueMongoDBDao) Get(catalogueId string) (utils.Map, error) {
 
 	log.Println("Get:: Got filter ", filter)
 
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -156,13 +166,13 @@ func (p *CatalogueMongoDBDao) Get(catalogueId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CatalogueMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CatalogueMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CatalogueDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCatalogues)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -175,7 +185,9 @@ func (p *CatalogueMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -194,17 +206,19 @@ func (p *CatalogueMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CatalogueMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CatalogueMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Brand Save - Begin", indata)
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "InsertOne", t.businessId)
+	insertResult, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,10 +231,10 @@ func (t *CatalogueMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Collection
-func (t *CatalogueMongoDBDao) Update(catalogueId string, indata utils.Map) (utils.Map, error) {
+func (t *CatalogueMongoDBDao) Update(ctx context.Context, catalogueId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -229,22 +243,24 @@ func (t *CatalogueMongoDBDao) Update(catalogueId string, indata utils.Map) (util
 	log.Printf("Update - Values %v", indata)
 
 	filter := bson.D{{Key: sales_common.FLD_CATALOGUE_ID, Value: catalogueId}}
-	updateResult, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "UpdateOne", t.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(catalogueId)
+	return t.Get(ctx, catalogueId)
 }
 
 // Delete - Delete Collection
-func (t *CatalogueMongoDBDao) Delete(catalogueId string) (int64, error) {
+func (t *CatalogueMongoDBDao) Delete(ctx context.Context, catalogueId string) (int64, error) {
 
 	log.Println("CatalogueMongoDBDao::Delete - Begin ", catalogueId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCatalogues)
 	if err != nil {
 		return 0, err
 	}
@@ -255,7 +271,9 @@ func (t *CatalogueMongoDBDao) Delete(catalogueId string) (int64, error) {
 	})
 
 	filter := bson.D{{Key: sales_common.FLD_CATALOGUE_ID, Value: catalogueId}}
-	res, err := collection.DeleteOne(ctx, filter, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCatalogues, "DeleteOne", t.businessId)
+	res, err := collection.DeleteOne(dbCtx, filter, opts)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -1,14 +1,17 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -29,12 +32,12 @@ func (p *CategoryMongoDBDao) InitializeDao(client utils.Map, businessId string)
 }
 
 // List - List all Collections
-func (t *CategoryMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CategoryMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCategories)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +80,16 @@ func (t *CategoryMongoDBDao) List(filter string, sort string, skip int64, limit
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +102,9 @@ func (t *CategoryMongoDBDao) List(filter string, sort string, skip int64, limit
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCategories, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +112,9 @@ func (t *CategoryMongoDBDao) List(filter string, sort string, skip int64, limit
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCategories, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +132,13 @@ func (t *CategoryMongoDBDao) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Get by code
-func (p *CategoryMongoDBDao) Get(categoryId string) (utils.Map, error) {
+func (p *CategoryMongoDBDao) Get(ctx context.Context, categoryId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CategoryMongoDBDao::Get:: Begin ", categoryId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCategories)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CATEGORY_ID, Value: categoryId}, {}}
@@ -140,7 +149,9 @@ func (p *CategoryMongoDBDao) Get(categoryId string) (utils.Map, error) {
 
 	log.Println("Get:: Got filter ", filter)
 
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -159,13 +170,13 @@ func (p *CategoryMongoDBDao) Get(categoryId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CategoryMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CategoryMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CategoryDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCategories)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -178,7 +189,9 @@ func (p *CategoryMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -197,17 +210,19 @@ func (p *CategoryMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CategoryMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CategoryMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Category Save - Begin", indata)
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "InsertOne", t.businessId)
+	insertResult, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -220,10 +235,10 @@ func (t *CategoryMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Collection
-func (t *CategoryMongoDBDao) Update(categoriId string, indata utils.Map) (utils.Map, error) {
+func (t *CategoryMongoDBDao) Update(ctx context.Context, categoriId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -232,22 +247,24 @@ func (t *CategoryMongoDBDao) Update(categoriId string, indata utils.Map) (utils.
 	log.Printf("Update - Values %v", indata)
 
 	filter := bson.D{{Key: sales_common.FLD_CATEGORY_ID, Value: categoriId}}
-	updateResult, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "UpdateOne", t.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(categoriId)
+	return t.Get(ctx, categoriId)
 }
 
 // Delete - Delete Collection
-func (t *CategoryMongoDBDao) Delete(categoryId string) (int64, error) {
+func (t *CategoryMongoDBDao) Delete(ctx context.Context, categoryId string) (int64, error) {
 
 	log.Println("CategoryMongoDBDao::Delete - Begin ", categoryId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
 	if err != nil {
 		return 0, err
 	}
@@ -258,7 +275,9 @@ func (t *CategoryMongoDBDao) Delete(categoryId string) (int64, error) {
 	})
 
 	filter := bson.D{{Key: sales_common.FLD_CATEGORY_ID, Value: categoryId}}
-	res, err := collection.DeleteOne(ctx, filter, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "DeleteOne", t.businessId)
+	res, err := collection.DeleteOne(dbCtx, filter, opts)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -266,3 +285,58 @@ func (t *CategoryMongoDBDao) Delete(categoryId string) (int64, error) {
 	log.Printf("CategoryMongoDBDao::Delete - End deleted %v documents\n", res.DeletedCount)
 	return res.DeletedCount, nil
 }
+
+// MoveSubtree - Move the Category and its sub Categories under the new ancestors in one update. Each sub
+// Category keeps its own ancestors from the Category on, so stale or short ancestors are rebuilt as well
+func (t *CategoryMongoDBDao) MoveSubtree(ctx context.Context, categoryId string, parentId string, ancestors []string) (int64, error) {
+
+	log.Println("CategoryMongoDBDao::MoveSubtree - Begin ", categoryId, parentId, ancestors)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCategories)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: sales_common.FLD_CATEGORY_ID, Value: categoryId}},
+			bson.D{{Key: sales_common.FLD_CATEGORY_ANCESTORS, Value: categoryId}}}},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	// Ancestors from the Category on, empty for the Category itself
+	ancestorsFld := "$" + sales_common.FLD_CATEGORY_ANCESTORS
+	index := bson.D{{Key: "$indexOfArray", Value: bson.A{ancestorsFld, categoryId}}}
+	suffix := bson.D{{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$lt", Value: bson.A{index, 0}}},
+		bson.A{},
+		bson.D{{Key: "$slice", Value: bson.A{ancestorsFld, index, bson.D{{Key: "$size", Value: ancestorsFld}}}}}}}}
+	parent := bson.D{{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{"$" + sales_common.FLD_CATEGORY_ID, categoryId}}},
+		bson.D{{Key: "$literal", Value: parentId}},
+		"$" + sales_common.FLD_CATEGORY_PARENT_ID}}}
+
+	fields := db_common.AmendFldsforUpdate(utils.Map{})
+	fields[sales_common.FLD_CATEGORY_ANCESTORS] = bson.D{{Key: "$concatArrays", Value: bson.A{bson.D{{Key: "$literal", Value: ancestors}}, suffix}}}
+	fields[sales_common.FLD_CATEGORY_PARENT_ID] = parent
+
+	// Path from the new ancestors, "/" joined with the Category
+	path := bson.D{{Key: "$reduce", Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$concatArrays", Value: bson.A{ancestorsFld, bson.A{"$" + sales_common.FLD_CATEGORY_ID}}}}},
+		{Key: "initialValue", Value: ""},
+		{Key: "in", Value: bson.D{{Key: "$concat", Value: bson.A{"$$value", "/", "$$this"}}}}}}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: fields}},
+		{{Key: "$set", Value: bson.D{{Key: sales_common.FLD_CATEGORY_PATH, Value: path}}}}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCategories, "UpdateMany", t.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CategoryMongoDBDao::MoveSubtree - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
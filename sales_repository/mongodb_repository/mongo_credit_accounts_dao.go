@@ -0,0 +1,339 @@
+package mongodb_repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreditAccountMongoDBDao - CreditAccount DAO Repository
+type CreditAccountMongoDBDao struct {
+	client     utils.Map
+	businessId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *CreditAccountMongoDBDao) InitializeDao(client utils.Map, businessId string) {
+	log.Println("Initialize CreditAccount Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+}
+
+// List - List all Collections
+func (t *CreditAccountMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbCreditAccounts)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (p *CreditAccountMongoDBDao) Get(ctx context.Context, creditAccountId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("CreditAccountMongoDBDao::Get:: Begin ", creditAccountId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditAccounts)
+	log.Println("Get:: Got Collection ")
+
+	filter := bson.D{{Key: sales_common.FLD_CREDIT_ACCOUNT_ID, Value: creditAccountId}, {}}
+
+	filter = append(filter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("Business CreditAccountMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Find - Find by Filter
+func (p *CreditAccountMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	// Find a single document
+	var result utils.Map
+
+	log.Println("CreditAccountDBDao::Find:: Begin ", filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditAccounts)
+	log.Println("Find:: Got Collection ", err)
+
+	bfilter := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(filter), true, &bfilter)
+	if err != nil {
+		fmt.Println("Error on filter Unmarshal", err)
+	}
+	bfilter = append(bfilter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Find:: Got filter ", bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Find:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CreditAccountDBDao::Find:: End Found a single document: \n", err)
+	return result, nil
+}
+
+// Create - Create Collection
+func (t *CreditAccountMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("CreditAccount Save - Begin", indata)
+	//CreditAccount
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditAccounts)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_CREDIT_ACCOUNT_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_CREDIT_ACCOUNT_ID].(string))
+}
+
+// Update - Update Collection
+func (t *CreditAccountMongoDBDao) Update(ctx context.Context, creditAccountId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Update - Begin")
+
+	//CreditAccount
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditAccounts)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+	log.Printf("Update - Values %v", indata)
+
+	filterCreditAccount := bson.D{{Key: sales_common.FLD_CREDIT_ACCOUNT_ID, Value: creditAccountId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCreditAccount, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Update a single document: ", updateResult1.ModifiedCount)
+
+	log.Println("Update - End")
+	return t.Get(ctx, creditAccountId)
+}
+
+// Delete - Delete Collection
+func (t *CreditAccountMongoDBDao) Delete(ctx context.Context, creditAccountId string) (int64, error) {
+
+	log.Println("CreditAccountMongoDBDao::Delete - Begin ", creditAccountId)
+
+	//CreditAccount
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditAccounts)
+	if err != nil {
+		return 0, err
+	}
+	optsCreditAccount := options.Delete().SetCollation(&options.Collation{
+		Locale:    db_common.LOCALE,
+		Strength:  1,
+		CaseLevel: false,
+	})
+
+	filterCreditAccount := bson.D{{Key: sales_common.FLD_CREDIT_ACCOUNT_ID, Value: creditAccountId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "DeleteOne", t.businessId)
+	resCreditAccount, err := collection.DeleteOne(dbCtx, filterCreditAccount, optsCreditAccount)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in delete ", err)
+		return 0, err
+	}
+	log.Printf("CreditAccountMongoDBDao::Delete - End deleted %v documents\n", resCreditAccount.DeletedCount)
+	return resCreditAccount.DeletedCount, nil
+}
+
+// ApplyOutstanding - Add the amount to the outstanding balance. Positive amount is added only if the
+// outstanding stays within the credit limit, else mongo.ErrNoDocuments is returned
+func (p *CreditAccountMongoDBDao) ApplyOutstanding(ctx context.Context, creditAccountId string, amount primitive.Decimal128) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CreditAccountMongoDBDao::ApplyOutstanding - Begin ", creditAccountId, amount)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditAccounts)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CREDIT_ACCOUNT_ID, Value: creditAccountId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	if amountRat, err := sales_common.DecimalToRat(amount); err == nil && amountRat.Sign() > 0 {
+		filter = append(filter, bson.E{Key: "$expr", Value: bson.D{{Key: "$lte", Value: bson.A{
+			bson.D{{Key: "$add", Value: bson.A{"$" + sales_common.FLD_CREDIT_OUTSTANDING, amount}}},
+			"$" + sales_common.FLD_CREDIT_LIMIT}}}})
+	}
+
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: sales_common.FLD_CREDIT_OUTSTANDING, Value: amount}}},
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	// Single atomic update, so that parallel Orders can not exceed the credit limit
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditAccounts, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("ApplyOutstanding:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CreditAccountMongoDBDao::ApplyOutstanding - End ", result[sales_common.FLD_CREDIT_OUTSTANDING])
+	return result, nil
+}
@@ -0,0 +1,408 @@
+package mongodb_repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreditInvoiceMongoDBDao - CreditInvoice DAO Repository
+type CreditInvoiceMongoDBDao struct {
+	client     utils.Map
+	businessId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *CreditInvoiceMongoDBDao) InitializeDao(client utils.Map, businessId string) {
+	log.Println("Initialize CreditInvoice Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+}
+
+// List - List all Collections
+func (t *CreditInvoiceMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbCreditInvoices)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (p *CreditInvoiceMongoDBDao) Get(ctx context.Context, invoiceId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("CreditInvoiceMongoDBDao::Get:: Begin ", invoiceId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditInvoices)
+	log.Println("Get:: Got Collection ")
+
+	filter := bson.D{{Key: sales_common.FLD_INVOICE_ID, Value: invoiceId}, {}}
+
+	filter = append(filter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("Business CreditInvoiceMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Find - Find by Filter
+func (p *CreditInvoiceMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	// Find a single document
+	var result utils.Map
+
+	log.Println("CreditInvoiceDBDao::Find:: Begin ", filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditInvoices)
+	log.Println("Find:: Got Collection ", err)
+
+	bfilter := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(filter), true, &bfilter)
+	if err != nil {
+		fmt.Println("Error on filter Unmarshal", err)
+	}
+	bfilter = append(bfilter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Find:: Got filter ", bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Find:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CreditInvoiceDBDao::Find:: End Found a single document: \n", err)
+	return result, nil
+}
+
+// Create - Create Collection
+func (t *CreditInvoiceMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("CreditInvoice Save - Begin", indata)
+	//CreditInvoice
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_INVOICE_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_INVOICE_ID].(string))
+}
+
+// Update - Update Collection
+func (t *CreditInvoiceMongoDBDao) Update(ctx context.Context, invoiceId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Update - Begin")
+
+	//CreditInvoice
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+	log.Printf("Update - Values %v", indata)
+
+	filterCreditInvoice := bson.D{{Key: sales_common.FLD_INVOICE_ID, Value: invoiceId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCreditInvoice, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Update a single document: ", updateResult1.ModifiedCount)
+
+	log.Println("Update - End")
+	return t.Get(ctx, invoiceId)
+}
+
+// Delete - Delete Collection
+func (t *CreditInvoiceMongoDBDao) Delete(ctx context.Context, invoiceId string) (int64, error) {
+
+	log.Println("CreditInvoiceMongoDBDao::Delete - Begin ", invoiceId)
+
+	//CreditInvoice
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return 0, err
+	}
+	optsCreditInvoice := options.Delete().SetCollation(&options.Collation{
+		Locale:    db_common.LOCALE,
+		Strength:  1,
+		CaseLevel: false,
+	})
+
+	filterCreditInvoice := bson.D{{Key: sales_common.FLD_INVOICE_ID, Value: invoiceId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "DeleteOne", t.businessId)
+	resCreditInvoice, err := collection.DeleteOne(dbCtx, filterCreditInvoice, optsCreditInvoice)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in delete ", err)
+		return 0, err
+	}
+	log.Printf("CreditInvoiceMongoDBDao::Delete - End deleted %v documents\n", resCreditInvoice.DeletedCount)
+	return resCreditInvoice.DeletedCount, nil
+}
+
+// ApplyPayment - Add the payment to the open Invoice only if the paid amount stays within the invoice
+// amount, else mongo.ErrNoDocuments is returned
+func (p *CreditInvoiceMongoDBDao) ApplyPayment(ctx context.Context, invoiceId string, amount primitive.Decimal128, payment utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CreditInvoiceMongoDBDao::ApplyPayment - Begin ", invoiceId, amount)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_INVOICE_ID, Value: invoiceId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: sales_common.FLD_INVOICE_STATUS, Value: sales_common.INVOICE_STATUS_OPEN},
+		{Key: db_common.FLD_IS_DELETED, Value: false},
+		{Key: "$expr", Value: bson.D{{Key: "$lte", Value: bson.A{
+			bson.D{{Key: "$add", Value: bson.A{"$" + sales_common.FLD_INVOICE_PAID, amount}}},
+			"$" + sales_common.FLD_INVOICE_AMOUNT}}}}}
+
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: sales_common.FLD_INVOICE_PAID, Value: amount}}},
+		{Key: "$push", Value: bson.D{{Key: sales_common.FLD_INVOICE_PAYMENTS, Value: payment}}},
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	// Single atomic update, so that the Invoice is never paid more than its amount
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("ApplyPayment:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CreditInvoiceMongoDBDao::ApplyPayment - End ", result[sales_common.FLD_INVOICE_PAID])
+	return result, nil
+}
+
+// VoidByOrder - Void the open Invoice of the Order and return it as it was before, mongo.ErrNoDocuments if
+// the Order has no open Invoice. So the Invoice is voided only once
+func (p *CreditInvoiceMongoDBDao) VoidByOrder(ctx context.Context, custOrderId string) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CreditInvoiceMongoDBDao::VoidByOrder - Begin ", custOrderId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ORDER_ID, Value: custOrderId},
+		{Key: sales_common.FLD_INVOICE_STATUS, Value: sales_common.INVOICE_STATUS_OPEN},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{sales_common.FLD_INVOICE_STATUS: sales_common.INVOICE_STATUS_VOID})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("VoidByOrder:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CreditInvoiceMongoDBDao::VoidByOrder - End ", result[sales_common.FLD_INVOICE_ID])
+	return result, nil
+}
+
+// ReassignAccount - Move all the Invoices of the Account to the other Account of the party
+func (p *CreditInvoiceMongoDBDao) ReassignAccount(ctx context.Context, fromAccountId string, toAccountId string, toPartyId string) (int64, error) {
+
+	log.Println("CreditInvoiceMongoDBDao::ReassignAccount - Begin ", fromAccountId, toAccountId, toPartyId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCreditInvoices)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CREDIT_ACCOUNT_ID, Value: fromAccountId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId}}
+	update := bson.D{{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{
+		sales_common.FLD_CREDIT_ACCOUNT_ID: toAccountId,
+		sales_common.FLD_CREDIT_PARTY_ID:   toPartyId})}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCreditInvoices, "UpdateMany", p.businessId)
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CreditInvoiceMongoDBDao::ReassignAccount - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
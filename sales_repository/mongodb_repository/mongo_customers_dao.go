@@ -1,13 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
-	"github.com/zapscloud/golib-platform/platform_common"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -30,12 +31,12 @@ func (p *CustomerMongoDBDao) InitializeDao(client utils.Map, businessId string)
 }
 
 // List - List all Collections
-func (t *CustomerMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *CustomerMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbCustomers)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
 	if err != nil {
 		return nil, err
 	}
@@ -78,14 +79,16 @@ func (t *CustomerMongoDBDao) List(filter string, sort string, skip int64, limit
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -98,7 +101,9 @@ func (t *CustomerMongoDBDao) List(filter string, sort string, skip int64, limit
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +111,9 @@ func (t *CustomerMongoDBDao) List(filter string, sort string, skip int64, limit
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -124,13 +131,13 @@ func (t *CustomerMongoDBDao) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Get by code
-func (t *CustomerMongoDBDao) Get(customerId string) (utils.Map, error) {
+func (t *CustomerMongoDBDao) Get(ctx context.Context, customerId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("CustomerMongoDBDao::Get:: Begin ", customerId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId}, {}}
@@ -140,7 +147,9 @@ func (t *CustomerMongoDBDao) Get(customerId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -159,13 +168,13 @@ func (t *CustomerMongoDBDao) Get(customerId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *CustomerMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *CustomerMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("CustomerDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbCustomers)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -178,7 +187,9 @@ func (p *CustomerMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -197,11 +208,11 @@ func (p *CustomerMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *CustomerMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *CustomerMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Customer Save - Begin", indata)
 	//Sales Customer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -209,7 +220,9 @@ func (t *CustomerMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -218,16 +231,16 @@ func (t *CustomerMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_CUSTOMER_ID])
 
-	return t.Get(indata[sales_common.FLD_CUSTOMER_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_CUSTOMER_ID].(string))
 }
 
 // Update - Update Collection
-func (t *CustomerMongoDBDao) Update(customerId string, indata utils.Map) (utils.Map, error) {
+func (t *CustomerMongoDBDao) Update(ctx context.Context, customerId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Customer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -236,23 +249,25 @@ func (t *CustomerMongoDBDao) Update(customerId string, indata utils.Map) (utils.
 	log.Printf("Update - Values %v", indata)
 
 	filterCustomer := bson.D{{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterCustomer, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterCustomer, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(customerId)
+	return t.Get(ctx, customerId)
 }
 
 // Delete - Delete Collection
-func (t *CustomerMongoDBDao) Delete(customerId string) (int64, error) {
+func (t *CustomerMongoDBDao) Delete(ctx context.Context, customerId string) (int64, error) {
 
 	log.Println("CustomerMongoDBDao::Delete - Begin ", customerId)
 
 	// Sales Customer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
 	if err != nil {
 		return 0, err
 	}
@@ -263,7 +278,9 @@ func (t *CustomerMongoDBDao) Delete(customerId string) (int64, error) {
 	})
 
 	filterCustomer := bson.D{{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId}}
-	resCustomer, err := collection.DeleteOne(ctx, filterCustomer, optsCustomer)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "DeleteOne", t.businessId)
+	resCustomer, err := collection.DeleteOne(dbCtx, filterCustomer, optsCustomer)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -272,40 +289,185 @@ func (t *CustomerMongoDBDao) Delete(customerId string) (int64, error) {
 	return resCustomer.DeletedCount, nil
 }
 
-// Find - Find by code
-func (t *CustomerMongoDBDao) Authenticate(auth_key string, auth_login string, auth_pwd string) (utils.Map, error) {
+// GetByLogin - Get by login key along with the password hash, for authentication
+func (t *CustomerMongoDBDao) GetByLogin(ctx context.Context, auth_key string, auth_login string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
-	log.Println("AppUserMongoDBDao::Find:: Begin ", auth_login)
+	log.Println("CustomerMongoDBDao::GetByLogin:: Begin ", auth_key, auth_login)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
-	log.Println("Find:: Got Collection ")
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: auth_key, Value: auth_login},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	log.Println("GetByLogin:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("GetByLogin:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
 
-	// filter := bson.D{{Key: platform_common.FLD_SYS_USER_EMAIL, Value: email}, {Key: platform_common.FLD_SYS_USER_PASSWORD, Value: password}}
+	log.Printf("CustomerMongoDBDao::GetByLogin:: End Found a single document\n")
+	return result, nil
+}
 
-	filter := bson.M{auth_key: auth_login, sales_common.FLD_CUSTOMER_PASSWORD: auth_pwd}
+// IncrementAttempts - Add one to the attempts field only while it is below maxAttempts, returns the Customer
+// along with the secrets, mongo.ErrNoDocuments if the attempts are used up
+func (t *CustomerMongoDBDao) IncrementAttempts(ctx context.Context, customerId string, attemptsField string, maxAttempts int) (utils.Map, error) {
+	var result utils.Map
 
-	log.Println("Find:: Got filter ", filter)
+	log.Println("CustomerMongoDBDao::IncrementAttempts:: Begin ", customerId, attemptsField)
 
-	singleResult := collection.FindOne(ctx, filter)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	if err != nil {
+		return result, err
+	}
 
+	// Missing attempts field is taken as no attempts
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false},
+		{Key: attemptsField, Value: bson.M{"$not": bson.M{"$gte": maxAttempts}}}}
+	update := bson.D{
+		{Key: "$inc", Value: bson.M{attemptsField: 1}},
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "FindOneAndUpdate", t.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
-		log.Println("Find:: Record not found ", singleResult.Err())
+		log.Println("IncrementAttempts:: Attempts used up or not found ", singleResult.Err())
 		return result, singleResult.Err()
 	}
-	singleResult.Decode(&result)
+	err = singleResult.Decode(&result)
 	if err != nil {
 		log.Println("Error in decode", err)
 		return result, err
 	}
 
-	// Delete Password
-	delete(result, platform_common.FLD_SYS_USER_PASSWORD)
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("CustomerMongoDBDao::IncrementAttempts:: End ", result[attemptsField])
+	return result, nil
+}
+
+// ConsumeResetCode - Clear the Password reset code only if it is still the given one, returns the Customer,
+// mongo.ErrNoDocuments if the code is used already or replaced
+func (t *CustomerMongoDBDao) ConsumeResetCode(ctx context.Context, customerId string, resetCode string) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("CustomerMongoDBDao::ConsumeResetCode:: Begin ", customerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	if err != nil {
+		return result, err
+	}
+
+	// Matching and clearing in one update, so the same code can be used only once even at the same time
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false},
+		{Key: sales_common.FLD_CUSTOMER_RESET_CODE, Value: resetCode}}
+	update := bson.D{
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{
+			sales_common.FLD_CUSTOMER_RESET_CODE:   "",
+			sales_common.FLD_CUSTOMER_RESET_EXPIRY: nil,
+		})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "FindOneAndUpdate", t.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("ConsumeResetCode:: Used already or not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
 
 	// Remove fields from result
 	result = db_common.AmendFldsForGet(result)
 
-	log.Printf("AppUserMongoDBDao::Find:: End Found a single document\n")
+	log.Println("CustomerMongoDBDao::ConsumeResetCode:: End ")
 	return result, nil
 }
+
+// FindDuplicates - Groups of the Customers with the same value of the field, ignoring the case and the
+// surrounding spaces. Grouped in the database, so the Customers are not loaded
+func (t *CustomerMongoDBDao) FindDuplicates(ctx context.Context, field string) ([]utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("CustomerMongoDBDao::FindDuplicates - Begin ", field)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbCustomers)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+			{Key: db_common.FLD_IS_DELETED, Value: false},
+			{Key: field, Value: bson.D{{Key: "$type", Value: "string"}, {Key: "$gt", Value: ""}}}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$toLower", Value: bson.D{{Key: "$trim", Value: bson.D{{Key: "input", Value: "$" + field}}}}}}},
+			{Key: sales_common.DUPLICATE_REPORT_CUSTOMER_IDS, Value: bson.D{{Key: "$push", Value: "$" + sales_common.FLD_CUSTOMER_ID}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$ne", Value: ""}}},
+			{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	opts := options.Aggregate().SetAllowDiskUse(true)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbCustomers, "Aggregate", t.businessId)
+	cursor, err := collection.Aggregate(dbCtx, pipeline, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	groups := []utils.Map{}
+	for _, value := range results {
+		customerIds := []string{}
+		if ids, ok := value[sales_common.DUPLICATE_REPORT_CUSTOMER_IDS].(bson.A); ok {
+			for _, id := range ids {
+				customerIds = append(customerIds, fmt.Sprint(id))
+			}
+		}
+		groups = append(groups, utils.Map{
+			sales_common.DUPLICATE_REPORT_MATCH_FIELD:  field,
+			sales_common.DUPLICATE_REPORT_MATCH_VALUE:  value["_id"],
+			sales_common.DUPLICATE_REPORT_CUSTOMER_IDS: customerIds,
+		})
+	}
+
+	log.Println("CustomerMongoDBDao::FindDuplicates - End ", len(groups))
+	return groups, nil
+}
@@ -0,0 +1,340 @@
+package mongodb_repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InventoryMongoDBDao - Inventory DAO Repository
+type InventoryMongoDBDao struct {
+	client     utils.Map
+	businessId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *InventoryMongoDBDao) InitializeDao(client utils.Map, businessId string) {
+	log.Println("Initialize Inventory Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+}
+
+// List - List all Collections
+func (t *InventoryMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbInventory)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbInventory)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbInventory, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbInventory, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (p *InventoryMongoDBDao) Get(ctx context.Context, inventoryId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("InventoryMongoDBDao::Get:: Begin ", inventoryId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbInventory)
+	log.Println("Get:: Got Collection ")
+
+	filter := bson.D{{Key: sales_common.FLD_INVENTORY_ID, Value: inventoryId}, {}}
+
+	filter = append(filter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("Business InventoryMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Find - Find by Filter
+func (p *InventoryMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	// Find a single document
+	var result utils.Map
+
+	log.Println("InventoryDBDao::Find:: Begin ", filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbInventory)
+	log.Println("Find:: Got Collection ", err)
+
+	bfilter := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(filter), true, &bfilter)
+	if err != nil {
+		fmt.Println("Error on filter Unmarshal", err)
+	}
+	bfilter = append(bfilter,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Find:: Got filter ", bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Find:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("InventoryDBDao::Find:: End Found a single document: \n", err)
+	return result, nil
+}
+
+// Create - Create Collection
+func (t *InventoryMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Inventory Save - Begin", indata)
+	//Inventory
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbInventory)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_INVENTORY_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_INVENTORY_ID].(string))
+}
+
+// Update - Update Collection
+func (t *InventoryMongoDBDao) Update(ctx context.Context, inventoryId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Update - Begin")
+
+	//Inventory
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbInventory)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+	log.Printf("Update - Values %v", indata)
+
+	filterInventory := bson.D{{Key: sales_common.FLD_INVENTORY_ID, Value: inventoryId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterInventory, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Update a single document: ", updateResult1.ModifiedCount)
+
+	log.Println("Update - End")
+	return t.Get(ctx, inventoryId)
+}
+
+// Delete - Delete Collection
+func (t *InventoryMongoDBDao) Delete(ctx context.Context, inventoryId string) (int64, error) {
+
+	log.Println("InventoryMongoDBDao::Delete - Begin ", inventoryId)
+
+	//Inventory
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbInventory)
+	if err != nil {
+		return 0, err
+	}
+	optsInventory := options.Delete().SetCollation(&options.Collation{
+		Locale:    db_common.LOCALE,
+		Strength:  1,
+		CaseLevel: false,
+	})
+
+	filterInventory := bson.D{{Key: sales_common.FLD_INVENTORY_ID, Value: inventoryId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "DeleteOne", t.businessId)
+	resInventory, err := collection.DeleteOne(dbCtx, filterInventory, optsInventory)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in delete ", err)
+		return 0, err
+	}
+	log.Printf("InventoryMongoDBDao::Delete - End deleted %v documents\n", resInventory.DeletedCount)
+	return resInventory.DeletedCount, nil
+}
+
+// ApplyStock - Add to the on hand and reserved stock. Applied only if both stay non negative and the reserved
+// stays within the on hand, else mongo.ErrNoDocuments is returned
+func (p *InventoryMongoDBDao) ApplyStock(ctx context.Context, inventoryId string, onHand float64, reserved float64) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("InventoryMongoDBDao::ApplyStock - Begin ", inventoryId, onHand, reserved)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbInventory)
+	if err != nil {
+		return result, err
+	}
+
+	newOnHand := bson.D{{Key: "$add", Value: bson.A{"$" + sales_common.FLD_STOCK_ON_HAND, onHand}}}
+	newReserved := bson.D{{Key: "$add", Value: bson.A{"$" + sales_common.FLD_STOCK_RESERVED, reserved}}}
+	filter := bson.D{
+		{Key: sales_common.FLD_INVENTORY_ID, Value: inventoryId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false},
+		{Key: "$expr", Value: bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$gte", Value: bson.A{newReserved, 0}}},
+			bson.D{{Key: "$gte", Value: bson.A{newOnHand, newReserved}}}}}}}}
+
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{
+			{Key: sales_common.FLD_STOCK_ON_HAND, Value: onHand},
+			{Key: sales_common.FLD_STOCK_RESERVED, Value: reserved}}},
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{})}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	// Single atomic update, so that parallel checkouts can not reserve more than the on hand stock
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbInventory, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("ApplyStock:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("InventoryMongoDBDao::ApplyStock - End ", result[sales_common.FLD_STOCK_ON_HAND], result[sales_common.FLD_STOCK_RESERVED])
+	return result, nil
+}
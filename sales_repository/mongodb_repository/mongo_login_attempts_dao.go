@@ -0,0 +1,165 @@
+package mongodb_repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginAttemptMongoDBDao - LoginAttempt DAO Repository
+type LoginAttemptMongoDBDao struct {
+	client     utils.Map
+	businessId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *LoginAttemptMongoDBDao) InitializeDao(client utils.Map, businessId string) {
+	log.Println("Initialize LoginAttempt Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+}
+
+// Get - Get by code
+func (p *LoginAttemptMongoDBDao) Get(ctx context.Context, attemptId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("LoginAttemptMongoDBDao::Get:: Begin ", attemptId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoginAttempts)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_LOGIN_ATTEMPT_ID, Value: attemptId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoginAttempts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("LoginAttemptMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// RecordFailure - Increment the failed attempts and set the indata values, creates the record if not exist
+func (p *LoginAttemptMongoDBDao) RecordFailure(ctx context.Context, attemptId string, indata utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("LoginAttemptMongoDBDao::RecordFailure - Begin ", attemptId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoginAttempts)
+	if err != nil {
+		return result, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+
+	filter := bson.D{
+		{Key: sales_common.FLD_LOGIN_ATTEMPT_ID, Value: attemptId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: sales_common.FLD_LOGIN_FAILED_ATTEMPTS, Value: 1}}},
+		{Key: "$set", Value: indata},
+		{Key: "$setOnInsert", Value: bson.D{{Key: db_common.FLD_CREATED_AT, Value: time.Now()}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	// Single atomic update, so that parallel attempts are all counted
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoginAttempts, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("RecordFailure:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("LoginAttemptMongoDBDao::RecordFailure - End ", result[sales_common.FLD_LOGIN_FAILED_ATTEMPTS])
+	return result, nil
+}
+
+// Update - Update Collection
+func (p *LoginAttemptMongoDBDao) Update(ctx context.Context, attemptId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("LoginAttemptMongoDBDao::Update - Begin ", attemptId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoginAttempts)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+	log.Printf("Update - Values %v", indata)
+
+	filter := bson.D{
+		{Key: sales_common.FLD_LOGIN_ATTEMPT_ID, Value: attemptId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoginAttempts, "UpdateOne", p.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Update a single document: ", updateResult.ModifiedCount)
+
+	log.Println("LoginAttemptMongoDBDao::Update - End")
+	return p.Get(ctx, attemptId)
+}
+
+// Delete - Delete Collection
+func (p *LoginAttemptMongoDBDao) Delete(ctx context.Context, attemptId string) (int64, error) {
+
+	log.Println("LoginAttemptMongoDBDao::Delete - Begin ", attemptId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoginAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_LOGIN_ATTEMPT_ID, Value: attemptId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId}}
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoginAttempts, "DeleteOne", p.businessId)
+	res, err := collection.DeleteOne(dbCtx, filter)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in delete ", err)
+		return 0, err
+	}
+	log.Printf("LoginAttemptMongoDBDao::Delete - End deleted %v documents\n", res.DeletedCount)
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,253 @@
+package mongodb_repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoyaltyBalanceMongoDBDao - LoyaltyBalance DAO Repository
+type LoyaltyBalanceMongoDBDao struct {
+	client     utils.Map
+	businessId string
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *LoyaltyBalanceMongoDBDao) InitializeDao(client utils.Map, businessId string) {
+	log.Println("Initialize LoyaltyBalance Mongodb DAO")
+	p.client = client
+	p.businessId = businessId
+}
+
+// List - List all Collections
+func (t *LoyaltyBalanceMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("Begin - Find All Collection Dao", sales_common.DbLoyaltyBalances)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbLoyaltyBalances)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Get Collection - Find All Collection Dao", filter, len(filter), sort, len(sort))
+
+	opts := options.Find()
+
+	filterdoc := bson.D{}
+	if len(filter) > 0 {
+		// filters, _ := strconv.Unquote(string(filter))
+		err = bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc)
+		if err != nil {
+			log.Println("Unmarshal Ext JSON error", err)
+			log.Println(filterdoc)
+		}
+	}
+
+	if len(sort) > 0 {
+		var sortdoc interface{}
+		err = bson.UnmarshalExtJSON([]byte(sort), true, &sortdoc)
+		if err != nil {
+			log.Println("Sort Unmarshal Error ", sort)
+		} else {
+			opts.SetSort(sortdoc)
+		}
+	}
+
+	if skip > 0 {
+		log.Println(filterdoc)
+		opts.SetSkip(skip)
+	}
+
+	if limit > 0 {
+		log.Println(filterdoc)
+		opts.SetLimit(limit)
+	}
+	filterdoc = append(filterdoc,
+		bson.E{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
+
+	log.Println("Parameter values ", filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a list of all returned documents and print them out
+	// see the mongo.Cursor documentation for more examples of using cursors
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		// log.Println("Item ", idx)
+		// Remove fields from result
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("Parameter values ", filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	basefilterdoc := bson.D{
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response := utils.Map{
+		db_common.LIST_SUMMARY: utils.Map{
+			db_common.LIST_TOTALSIZE:    totalcount,
+			db_common.LIST_FILTEREDSIZE: filtercount,
+			db_common.LIST_RESULTSIZE:   len(listdata),
+		},
+		db_common.LIST_RESULT: listdata,
+	}
+
+	return response, nil
+}
+
+// Get - Get by code
+func (p *LoyaltyBalanceMongoDBDao) Get(ctx context.Context, customerId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("LoyaltyBalanceMongoDBDao::Get:: Begin ", customerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoyaltyBalances)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	log.Println("Get:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("LoyaltyBalanceMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Apply - Add the points to the balance and the counters, creates the record if not exist. Points are
+// deducted only if the balance has enough, else mongo.ErrNoDocuments is returned
+func (p *LoyaltyBalanceMongoDBDao) Apply(ctx context.Context, customerId string, points int64, counters utils.Map) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("LoyaltyBalanceMongoDBDao::Apply - Begin ", customerId, points)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoyaltyBalances)
+	if err != nil {
+		return result, err
+	}
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	if points < 0 {
+		filter = append(filter, bson.E{Key: sales_common.FLD_LOYALTY_POINTS_BALANCE, Value: bson.D{{Key: "$gte", Value: -points}}})
+	}
+
+	inc := bson.D{{Key: sales_common.FLD_LOYALTY_POINTS_BALANCE, Value: points}}
+	for key, value := range counters {
+		inc = append(inc, bson.E{Key: key, Value: value})
+	}
+	update := bson.D{
+		{Key: "$inc", Value: inc},
+		{Key: "$set", Value: db_common.AmendFldsforUpdate(utils.Map{})},
+		{Key: "$setOnInsert", Value: bson.D{{Key: db_common.FLD_CREATED_AT, Value: time.Now()}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(points >= 0).SetReturnDocument(options.After)
+
+	// Single atomic update, so that the balance never goes below zero
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "FindOneAndUpdate", p.businessId)
+	singleResult := collection.FindOneAndUpdate(dbCtx, filter, update, opts)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Apply:: Failed ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("LoyaltyBalanceMongoDBDao::Apply - End ", result[sales_common.FLD_LOYALTY_POINTS_BALANCE])
+	return result, nil
+}
+
+// Save - Replace the balance and the counters with the indata values, creates the record if not exist
+func (p *LoyaltyBalanceMongoDBDao) Save(ctx context.Context, customerId string, indata utils.Map) (utils.Map, error) {
+
+	log.Println("LoyaltyBalanceMongoDBDao::Save - Begin ", customerId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbLoyaltyBalances)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Modify Fields for Update
+	indata = db_common.AmendFldsforUpdate(indata)
+
+	filter := bson.D{
+		{Key: sales_common.FLD_CUSTOMER_ID, Value: customerId},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: p.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+	update := bson.D{
+		{Key: "$set", Value: indata},
+		{Key: "$setOnInsert", Value: bson.D{{Key: db_common.FLD_CREATED_AT, Value: time.Now()}}}}
+	opts := options.Update().SetUpsert(true)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbLoyaltyBalances, "UpdateOne", p.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filter, update, opts)
+	span.End(err)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	log.Println("Save a single document: ", updateResult.ModifiedCount, updateResult.UpsertedCount)
+
+	log.Println("LoyaltyBalanceMongoDBDao::Save - End")
+	return p.Get(ctx, customerId)
+}
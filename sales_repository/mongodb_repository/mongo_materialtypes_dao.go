@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *MaterialTypeMongoDBDao) InitializeDao(client utils.Map, businessId stri
 }
 
 // List - List all Collections
-func (t *MaterialTypeMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *MaterialTypeMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbMaterialTypes)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *MaterialTypeMongoDBDao) List(filter string, sort string, skip int64, li
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +101,9 @@ func (t *MaterialTypeMongoDBDao) List(filter string, sort string, skip int64, li
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,9 @@ func (t *MaterialTypeMongoDBDao) List(filter string, sort string, skip int64, li
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +131,13 @@ func (t *MaterialTypeMongoDBDao) List(filter string, sort string, skip int64, li
 }
 
 // Get - Get by code
-func (p *MaterialTypeMongoDBDao) Get(materialTypeId string) (utils.Map, error) {
+func (p *MaterialTypeMongoDBDao) Get(ctx context.Context, materialTypeId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("MaterialTypeMongoDBDao::Get:: Begin ", materialTypeId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMaterialTypes)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_MATERIAL_TYPE_ID, Value: materialTypeId}, {}}
@@ -140,7 +148,9 @@ func (p *MaterialTypeMongoDBDao) Get(materialTypeId string) (utils.Map, error) {
 
 	log.Println("Get:: Got filter ", filter)
 
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -159,13 +169,13 @@ func (p *MaterialTypeMongoDBDao) Get(materialTypeId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *MaterialTypeMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *MaterialTypeMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("MaterialTypeDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMaterialTypes)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -178,7 +188,9 @@ func (p *MaterialTypeMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -197,17 +209,19 @@ func (p *MaterialTypeMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *MaterialTypeMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *MaterialTypeMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("MaterialType Save - Begin", indata)
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "InsertOne", t.businessId)
+	insertResult, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -220,10 +234,10 @@ func (t *MaterialTypeMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Collection
-func (t *MaterialTypeMongoDBDao) Update(categoriId string, indata utils.Map) (utils.Map, error) {
+func (t *MaterialTypeMongoDBDao) Update(ctx context.Context, categoriId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -232,22 +246,24 @@ func (t *MaterialTypeMongoDBDao) Update(categoriId string, indata utils.Map) (ut
 	log.Printf("Update - Values %v", indata)
 
 	filter := bson.D{{Key: sales_common.FLD_MATERIAL_TYPE_ID, Value: categoriId}}
-	updateResult, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "UpdateOne", t.businessId)
+	updateResult, err := collection.UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(categoriId)
+	return t.Get(ctx, categoriId)
 }
 
 // Delete - Delete Collection
-func (t *MaterialTypeMongoDBDao) Delete(materialTypeId string) (int64, error) {
+func (t *MaterialTypeMongoDBDao) Delete(ctx context.Context, materialTypeId string) (int64, error) {
 
 	log.Println("MaterialTypeMongoDBDao::Delete - Begin ", materialTypeId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMaterialTypes)
 	if err != nil {
 		return 0, err
 	}
@@ -258,7 +274,9 @@ func (t *MaterialTypeMongoDBDao) Delete(materialTypeId string) (int64, error) {
 	})
 
 	filter := bson.D{{Key: sales_common.FLD_MATERIAL_TYPE_ID, Value: materialTypeId}}
-	res, err := collection.DeleteOne(ctx, filter, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMaterialTypes, "DeleteOne", t.businessId)
+	res, err := collection.DeleteOne(dbCtx, filter, opts)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *MediaMongoDBDao) InitializeDao(client utils.Map, businessId string) {
 }
 
 // List - List all Collections
-func (t *MediaMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *MediaMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbMedias)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *MediaMongoDBDao) List(filter string, sort string, skip int64, limit int
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +101,9 @@ func (t *MediaMongoDBDao) List(filter string, sort string, skip int64, limit int
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbMedias, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,9 @@ func (t *MediaMongoDBDao) List(filter string, sort string, skip int64, limit int
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbMedias, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +131,13 @@ func (t *MediaMongoDBDao) List(filter string, sort string, skip int64, limit int
 }
 
 // Get - Get by code
-func (p *MediaMongoDBDao) Get(mediaId string) (utils.Map, error) {
+func (p *MediaMongoDBDao) Get(ctx context.Context, mediaId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("MediaMongoDBDao::Get:: Begin ", mediaId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMedias)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_MEDIA_ID, Value: mediaId}, {}}
@@ -139,7 +147,9 @@ func (p *MediaMongoDBDao) Get(mediaId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +168,13 @@ func (p *MediaMongoDBDao) Get(mediaId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *MediaMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *MediaMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("MediaDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbMedias)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +187,9 @@ func (p *MediaMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +208,11 @@ func (p *MediaMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *MediaMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *MediaMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Media Save - Begin", indata)
 	//Business_media
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +220,9 @@ func (t *MediaMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +231,16 @@ func (t *MediaMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_MEDIA_ID])
 
-	return t.Get(indata[sales_common.FLD_MEDIA_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_MEDIA_ID].(string))
 }
 
 // Update - Update Collection
-func (t *MediaMongoDBDao) Update(mediaId string, indata utils.Map) (utils.Map, error) {
+func (t *MediaMongoDBDao) Update(ctx context.Context, mediaId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//media
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +249,25 @@ func (t *MediaMongoDBDao) Update(mediaId string, indata utils.Map) (utils.Map, e
 	log.Printf("Update - Values %v", indata)
 
 	filterMedia := bson.D{{Key: sales_common.FLD_MEDIA_ID, Value: mediaId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterMedia, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterMedia, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(mediaId)
+	return t.Get(ctx, mediaId)
 }
 
 // Delete - Delete Collection
-func (t *MediaMongoDBDao) Delete(mediaId string) (int64, error) {
+func (t *MediaMongoDBDao) Delete(ctx context.Context, mediaId string) (int64, error) {
 
 	log.Println("MediaMongoDBDao::Delete - Begin ", mediaId)
 
 	//BusinessMedia
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMedias)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +278,9 @@ func (t *MediaMongoDBDao) Delete(mediaId string) (int64, error) {
 	})
 
 	filterMedia := bson.D{{Key: sales_common.FLD_MEDIA_ID, Value: mediaId}}
-	resMedia, err := collection.DeleteOne(ctx, filterMedia, optsMedia)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMedias, "DeleteOne", t.businessId)
+	resMedia, err := collection.DeleteOne(dbCtx, filterMedia, optsMedia)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
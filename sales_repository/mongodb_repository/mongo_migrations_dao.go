@@ -0,0 +1,196 @@
+package mongodb_repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/mongo_utils"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationMongoDBDao - Migration DAO Repository
+type MigrationMongoDBDao struct {
+	client utils.Map
+}
+
+func init() {
+	log.SetFlags(log.Lshortfile | log.LstdFlags | log.Lmicroseconds)
+}
+
+func (p *MigrationMongoDBDao) InitializeDao(client utils.Map) {
+	log.Println("Initialize Migration Mongodb DAO")
+	p.client = client
+}
+
+// List - List all applied Migrations
+func (t *MigrationMongoDBDao) List(ctx context.Context) ([]utils.Map, error) {
+	var results []utils.Map
+
+	log.Println("MigrationMongoDBDao::List:: Begin ")
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: sales_common.FLD_MIGRATION_ID, Value: 1}})
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMigrations, "Find", "")
+	cursor, err := collection.Find(dbCtx, bson.D{}, opts)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cursor.All(dbCtx, &results); err != nil {
+		return nil, err
+	}
+
+	listdata := []utils.Map{}
+	for _, value := range results {
+		value = db_common.AmendFldsForGet(value)
+		listdata = append(listdata, value)
+	}
+
+	log.Println("MigrationMongoDBDao::List:: End ", len(listdata))
+	return listdata, nil
+}
+
+// Get - Get applied Migration by id
+func (t *MigrationMongoDBDao) Get(ctx context.Context, migrationId string) (utils.Map, error) {
+	// Get a single document
+	var result utils.Map
+
+	log.Println("MigrationMongoDBDao::Get:: Begin ", migrationId)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: sales_common.FLD_MIGRATION_ID, Value: migrationId}}
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMigrations, "FindOne", "")
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("Get:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Printf("MigrationMongoDBDao::Get:: End Found a single document\n")
+	return result, nil
+}
+
+// Create - Record the applied Migration
+func (t *MigrationMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+
+	log.Println("Migration Save - Begin", indata)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbMigrations)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	// Add Fields for Create
+	indata = db_common.AmendFldsforCreate(indata)
+
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbMigrations, "InsertOne", "")
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
+	if err != nil {
+		log.Println("Error in insert ", err)
+		return utils.Map{}, err
+	}
+	log.Println("Inserted a single document: ", insertResult1.InsertedID)
+	log.Println("Save - End", indata[sales_common.FLD_MIGRATION_ID])
+
+	return t.Get(ctx, indata[sales_common.FLD_MIGRATION_ID].(string))
+}
+
+// CountDocuments - Count the documents of any collection for all businesses
+func (t *MigrationMongoDBDao) CountDocuments(ctx context.Context, collectionName string, filter utils.Map) (int64, error) {
+
+	log.Println("MigrationMongoDBDao::CountDocuments - Begin ", collectionName, filter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, collectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	_, span := sales_telemetry.StartDB(ctx, collectionName, "CountDocuments", "")
+	count, err := collection.CountDocuments(dbCtx, filter)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("MigrationMongoDBDao::CountDocuments - End ", count)
+	return count, nil
+}
+
+// UpdateMany - Update the documents of any collection for all businesses, the update is a document of update
+// operators or an aggregation pipeline
+func (t *MigrationMongoDBDao) UpdateMany(ctx context.Context, collectionName string, filter utils.Map, update interface{}) (int64, error) {
+
+	log.Println("MigrationMongoDBDao::UpdateMany - Begin ", collectionName, filter, update)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, collectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	_, span := sales_telemetry.StartDB(ctx, collectionName, "UpdateMany", "")
+	updateResult, err := collection.UpdateMany(dbCtx, filter, update)
+	span.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("MigrationMongoDBDao::UpdateMany - End ", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
+
+// CreateIndex - Create the ascending index on the keys of any collection, only on the documents matching
+// the partialFilter if given. Creating an existing index is harmless
+func (t *MigrationMongoDBDao) CreateIndex(ctx context.Context, collectionName string, keys []string, unique bool, partialFilter utils.Map) (string, error) {
+
+	log.Println("MigrationMongoDBDao::CreateIndex - Begin ", collectionName, keys, unique, partialFilter)
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, collectionName)
+	if err != nil {
+		return "", err
+	}
+
+	indexKeys := bson.D{}
+	for _, key := range keys {
+		indexKeys = append(indexKeys, bson.E{Key: key, Value: 1})
+	}
+	opts := options.Index().SetUnique(unique)
+	if len(partialFilter) > 0 {
+		opts.SetPartialFilterExpression(partialFilter)
+	}
+
+	_, span := sales_telemetry.StartDB(ctx, collectionName, "CreateIndex", "")
+	indexName, err := collection.Indexes().CreateOne(dbCtx, mongo.IndexModel{Keys: indexKeys, Options: opts})
+	span.End(err)
+	if err != nil {
+		return "", err
+	}
+
+	log.Println("MigrationMongoDBDao::CreateIndex - End ", indexName)
+	return indexName, nil
+}
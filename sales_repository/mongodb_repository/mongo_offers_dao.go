@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *OfferMongoDBDao) InitializeDao(client utils.Map, businessId string) {
 }
 
 // List - List all Collections
-func (t *OfferMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *OfferMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbOffers)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *OfferMongoDBDao) List(filter string, sort string, skip int64, limit int
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +101,9 @@ func (t *OfferMongoDBDao) List(filter string, sort string, skip int64, limit int
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbOffers, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,9 @@ func (t *OfferMongoDBDao) List(filter string, sort string, skip int64, limit int
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbOffers, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +131,13 @@ func (t *OfferMongoDBDao) List(filter string, sort string, skip int64, limit int
 }
 
 // Get - Get by code
-func (t *OfferMongoDBDao) Get(offerId string) (utils.Map, error) {
+func (t *OfferMongoDBDao) Get(ctx context.Context, offerId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("OfferMongoDBDao::Get:: Begin ", offerId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_OFFER_ID, Value: offerId}, {}}
@@ -139,7 +147,9 @@ func (t *OfferMongoDBDao) Get(offerId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +168,13 @@ func (t *OfferMongoDBDao) Get(offerId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *OfferMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *OfferMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("OFFERDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbOffers)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +187,9 @@ func (p *OfferMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +208,11 @@ func (p *OfferMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *OfferMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *OfferMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Offer Save - Begin", indata)
 	//Sales Offer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +220,9 @@ func (t *OfferMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +231,16 @@ func (t *OfferMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_OFFER_ID])
 
-	return t.Get(indata[sales_common.FLD_OFFER_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_OFFER_ID].(string))
 }
 
 // Update - Update Collection
-func (t *OfferMongoDBDao) Update(offerId string, indata utils.Map) (utils.Map, error) {
+func (t *OfferMongoDBDao) Update(ctx context.Context, offerId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Offer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +249,25 @@ func (t *OfferMongoDBDao) Update(offerId string, indata utils.Map) (utils.Map, e
 	log.Printf("Update - Values %v", indata)
 
 	filterOFFER := bson.D{{Key: sales_common.FLD_OFFER_ID, Value: offerId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterOFFER, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterOFFER, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(offerId)
+	return t.Get(ctx, offerId)
 }
 
 // Delete - Delete Collection
-func (t *OfferMongoDBDao) Delete(offerId string) (int64, error) {
+func (t *OfferMongoDBDao) Delete(ctx context.Context, offerId string) (int64, error) {
 
 	log.Println("OfferMongoDBDao::Delete - Begin ", offerId)
 
 	// Sales Offer
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbOffers)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +278,9 @@ func (t *OfferMongoDBDao) Delete(offerId string) (int64, error) {
 	})
 
 	filterOFFER := bson.D{{Key: sales_common.FLD_OFFER_ID, Value: offerId}}
-	resOFFER, err := collection.DeleteOne(ctx, filterOFFER, optsOFFER)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbOffers, "DeleteOne", t.businessId)
+	resOFFER, err := collection.DeleteOne(dbCtx, filterOFFER, optsOFFER)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
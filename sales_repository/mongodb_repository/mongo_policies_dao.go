@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *PoliciesMongoDBDao) InitializeDao(client utils.Map, businessId string)
 }
 
 // List - List all Collections
-func (t *PoliciesMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *PoliciesMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbPolicies)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *PoliciesMongoDBDao) List(filter string, sort string, skip int64, limit
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +101,9 @@ func (t *PoliciesMongoDBDao) List(filter string, sort string, skip int64, limit
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,9 @@ func (t *PoliciesMongoDBDao) List(filter string, sort string, skip int64, limit
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +131,13 @@ func (t *PoliciesMongoDBDao) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Get by code
-func (t *PoliciesMongoDBDao) Get(policiesId string) (utils.Map, error) {
+func (t *PoliciesMongoDBDao) Get(ctx context.Context, policiesId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("PoliciesMongoDBDao::Get:: Begin ", policiesId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_POLICY_ID, Value: policiesId}, {}}
@@ -139,7 +147,9 @@ func (t *PoliciesMongoDBDao) Get(policiesId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +168,13 @@ func (t *PoliciesMongoDBDao) Get(policiesId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *PoliciesMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *PoliciesMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("PoliciesDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbPolicies)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +187,9 @@ func (p *PoliciesMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +208,11 @@ func (p *PoliciesMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *PoliciesMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *PoliciesMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Policies Save - Begin", indata)
 	//Sales Policies
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +220,9 @@ func (t *PoliciesMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +231,16 @@ func (t *PoliciesMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_POLICY_ID])
 
-	return t.Get(indata[sales_common.FLD_POLICY_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_POLICY_ID].(string))
 }
 
 // Update - Update Collection
-func (t *PoliciesMongoDBDao) Update(policiesId string, indata utils.Map) (utils.Map, error) {
+func (t *PoliciesMongoDBDao) Update(ctx context.Context, policiesId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Policies
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +249,25 @@ func (t *PoliciesMongoDBDao) Update(policiesId string, indata utils.Map) (utils.
 	log.Printf("Update - Values %v", indata)
 
 	filterPolicies := bson.D{{Key: sales_common.FLD_POLICY_ID, Value: policiesId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterPolicies, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterPolicies, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(policiesId)
+	return t.Get(ctx, policiesId)
 }
 
 // Delete - Delete Collection
-func (t *PoliciesMongoDBDao) Delete(policiesId string) (int64, error) {
+func (t *PoliciesMongoDBDao) Delete(ctx context.Context, policiesId string) (int64, error) {
 
 	log.Println("PoliciesMongoDBDao::Delete - Begin ", policiesId)
 
 	// Sales Policies
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbPolicies)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +278,9 @@ func (t *PoliciesMongoDBDao) Delete(policiesId string) (int64, error) {
 	})
 
 	filterPolicies := bson.D{{Key: sales_common.FLD_POLICY_ID, Value: policiesId}}
-	resPolicies, err := collection.DeleteOne(ctx, filterPolicies, optsPolicies)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbPolicies, "DeleteOne", t.businessId)
+	resPolicies, err := collection.DeleteOne(dbCtx, filterPolicies, optsPolicies)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *ProductMongoDBDao) InitializeDao(client utils.Map, businessId string) {
 }
 
 // List - List all Collections
-func (t *ProductMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *ProductMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbProducts)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *ProductMongoDBDao) List(filter string, sort string, skip int64, limit i
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -99,7 +103,9 @@ func (t *ProductMongoDBDao) List(filter string, sort string, skip int64, limit i
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbProducts, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +113,9 @@ func (t *ProductMongoDBDao) List(filter string, sort string, skip int64, limit i
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbProducts, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -125,13 +133,13 @@ func (t *ProductMongoDBDao) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Get by code
-func (p *ProductMongoDBDao) Get(productId string) (utils.Map, error) {
+func (p *ProductMongoDBDao) Get(ctx context.Context, productId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("ProductMongoDBDao::Get:: Begin ", productId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbProducts)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_PRODUCT_ID, Value: productId}, {}}
@@ -141,7 +149,9 @@ func (p *ProductMongoDBDao) Get(productId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -160,13 +170,13 @@ func (p *ProductMongoDBDao) Get(productId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *ProductMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *ProductMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("ProductDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbProducts)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -179,7 +189,9 @@ func (p *ProductMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -198,19 +210,21 @@ func (p *ProductMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *ProductMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *ProductMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Product Save - Begin", indata)
 
 	//BusinessProduct
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResultProduct, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "InsertOne", t.businessId)
+	insertResultProduct, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -223,12 +237,12 @@ func (t *ProductMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Collection
-func (t *ProductMongoDBDao) Update(productId string, indata utils.Map) (utils.Map, error) {
+func (t *ProductMongoDBDao) Update(ctx context.Context, productId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//BusinessProduct
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -237,23 +251,25 @@ func (t *ProductMongoDBDao) Update(productId string, indata utils.Map) (utils.Ma
 	log.Printf("Update - Values %v", indata)
 
 	filterProduct := bson.D{{Key: sales_common.FLD_PRODUCT_ID, Value: productId}}
-	updateResultProduct, err := collection.UpdateOne(ctx, filterProduct, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "UpdateOne", t.businessId)
+	updateResultProduct, err := collection.UpdateOne(dbCtx, filterProduct, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResultProduct.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(productId)
+	return t.Get(ctx, productId)
 }
 
 // Delete - Delete Collection
-func (t *ProductMongoDBDao) Delete(productId string) (int64, error) {
+func (t *ProductMongoDBDao) Delete(ctx context.Context, productId string) (int64, error) {
 
 	log.Println("ProductMongoDBDao::Delete - Begin ", productId)
 
 	//BusinessProduct
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbProducts)
 	if err != nil {
 		return 0, err
 	}
@@ -264,7 +280,9 @@ func (t *ProductMongoDBDao) Delete(productId string) (int64, error) {
 	})
 
 	filterProduct := bson.D{{Key: sales_common.FLD_PRODUCT_ID, Value: productId}}
-	resProduct, err := collection.DeleteOne(ctx, filterProduct, optsProduct)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbProducts, "DeleteOne", t.businessId)
+	resProduct, err := collection.DeleteOne(dbCtx, filterProduct, optsProduct)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
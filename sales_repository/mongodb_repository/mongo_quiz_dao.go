@@ -1,12 +1,14 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +31,12 @@ func (p *QuizMongoDBDao) InitializeDao(client utils.Map, businessId string) {
 }
 
 // List - List all Collections
-func (t *QuizMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *QuizMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbQuiz)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +79,16 @@ func (t *QuizMongoDBDao) List(filter string, sort string, skip int64, limit int6
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +101,9 @@ func (t *QuizMongoDBDao) List(filter string, sort string, skip int64, limit int6
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,9 @@ func (t *QuizMongoDBDao) List(filter string, sort string, skip int64, limit int6
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +131,13 @@ func (t *QuizMongoDBDao) List(filter string, sort string, skip int64, limit int6
 }
 
 // Get - Get by code
-func (t *QuizMongoDBDao) Get(quizId string) (utils.Map, error) {
+func (t *QuizMongoDBDao) Get(ctx context.Context, quizId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("QuizMongoDBDao::Get:: Begin ", quizId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_QUIZ_ID, Value: quizId}, {}}
@@ -139,7 +147,9 @@ func (t *QuizMongoDBDao) Get(quizId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +168,13 @@ func (t *QuizMongoDBDao) Get(quizId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *QuizMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *QuizMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("QuizDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbQuiz)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +187,9 @@ func (p *QuizMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +208,11 @@ func (p *QuizMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *QuizMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *QuizMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Quiz Save - Begin", indata)
 	//Sales Quiz
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +220,9 @@ func (t *QuizMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +231,16 @@ func (t *QuizMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_QUIZ_ID])
 
-	return t.Get(indata[sales_common.FLD_QUIZ_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_QUIZ_ID].(string))
 }
 
 // Update - Update Collection
-func (t *QuizMongoDBDao) Update(quizId string, indata utils.Map) (utils.Map, error) {
+func (t *QuizMongoDBDao) Update(ctx context.Context, quizId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Quiz
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +249,25 @@ func (t *QuizMongoDBDao) Update(quizId string, indata utils.Map) (utils.Map, err
 	log.Printf("Update - Values %v", indata)
 
 	filterQuiz := bson.D{{Key: sales_common.FLD_QUIZ_ID, Value: quizId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterQuiz, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterQuiz, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(quizId)
+	return t.Get(ctx, quizId)
 }
 
 // Delete - Delete Collection
-func (t *QuizMongoDBDao) Delete(quizId string) (int64, error) {
+func (t *QuizMongoDBDao) Delete(ctx context.Context, quizId string) (int64, error) {
 
 	log.Println("QuizMongoDBDao::Delete - Begin ", quizId)
 
 	// Sales Quiz
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbQuiz)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +278,9 @@ func (t *QuizMongoDBDao) Delete(quizId string) (int64, error) {
 	})
 
 	filterQuiz := bson.D{{Key: sales_common.FLD_QUIZ_ID, Value: quizId}}
-	resQuiz, err := collection.DeleteOne(ctx, filterQuiz, optsQuiz)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbQuiz, "DeleteOne", t.businessId)
+	resQuiz, err := collection.DeleteOne(dbCtx, filterQuiz, optsQuiz)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
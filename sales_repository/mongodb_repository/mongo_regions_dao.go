@@ -1,12 +1,16 @@
 package mongodb_repository
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/mongo_utils"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,12 +33,12 @@ func (p *RegionMongoDBDao) InitializeDao(client utils.Map, businessId string) {
 }
 
 // List - List all Collections
-func (t *RegionMongoDBDao) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (t *RegionMongoDBDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
 	var results []utils.Map
 
 	log.Println("Begin - Find All Collection Dao", sales_common.DbRegions)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +81,16 @@ func (t *RegionMongoDBDao) List(filter string, sort string, skip int64, limit in
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Parameter values ", filterdoc, opts)
-	cursor, err := collection.Find(ctx, filterdoc, opts)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "Find", t.businessId)
+	cursor, err := collection.Find(dbCtx, filterdoc, opts)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
 	// get a list of all returned documents and print them out
 	// see the mongo.Cursor documentation for more examples of using cursors
-	if err = cursor.All(ctx, &results); err != nil {
+	if err = cursor.All(dbCtx, &results); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +103,9 @@ func (t *RegionMongoDBDao) List(filter string, sort string, skip int64, limit in
 	}
 
 	log.Println("Parameter values ", filterdoc)
-	filtercount, err := collection.CountDocuments(ctx, filterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbRegions, "CountDocuments", t.businessId)
+	filtercount, err := collection.CountDocuments(dbCtx, filterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +113,9 @@ func (t *RegionMongoDBDao) List(filter string, sort string, skip int64, limit in
 	basefilterdoc := bson.D{
 		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
 		{Key: db_common.FLD_IS_DELETED, Value: false}}
-	totalcount, err := collection.CountDocuments(ctx, basefilterdoc)
+	_, span = sales_telemetry.StartDB(ctx, sales_common.DbRegions, "CountDocuments", t.businessId)
+	totalcount, err := collection.CountDocuments(dbCtx, basefilterdoc)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +133,13 @@ func (t *RegionMongoDBDao) List(filter string, sort string, skip int64, limit in
 }
 
 // Get - Get by code
-func (t *RegionMongoDBDao) Get(regionId string) (utils.Map, error) {
+func (t *RegionMongoDBDao) Get(ctx context.Context, regionId string) (utils.Map, error) {
 	// Get a single document
 	var result utils.Map
 
 	log.Println("RegionMongoDBDao::Get:: Begin ", regionId)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
 	log.Println("Get:: Got Collection ")
 
 	filter := bson.D{{Key: sales_common.FLD_REGION_ID, Value: regionId}, {}}
@@ -139,7 +149,9 @@ func (t *RegionMongoDBDao) Get(regionId string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Get:: Got filter ", filter)
-	singleResult := collection.FindOne(ctx, filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Get:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -158,13 +170,13 @@ func (t *RegionMongoDBDao) Get(regionId string) (utils.Map, error) {
 }
 
 // Find - Find by Filter
-func (p *RegionMongoDBDao) Find(filter string) (utils.Map, error) {
+func (p *RegionMongoDBDao) Find(ctx context.Context, filter string) (utils.Map, error) {
 	// Find a single document
 	var result utils.Map
 
 	log.Println("RegionDBDao::Find:: Begin ", filter)
 
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(p.client, sales_common.DbRegions)
 	log.Println("Find:: Got Collection ", err)
 
 	bfilter := bson.D{}
@@ -177,7 +189,9 @@ func (p *RegionMongoDBDao) Find(filter string) (utils.Map, error) {
 		bson.E{Key: db_common.FLD_IS_DELETED, Value: false})
 
 	log.Println("Find:: Got filter ", bfilter)
-	singleResult := collection.FindOne(ctx, bfilter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "FindOne", p.businessId)
+	singleResult := collection.FindOne(dbCtx, bfilter)
+	span.End(singleResult.Err())
 	if singleResult.Err() != nil {
 		log.Println("Find:: Record not found ", singleResult.Err())
 		return result, singleResult.Err()
@@ -196,11 +210,11 @@ func (p *RegionMongoDBDao) Find(filter string) (utils.Map, error) {
 }
 
 // Create - Create Collection
-func (t *RegionMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
+func (t *RegionMongoDBDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Region Save - Begin", indata)
 	//Sales Region
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -208,7 +222,9 @@ func (t *RegionMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	// Add Fields for Create
 	indata = db_common.AmendFldsforCreate(indata)
 
-	insertResult1, err := collection.InsertOne(ctx, indata)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "InsertOne", t.businessId)
+	insertResult1, err := collection.InsertOne(dbCtx, indata)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in insert ", err)
 		return utils.Map{}, err
@@ -217,16 +233,16 @@ func (t *RegionMongoDBDao) Create(indata utils.Map) (utils.Map, error) {
 	log.Println("Inserted a single document: ", insertResult1.InsertedID)
 	log.Println("Save - End", indata[sales_common.FLD_REGION_ID])
 
-	return t.Get(indata[sales_common.FLD_REGION_ID].(string))
+	return t.Get(ctx, indata[sales_common.FLD_REGION_ID].(string))
 }
 
 // Update - Update Collection
-func (t *RegionMongoDBDao) Update(regionId string, indata utils.Map) (utils.Map, error) {
+func (t *RegionMongoDBDao) Update(ctx context.Context, regionId string, indata utils.Map) (utils.Map, error) {
 
 	log.Println("Update - Begin")
 
 	//Sales Region
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -235,23 +251,25 @@ func (t *RegionMongoDBDao) Update(regionId string, indata utils.Map) (utils.Map,
 	log.Printf("Update - Values %v", indata)
 
 	filterRegion := bson.D{{Key: sales_common.FLD_REGION_ID, Value: regionId}}
-	updateResult1, err := collection.UpdateOne(ctx, filterRegion, bson.D{{Key: "$set", Value: indata}})
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "UpdateOne", t.businessId)
+	updateResult1, err := collection.UpdateOne(dbCtx, filterRegion, bson.D{{Key: "$set", Value: indata}})
+	span.End(err)
 	if err != nil {
 		return utils.Map{}, err
 	}
 	log.Println("Update a single document: ", updateResult1.ModifiedCount)
 
 	log.Println("Update - End")
-	return t.Get(regionId)
+	return t.Get(ctx, regionId)
 }
 
 // Delete - Delete Collection
-func (t *RegionMongoDBDao) Delete(regionId string) (int64, error) {
+func (t *RegionMongoDBDao) Delete(ctx context.Context, regionId string) (int64, error) {
 
 	log.Println("RegionMongoDBDao::Delete - Begin ", regionId)
 
 	// Sales Region
-	collection, ctx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
 	if err != nil {
 		return 0, err
 	}
@@ -262,7 +280,9 @@ func (t *RegionMongoDBDao) Delete(regionId string) (int64, error) {
 	})
 
 	filterRegion := bson.D{{Key: sales_common.FLD_REGION_ID, Value: regionId}}
-	resRegion, err := collection.DeleteOne(ctx, filterRegion, optsRegion)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "DeleteOne", t.businessId)
+	resRegion, err := collection.DeleteOne(dbCtx, filterRegion, optsRegion)
+	span.End(err)
 	if err != nil {
 		log.Println("Error in delete ", err)
 		return 0, err
@@ -270,3 +290,60 @@ func (t *RegionMongoDBDao) Delete(regionId string) (int64, error) {
 	log.Printf("RegionMongoDBDao::Delete - End deleted %v documents\n", resRegion.DeletedCount)
 	return resRegion.DeletedCount, nil
 }
+
+// FindByPincode - Find the Region whose pincode ranges include the pincode
+func (t *RegionMongoDBDao) FindByPincode(ctx context.Context, pincode string) (utils.Map, error) {
+	var result utils.Map
+
+	log.Println("RegionMongoDBDao::FindByPincode:: Begin ", pincode)
+
+	pincode = strings.TrimSpace(pincode)
+	err := sales_common.ValidatePincode(pincode)
+	if err != nil {
+		return result, err
+	}
+
+	collection, dbCtx, err := mongo_utils.GetMongoDbCollection(t.client, sales_common.DbRegions)
+	if err != nil {
+		return result, err
+	}
+
+	// Ranges could be stored as numbers or as strings of same length
+	pincodeRanges := bson.A{bson.D{{Key: "$elemMatch", Value: bson.D{
+		{Key: sales_common.FLD_REGION_PINCODE_FROM, Value: bson.D{{Key: "$lte", Value: pincode}}},
+		{Key: sales_common.FLD_REGION_PINCODE_TO, Value: bson.D{{Key: "$gte", Value: pincode}}}}}}}
+	if pincodeNum, err := strconv.ParseInt(pincode, 10, 64); err == nil {
+		pincodeRanges = append(pincodeRanges, bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: sales_common.FLD_REGION_PINCODE_FROM, Value: bson.D{{Key: "$lte", Value: pincodeNum}}},
+			{Key: sales_common.FLD_REGION_PINCODE_TO, Value: bson.D{{Key: "$gte", Value: pincodeNum}}}}}})
+	}
+	orRanges := bson.A{}
+	for _, pincodeRange := range pincodeRanges {
+		orRanges = append(orRanges, bson.D{{Key: sales_common.FLD_REGION_PINCODES, Value: pincodeRange}})
+	}
+
+	filter := bson.D{
+		{Key: "$or", Value: orRanges},
+		{Key: sales_common.FLD_BUSINESS_ID, Value: t.businessId},
+		{Key: db_common.FLD_IS_DELETED, Value: false}}
+
+	log.Println("FindByPincode:: Got filter ", filter)
+	_, span := sales_telemetry.StartDB(ctx, sales_common.DbRegions, "FindOne", t.businessId)
+	singleResult := collection.FindOne(dbCtx, filter)
+	span.End(singleResult.Err())
+	if singleResult.Err() != nil {
+		log.Println("FindByPincode:: Record not found ", singleResult.Err())
+		return result, singleResult.Err()
+	}
+	err = singleResult.Decode(&result)
+	if err != nil {
+		log.Println("Error in decode", err)
+		return result, err
+	}
+
+	// Remove fields from result
+	result = db_common.AmendFldsForGet(result)
+
+	log.Println("RegionMongoDBDao::FindByPincode:: End Found a single document")
+	return result, nil
+}
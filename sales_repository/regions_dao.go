@@ -1,6 +1,8 @@
 package sales_repository
 
 import (
+	"context"
+
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
 
@@ -12,17 +14,20 @@ type RegionDao interface {
 	// InitializeDao
 	InitializeDao(client utils.Map, businessId string)
 	//List - List all Collections
-	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
 	// Get - Get by code
-	Get(regionId string) (utils.Map, error)
+	Get(ctx context.Context, regionId string) (utils.Map, error)
 	// Find - Find by filter
-	Find(filter string) (utils.Map, error)
+	Find(ctx context.Context, filter string) (utils.Map, error)
 	// Create - Create Collection
-	Create(indata utils.Map) (utils.Map, error)
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
 	// Update - Update Collection
-	Update(regionId string, indata utils.Map) (utils.Map, error)
+	Update(ctx context.Context, regionId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Collection
-	Delete(regionId string) (int64, error)
+	Delete(ctx context.Context, regionId string) (int64, error)
+
+	// FindByPincode - Find the Region whose pincode ranges include the pincode
+	FindByPincode(ctx context.Context, pincode string) (utils.Map, error)
 }
 
 // NewRegionDao - Contruct Business Region Dao
@@ -0,0 +1,57 @@
+package sales_repository
+
+import (
+	"context"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_repository/mongodb_repository"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// StockReservationDao - Stock Reservation DAO Repository, the stock held by the Carts and Orders
+type StockReservationDao interface {
+	// InitializeDao
+	InitializeDao(client utils.Map, businessId string)
+	//List - List all Collections
+	List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Get by code
+	Get(ctx context.Context, reservationId string) (utils.Map, error)
+	// Find - Find by filter
+	Find(ctx context.Context, filter string) (utils.Map, error)
+	// Create - Create Collection
+	Create(ctx context.Context, indata utils.Map) (utils.Map, error)
+	// Update - Update Collection
+	Update(ctx context.Context, reservationId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Collection
+	Delete(ctx context.Context, reservationId string) (int64, error)
+	// Transition - Change the status of the Reservation atomically, only if it is in the fromStatus, else
+	// mongo.ErrNoDocuments
+	Transition(ctx context.Context, reservationId string, fromStatus string, toStatus string) (utils.Map, error)
+	// ReassignCustomer - Move all the Reservations of the Customer to the other Customer
+	ReassignCustomer(ctx context.Context, fromCustomerId string, toCustomerId string) (int64, error)
+}
+
+// NewStockReservationDao - Contruct Business StockReservation Dao
+func NewStockReservationDao(client utils.Map, business_id string) StockReservationDao {
+	var daoStockReservation StockReservationDao = nil
+
+	// Get DatabaseType and no need to validate error
+	// since the dbType was assigned with correct value after dbService was created
+	dbType, _ := db_common.GetDatabaseType(client)
+
+	switch dbType {
+	case db_common.DATABASE_TYPE_MONGODB:
+		daoStockReservation = &mongodb_repository.StockReservationMongoDBDao{}
+	case db_common.DATABASE_TYPE_ZAPSDB:
+		// *Not Implemented yet*
+	case db_common.DATABASE_TYPE_MYSQLDB:
+		// *Not Implemented yet*
+	}
+
+	if daoStockReservation != nil {
+		// Initialize the Dao
+		daoStockReservation.InitializeDao(client, business_id)
+	}
+
+	return daoStockReservation
+}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type bannerBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       BannerService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewBannerService - Construct Banner
@@ -67,6 +70,7 @@ func NewBannerService(props utils.Map) (BannerService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *bannerBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *bannerBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *bannerBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("bannerBaseService::FindAll - Begin")
 
-	listdata, err := p.daoBanner.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoBanner.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *bannerBaseService) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Find By Code
-func (p *bannerBaseService) Get(bannerId string) (utils.Map, error) {
+func (p *bannerBaseService) Get(bannerId string) (_ utils.Map, err error) {
 	log.Printf("bannerBaseService::Get::  Begin %v", bannerId)
 
-	data, err := p.daoBanner.Get(bannerId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBanner.Get(ctx, bannerId)
 
 	log.Println("bannerBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *bannerBaseService) Find(filter string) (utils.Map, error) {
+func (p *bannerBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("BannerService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoBanner.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBanner.Find(ctx, filter)
 	log.Println("BannerService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *bannerBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *bannerBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BannerService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var bannerId string
 
 	dataval, dataok := indata[sales_common.FLD_BANNER_ID]
@@ -146,7 +163,7 @@ func (p *bannerBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_BANNER_ID] = bannerId
 
-	data, err := p.daoBanner.Create(indata)
+	data, err := p.daoBanner.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *bannerBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *bannerBaseService) Update(bannerId string, indata utils.Map) (utils.Map, error) {
+func (p *bannerBaseService) Update(bannerId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BannerService::Update - Begin")
 
-	data, err := p.daoBanner.Update(bannerId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBanner.Update(ctx, bannerId, indata)
 
 	log.Println("BannerService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *bannerBaseService) Delete(bannerId string, delete_permanent bool) error {
+func (p *bannerBaseService) Delete(bannerId string, delete_permanent bool) (err error) {
 
 	log.Println("BannerService::Delete - Begin", bannerId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "banner", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoBanner.Delete(bannerId)
+		result, err := p.daoBanner.Delete(ctx, bannerId)
 		if err != nil {
 			return err
 		}
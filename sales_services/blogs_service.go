@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type blogBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       BlogService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewBlogService - Construct Blog
@@ -67,6 +70,7 @@ func NewBlogService(props utils.Map) (BlogService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *blogBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *blogBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *blogBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("blogBaseService::FindAll - Begin")
 
-	listdata, err := p.daoBlog.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoBlog.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *blogBaseService) List(filter string, sort string, skip int64, limit int
 }
 
 // Get - Find By Code
-func (p *blogBaseService) Get(blogId string) (utils.Map, error) {
+func (p *blogBaseService) Get(blogId string) (_ utils.Map, err error) {
 	log.Printf("blogBaseService::Get::  Begin %v", blogId)
 
-	data, err := p.daoBlog.Get(blogId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBlog.Get(ctx, blogId)
 
 	log.Println("blogBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *blogBaseService) Find(filter string) (utils.Map, error) {
+func (p *blogBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("BlogService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoBlog.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBlog.Find(ctx, filter)
 	log.Println("BlogService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *blogBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *blogBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BlogService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var blogId string
 
 	dataval, dataok := indata[sales_common.FLD_BLOG_ID]
@@ -146,7 +163,7 @@ func (p *blogBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_BLOG_ID] = blogId
 
-	data, err := p.daoBlog.Create(indata)
+	data, err := p.daoBlog.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *blogBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *blogBaseService) Update(blogId string, indata utils.Map) (utils.Map, error) {
+func (p *blogBaseService) Update(blogId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BlogService::Update - Begin")
 
-	data, err := p.daoBlog.Update(blogId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBlog.Update(ctx, blogId, indata)
 
 	log.Println("BlogService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *blogBaseService) Delete(blogId string, delete_permanent bool) error {
+func (p *blogBaseService) Delete(blogId string, delete_permanent bool) (err error) {
 
 	log.Println("BlogService::Delete - Begin", blogId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "blog", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoBlog.Delete(blogId)
+		result, err := p.daoBlog.Delete(ctx, blogId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -39,6 +41,7 @@ type brandBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       BrandService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewBrandService - Construct Brand
@@ -68,6 +71,7 @@ func NewBrandService(props utils.Map) (BrandService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *brandBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *brandBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *brandBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("brandBaseService::FindAll - Begin")
 
-	listdata, err := p.daoBrand.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoBrand.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *brandBaseService) List(filter string, sort string, skip int64, limit in
 }
 
 // Get - Find By Code
-func (p *brandBaseService) Get(brandId string) (utils.Map, error) {
+func (p *brandBaseService) Get(brandId string) (_ utils.Map, err error) {
 	log.Printf("brandBaseService::Get::  Begin %v", brandId)
 
-	data, err := p.daoBrand.Get(brandId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBrand.Get(ctx, brandId)
 
 	log.Println("brandBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *brandBaseService) Find(filter string) (utils.Map, error) {
+func (p *brandBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("brandService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoBrand.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBrand.Find(ctx, filter)
 	log.Println("brandService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *brandBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *brandBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BrandService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var brandId string
 
 	dataval, dataok := indata[sales_common.FLD_BRAND_ID]
@@ -147,7 +164,7 @@ func (p *brandBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_BRAND_ID] = brandId
 
-	data, err := p.daoBrand.Create(indata)
+	data, err := p.daoBrand.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *brandBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *brandBaseService) Update(brandId string, indata utils.Map) (utils.Map, error) {
+func (p *brandBaseService) Update(brandId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BrandService::Update - Begin")
 
-	data, err := p.daoBrand.Update(brandId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoBrand.Update(ctx, brandId, indata)
 
 	log.Println("BrandService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *brandBaseService) Delete(brandId string, delete_permanent bool) error {
+func (p *brandBaseService) Delete(brandId string, delete_permanent bool) (err error) {
 
 	log.Println("BrandService::Delete - Begin", brandId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "brand", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoBrand.Delete(brandId)
+		result, err := p.daoBrand.Delete(ctx, brandId)
 		if err != nil {
 			return err
 		}
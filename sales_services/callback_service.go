@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type callbackBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       CallbackService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewCallbackService - Construct Callback
@@ -67,6 +70,7 @@ func NewCallbackService(props utils.Map) (CallbackService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *callbackBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *callbackBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *callbackBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("callbackBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCallback.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "callback", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCallback.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *callbackBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *callbackBaseService) Get(callbackId string) (utils.Map, error) {
+func (p *callbackBaseService) Get(callbackId string) (_ utils.Map, err error) {
 	log.Printf("callbackBaseService::Get::  Begin %v", callbackId)
 
-	data, err := p.daoCallback.Get(callbackId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "callback", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCallback.Get(ctx, callbackId)
 
 	log.Println("callbackBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *callbackBaseService) Find(filter string) (utils.Map, error) {
+func (p *callbackBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("CallbackService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCallback.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "callback", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCallback.Find(ctx, filter)
 	log.Println("CallbackService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *callbackBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *callbackBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CallbackService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "callback", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var callbackId string
 
 	dataval, dataok := indata[sales_common.FLD_CALLBACK_ID]
@@ -147,7 +164,7 @@ func (p *callbackBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_CALLBACK_ID] = callbackId
 	indata[sales_common.FLD_IS_FULFILLED] = false
 
-	data, err := p.daoCallback.Create(indata)
+	data, err := p.daoCallback.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,11 +174,14 @@ func (p *callbackBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *callbackBaseService) Update(callbackId string, indata utils.Map) (utils.Map, error) {
+func (p *callbackBaseService) Update(callbackId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CallbackService::Update - Begin")
 
-	data, err := p.daoCallback.Update(callbackId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "callback", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCallback.Update(ctx, callbackId, indata)
 
 	log.Println("CallbackService::Update - End ")
 	return data, err
@@ -1,18 +1,24 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/db_utils"
 	"github.com/zapscloud/golib-platform/platform_repository"
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_notifier"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CampaignService interface {
@@ -28,17 +34,31 @@ type CampaignService interface {
 	Update(campaignId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Service
 	Delete(campaignId string, delete_permanent bool) error
+	// SendCampaign - Send the Campaign to the Customers with granted consent for its channel, once
+	SendCampaign(campaignId string) (utils.Map, error)
 
 	EndService()
 }
 
+// Fields of the Campaign set by SendCampaign
+var campaignSendFields = []string{
+	sales_common.FLD_CAMPAIGN_STATUS,
+	sales_common.FLD_CAMPAIGN_SENT_AT,
+	sales_common.FLD_CAMPAIGN_SEND_REPORT,
+	sales_common.FLD_CAMPAIGN_SEND_CURSOR,
+	sales_common.FLD_CAMPAIGN_SEND_BEAT,
+}
+
 type campaignBaseService struct {
 	db_utils.DatabaseService
 	dbRegion    db_utils.DatabaseService
 	daoCampaign sales_repository.CampaignDao
+	daoCustomer sales_repository.CustomerDao
+	daoConsent  customer_repository.CustomerConsentDao
 	daoBusiness platform_repository.BusinessDao
 	child       CampaignService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewCampaignService - Construct Campaign
@@ -68,6 +88,7 @@ func NewCampaignService(props utils.Map) (CampaignService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -95,14 +116,19 @@ func (p *campaignBaseService) initializeService() {
 	log.Printf("CampaignService:: GetBusinessDao ")
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoCampaign = sales_repository.NewCampaignDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoConsent = customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, "")
 }
 
 // List - List All records
-func (p *campaignBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *campaignBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("CampaignBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCampaign.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCampaign.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +138,37 @@ func (p *campaignBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *campaignBaseService) Get(campaignId string) (utils.Map, error) {
+func (p *campaignBaseService) Get(campaignId string) (_ utils.Map, err error) {
 	log.Printf("campaignBaseService::Get::  Begin %v", campaignId)
 
-	data, err := p.daoCampaign.Get(campaignId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCampaign.Get(ctx, campaignId)
 
 	log.Println("campaignBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *campaignBaseService) Find(filter string) (utils.Map, error) {
+func (p *campaignBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("campaignBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCampaign.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCampaign.Find(ctx, filter)
 	log.Println("campaignBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *campaignBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *campaignBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CampaignService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var campaignId string
 
 	dataval, dataok := indata[sales_common.FLD_CAMPAIGN_ID]
@@ -147,7 +183,7 @@ func (p *campaignBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CAMPAIGN_ID] = campaignId
 
-	data, err := p.daoCampaign.Create(indata)
+	data, err := p.daoCampaign.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +193,34 @@ func (p *campaignBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *campaignBaseService) Update(campaignId string, indata utils.Map) (utils.Map, error) {
+func (p *campaignBaseService) Update(campaignId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CampaignService::Update - Begin")
 
-	data, err := p.daoCampaign.Update(campaignId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Set only by SendCampaign
+	for _, field := range campaignSendFields {
+		delete(indata, field)
+	}
+
+	data, err := p.daoCampaign.Update(ctx, campaignId, indata)
 
 	log.Println("CampaignService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *campaignBaseService) Delete(campaignId string, delete_permanent bool) error {
+func (p *campaignBaseService) Delete(campaignId string, delete_permanent bool) (err error) {
 
 	log.Println("CampaignService::Delete - Begin", campaignId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCampaign.Delete(campaignId)
+		result, err := p.daoCampaign.Delete(ctx, campaignId)
 		if err != nil {
 			return err
 		}
@@ -191,6 +238,144 @@ func (p *campaignBaseService) Delete(campaignId string, delete_permanent bool) e
 	return nil
 }
 
+// SendCampaign - Send the Campaign to the Customers with granted consent for its channel, once. The Campaign
+// is claimed as sending first, a stopped send is resumed after the last batch recorded
+func (p *campaignBaseService) SendCampaign(campaignId string) (_ utils.Map, err error) {
+
+	log.Println("CampaignService::SendCampaign - Begin", campaignId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "campaign", "SendCampaign", p.businessId)
+	defer span.EndWith(&err)
+
+	dataCampaign, err := p.Get(campaignId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	errSent := &utils.AppError{ErrorCode: "S30340208", ErrorMsg: "Campaign already sent", ErrorDetail: "Campaign " + campaignId + " is sent already or being sent"}
+	if _, err := sales_common.GetMemberDataTime(dataCampaign, sales_common.FLD_CAMPAIGN_SENT_AT); err == nil {
+		return utils.Map{}, errSent
+	}
+	channel, _ := utils.GetMemberDataStr(dataCampaign, sales_common.FLD_CAMPAIGN_CHANNEL)
+	subject, _ := utils.GetMemberDataStr(dataCampaign, sales_common.FLD_CAMPAIGN_SUBJECT)
+	body, _ := utils.GetMemberDataStr(dataCampaign, sales_common.FLD_CAMPAIGN_BODY)
+	contactField, ok := consentContactFields[channel]
+	if !ok || len(body) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340207", ErrorMsg: "Campaign not ready", ErrorDetail: "Campaign should have the channel (email, sms or whatsapp) and the body"}
+		return utils.Map{}, err
+	}
+
+	// Claim before sending, so a retry or a parallel call does not send again
+	staleBefore := time.Now().Add(-sales_common.CAMPAIGN_SEND_STALE_MINUTES * time.Minute)
+	dataCampaign, err = p.daoCampaign.ClaimSend(ctx, campaignId, staleBefore, utils.Map{
+		sales_common.FLD_CAMPAIGN_STATUS:    sales_common.CAMPAIGN_STATUS_SENDING,
+		sales_common.FLD_CAMPAIGN_SEND_BEAT: time.Now(),
+	})
+	if err == mongo.ErrNoDocuments {
+		return utils.Map{}, errSent
+	} else if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Resume after the last batch sent, if the earlier send was stopped
+	cursor, _ := dataCampaign[sales_common.FLD_CAMPAIGN_SEND_CURSOR].(string)
+	dataReport, _ := sales_common.GetMemberDataMap(dataCampaign, sales_common.FLD_CAMPAIGN_SEND_REPORT)
+	sent, _ := utils.GetMemberDataInt(dataReport, sales_common.CAMPAIGN_REPORT_SENT, true)
+	failed, _ := utils.GetMemberDataInt(dataReport, sales_common.CAMPAIGN_REPORT_FAILED, true)
+	skipped, _ := utils.GetMemberDataInt(dataReport, sales_common.CAMPAIGN_REPORT_SKIPPED, true)
+
+	sort := `{"` + sales_common.FLD_CONSENT_ID + `": 1}`
+	for {
+		// Only the Customers who consented for the channel
+		fields := utils.Map{
+			sales_common.FLD_CONSENT_CHANNEL: channel,
+			sales_common.FLD_CONSENT_STATUS:  sales_common.CONSENT_STATUS_GRANTED,
+		}
+		if len(cursor) > 0 {
+			fields[sales_common.FLD_CONSENT_ID] = utils.Map{"$gt": cursor}
+		}
+		filter, err := sales_common.BuildFilter(fields)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		listdata, err := p.daoConsent.List(ctx, filter, sort, 0, sales_common.CAMPAIGN_SEND_BATCH_SIZE)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		consents, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+		for _, consent := range consents {
+			customerId, _ := utils.GetMemberDataStr(consent, sales_common.FLD_CUSTOMER_ID)
+
+			dataCustomer, err := p.daoCustomer.Get(ctx, customerId)
+			sendTo, _ := utils.GetMemberDataStr(dataCustomer, contactField)
+			if err != nil || len(sendTo) == 0 {
+				// Deleted Customer, or the email/phone was removed after the consent
+				skipped++
+				continue
+			}
+
+			token, err := consentUnsubscribeToken(customerId, channel)
+			if err == nil {
+				err = sales_notifier.Send(sales_notifier.Message{
+					Channel:    channel,
+					To:         sendTo,
+					Purpose:    sales_notifier.PURPOSE_CAMPAIGN,
+					BusinessId: p.businessId,
+					Subject:    subject,
+					Body:       body,
+					Data:       utils.Map{sales_notifier.DATA_TOKEN: token, sales_common.FLD_CAMPAIGN_ID: campaignId, sales_common.FLD_CUSTOMER_ID: customerId},
+				})
+			}
+			if err != nil {
+				log.Println("CampaignService::SendCampaign - Send failed ", customerId, err)
+				failed++
+				continue
+			}
+			sent++
+		}
+
+		if len(consents) == 0 {
+			break
+		}
+
+		// Record the progress, so a stopped send resumes after this batch
+		cursor, _ = utils.GetMemberDataStr(consents[len(consents)-1], sales_common.FLD_CONSENT_ID)
+		_, err = p.daoCampaign.Update(ctx, campaignId, utils.Map{
+			sales_common.FLD_CAMPAIGN_SEND_CURSOR: cursor,
+			sales_common.FLD_CAMPAIGN_SEND_REPORT: campaignReport(sent, failed, skipped),
+			sales_common.FLD_CAMPAIGN_SEND_BEAT:   time.Now(),
+		})
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		if len(consents) < sales_common.CAMPAIGN_SEND_BATCH_SIZE {
+			break
+		}
+	}
+
+	report := campaignReport(sent, failed, skipped)
+	indata := utils.Map{
+		sales_common.FLD_CAMPAIGN_STATUS:      sales_common.CAMPAIGN_STATUS_SENT,
+		sales_common.FLD_CAMPAIGN_SENT_AT:     time.Now(),
+		sales_common.FLD_CAMPAIGN_SEND_REPORT: report,
+	}
+	_, err = p.daoCampaign.Update(ctx, campaignId, indata)
+
+	log.Println("CampaignService::SendCampaign - End ", report, err)
+	return report, err
+}
+
+// campaignReport - Send report of the Campaign
+func campaignReport(sent int, failed int, skipped int) utils.Map {
+	return utils.Map{
+		sales_common.CAMPAIGN_REPORT_SENT:    sent,
+		sales_common.CAMPAIGN_REPORT_FAILED:  failed,
+		sales_common.CAMPAIGN_REPORT_SKIPPED: skipped,
+	}
+}
+
 func (p *campaignBaseService) errorReturn(err error) (CampaignService, error) {
 	// Close the Database Connection
 	p.EndService()
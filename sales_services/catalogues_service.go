@@ -1,9 +1,11 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/db_utils"
@@ -11,6 +13,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -29,6 +32,23 @@ type CatalogueService interface {
 	// Delete - Delete Service
 	Delete(catalogueId string, delete_permanent bool) error
 
+	// ListItems - List the Products and Categories in the draft of the Catalogue in their order
+	ListItems(catalogueId string) (utils.Map, error)
+	// AddItem - Add Product or Category to the draft of the Catalogue, at the end if no position given
+	AddItem(catalogueId string, indata utils.Map) (utils.Map, error)
+	// UpdateItem - Update the item in the draft of the Catalogue
+	UpdateItem(catalogueId string, itemId string, indata utils.Map) (utils.Map, error)
+	// RemoveItem - Remove the item from the draft of the Catalogue
+	RemoveItem(catalogueId string, itemId string) error
+	// ReorderItems - Set the order of all the items in the draft of the Catalogue
+	ReorderItems(catalogueId string, itemIds []string) (utils.Map, error)
+	// Publish - Publish the draft of the Catalogue as a new immutable version
+	Publish(catalogueId string) (utils.Map, error)
+	// GetPublished - Get the published version of the Catalogue, the current one if version is 0
+	GetPublished(catalogueId string, version int) (utils.Map, error)
+	// ListVersions - List the published versions of the Catalogue, latest first
+	ListVersions(catalogueId string, skip int64, limit int64) (utils.Map, error)
+
 	EndService()
 }
 
@@ -37,9 +57,15 @@ type catalogueBaseService struct {
 	db_utils.DatabaseService
 	dbRegion     db_utils.DatabaseService
 	daoCatalogue sales_repository.CatalogueDao
+	daoItem      sales_repository.CatalogueItemDao
+	daoVersion   sales_repository.CatalogueVersionDao
+	daoProduct   sales_repository.ProductDao
+	daoVariant   sales_repository.ProductVariantDao
+	daoCategory  sales_repository.CategoryDao
 	daoBusiness  platform_repository.BusinessDao
 	child        CatalogueService
 	businessId   string
+	ctx          context.Context
 }
 
 // NewCatalogueService - Construct Catalogue
@@ -69,6 +95,7 @@ func NewCatalogueService(props utils.Map) (CatalogueService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -96,14 +123,22 @@ func (p *catalogueBaseService) initializeService() {
 	log.Printf("CatalogueService:: GetBusinessDao ")
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoCatalogue = sales_repository.NewCatalogueDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoItem = sales_repository.NewCatalogueItemDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoVersion = sales_repository.NewCatalogueVersionDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoProduct = sales_repository.NewProductDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoVariant = sales_repository.NewProductVariantDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCategory = sales_repository.NewCategoryDao(p.dbRegion.GetClient(), p.businessId)
 }
 
 // List - List All records
-func (p *catalogueBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *catalogueBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("catalogueBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCatalogue.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCatalogue.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -113,28 +148,37 @@ func (p *catalogueBaseService) List(filter string, sort string, skip int64, limi
 }
 
 // Get - Find By Code
-func (p *catalogueBaseService) Get(catalogueId string) (utils.Map, error) {
+func (p *catalogueBaseService) Get(catalogueId string) (_ utils.Map, err error) {
 	log.Printf("catalogueBaseService::Get::  Begin %v", catalogueId)
 
-	data, err := p.daoCatalogue.Get(catalogueId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCatalogue.Get(ctx, catalogueId)
 
 	log.Println("BrandService::Get:: End ", err)
 	return data, err
 }
 
-func (p *catalogueBaseService) Find(filter string) (utils.Map, error) {
+func (p *catalogueBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("catalogueBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCatalogue.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCatalogue.Find(ctx, filter)
 	log.Println("catalogueBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *catalogueBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *catalogueBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CatalogueService::Create - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var catalogueId string
 
 	dataval, dataok := indata[sales_common.FLD_CATALOGUE_ID]
@@ -148,8 +192,10 @@ func (p *catalogueBaseService) Create(indata utils.Map) (utils.Map, error) {
 	// Assign Business Id
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CATALOGUE_ID] = catalogueId
+	delete(indata, sales_common.FLD_CATALOGUE_PUBLISHED_VERSION)
+	delete(indata, sales_common.FLD_CATALOGUE_PUBLISHED_AT)
 
-	data, err := p.daoCatalogue.Create(indata)
+	data, err := p.daoCatalogue.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -159,23 +205,33 @@ func (p *catalogueBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *catalogueBaseService) Update(catalogueId string, indata utils.Map) (utils.Map, error) {
+func (p *catalogueBaseService) Update(catalogueId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CatalogueService::Update - Begin")
 
-	data, err := p.daoCatalogue.Update(catalogueId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Published version is changed only by Publish
+	delete(indata, sales_common.FLD_CATALOGUE_PUBLISHED_VERSION)
+	delete(indata, sales_common.FLD_CATALOGUE_PUBLISHED_AT)
+
+	data, err := p.daoCatalogue.Update(ctx, catalogueId, indata)
 
 	log.Println("CatalogueService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *catalogueBaseService) Delete(catalogueId string, delete_permanent bool) error {
+func (p *catalogueBaseService) Delete(catalogueId string, delete_permanent bool) (err error) {
 
 	log.Println("BrandService::Delete - Begin", catalogueId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCatalogue.Delete(catalogueId)
+		result, err := p.daoCatalogue.Delete(ctx, catalogueId)
 		if err != nil {
 			return err
 		}
@@ -193,6 +249,403 @@ func (p *catalogueBaseService) Delete(catalogueId string, delete_permanent bool)
 	return nil
 }
 
+// ListItems - List the Products and Categories in the draft of the Catalogue in their order
+func (p *catalogueBaseService) ListItems(catalogueId string) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::ListItems - Begin ", catalogueId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "ListItems", p.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATALOGUE_ID: catalogueId})
+	if err != nil {
+		return nil, err
+	}
+	sort := `{"` + sales_common.FLD_CATALOGUE_ITEM_POSITION + `": 1}`
+	listdata, err := p.daoItem.List(ctx, filter, sort, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("CatalogueService::ListItems - End ")
+	return listdata, nil
+}
+
+// AddItem - Add Product or Category to the draft of the Catalogue, at the end if no position given
+func (p *catalogueBaseService) AddItem(catalogueId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::AddItem - Begin ", catalogueId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "AddItem", p.businessId)
+	defer span.EndWith(&err)
+
+	err = p.validateCatalogue(ctx, catalogueId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	itemType, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CATALOGUE_ITEM_TYPE)
+	refField, err := catalogueItemRefField(itemType)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	refId, _ := utils.GetMemberDataStr(indata, refField)
+	switch itemType {
+	case sales_common.CATALOGUE_ITEM_TYPE_PRODUCT:
+		_, err = p.daoProduct.Get(ctx, refId)
+	case sales_common.CATALOGUE_ITEM_TYPE_CATEGORY:
+		_, err = p.daoCategory.Get(ctx, refId)
+	}
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340234", ErrorMsg: "Invalid Catalogue Item", ErrorDetail: "Given " + refField + " " + refId + " is not exist"}
+		return utils.Map{}, err
+	}
+
+	items, err := p.getItems(catalogueId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	position := 0
+	for _, item := range items {
+		if item[sales_common.FLD_CATALOGUE_ITEM_TYPE] == itemType && item[refField] == refId {
+			err := &utils.AppError{ErrorCode: "S30340235", ErrorMsg: "Duplicate Catalogue Item", ErrorDetail: "Given " + refField + " " + refId + " is already in the Catalogue"}
+			return utils.Map{}, err
+		}
+		itemPosition, _ := utils.GetMemberDataInt(item, sales_common.FLD_CATALOGUE_ITEM_POSITION, true)
+		if itemPosition > position {
+			position = itemPosition
+		}
+	}
+	if _, ok := indata[sales_common.FLD_CATALOGUE_ITEM_POSITION]; !ok {
+		indata[sales_common.FLD_CATALOGUE_ITEM_POSITION] = position + 1
+	}
+
+	itemId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CATALOGUE_ITEM_ID)
+	if len(itemId) > 0 {
+		itemId = strings.ToLower(itemId)
+	} else {
+		itemId = utils.GenerateUniqueId("catitm")
+		log.Println("Unique Catalogue Item ID", itemId)
+	}
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_CATALOGUE_ID] = catalogueId
+	indata[sales_common.FLD_CATALOGUE_ITEM_ID] = itemId
+
+	data, err := p.daoItem.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("CatalogueService::AddItem - End ")
+	return data, nil
+}
+
+// UpdateItem - Update the item in the draft of the Catalogue, the Product or Category of the item can not be changed
+func (p *catalogueBaseService) UpdateItem(catalogueId string, itemId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::UpdateItem - Begin ", catalogueId, itemId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "UpdateItem", p.businessId)
+	defer span.EndWith(&err)
+
+	_, err = p.getItem(ctx, catalogueId, itemId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Delete Key values
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_CATALOGUE_ID)
+	delete(indata, sales_common.FLD_CATALOGUE_ITEM_ID)
+	delete(indata, sales_common.FLD_CATALOGUE_ITEM_TYPE)
+	delete(indata, sales_common.FLD_PRODUCT_ID)
+	delete(indata, sales_common.FLD_CATEGORY_ID)
+
+	data, err := p.daoItem.Update(ctx, itemId, indata)
+
+	log.Println("CatalogueService::UpdateItem - End ")
+	return data, err
+}
+
+// RemoveItem - Remove the item from the draft of the Catalogue
+func (p *catalogueBaseService) RemoveItem(catalogueId string, itemId string) (err error) {
+
+	log.Println("CatalogueService::RemoveItem - Begin ", catalogueId, itemId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "RemoveItem", p.businessId)
+	defer span.EndWith(&err)
+
+	_, err = p.getItem(ctx, catalogueId, itemId)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.daoItem.Delete(ctx, itemId)
+	if err != nil {
+		return err
+	}
+
+	log.Println("CatalogueService::RemoveItem - End ", result)
+	return nil
+}
+
+// ReorderItems - Set the order of all the items in the draft of the Catalogue
+func (p *catalogueBaseService) ReorderItems(catalogueId string, itemIds []string) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::ReorderItems - Begin ", catalogueId, itemIds)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "ReorderItems", p.businessId)
+	defer span.EndWith(&err)
+
+	items, err := p.getItems(catalogueId)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := map[string]int{}
+	for idx, itemId := range itemIds {
+		positions[itemId] = idx + 1
+	}
+	if len(positions) != len(itemIds) || len(positions) != len(items) {
+		err := &utils.AppError{ErrorCode: "S30340237", ErrorMsg: "Invalid Catalogue Items Order", ErrorDetail: "Each of the items of the Catalogue should be given once"}
+		return nil, err
+	}
+	for _, item := range items {
+		itemId, _ := utils.GetMemberDataStr(item, sales_common.FLD_CATALOGUE_ITEM_ID)
+		if _, ok := positions[itemId]; !ok {
+			err := &utils.AppError{ErrorCode: "S30340237", ErrorMsg: "Invalid Catalogue Items Order", ErrorDetail: "Each of the items of the Catalogue should be given once"}
+			return nil, err
+		}
+	}
+
+	for itemId, position := range positions {
+		indata := utils.Map{sales_common.FLD_CATALOGUE_ITEM_POSITION: position}
+		_, err = p.daoItem.Update(ctx, itemId, indata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Println("CatalogueService::ReorderItems - End ")
+	return p.ListItems(catalogueId)
+}
+
+// Publish - Publish the draft of the Catalogue as a new immutable version. Each item has the copy of its Product,
+// or of its Category with the Products of the Category and its sub Categories, as at the time of publishing
+func (p *catalogueBaseService) Publish(catalogueId string) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::Publish - Begin ", catalogueId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "Publish", p.businessId)
+	defer span.EndWith(&err)
+
+	dataCatalogue, err := p.daoCatalogue.Get(ctx, catalogueId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340233", ErrorMsg: "Invalid CatalogueId", ErrorDetail: "Given CatalogueId " + catalogueId + " is not exist"}
+		return nil, err
+	}
+
+	items, err := p.getItems(catalogueId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Products of all the items, to set their variants at once
+	products := []utils.Map{}
+	snapshotItems := []utils.Map{}
+	for _, item := range items {
+		itemType, _ := utils.GetMemberDataStr(item, sales_common.FLD_CATALOGUE_ITEM_TYPE)
+		refField, err := catalogueItemRefField(itemType)
+		if err != nil {
+			return nil, err
+		}
+		refId, _ := utils.GetMemberDataStr(item, refField)
+
+		var itemData utils.Map
+		switch itemType {
+		case sales_common.CATALOGUE_ITEM_TYPE_PRODUCT:
+			itemData, err = p.daoProduct.Get(ctx, refId)
+			if err == nil {
+				products = append(products, itemData)
+			}
+		case sales_common.CATALOGUE_ITEM_TYPE_CATEGORY:
+			itemData, err = p.daoCategory.Get(ctx, refId)
+			if err == nil {
+				var categoryProducts []utils.Map
+				categoryProducts, err = p.getCategoryProducts(ctx, refId)
+				item[sales_common.FLD_CATALOGUE_ITEM_PRODUCTS] = categoryProducts
+				products = append(products, categoryProducts...)
+			}
+		}
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340234", ErrorMsg: "Invalid Catalogue Item", ErrorDetail: "Given " + refField + " " + refId + " is not exist"}
+			return nil, err
+		}
+		item[sales_common.FLD_CATALOGUE_ITEM_DATA] = itemData
+		snapshotItems = append(snapshotItems, item)
+	}
+
+	err = attachProductVariants(ctx, p.daoVariant, utils.Map{db_common.LIST_RESULT: products})
+	if err != nil {
+		return nil, err
+	}
+
+	// Next version after the latest published, the unique index fails the concurrent publish of the same version
+	version := 1
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATALOGUE_ID: catalogueId})
+	if err != nil {
+		return nil, err
+	}
+	sort := `{"` + sales_common.FLD_CATALOGUE_VERSION + `": -1}`
+	listdata, err := p.daoVersion.List(ctx, filter, sort, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if versions, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT); len(versions) > 0 {
+		latest, _ := utils.GetMemberDataInt(versions[0], sales_common.FLD_CATALOGUE_VERSION, true)
+		version = latest + 1
+	}
+
+	publishedAt := time.Now()
+	versionId := utils.GenerateUniqueId("catver")
+	indata := utils.Map{
+		sales_common.FLD_BUSINESS_ID:            p.businessId,
+		sales_common.FLD_CATALOGUE_VERSION_ID:   versionId,
+		sales_common.FLD_CATALOGUE_ID:           catalogueId,
+		sales_common.FLD_CATALOGUE_VERSION:      version,
+		sales_common.FLD_CATALOGUE_PUBLISHED_AT: publishedAt,
+		sales_common.FLD_CATALOGUE_DATA:         dataCatalogue,
+		sales_common.FLD_CATALOGUE_ITEMS:        snapshotItems,
+	}
+	data, err := p.daoVersion.Create(ctx, indata)
+	if err != nil {
+		return nil, err
+	}
+
+	indata = utils.Map{
+		sales_common.FLD_CATALOGUE_PUBLISHED_VERSION: version,
+		sales_common.FLD_CATALOGUE_PUBLISHED_AT:      publishedAt,
+	}
+	_, err = p.daoCatalogue.Update(ctx, catalogueId, indata)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("CatalogueService::Publish - End ", version)
+	return data, nil
+}
+
+// GetPublished - Get the published version of the Catalogue, the current one if version is 0
+func (p *catalogueBaseService) GetPublished(catalogueId string, version int) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::GetPublished - Begin ", catalogueId, version)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "GetPublished", p.businessId)
+	defer span.EndWith(&err)
+
+	if version == 0 {
+		dataCatalogue, err := p.daoCatalogue.Get(ctx, catalogueId)
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340233", ErrorMsg: "Invalid CatalogueId", ErrorDetail: "Given CatalogueId " + catalogueId + " is not exist"}
+			return nil, err
+		}
+		version, _ = utils.GetMemberDataInt(dataCatalogue, sales_common.FLD_CATALOGUE_PUBLISHED_VERSION, true)
+	}
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATALOGUE_ID: catalogueId, sales_common.FLD_CATALOGUE_VERSION: version})
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.daoVersion.Find(ctx, filter)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340238", ErrorMsg: "Catalogue not Published", ErrorDetail: fmt.Sprintf("Catalogue %s has no published version %d", catalogueId, version)}
+		return nil, err
+	}
+
+	log.Println("CatalogueService::GetPublished - End ")
+	return data, nil
+}
+
+// ListVersions - List the published versions of the Catalogue, latest first
+func (p *catalogueBaseService) ListVersions(catalogueId string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CatalogueService::ListVersions - Begin ", catalogueId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "catalogue", "ListVersions", p.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATALOGUE_ID: catalogueId})
+	if err != nil {
+		return nil, err
+	}
+	sort := `{"` + sales_common.FLD_CATALOGUE_VERSION + `": -1}`
+	listdata, err := p.daoVersion.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("CatalogueService::ListVersions - End ")
+	return listdata, nil
+}
+
+// validateCatalogue - Verify the Catalogue exist
+func (p *catalogueBaseService) validateCatalogue(ctx context.Context, catalogueId string) error {
+	_, err := p.daoCatalogue.Get(ctx, catalogueId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340233", ErrorMsg: "Invalid CatalogueId", ErrorDetail: "Given CatalogueId " + catalogueId + " is not exist"}
+		return err
+	}
+	return nil
+}
+
+// getItems - Get all the items in the draft of the Catalogue in their order
+func (p *catalogueBaseService) getItems(catalogueId string) ([]utils.Map, error) {
+	listdata, err := p.ListItems(catalogueId)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	return items, nil
+}
+
+// getItem - Get the item of the Catalogue
+func (p *catalogueBaseService) getItem(ctx context.Context, catalogueId string, itemId string) (utils.Map, error) {
+	data, err := p.daoItem.Get(ctx, itemId)
+	if err != nil || data[sales_common.FLD_CATALOGUE_ID] != catalogueId {
+		err := &utils.AppError{ErrorCode: "S30340236", ErrorMsg: "Invalid Catalogue ItemId", ErrorDetail: "Given ItemId " + itemId + " is not exist in the Catalogue " + catalogueId}
+		return nil, err
+	}
+	return data, nil
+}
+
+// getCategoryProducts - Get the Products of the Category and its sub Categories
+func (p *catalogueBaseService) getCategoryProducts(ctx context.Context, categoryId string) ([]utils.Map, error) {
+	filter, err := categoryProductsFilter(ctx, p.daoCategory, categoryId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	listdata, err := p.daoProduct.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	products, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	return products, nil
+}
+
+// catalogueItemRefField - Field of the Product or Category id for the item type
+func catalogueItemRefField(itemType string) (string, error) {
+	switch itemType {
+	case sales_common.CATALOGUE_ITEM_TYPE_PRODUCT:
+		return sales_common.FLD_PRODUCT_ID, nil
+	case sales_common.CATALOGUE_ITEM_TYPE_CATEGORY:
+		return sales_common.FLD_CATEGORY_ID, nil
+	}
+	err := &utils.AppError{ErrorCode: "S30340234", ErrorMsg: "Invalid Catalogue Item", ErrorDetail: sales_common.FLD_CATALOGUE_ITEM_TYPE + " should be " +
+		sales_common.CATALOGUE_ITEM_TYPE_PRODUCT + " or " + sales_common.CATALOGUE_ITEM_TYPE_CATEGORY}
+	return "", err
+}
+
 func (p *catalogueBaseService) errorReturn(err error) (CatalogueService, error) {
 	// Close the Database Connection
 	p.EndService()
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -28,6 +30,14 @@ type CategoryService interface {
 	Update(categoryId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Service
 	Delete(categoryId string, delete_permanent bool) error
+	// Validate - Validate the Category to be created or updated, nothing will be written
+	Validate(indata utils.Map) error
+
+	// GetTree - Get the Category with its sub Categories nested in category_children, all the root
+	// Categories if categoryId is empty
+	GetTree(categoryId string) (utils.Map, error)
+	// MoveCategory - Move the Category with its sub Categories under the parent, to the root if parentId is empty
+	MoveCategory(categoryId string, parentId string) (utils.Map, error)
 
 	EndService()
 }
@@ -40,6 +50,7 @@ type categoryBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       CategoryService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewCategoryService - Construct Category
@@ -69,6 +80,7 @@ func NewCategoryService(props utils.Map) (CategoryService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -99,11 +111,14 @@ func (p *categoryBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *categoryBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *categoryBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("categoryBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCategory.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCategory.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -113,28 +128,37 @@ func (p *categoryBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *categoryBaseService) Get(categoryId string) (utils.Map, error) {
+func (p *categoryBaseService) Get(categoryId string) (_ utils.Map, err error) {
 	log.Printf("categoryBaseService::Get::  Begin %v", categoryId)
 
-	data, err := p.daoCategory.Get(categoryId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCategory.Get(ctx, categoryId)
 
 	log.Println("BrandService::Get:: End ", err)
 	return data, err
 }
 
-func (p *categoryBaseService) Find(filter string) (utils.Map, error) {
+func (p *categoryBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("categoryBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCategory.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCategory.Find(ctx, filter)
 	log.Println("categoryBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *categoryBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *categoryBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CategoryService::Create - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var categoryId string
 
 	dataval, dataok := indata[sales_common.FLD_CATEGORY_ID]
@@ -149,7 +173,17 @@ func (p *categoryBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CATEGORY_ID] = categoryId
 
-	data, err := p.daoCategory.Create(indata)
+	// Place under the parent Category
+	parentId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CATEGORY_PARENT_ID)
+	ancestors, err := p.getParentPath(ctx, categoryId, parentId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	indata[sales_common.FLD_CATEGORY_PARENT_ID] = parentId
+	indata[sales_common.FLD_CATEGORY_ANCESTORS] = ancestors
+	indata[sales_common.FLD_CATEGORY_PATH] = categoryPath(ancestors, categoryId)
+
+	data, err := p.daoCategory.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -159,23 +193,63 @@ func (p *categoryBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *categoryBaseService) Update(categoryId string, indata utils.Map) (utils.Map, error) {
+func (p *categoryBaseService) Update(categoryId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CategoryService::Update - Begin")
 
-	data, err := p.daoCategory.Update(categoryId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Tree fields are changed only by MoveCategory
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_CATEGORY_ID)
+	delete(indata, sales_common.FLD_CATEGORY_ANCESTORS)
+	delete(indata, sales_common.FLD_CATEGORY_PATH)
+	if dataVal, ok := indata[sales_common.FLD_CATEGORY_PARENT_ID]; ok {
+		delete(indata, sales_common.FLD_CATEGORY_PARENT_ID)
+		// null moves the Category to the root
+		parentId, isStr := dataVal.(string)
+		if dataVal != nil && !isStr {
+			err := &utils.AppError{ErrorCode: "S30340229", ErrorMsg: "Invalid Parent Category", ErrorDetail: sales_common.FLD_CATEGORY_PARENT_ID + " should be a Category Id or empty"}
+			return utils.Map{}, err
+		}
+		data, err := p.MoveCategory(categoryId, parentId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		if len(indata) == 0 {
+			log.Println("CategoryService::Update - End ")
+			return data, nil
+		}
+	}
+
+	data, err := p.daoCategory.Update(ctx, categoryId, indata)
 
 	log.Println("CategoryService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *categoryBaseService) Delete(categoryId string, delete_permanent bool) error {
+func (p *categoryBaseService) Delete(categoryId string, delete_permanent bool) (err error) {
 
 	log.Println("BrandService::Delete - Begin", categoryId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	// Sub Categories should be moved or deleted first
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATEGORY_PARENT_ID: categoryId})
+	if err != nil {
+		return err
+	}
+	_, err = p.daoCategory.Find(ctx, filter)
+	if err == nil {
+		err := &utils.AppError{ErrorCode: "S30340231", ErrorMsg: "Category has Sub Categories", ErrorDetail: "Category " + categoryId + " has sub Categories, move or delete them first"}
+		return err
+	}
+
 	if delete_permanent {
-		result, err := p.daoCategory.Delete(categoryId)
+		result, err := p.daoCategory.Delete(ctx, categoryId)
 		if err != nil {
 			return err
 		}
@@ -193,6 +267,155 @@ func (p *categoryBaseService) Delete(categoryId string, delete_permanent bool) e
 	return nil
 }
 
+// Validate - Validate the Category to be created or updated, nothing will be written
+func (p *categoryBaseService) Validate(indata utils.Map) (err error) {
+
+	log.Println("CategoryService::Validate - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "Validate", p.businessId)
+	defer span.EndWith(&err)
+
+	categoryId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CATEGORY_ID)
+	parentId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CATEGORY_PARENT_ID)
+	_, err = p.getParentPath(ctx, strings.ToLower(categoryId), parentId)
+
+	log.Println("CategoryService::Validate - End ", err)
+	return err
+}
+
+// GetTree - Get the Category with its sub Categories nested in category_children, all the root
+// Categories if categoryId is empty
+func (p *categoryBaseService) GetTree(categoryId string) (_ utils.Map, err error) {
+
+	log.Println("CategoryService::GetTree - Begin ", categoryId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "GetTree", p.businessId)
+	defer span.EndWith(&err)
+
+	tree := utils.Map{}
+	filter := ""
+	if len(categoryId) > 0 {
+		dataCategory, err := p.daoCategory.Get(ctx, categoryId)
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340232", ErrorMsg: "Invalid CategoryId", ErrorDetail: "Given CategoryId " + categoryId + " is not exist"}
+			return nil, err
+		}
+		tree = dataCategory
+		filter, err = sales_common.BuildFilter(utils.Map{sales_common.FLD_CATEGORY_ANCESTORS: categoryId})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	listdata, err := p.daoCategory.List(ctx, filter, `{"`+sales_common.FLD_CATEGORY_PATH+`": 1}`, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	categories, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	// Nest each Category in its parent, the ones without parent in the list go to the top of the tree
+	nodes := map[string]utils.Map{categoryId: tree}
+	for _, category := range categories {
+		id, _ := utils.GetMemberDataStr(category, sales_common.FLD_CATEGORY_ID)
+		category[sales_common.FLD_CATEGORY_CHILDREN] = []utils.Map{}
+		nodes[id] = category
+	}
+	tree[sales_common.FLD_CATEGORY_CHILDREN] = []utils.Map{}
+	for _, category := range categories {
+		parentId, _ := utils.GetMemberDataStr(category, sales_common.FLD_CATEGORY_PARENT_ID)
+		parent, ok := nodes[parentId]
+		if !ok {
+			parent = tree
+		}
+		parent[sales_common.FLD_CATEGORY_CHILDREN] = append(parent[sales_common.FLD_CATEGORY_CHILDREN].([]utils.Map), category)
+	}
+
+	log.Println("CategoryService::GetTree - End ", len(categories))
+	return tree, nil
+}
+
+// MoveCategory - Move the Category with its sub Categories under the parent, to the root if parentId is empty
+func (p *categoryBaseService) MoveCategory(categoryId string, parentId string) (_ utils.Map, err error) {
+
+	log.Println("CategoryService::MoveCategory - Begin ", categoryId, parentId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "category", "MoveCategory", p.businessId)
+	defer span.EndWith(&err)
+
+	dataCategory, err := p.daoCategory.Get(ctx, categoryId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340232", ErrorMsg: "Invalid CategoryId", ErrorDetail: "Given CategoryId " + categoryId + " is not exist"}
+		return nil, err
+	}
+
+	ancestors, err := p.getParentPath(ctx, categoryId, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	// One update moves the Category with its sub Categories, a failed move can be retried as it is
+	count, err := p.daoCategory.MoveSubtree(ctx, categoryId, parentId, ancestors)
+	if err != nil {
+		return nil, err
+	}
+
+	// A concurrent move may have placed the parent under the Category meanwhile, move it back
+	if len(parentId) > 0 {
+		dataParent, err := p.daoCategory.Get(ctx, parentId)
+		if err != nil {
+			return nil, err
+		}
+		parentAncestors, _ := sales_common.GetMemberDataStrArray(dataParent, sales_common.FLD_CATEGORY_ANCESTORS)
+		if containsId(parentAncestors, categoryId) {
+			oldParentId, _ := utils.GetMemberDataStr(dataCategory, sales_common.FLD_CATEGORY_PARENT_ID)
+			oldAncestors, _ := sales_common.GetMemberDataStrArray(dataCategory, sales_common.FLD_CATEGORY_ANCESTORS)
+			_, err = p.daoCategory.MoveSubtree(ctx, categoryId, oldParentId, oldAncestors)
+			if err != nil {
+				return nil, err
+			}
+			err = &utils.AppError{ErrorCode: "S30340230", ErrorMsg: "Invalid Parent Category", ErrorDetail: "Category " + categoryId + " can not be moved under itself or its sub Category " + parentId}
+			return nil, err
+		}
+	}
+
+	data, err := p.daoCategory.Get(ctx, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("CategoryService::MoveCategory - End ", count)
+	return data, nil
+}
+
+// getParentPath - Ancestors of the Category when placed under the parent, which should exist and should not
+// be the Category or one of its sub Categories
+func (p *categoryBaseService) getParentPath(ctx context.Context, categoryId string, parentId string) ([]string, error) {
+	if len(parentId) == 0 {
+		return []string{}, nil
+	}
+
+	dataParent, err := p.daoCategory.Get(ctx, parentId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340229", ErrorMsg: "Invalid Parent Category", ErrorDetail: "Given " + sales_common.FLD_CATEGORY_PARENT_ID + " " + parentId + " is not exist"}
+		return nil, err
+	}
+
+	ancestors, _ := sales_common.GetMemberDataStrArray(dataParent, sales_common.FLD_CATEGORY_ANCESTORS)
+	ancestors = append(ancestors, parentId)
+	for _, ancestorId := range ancestors {
+		if ancestorId == categoryId {
+			err := &utils.AppError{ErrorCode: "S30340230", ErrorMsg: "Invalid Parent Category", ErrorDetail: "Category " + categoryId + " can not be moved under itself or its sub Category " + parentId}
+			return nil, err
+		}
+	}
+	return ancestors, nil
+}
+
+// categoryPath - Materialized path of the Category from its ancestors
+func categoryPath(ancestors []string, categoryId string) string {
+	return "/" + strings.Join(append(append([]string{}, ancestors...), categoryId), "/")
+}
+
 func (p *categoryBaseService) errorReturn(err error) (CategoryService, error) {
 	// Close the Database Connection
 	p.EndService()
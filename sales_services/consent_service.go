@@ -0,0 +1,461 @@
+package sales_services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_notifier"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// ConsentService - Marketing consent of the Customers for each channel.
+//
+// Consent keeps the current status of the Customer for the channel and every change is appended to the
+// Consent history with its source. Channels configured for double opt-in stay pending till the Customer
+// confirms with the Token sent to the channel. Campaigns are sent only to the Customers with granted
+// consent, with an unsubscribe Token to withdraw it.
+type ConsentService interface {
+	// GetConsents - Get the consent of the Customer for all the channels
+	GetConsents(customerId string) (utils.Map, error)
+	// ListHistory - List the consent changes of the Customer
+	ListHistory(customerId string, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// GrantConsent - Grant the consent for the channel, pending till confirmed if the channel needs double opt-in
+	GrantConsent(customerId string, channel string, source string) (utils.Map, error)
+	// ConfirmConsent - Confirm the pending consent with the double opt-in Token
+	ConfirmConsent(token string) (utils.Map, error)
+	// WithdrawConsent - Withdraw the consent for the channel
+	WithdrawConsent(customerId string, channel string, source string) (utils.Map, error)
+	// UnsubscribeToken - Get the Token to withdraw the consent without login, e.g. for the unsubscribe link
+	UnsubscribeToken(customerId string, channel string) (string, error)
+	// Unsubscribe - Withdraw the consent with the unsubscribe Token
+	Unsubscribe(token string) (utils.Map, error)
+
+	EndService()
+}
+
+// consentContactFields - Customer field having the address for the channel
+var consentContactFields = map[string]string{
+	sales_notifier.CHANNEL_EMAIL:    sales_common.FLD_CUSTOMER_EMAIL,
+	sales_notifier.CHANNEL_SMS:      sales_common.FLD_CUSTOMER_PHONE,
+	sales_notifier.CHANNEL_WHATSAPP: sales_common.FLD_CUSTOMER_PHONE,
+}
+
+type consentBaseService struct {
+	db_utils.DatabaseService
+	dbRegion      db_utils.DatabaseService
+	daoCustomer   sales_repository.CustomerDao
+	daoPreference sales_repository.PreferenceDao
+	daoBusiness   platform_repository.BusinessDao
+	child         ConsentService
+	businessId    string
+	ctx           context.Context
+}
+
+// NewConsentService - Construct Consent
+func NewConsentService(props utils.Map) (ConsentService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("ConsentService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := consentBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// consentBaseService - Close all the services
+func (p *consentBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *consentBaseService) initializeService() {
+	log.Printf("ConsentService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoPreference = sales_repository.NewPreferenceDao(p.dbRegion.GetClient(), p.businessId)
+}
+
+// GetConsents - Get the consent of the Customer for all the channels
+func (p *consentBaseService) GetConsents(customerId string) (_ utils.Map, err error) {
+
+	log.Println("ConsentService::GetConsents - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "consent", "GetConsents", p.businessId)
+	defer span.EndWith(&err)
+
+	daoConsent := customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, customerId)
+	listdata, err := daoConsent.List(ctx, "", "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never expose the confirmation hash
+	results, _ := listdata[db_common.LIST_RESULT].([]utils.Map)
+	for _, value := range results {
+		delete(value, sales_common.FLD_CONSENT_CONFIRM_HASH)
+	}
+
+	log.Println("ConsentService::GetConsents - End ")
+	return listdata, nil
+}
+
+// ListHistory - List the consent changes of the Customer
+func (p *consentBaseService) ListHistory(customerId string, filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("ConsentService::ListHistory - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "consent", "ListHistory", p.businessId)
+	defer span.EndWith(&err)
+
+	daoEvent := customer_repository.NewCustomerConsentEventDao(p.dbRegion.GetClient(), p.businessId, customerId)
+	listdata, err := daoEvent.List(ctx, filter, sort, skip, limit)
+
+	log.Println("ConsentService::ListHistory - End ", err)
+	return listdata, err
+}
+
+// GrantConsent - Grant the consent for the channel, pending till confirmed if the channel needs double opt-in
+func (p *consentBaseService) GrantConsent(customerId string, channel string, source string) (_ utils.Map, err error) {
+
+	log.Println("ConsentService::GrantConsent - Begin ", customerId, channel, source)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "consent", "GrantConsent", p.businessId)
+	defer span.EndWith(&err)
+
+	sendTo, err := p.getContact(ctx, customerId, channel)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	if len(source) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340201", ErrorMsg: "Missing Source", ErrorDetail: "Source of the consent should be given"}
+		return utils.Map{}, err
+	}
+
+	dataConsent, err := p.findConsent(ctx, customerId, channel)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	status, _ := utils.GetMemberDataStr(dataConsent, sales_common.FLD_CONSENT_STATUS)
+	if status == sales_common.CONSENT_STATUS_GRANTED {
+		return dataConsent, nil
+	}
+
+	now := time.Now()
+	if !p.needsDoubleOptIn(ctx, channel) {
+		indata := utils.Map{
+			sales_common.FLD_CONSENT_STATUS:     sales_common.CONSENT_STATUS_GRANTED,
+			sales_common.FLD_CONSENT_SOURCE:     source,
+			sales_common.FLD_CONSENT_GRANTED_AT: now,
+		}
+		data, err := p.saveConsent(ctx, customerId, channel, dataConsent, indata)
+
+		log.Println("ConsentService::GrantConsent - End ", err)
+		return data, err
+	}
+
+	// Double opt-in, send the confirmation
+	requestedAt, err := sales_common.GetMemberDataTime(dataConsent, sales_common.FLD_CONSENT_REQUESTED_AT)
+	if status == sales_common.CONSENT_STATUS_PENDING && err == nil && now.Sub(requestedAt) < sales_common.VERIFY_RESEND_SECONDS*time.Second {
+		err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340204", ErrorMsg: "Confirmation already sent",
+			ErrorDetail: fmt.Sprintf("Confirmation can be resent after %d seconds", sales_common.VERIFY_RESEND_SECONDS)}
+		return utils.Map{}, err
+	}
+
+	// Signed Token, the nonce makes only the latest Token valid
+	expiry := now.Add(sales_common.CONSENT_CONFIRM_VALIDITY_HOURS * time.Hour)
+	nonce := utils.GenerateUniqueId("cnf")
+	token, err := sales_common.SignToken(customerId, channel, sales_common.CONSENT_CONFIRM_TOKEN_PURPOSE, strconv.FormatInt(expiry.Unix(), 10), nonce)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_CONSENT_STATUS:       sales_common.CONSENT_STATUS_PENDING,
+		sales_common.FLD_CONSENT_SOURCE:       source,
+		sales_common.FLD_CONSENT_REQUESTED_AT: now,
+		sales_common.FLD_CONSENT_CONFIRM_HASH: utils.SHA(nonce),
+	}
+	data, err := p.saveConsent(ctx, customerId, channel, dataConsent, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	err = sales_notifier.Send(sales_notifier.Message{
+		Channel:    channel,
+		To:         sendTo,
+		Purpose:    sales_notifier.PURPOSE_CONSENT_CONFIRM,
+		BusinessId: p.businessId,
+		Subject:    "Confirm your subscription",
+		Body:       "Use the link sent to confirm that you want to receive our offers. It is valid for " + strconv.Itoa(sales_common.CONSENT_CONFIRM_VALIDITY_HOURS) + " hours.",
+		Data:       utils.Map{sales_notifier.DATA_TOKEN: token, sales_common.FLD_CUSTOMER_ID: customerId},
+	})
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("ConsentService::GrantConsent - End pending confirmation")
+	return data, nil
+}
+
+// ConfirmConsent - Confirm the pending consent with the double opt-in Token
+func (p *consentBaseService) ConfirmConsent(token string) (_ utils.Map, err error) {
+
+	log.Println("ConsentService::ConfirmConsent - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "consent", "ConfirmConsent", p.businessId)
+	defer span.EndWith(&err)
+
+	errInvalid := &utils.AppError{ErrorCode: "S30340205", ErrorMsg: "Invalid Confirmation", ErrorDetail: "Confirmation is invalid or expired, subscribe again"}
+
+	values, err := sales_common.ParseSignedToken(token)
+	if err != nil || len(values) != 5 || values[2] != sales_common.CONSENT_CONFIRM_TOKEN_PURPOSE {
+		return utils.Map{}, errInvalid
+	}
+	customerId, channel, nonce := values[0], values[1], values[4]
+	expiryUnix, err := strconv.ParseInt(values[3], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiryUnix, 0)) {
+		return utils.Map{}, errInvalid
+	}
+
+	dataConsent, err := p.findConsent(ctx, customerId, channel)
+	if err != nil || dataConsent == nil {
+		return utils.Map{}, errInvalid
+	}
+
+	// Token is used already, a newer one was sent or the consent was withdrawn in the meantime
+	status, _ := utils.GetMemberDataStr(dataConsent, sales_common.FLD_CONSENT_STATUS)
+	confirmHash, _ := utils.GetMemberDataStr(dataConsent, sales_common.FLD_CONSENT_CONFIRM_HASH)
+	if status != sales_common.CONSENT_STATUS_PENDING || len(confirmHash) == 0 ||
+		subtle.ConstantTimeCompare([]byte(confirmHash), []byte(utils.SHA(nonce))) != 1 {
+		return utils.Map{}, errInvalid
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_CONSENT_STATUS:       sales_common.CONSENT_STATUS_GRANTED,
+		sales_common.FLD_CONSENT_SOURCE:       sales_common.CONSENT_SOURCE_CONFIRMATION,
+		sales_common.FLD_CONSENT_GRANTED_AT:   time.Now(),
+		sales_common.FLD_CONSENT_CONFIRM_HASH: "",
+	}
+	data, err := p.saveConsent(ctx, customerId, channel, dataConsent, indata)
+
+	log.Println("ConsentService::ConfirmConsent - End ", err)
+	return data, err
+}
+
+// WithdrawConsent - Withdraw the consent for the channel
+func (p *consentBaseService) WithdrawConsent(customerId string, channel string, source string) (_ utils.Map, err error) {
+
+	log.Println("ConsentService::WithdrawConsent - Begin ", customerId, channel, source)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "consent", "WithdrawConsent", p.businessId)
+	defer span.EndWith(&err)
+
+	if _, ok := consentContactFields[channel]; !ok {
+		err := &utils.AppError{ErrorCode: "S30340200", ErrorMsg: "Invalid Channel", ErrorDetail: "Consent can be given only for email, sms or whatsapp"}
+		return utils.Map{}, err
+	} else if len(source) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340201", ErrorMsg: "Missing Source", ErrorDetail: "Source of the consent should be given"}
+		return utils.Map{}, err
+	}
+
+	dataConsent, err := p.findConsent(ctx, customerId, channel)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	status, _ := utils.GetMemberDataStr(dataConsent, sales_common.FLD_CONSENT_STATUS)
+	if status == sales_common.CONSENT_STATUS_WITHDRAWN {
+		return dataConsent, nil
+	}
+
+	// Recorded even without an earlier consent, so that the opt-out is kept
+	indata := utils.Map{
+		sales_common.FLD_CONSENT_STATUS:       sales_common.CONSENT_STATUS_WITHDRAWN,
+		sales_common.FLD_CONSENT_SOURCE:       source,
+		sales_common.FLD_CONSENT_WITHDRAWN_AT: time.Now(),
+		sales_common.FLD_CONSENT_CONFIRM_HASH: "",
+	}
+	data, err := p.saveConsent(ctx, customerId, channel, dataConsent, indata)
+
+	log.Println("ConsentService::WithdrawConsent - End ", err)
+	return data, err
+}
+
+// UnsubscribeToken - Get the Token to withdraw the consent without login, e.g. for the unsubscribe link
+func (p *consentBaseService) UnsubscribeToken(customerId string, channel string) (string, error) {
+	if _, ok := consentContactFields[channel]; !ok {
+		err := &utils.AppError{ErrorCode: "S30340200", ErrorMsg: "Invalid Channel", ErrorDetail: "Consent can be given only for email, sms or whatsapp"}
+		return "", err
+	}
+	return consentUnsubscribeToken(customerId, channel)
+}
+
+// Unsubscribe - Withdraw the consent with the unsubscribe Token
+func (p *consentBaseService) Unsubscribe(token string) (utils.Map, error) {
+
+	log.Println("ConsentService::Unsubscribe - Begin")
+
+	values, err := sales_common.ParseSignedToken(token)
+	if err != nil || len(values) != 3 || values[2] != sales_common.CONSENT_UNSUBSCRIBE_TOKEN_PURPOSE {
+		err := &utils.AppError{ErrorCode: "S30340206", ErrorMsg: "Invalid Unsubscribe Token", ErrorDetail: "Given unsubscribe Token is not valid"}
+		return utils.Map{}, err
+	}
+
+	data, err := p.WithdrawConsent(values[0], values[1], sales_common.CONSENT_SOURCE_UNSUBSCRIBE)
+
+	log.Println("ConsentService::Unsubscribe - End ", err)
+	return data, err
+}
+
+// getContact - Get the email or phone of the Customer for the channel
+func (p *consentBaseService) getContact(ctx context.Context, customerId string, channel string) (string, error) {
+	contactField, ok := consentContactFields[channel]
+	if !ok {
+		err := &utils.AppError{ErrorCode: "S30340200", ErrorMsg: "Invalid Channel", ErrorDetail: "Consent can be given only for email, sms or whatsapp"}
+		return "", err
+	}
+
+	dataCustomer, err := p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340202", ErrorMsg: "Invalid CustomerId", ErrorDetail: "Given CustomerId is not exist"}
+		return "", err
+	}
+
+	sendTo, _ := utils.GetMemberDataStr(dataCustomer, contactField)
+	if len(sendTo) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340203", ErrorMsg: "Missing Contact", ErrorDetail: "Customer has no " + contactField + " for " + channel}
+		return "", err
+	}
+	return sendTo, nil
+}
+
+// needsDoubleOptIn - Whether the channel is in double_opt_in of the marketing_consent Preference
+func (p *consentBaseService) needsDoubleOptIn(ctx context.Context, channel string) bool {
+	dataPref, err := p.daoPreference.Get(ctx, sales_common.PREFERENCE_MARKETING_CONSENT)
+	if err != nil {
+		return false
+	}
+
+	channels, _ := sales_common.GetMemberDataStrArray(dataPref, sales_common.FLD_CONSENT_DOUBLE_OPTIN)
+	for _, value := range channels {
+		if value == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// findConsent - Get the consent of the Customer for the channel, nil if never given
+func (p *consentBaseService) findConsent(ctx context.Context, customerId string, channel string) (utils.Map, error) {
+	daoConsent := customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, customerId)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CONSENT_CHANNEL: channel})
+	if err != nil {
+		return nil, err
+	}
+	dataConsent, err := daoConsent.Find(ctx, filter)
+	if err != nil {
+		// Not found
+		return nil, nil
+	}
+	return dataConsent, nil
+}
+
+// saveConsent - Create or update the consent and append the change to the history
+func (p *consentBaseService) saveConsent(ctx context.Context, customerId string, channel string, dataConsent utils.Map, indata utils.Map) (utils.Map, error) {
+	daoConsent := customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, customerId)
+
+	var data utils.Map
+	var err error
+	if dataConsent == nil {
+		indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+		indata[sales_common.FLD_CUSTOMER_ID] = customerId
+		indata[sales_common.FLD_CONSENT_ID] = utils.GenerateUniqueId("cnst")
+		indata[sales_common.FLD_CONSENT_CHANNEL] = channel
+
+		data, err = daoConsent.Create(ctx, indata)
+	} else {
+		consentId, _ := utils.GetMemberDataStr(dataConsent, sales_common.FLD_CONSENT_ID)
+
+		data, err = daoConsent.Update(ctx, consentId, indata)
+	}
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	event := utils.Map{
+		sales_common.FLD_BUSINESS_ID:      p.businessId,
+		sales_common.FLD_CUSTOMER_ID:      customerId,
+		sales_common.FLD_CONSENT_EVENT_ID: utils.GenerateUniqueId("cnev"),
+		sales_common.FLD_CONSENT_ID:       data[sales_common.FLD_CONSENT_ID],
+		sales_common.FLD_CONSENT_CHANNEL:  channel,
+		sales_common.FLD_CONSENT_STATUS:   indata[sales_common.FLD_CONSENT_STATUS],
+		sales_common.FLD_CONSENT_SOURCE:   indata[sales_common.FLD_CONSENT_SOURCE],
+	}
+	daoEvent := customer_repository.NewCustomerConsentEventDao(p.dbRegion.GetClient(), p.businessId, customerId)
+	_, err = daoEvent.Create(ctx, event)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Never expose the confirmation hash
+	delete(data, sales_common.FLD_CONSENT_CONFIRM_HASH)
+	return data, nil
+}
+
+// consentUnsubscribeToken - Signed Token with the Customer and channel to withdraw the consent
+func consentUnsubscribeToken(customerId string, channel string) (string, error) {
+	return sales_common.SignToken(customerId, channel, sales_common.CONSENT_UNSUBSCRIBE_TOKEN_PURPOSE)
+}
+
+func (p *consentBaseService) errorReturn(err error) (ConsentService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
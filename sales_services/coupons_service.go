@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -39,6 +41,7 @@ type couponBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       CouponService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewCouponService - Construct Coupon
@@ -68,6 +71,7 @@ func NewCouponService(props utils.Map) (CouponService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *couponBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *couponBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *couponBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("CouponBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCoupon.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCoupon.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *couponBaseService) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Find By Code
-func (p *couponBaseService) Get(couponId string) (utils.Map, error) {
+func (p *couponBaseService) Get(couponId string) (_ utils.Map, err error) {
 	log.Printf("couponBaseService::Get::  Begin %v", couponId)
 
-	data, err := p.daoCoupon.Get(couponId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCoupon.Get(ctx, couponId)
 
 	log.Println("couponBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *couponBaseService) Find(filter string) (utils.Map, error) {
+func (p *couponBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("couponBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCoupon.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCoupon.Find(ctx, filter)
 	log.Println("couponBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *couponBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *couponBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CouponService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var couponId string
 
 	dataval, dataok := indata[sales_common.FLD_COUPON_ID]
@@ -147,7 +164,7 @@ func (p *couponBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_COUPON_ID] = couponId
 
-	data, err := p.daoCoupon.Create(indata)
+	data, err := p.daoCoupon.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *couponBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *couponBaseService) Update(couponId string, indata utils.Map) (utils.Map, error) {
+func (p *couponBaseService) Update(couponId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CouponService::Update - Begin")
 
-	data, err := p.daoCoupon.Update(couponId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCoupon.Update(ctx, couponId, indata)
 
 	log.Println("CouponService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *couponBaseService) Delete(couponId string, delete_permanent bool) error {
+func (p *couponBaseService) Delete(couponId string, delete_permanent bool) (err error) {
 
 	log.Println("CouponService::Delete - Begin", couponId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "coupon", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCoupon.Delete(couponId)
+		result, err := p.daoCoupon.Delete(ctx, couponId)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,496 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreditLedger - Credit of the Customers and Dealers buying on payment terms.
+//
+// Each Order bought on credit raises an Invoice due after the payment terms of the Account and adds to the
+// outstanding balance, only if it stays within the credit limit. Payments against the Invoice reduce the
+// outstanding. Failed Orders void the Invoice and release its unpaid amount. The Account and its Invoices are in
+// one currency, the amounts are Decimal128 in the decimals of the currency and computed as big.Rat.
+type CreditLedger struct {
+	businessId  string
+	daoAccount  sales_repository.CreditAccountDao
+	daoInvoice  sales_repository.CreditInvoiceDao
+	daoCustomer sales_repository.CustomerDao
+	daoDealer   sales_repository.DealerDao
+}
+
+// NewCreditLedger - Construct CreditLedger on the Region database client
+func NewCreditLedger(client utils.Map, businessId string) *CreditLedger {
+	return &CreditLedger{
+		businessId:  businessId,
+		daoAccount:  sales_repository.NewCreditAccountDao(client, businessId),
+		daoInvoice:  sales_repository.NewCreditInvoiceDao(client, businessId),
+		daoCustomer: sales_repository.NewCustomerDao(client, businessId),
+		daoDealer:   sales_repository.NewDealerDao(client, businessId),
+	}
+}
+
+// GetAccount - Get the Credit Account of the Customer or Dealer
+func (l *CreditLedger) GetAccount(ctx context.Context, partyType string, partyId string) (_ utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "GetAccount", l.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CREDIT_PARTY_TYPE: partyType, sales_common.FLD_CREDIT_PARTY_ID: partyId})
+	if err != nil {
+		return nil, err
+	}
+	dataAccount, err := l.daoAccount.Find(ctx, filter)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340210", ErrorMsg: "No Credit Account", ErrorDetail: "The " + partyType + " " + partyId + " can not buy on credit"}
+		return nil, err
+	}
+	return dataAccount, nil
+}
+
+// SetTerms - Set the currency, credit limit and payment terms of the Customer or Dealer, creates the Account if not
+// exist. The limit can be set below the outstanding, then no more Orders are accepted on credit till paid. The
+// currency can be changed only while nothing is outstanding
+func (l *CreditLedger) SetTerms(ctx context.Context, partyType string, partyId string, currency string, creditLimit primitive.Decimal128, termsDays int) (_ utils.Map, err error) {
+
+	log.Println("CreditLedger::SetTerms - Begin ", partyType, partyId, currency, creditLimit, termsDays)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "SetTerms", l.businessId)
+	defer span.EndWith(&err)
+
+	if !sales_common.IsValidCurrency(currency) {
+		err := &utils.AppError{ErrorCode: "S30340240", ErrorMsg: "Invalid Currency", ErrorDetail: sales_common.FLD_CURRENCY + " should be an ISO 4217 code, e.g. INR"}
+		return nil, err
+	}
+	scale := sales_common.CurrencyScale(currency)
+	limitRat, err := sales_common.DecimalToRat(creditLimit)
+	if err != nil || limitRat.Sign() < 0 || termsDays < 0 {
+		err := &utils.AppError{ErrorCode: "S30340213", ErrorMsg: "Invalid Credit Terms", ErrorDetail: "Credit limit and payment terms days should not be negative"}
+		return nil, err
+	} else if !sales_common.HasScale(limitRat, scale) {
+		err := &utils.AppError{ErrorCode: "S30340213", ErrorMsg: "Invalid Credit Terms", ErrorDetail: fmt.Sprintf("Credit limit should have at most %d decimals in %s", scale, currency)}
+		return nil, err
+	}
+
+	switch partyType {
+	case sales_common.CREDIT_PARTY_CUSTOMER:
+		_, err = l.daoCustomer.Get(ctx, partyId)
+	case sales_common.CREDIT_PARTY_DEALER:
+		_, err = l.daoDealer.Get(ctx, partyId)
+	default:
+		err = fmt.Errorf("invalid party type %s", partyType)
+	}
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340214", ErrorMsg: "Invalid Party", ErrorDetail: "Given " + partyType + " " + partyId + " is not exist"}
+		return nil, err
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_CURRENCY:          currency,
+		sales_common.FLD_CREDIT_LIMIT:      sales_common.RatToDecimal(limitRat, scale),
+		sales_common.FLD_CREDIT_TERMS_DAYS: termsDays,
+	}
+
+	dataAccount, err := l.GetAccount(ctx, partyType, partyId)
+	if err != nil {
+		indata[sales_common.FLD_BUSINESS_ID] = l.businessId
+		indata[sales_common.FLD_CREDIT_ACCOUNT_ID] = utils.GenerateUniqueId("cacc")
+		indata[sales_common.FLD_CREDIT_PARTY_TYPE] = partyType
+		indata[sales_common.FLD_CREDIT_PARTY_ID] = partyId
+		indata[sales_common.FLD_CREDIT_OUTSTANDING] = sales_common.RatToDecimal(new(big.Rat), scale)
+
+		dataAccount, err = l.daoAccount.Create(ctx, indata)
+	} else {
+		creditAccountId, _ := utils.GetMemberDataStr(dataAccount, sales_common.FLD_CREDIT_ACCOUNT_ID)
+		accountCurrency, _ := utils.GetMemberDataStr(dataAccount, sales_common.FLD_CURRENCY)
+		// Accounts created before the currency was added get it set by the terms
+		if len(accountCurrency) > 0 && accountCurrency != currency && accountOutstanding(dataAccount).Sign() != 0 {
+			err := &utils.AppError{ErrorCode: "S30340218", ErrorMsg: "Currency change not allowed", ErrorDetail: "Credit Account of the " + partyType + " " + partyId + " has outstanding in " + accountCurrency}
+			return nil, err
+		}
+
+		dataAccount, err = l.daoAccount.Update(ctx, creditAccountId, indata)
+	}
+
+	log.Println("CreditLedger::SetTerms - End ", err)
+	return dataAccount, err
+}
+
+// Charge - Raise the Invoice for the Order in the currency of the Account of the Customer or Dealer, fails if the
+// outstanding would exceed the credit limit
+func (l *CreditLedger) Charge(ctx context.Context, partyType string, partyId string, custOrderId string, currency string, amount primitive.Decimal128) (_ utils.Map, err error) {
+
+	log.Println("CreditLedger::Charge - Begin ", partyType, partyId, custOrderId, currency, amount)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "Charge", l.businessId)
+	defer span.EndWith(&err)
+
+	dataAccount, err := l.GetAccount(ctx, partyType, partyId)
+	if err != nil {
+		return nil, err
+	}
+	creditAccountId, _ := utils.GetMemberDataStr(dataAccount, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	termsDays, _ := sales_common.GetMemberDataFloat(dataAccount, sales_common.FLD_CREDIT_TERMS_DAYS)
+	if accountCurrency, _ := utils.GetMemberDataStr(dataAccount, sales_common.FLD_CURRENCY); accountCurrency != currency {
+		err := &utils.AppError{ErrorCode: "S30340217", ErrorMsg: "Currency mismatch", ErrorDetail: "Credit Account of the " + partyType + " " + partyId + " is in " + accountCurrency + ", not " + currency}
+		return nil, err
+	}
+
+	scale := sales_common.CurrencyScale(currency)
+	amountRat, err := sales_common.DecimalToRat(amount)
+	if err != nil || amountRat.Sign() < 0 {
+		err := &utils.AppError{ErrorCode: "S30340215", ErrorMsg: "Invalid Amount", ErrorDetail: "Invoice amount should not be negative"}
+		return nil, err
+	}
+	amount = sales_common.RatToDecimal(amountRat, scale)
+	if amountRat.Sign() > 0 {
+		// Add to the outstanding first, so that the limit is verified atomically
+		_, err = l.daoAccount.ApplyOutstanding(ctx, creditAccountId, amount)
+		if err != nil {
+			available := new(big.Rat)
+			if creditLimit, err := getMemberDataRat(dataAccount, sales_common.FLD_CREDIT_LIMIT); err == nil {
+				available.Sub(creditLimit, accountOutstanding(dataAccount))
+			}
+			if available.Sign() < 0 {
+				available.SetInt64(0)
+			}
+			err := &utils.AppError{ErrorCode: "S30340211", ErrorMsg: "Credit Limit exceeded",
+				ErrorDetail: fmt.Sprintf("Order of %s exceeds the available credit of %s %s", amountRat.FloatString(scale), available.FloatString(scale), currency)}
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	invoice := utils.Map{
+		sales_common.FLD_BUSINESS_ID:       l.businessId,
+		sales_common.FLD_INVOICE_ID:        utils.GenerateUniqueId("cinv"),
+		sales_common.FLD_CREDIT_ACCOUNT_ID: creditAccountId,
+		sales_common.FLD_CREDIT_PARTY_TYPE: partyType,
+		sales_common.FLD_CREDIT_PARTY_ID:   partyId,
+		sales_common.FLD_CUSTOMER_ORDER_ID: custOrderId,
+		sales_common.FLD_CURRENCY:          currency,
+		sales_common.FLD_INVOICE_AMOUNT:    amount,
+		sales_common.FLD_INVOICE_PAID:      sales_common.RatToDecimal(new(big.Rat), scale),
+		sales_common.FLD_INVOICE_STATUS:    sales_common.INVOICE_STATUS_OPEN,
+		sales_common.FLD_INVOICE_DUE_AT:    now.AddDate(0, 0, int(termsDays)),
+		sales_common.FLD_INVOICE_PAYMENTS:  []utils.Map{},
+	}
+	if amountRat.Sign() == 0 {
+		invoice[sales_common.FLD_INVOICE_STATUS] = sales_common.INVOICE_STATUS_PAID
+		invoice[sales_common.FLD_INVOICE_PAID_AT] = now
+	}
+
+	dataInvoice, err := l.daoInvoice.Create(ctx, invoice)
+	if err != nil {
+		// Release the charged amount
+		if amountRat.Sign() > 0 {
+			l.applyOutstanding(ctx, creditAccountId, new(big.Rat).Neg(amountRat), currency)
+		}
+		return nil, err
+	}
+
+	log.Println("CreditLedger::Charge - End ", dataInvoice[sales_common.FLD_INVOICE_ID])
+	return dataInvoice, nil
+}
+
+// Void - Void the open Invoice of the Order and release its unpaid amount, nothing to do if the Order
+// has no open Invoice
+func (l *CreditLedger) Void(ctx context.Context, custOrderId string) (err error) {
+
+	log.Println("CreditLedger::Void - Begin ", custOrderId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "Void", l.businessId)
+	defer span.EndWith(&err)
+
+	// Voided atomically, the unpaid amount is released from the Invoice as it was when voided
+	dataInvoice, err := l.daoInvoice.VoidByOrder(ctx, custOrderId)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	invoiceId, _ := utils.GetMemberDataStr(dataInvoice, sales_common.FLD_INVOICE_ID)
+	creditAccountId, _ := utils.GetMemberDataStr(dataInvoice, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	currency, _ := utils.GetMemberDataStr(dataInvoice, sales_common.FLD_CURRENCY)
+
+	err = l.applyOutstanding(ctx, creditAccountId, new(big.Rat).Neg(invoiceDue(dataInvoice)), currency)
+
+	log.Println("CreditLedger::Void - End ", invoiceId, err)
+	return err
+}
+
+// Pay - Record the payment in the currency of the open Invoice, the Invoice is paid when the whole amount is paid
+func (l *CreditLedger) Pay(ctx context.Context, invoiceId string, amount primitive.Decimal128, reference string) (_ utils.Map, err error) {
+
+	log.Println("CreditLedger::Pay - Begin ", invoiceId, amount, reference)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "Pay", l.businessId)
+	defer span.EndWith(&err)
+
+	dataInvoice, err := l.daoInvoice.Get(ctx, invoiceId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340212", ErrorMsg: "Invalid Payment", ErrorDetail: "Invoice " + invoiceId + " is not exist"}
+		return nil, err
+	}
+	currency, _ := utils.GetMemberDataStr(dataInvoice, sales_common.FLD_CURRENCY)
+	scale := sales_common.CurrencyScale(currency)
+
+	amountRat, err := sales_common.DecimalToRat(amount)
+	if err != nil || amountRat.Sign() <= 0 || !sales_common.HasScale(amountRat, scale) {
+		err := &utils.AppError{ErrorCode: "S30340215", ErrorMsg: "Invalid Amount", ErrorDetail: fmt.Sprintf("Payment amount should be greater than 0 with at most %d decimals in %s", scale, currency)}
+		return nil, err
+	}
+	amount = sales_common.RatToDecimal(amountRat, scale)
+
+	now := time.Now()
+	payment := utils.Map{
+		sales_common.FLD_INVOICE_PAYMENT_AMOUNT: amount,
+		sales_common.FLD_INVOICE_PAYMENT_REF:    reference,
+		sales_common.FLD_INVOICE_PAID_AT:        now,
+	}
+	dataInvoice, err = l.daoInvoice.ApplyPayment(ctx, invoiceId, amount, payment)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340212", ErrorMsg: "Invalid Payment", ErrorDetail: "Invoice " + invoiceId + " is not open or the payment exceeds the amount due"}
+		return nil, err
+	}
+
+	creditAccountId, _ := utils.GetMemberDataStr(dataInvoice, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	err = l.applyOutstanding(ctx, creditAccountId, new(big.Rat).Neg(amountRat), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoiceDue(dataInvoice).Sign() <= 0 {
+		indata := utils.Map{
+			sales_common.FLD_INVOICE_STATUS:  sales_common.INVOICE_STATUS_PAID,
+			sales_common.FLD_INVOICE_PAID_AT: now,
+		}
+		dataInvoice, err = l.daoInvoice.Update(ctx, invoiceId, indata)
+	}
+
+	log.Println("CreditLedger::Pay - End ", err)
+	return dataInvoice, err
+}
+
+// Aging - Due amount of the open Invoices by the days past due as of now, of one Account or all the
+// Accounts of the party type if partyId is empty, or all the Accounts if both are empty. The totals are
+// by currency
+func (l *CreditLedger) Aging(ctx context.Context, partyType string, partyId string) (_ utils.Map, err error) {
+
+	log.Println("CreditLedger::Aging - Begin ", partyType, partyId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "Aging", l.businessId)
+	defer span.EndWith(&err)
+
+	filterData := utils.Map{sales_common.FLD_INVOICE_STATUS: sales_common.INVOICE_STATUS_OPEN}
+	if len(partyType) > 0 {
+		filterData[sales_common.FLD_CREDIT_PARTY_TYPE] = partyType
+	}
+	if len(partyId) > 0 {
+		filterData[sales_common.FLD_CREDIT_PARTY_ID] = partyId
+	}
+	filter, err := sales_common.BuildFilter(filterData)
+	if err != nil {
+		return nil, err
+	}
+	sort := `{"` + sales_common.FLD_CREDIT_ACCOUNT_ID + `": 1, "` + sales_common.FLD_INVOICE_DUE_AT + `": 1}`
+
+	listdata, err := l.daoInvoice.List(ctx, filter, sort, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	invoices, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	now := time.Now()
+	totals := utils.Map{}
+	rows := []utils.Map{}
+	rowIndex := map[string]utils.Map{}
+	for _, invoice := range invoices {
+		creditAccountId, _ := utils.GetMemberDataStr(invoice, sales_common.FLD_CREDIT_ACCOUNT_ID)
+		currency, _ := utils.GetMemberDataStr(invoice, sales_common.FLD_CURRENCY)
+		row, ok := rowIndex[creditAccountId]
+		if !ok {
+			row = newAgingBuckets()
+			row[sales_common.FLD_CREDIT_ACCOUNT_ID] = creditAccountId
+			row[sales_common.FLD_CREDIT_PARTY_TYPE] = invoice[sales_common.FLD_CREDIT_PARTY_TYPE]
+			row[sales_common.FLD_CREDIT_PARTY_ID] = invoice[sales_common.FLD_CREDIT_PARTY_ID]
+			row[sales_common.FLD_CURRENCY] = currency
+			rowIndex[creditAccountId] = row
+			rows = append(rows, row)
+		}
+		if _, ok := totals[currency]; !ok {
+			totals[currency] = newAgingBuckets()
+		}
+
+		dueAt, _ := sales_common.GetMemberDataTime(invoice, sales_common.FLD_INVOICE_DUE_AT)
+		bucket := agingBucket(now.Sub(dueAt))
+		due := invoiceDue(invoice)
+		for _, buckets := range []utils.Map{row, totals[currency].(utils.Map)} {
+			buckets[bucket].(*big.Rat).Add(buckets[bucket].(*big.Rat), due)
+			buckets[sales_common.AGING_TOTAL].(*big.Rat).Add(buckets[sales_common.AGING_TOTAL].(*big.Rat), due)
+		}
+	}
+
+	// Amounts in the decimals of the currency
+	for _, row := range rows {
+		currency, _ := utils.GetMemberDataStr(row, sales_common.FLD_CURRENCY)
+		agingDecimals(row, currency)
+	}
+	for currency, buckets := range totals {
+		agingDecimals(buckets.(utils.Map), currency)
+	}
+
+	report := utils.Map{
+		sales_common.AGING_REPORT_AS_OF:  now,
+		sales_common.AGING_REPORT_ROWS:   rows,
+		sales_common.AGING_REPORT_TOTALS: totals,
+	}
+
+	log.Println("CreditLedger::Aging - End ", len(rows))
+	return report, nil
+}
+
+// MergeAccounts - Move the Account of the party to the other party on merge. The Account is re-pointed if
+// the other party has no Account, else its Invoices are moved and the outstanding of the other Account is
+// rebuilt from the open Invoices. Safe to run again, returns the number of Invoices moved
+func (l *CreditLedger) MergeAccounts(ctx context.Context, partyType string, fromPartyId string, toPartyId string) (_ int64, err error) {
+
+	log.Println("CreditLedger::MergeAccounts - Begin ", partyType, fromPartyId, toPartyId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "credit_ledger", "MergeAccounts", l.businessId)
+	defer span.EndWith(&err)
+
+	fromAccount, err := l.GetAccount(ctx, partyType, fromPartyId)
+	if err != nil {
+		// No Account to move
+		return 0, nil
+	}
+	fromAccountId, _ := utils.GetMemberDataStr(fromAccount, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	fromCurrency, _ := utils.GetMemberDataStr(fromAccount, sales_common.FLD_CURRENCY)
+
+	toAccount, err := l.GetAccount(ctx, partyType, toPartyId)
+	if err != nil {
+		_, err = l.daoAccount.Update(ctx, fromAccountId, utils.Map{sales_common.FLD_CREDIT_PARTY_ID: toPartyId})
+		if err != nil {
+			return 0, err
+		}
+		return l.daoInvoice.ReassignAccount(ctx, fromAccountId, fromAccountId, toPartyId)
+	}
+	toAccountId, _ := utils.GetMemberDataStr(toAccount, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	if toCurrency, _ := utils.GetMemberDataStr(toAccount, sales_common.FLD_CURRENCY); toCurrency != fromCurrency {
+		err := &utils.AppError{ErrorCode: "S30340217", ErrorMsg: "Currency mismatch", ErrorDetail: "Credit Account of the " + partyType + " " + fromPartyId + " is in " + fromCurrency + ", not " + toCurrency}
+		return 0, err
+	}
+
+	moved, err := l.daoInvoice.ReassignAccount(ctx, fromAccountId, toAccountId, toPartyId)
+	if err != nil {
+		return 0, err
+	}
+	err = l.rebuildOutstanding(ctx, toAccountId, fromCurrency)
+	if err != nil {
+		return moved, err
+	}
+	_, err = l.daoAccount.Delete(ctx, fromAccountId)
+
+	log.Println("CreditLedger::MergeAccounts - End ", moved, err)
+	return moved, err
+}
+
+// rebuildOutstanding - Set the outstanding of the Account to the due amount of its open Invoices
+func (l *CreditLedger) rebuildOutstanding(ctx context.Context, creditAccountId string, currency string) error {
+	filter, err := sales_common.BuildFilter(utils.Map{
+		sales_common.FLD_CREDIT_ACCOUNT_ID: creditAccountId,
+		sales_common.FLD_INVOICE_STATUS:    sales_common.INVOICE_STATUS_OPEN,
+	})
+	if err != nil {
+		return err
+	}
+	listdata, err := l.daoInvoice.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	invoices, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	outstanding := new(big.Rat)
+	for _, invoice := range invoices {
+		outstanding.Add(outstanding, invoiceDue(invoice))
+	}
+	_, err = l.daoAccount.Update(ctx, creditAccountId, utils.Map{sales_common.FLD_CREDIT_OUTSTANDING: sales_common.RatToDecimal(outstanding, sales_common.CurrencyScale(currency))})
+	return err
+}
+
+// applyOutstanding - Add the amount, in the decimals of the currency, to the outstanding of the Account
+func (l *CreditLedger) applyOutstanding(ctx context.Context, creditAccountId string, amount *big.Rat, currency string) error {
+	_, err := l.daoAccount.ApplyOutstanding(ctx, creditAccountId, sales_common.RatToDecimal(amount, sales_common.CurrencyScale(currency)))
+	if err != nil {
+		log.Println("CreditLedger::applyOutstanding - Failed ", creditAccountId, amount, err)
+	}
+	return err
+}
+
+// accountOutstanding - Outstanding of the Account, zero if not set
+func accountOutstanding(dataAccount utils.Map) *big.Rat {
+	if outstanding, err := getMemberDataRat(dataAccount, sales_common.FLD_CREDIT_OUTSTANDING); err == nil {
+		return outstanding
+	}
+	return new(big.Rat)
+}
+
+// invoiceDue - Unpaid amount of the Invoice
+func invoiceDue(dataInvoice utils.Map) *big.Rat {
+	due := new(big.Rat)
+	if amount, err := getMemberDataRat(dataInvoice, sales_common.FLD_INVOICE_AMOUNT); err == nil {
+		due.Set(amount)
+	}
+	if paid, err := getMemberDataRat(dataInvoice, sales_common.FLD_INVOICE_PAID); err == nil {
+		due.Sub(due, paid)
+	}
+	return due
+}
+
+// agingBucket - Bucket for the time past the due date
+func agingBucket(pastDue time.Duration) string {
+	days := int(pastDue.Hours() / 24)
+	switch {
+	case pastDue <= 0:
+		return sales_common.AGING_CURRENT
+	case days <= 30:
+		return sales_common.AGING_DAYS_1_30
+	case days <= 60:
+		return sales_common.AGING_DAYS_31_60
+	case days <= 90:
+		return sales_common.AGING_DAYS_61_90
+	}
+	return sales_common.AGING_DAYS_OVER_90
+}
+
+// agingBuckets - Buckets of the Aging report, in the order of the days past due
+var agingBuckets = []string{sales_common.AGING_CURRENT, sales_common.AGING_DAYS_1_30, sales_common.AGING_DAYS_31_60,
+	sales_common.AGING_DAYS_61_90, sales_common.AGING_DAYS_OVER_90, sales_common.AGING_TOTAL}
+
+// newAgingBuckets - Buckets summed as big.Rat
+func newAgingBuckets() utils.Map {
+	buckets := utils.Map{}
+	for _, bucket := range agingBuckets {
+		buckets[bucket] = new(big.Rat)
+	}
+	return buckets
+}
+
+// agingDecimals - Convert the summed buckets to decimal in the decimals of the currency
+func agingDecimals(buckets utils.Map, currency string) {
+	for _, bucket := range agingBuckets {
+		buckets[bucket] = sales_common.RatToDecimal(buckets[bucket].(*big.Rat), sales_common.CurrencyScale(currency))
+	}
+}
@@ -0,0 +1,373 @@
+package sales_services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeCreditAccountDao - Accounts in memory, the outstanding is added only within the limit like the Mongo DAO
+type fakeCreditAccountDao struct {
+	sales_repository.CreditAccountDao
+	t        *testing.T
+	accounts map[string]utils.Map
+}
+
+func (f *fakeCreditAccountDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	for _, account := range f.accounts {
+		if matchFilter(f.t, account, filter) {
+			return copyMap(account), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeCreditAccountDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	creditAccountId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CREDIT_ACCOUNT_ID)
+	f.accounts[creditAccountId] = copyMap(indata)
+	return indata, nil
+}
+
+func (f *fakeCreditAccountDao) Update(ctx context.Context, creditAccountId string, indata utils.Map) (utils.Map, error) {
+	account, ok := f.accounts[creditAccountId]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	for key, value := range indata {
+		account[key] = value
+	}
+	return copyMap(account), nil
+}
+
+func (f *fakeCreditAccountDao) ApplyOutstanding(ctx context.Context, creditAccountId string, amount primitive.Decimal128) (utils.Map, error) {
+	account, ok := f.accounts[creditAccountId]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	amountRat, _ := sales_common.DecimalToRat(amount)
+	outstanding := new(big.Rat).Add(accountOutstanding(account), amountRat)
+	creditLimit, _ := getMemberDataRat(account, sales_common.FLD_CREDIT_LIMIT)
+	if amountRat.Sign() > 0 && outstanding.Cmp(creditLimit) > 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	account[sales_common.FLD_CREDIT_OUTSTANDING] = sales_common.RatToDecimal(outstanding, 2)
+	return copyMap(account), nil
+}
+
+// fakeCreditInvoiceDao - Invoices in the order created
+type fakeCreditInvoiceDao struct {
+	sales_repository.CreditInvoiceDao
+	t        *testing.T
+	invoices []utils.Map
+}
+
+func (f *fakeCreditInvoiceDao) find(invoiceId string) utils.Map {
+	for _, invoice := range f.invoices {
+		if invoice[sales_common.FLD_INVOICE_ID] == invoiceId {
+			return invoice
+		}
+	}
+	return nil
+}
+
+func (f *fakeCreditInvoiceDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	return listMatching(f.t, f.invoices, filter), nil
+}
+
+func (f *fakeCreditInvoiceDao) Get(ctx context.Context, invoiceId string) (utils.Map, error) {
+	if invoice := f.find(invoiceId); invoice != nil {
+		return copyMap(invoice), nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeCreditInvoiceDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	f.invoices = append(f.invoices, copyMap(indata))
+	return indata, nil
+}
+
+func (f *fakeCreditInvoiceDao) Update(ctx context.Context, invoiceId string, indata utils.Map) (utils.Map, error) {
+	invoice := f.find(invoiceId)
+	for key, value := range indata {
+		invoice[key] = value
+	}
+	return copyMap(invoice), nil
+}
+
+func (f *fakeCreditInvoiceDao) ApplyPayment(ctx context.Context, invoiceId string, amount primitive.Decimal128, payment utils.Map) (utils.Map, error) {
+	invoice := f.find(invoiceId)
+	amountRat, _ := sales_common.DecimalToRat(amount)
+	if invoice == nil || invoice[sales_common.FLD_INVOICE_STATUS] != sales_common.INVOICE_STATUS_OPEN || amountRat.Cmp(invoiceDue(invoice)) > 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	paid, _ := getMemberDataRat(invoice, sales_common.FLD_INVOICE_PAID)
+	invoice[sales_common.FLD_INVOICE_PAID] = sales_common.RatToDecimal(paid.Add(paid, amountRat), 2)
+	invoice[sales_common.FLD_INVOICE_PAYMENTS] = append(invoice[sales_common.FLD_INVOICE_PAYMENTS].([]utils.Map), payment)
+	return copyMap(invoice), nil
+}
+
+func (f *fakeCreditInvoiceDao) VoidByOrder(ctx context.Context, custOrderId string) (utils.Map, error) {
+	for _, invoice := range f.invoices {
+		if invoice[sales_common.FLD_CUSTOMER_ORDER_ID] == custOrderId && invoice[sales_common.FLD_INVOICE_STATUS] == sales_common.INVOICE_STATUS_OPEN {
+			before := copyMap(invoice)
+			invoice[sales_common.FLD_INVOICE_STATUS] = sales_common.INVOICE_STATUS_VOID
+			return before, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// newTestCreditLedger - Customer cust1 with the Account acc1 in USD, limit 1000.00 and the outstanding given
+func newTestCreditLedger(t *testing.T, outstanding string) (*CreditLedger, *fakeCreditAccountDao, *fakeCreditInvoiceDao) {
+	daoAccount := &fakeCreditAccountDao{t: t, accounts: map[string]utils.Map{
+		"acc1": {
+			sales_common.FLD_CREDIT_ACCOUNT_ID:  "acc1",
+			sales_common.FLD_CREDIT_PARTY_TYPE:  sales_common.CREDIT_PARTY_CUSTOMER,
+			sales_common.FLD_CREDIT_PARTY_ID:    "cust1",
+			sales_common.FLD_CURRENCY:           "USD",
+			sales_common.FLD_CREDIT_LIMIT:       decimalOf(t, "1000.00"),
+			sales_common.FLD_CREDIT_TERMS_DAYS:  30,
+			sales_common.FLD_CREDIT_OUTSTANDING: decimalOf(t, outstanding),
+		},
+	}}
+	daoInvoice := &fakeCreditInvoiceDao{t: t}
+	l := &CreditLedger{
+		businessId:  "biz1",
+		daoAccount:  daoAccount,
+		daoInvoice:  daoInvoice,
+		daoCustomer: &fakeCustomerDao{customer: utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}},
+	}
+	return l, daoAccount, daoInvoice
+}
+
+func outstandingOf(daoAccount *fakeCreditAccountDao, creditAccountId string) string {
+	return daoAccount.accounts[creditAccountId][sales_common.FLD_CREDIT_OUTSTANDING].(primitive.Decimal128).String()
+}
+
+func TestCreditCharge(t *testing.T) {
+	tests := []struct {
+		name            string
+		outstanding     string
+		partyId         string
+		currency        string
+		amount          string
+		wantCode        string
+		wantAmount      string
+		wantStatus      string
+		wantOutstanding string
+	}{
+		{"within limit", "200.00", "cust1", "USD", "300.00", "", "300.00", sales_common.INVOICE_STATUS_OPEN, "500.00"},
+		{"up to limit", "200.00", "cust1", "USD", "800.00", "", "800.00", sales_common.INVOICE_STATUS_OPEN, "1000.00"},
+		{"over limit", "200.00", "cust1", "USD", "800.01", "S30340211", "", "", "200.00"},
+		{"over limit already", "1200.00", "cust1", "USD", "1.00", "S30340211", "", "", "1200.00"},
+		{"rounded to currency", "0.00", "cust1", "USD", "10.005", "", "10.01", sales_common.INVOICE_STATUS_OPEN, "10.01"},
+		{"nothing payable", "0.00", "cust1", "USD", "0", "", "0.00", sales_common.INVOICE_STATUS_PAID, "0.00"},
+		{"negative", "0.00", "cust1", "USD", "-1.00", "S30340215", "", "", "0.00"},
+		{"other currency", "0.00", "cust1", "EUR", "10.00", "S30340217", "", "", "0.00"},
+		{"no account", "0.00", "cust2", "USD", "10.00", "S30340210", "", "", "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, daoAccount, daoInvoice := newTestCreditLedger(t, tt.outstanding)
+
+			dataInvoice, err := l.Charge(context.Background(), sales_common.CREDIT_PARTY_CUSTOMER, tt.partyId, "ord1", tt.currency, decimalOf(t, tt.amount))
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("Charge() error = %v, want code %q", err, tt.wantCode)
+			}
+			if got := outstandingOf(daoAccount, "acc1"); got != tt.wantOutstanding {
+				t.Errorf("outstanding = %s, want %s", got, tt.wantOutstanding)
+			}
+			if err != nil {
+				if len(daoInvoice.invoices) != 0 {
+					t.Errorf("Charge() failed but created %v", daoInvoice.invoices)
+				}
+				return
+			}
+
+			if got := dataInvoice[sales_common.FLD_INVOICE_AMOUNT].(primitive.Decimal128).String(); got != tt.wantAmount {
+				t.Errorf("invoice amount = %s, want %s", got, tt.wantAmount)
+			}
+			if got := dataInvoice[sales_common.FLD_INVOICE_STATUS]; got != tt.wantStatus {
+				t.Errorf("invoice status = %v, want %s", got, tt.wantStatus)
+			}
+			if got := dataInvoice[sales_common.FLD_CURRENCY]; got != "USD" {
+				t.Errorf("invoice currency = %v, want USD", got)
+			}
+		})
+	}
+}
+
+func TestCreditPay(t *testing.T) {
+	l, daoAccount, _ := newTestCreditLedger(t, "0.00")
+	ctx := context.Background()
+
+	dataInvoice, err := l.Charge(ctx, sales_common.CREDIT_PARTY_CUSTOMER, "cust1", "ord1", "USD", decimalOf(t, "300.00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoiceId := dataInvoice[sales_common.FLD_INVOICE_ID].(string)
+
+	steps := []struct {
+		name            string
+		invoiceId       string
+		amount          string
+		wantCode        string
+		wantStatus      string
+		wantOutstanding string
+	}{
+		{"part", invoiceId, "100.00", "", sales_common.INVOICE_STATUS_OPEN, "200.00"},
+		{"more than due", invoiceId, "200.01", "S30340212", "", "200.00"},
+		{"finer than currency", invoiceId, "0.001", "S30340215", "", "200.00"},
+		{"zero", invoiceId, "0", "S30340215", "", "200.00"},
+		{"rest", invoiceId, "200.00", "", sales_common.INVOICE_STATUS_PAID, "0.00"},
+		{"already paid", invoiceId, "1.00", "S30340212", "", "0.00"},
+		{"unknown invoice", "cinv-x", "1.00", "S30340212", "", "0.00"},
+	}
+	for _, step := range steps {
+		dataInvoice, err := l.Pay(ctx, step.invoiceId, decimalOf(t, step.amount), "ref-"+step.name)
+		if got := errorCode(err); got != step.wantCode {
+			t.Fatalf("%s: Pay() error = %v, want code %q", step.name, err, step.wantCode)
+		}
+		if err == nil && dataInvoice[sales_common.FLD_INVOICE_STATUS] != step.wantStatus {
+			t.Errorf("%s: invoice status = %v, want %s", step.name, dataInvoice[sales_common.FLD_INVOICE_STATUS], step.wantStatus)
+		}
+		if got := outstandingOf(daoAccount, "acc1"); got != step.wantOutstanding {
+			t.Errorf("%s: outstanding = %s, want %s", step.name, got, step.wantOutstanding)
+		}
+	}
+}
+
+func TestCreditVoid(t *testing.T) {
+	l, daoAccount, daoInvoice := newTestCreditLedger(t, "0.00")
+	ctx := context.Background()
+
+	dataInvoice, err := l.Charge(ctx, sales_common.CREDIT_PARTY_CUSTOMER, "cust1", "ord1", "USD", decimalOf(t, "300.00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Pay(ctx, dataInvoice[sales_common.FLD_INVOICE_ID].(string), decimalOf(t, "100.00"), "ref1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the unpaid amount is released, and only once
+	for i := 0; i < 2; i++ {
+		if err := l.Void(ctx, "ord1"); err != nil {
+			t.Fatalf("Void() error = %v", err)
+		}
+		if got := outstandingOf(daoAccount, "acc1"); got != "0.00" {
+			t.Errorf("outstanding after Void() %d = %s, want 0.00", i+1, got)
+		}
+	}
+	if got := daoInvoice.invoices[0][sales_common.FLD_INVOICE_STATUS]; got != sales_common.INVOICE_STATUS_VOID {
+		t.Errorf("invoice status = %v, want void", got)
+	}
+
+	// Order without Invoice
+	if err := l.Void(ctx, "ord2"); err != nil {
+		t.Errorf("Void() without invoice error = %v", err)
+	}
+}
+
+func TestCreditSetTerms(t *testing.T) {
+	tests := []struct {
+		name            string
+		accountCurrency string
+		outstanding     string
+		currency        string
+		limit           string
+		termsDays       int
+		wantCode        string
+	}{
+		{"same currency", "USD", "200.00", "USD", "500.00", 45, ""},
+		{"limit below outstanding", "USD", "200.00", "USD", "100.00", 30, ""},
+		{"currency changed without outstanding", "USD", "0.00", "EUR", "500.00", 30, ""},
+		{"currency changed with outstanding", "USD", "200.00", "EUR", "500.00", 30, "S30340218"},
+		// Accounts created before the currency was added
+		{"currency set on legacy account", "", "200.00", "EUR", "500.00", 30, ""},
+		{"finer than currency", "USD", "0.00", "USD", "500.001", 30, "S30340213"},
+		{"negative limit", "USD", "0.00", "USD", "-1", 30, "S30340213"},
+		{"negative terms", "USD", "0.00", "USD", "500.00", -1, "S30340213"},
+		{"invalid currency", "USD", "0.00", "usd", "500.00", 30, "S30340240"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, daoAccount, _ := newTestCreditLedger(t, tt.outstanding)
+			daoAccount.accounts["acc1"][sales_common.FLD_CURRENCY] = tt.accountCurrency
+
+			_, err := l.SetTerms(context.Background(), sales_common.CREDIT_PARTY_CUSTOMER, "cust1", tt.currency, decimalOf(t, tt.limit), tt.termsDays)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("SetTerms() error = %v, want code %q", err, tt.wantCode)
+			}
+			if err == nil && daoAccount.accounts["acc1"][sales_common.FLD_CURRENCY] != tt.currency {
+				t.Errorf("currency = %v, want %s", daoAccount.accounts["acc1"][sales_common.FLD_CURRENCY], tt.currency)
+			}
+		})
+	}
+}
+
+func TestCreditAging(t *testing.T) {
+	l, _, daoInvoice := newTestCreditLedger(t, "0.00")
+	now := time.Now()
+
+	invoice := func(creditAccountId string, currency string, amount string, paid string, dueDays int, status string) utils.Map {
+		return utils.Map{
+			sales_common.FLD_INVOICE_ID:        utils.GenerateUniqueId("cinv"),
+			sales_common.FLD_CREDIT_ACCOUNT_ID: creditAccountId,
+			sales_common.FLD_CREDIT_PARTY_TYPE: sales_common.CREDIT_PARTY_CUSTOMER,
+			sales_common.FLD_CREDIT_PARTY_ID:   "party-" + creditAccountId,
+			sales_common.FLD_CURRENCY:          currency,
+			sales_common.FLD_INVOICE_AMOUNT:    decimalOf(t, amount),
+			sales_common.FLD_INVOICE_PAID:      decimalOf(t, paid),
+			sales_common.FLD_INVOICE_STATUS:    status,
+			sales_common.FLD_INVOICE_DUE_AT:    now.AddDate(0, 0, dueDays),
+		}
+	}
+	daoInvoice.invoices = []utils.Map{
+		invoice("acc1", "USD", "100.00", "0.00", 5, sales_common.INVOICE_STATUS_OPEN),
+		invoice("acc1", "USD", "200.00", "50.50", -10, sales_common.INVOICE_STATUS_OPEN),
+		invoice("acc1", "USD", "300.00", "0.00", -45, sales_common.INVOICE_STATUS_OPEN),
+		invoice("acc1", "USD", "400.00", "0.00", -100, sales_common.INVOICE_STATUS_OPEN),
+		invoice("acc1", "USD", "999.00", "0.00", -100, sales_common.INVOICE_STATUS_VOID),
+		invoice("acc2", "USD", "10.00", "0.00", -70, sales_common.INVOICE_STATUS_OPEN),
+		invoice("acc3", "JPY", "5000", "1000", -20, sales_common.INVOICE_STATUS_OPEN),
+	}
+
+	report, err := l.Aging(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("Aging() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		buckets utils.Map
+		want    map[string]string
+	}{
+		{"acc1", report[sales_common.AGING_REPORT_ROWS].([]utils.Map)[0], map[string]string{
+			sales_common.AGING_CURRENT: "100.00", sales_common.AGING_DAYS_1_30: "149.50", sales_common.AGING_DAYS_31_60: "300.00",
+			sales_common.AGING_DAYS_61_90: "0.00", sales_common.AGING_DAYS_OVER_90: "400.00", sales_common.AGING_TOTAL: "949.50"}},
+		{"USD", report[sales_common.AGING_REPORT_TOTALS].(utils.Map)["USD"].(utils.Map), map[string]string{
+			sales_common.AGING_DAYS_61_90: "10.00", sales_common.AGING_TOTAL: "959.50"}},
+		{"JPY", report[sales_common.AGING_REPORT_TOTALS].(utils.Map)["JPY"].(utils.Map), map[string]string{
+			sales_common.AGING_CURRENT: "0", sales_common.AGING_DAYS_1_30: "4000", sales_common.AGING_TOTAL: "4000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for bucket, want := range tt.want {
+				if got := tt.buckets[bucket].(primitive.Decimal128).String(); got != want {
+					t.Errorf("%s = %s, want %s", bucket, got, want)
+				}
+			}
+		})
+	}
+
+	if rows := report[sales_common.AGING_REPORT_ROWS].([]utils.Map); len(rows) != 3 {
+		t.Errorf("rows = %d, want 3", len(rows))
+	}
+}
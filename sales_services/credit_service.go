@@ -0,0 +1,212 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreditService - Credit limits, payment terms and Invoices of the Customers and Dealers buying on credit,
+// see CreditLedger for the rules
+type CreditService interface {
+	// SetCreditTerms - Set the currency, credit limit and payment terms days of the Customer or Dealer
+	SetCreditTerms(partyType string, partyId string, currency string, creditLimit primitive.Decimal128, termsDays int) (utils.Map, error)
+	// GetAccount - Get the Credit Account with the outstanding balance of the Customer or Dealer
+	GetAccount(partyType string, partyId string) (utils.Map, error)
+	// ListAccounts - List the Credit Accounts
+	ListAccounts(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// ListInvoices - List the Invoices
+	ListInvoices(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// GetInvoice - Get the Invoice with its payments
+	GetInvoice(invoiceId string) (utils.Map, error)
+	// RecordPayment - Record the payment against the Invoice, in the currency of the Invoice
+	RecordPayment(invoiceId string, amount primitive.Decimal128, reference string) (utils.Map, error)
+	// AgingReport - Due amount of the open Invoices by days past due, of one Account if partyId is given
+	AgingReport(partyType string, partyId string) (utils.Map, error)
+
+	EndService()
+}
+
+type creditBaseService struct {
+	db_utils.DatabaseService
+	dbRegion    db_utils.DatabaseService
+	ledger      *CreditLedger
+	daoAccount  sales_repository.CreditAccountDao
+	daoInvoice  sales_repository.CreditInvoiceDao
+	daoBusiness platform_repository.BusinessDao
+	child       CreditService
+	businessId  string
+	ctx         context.Context
+}
+
+// NewCreditService - Construct Credit
+func NewCreditService(props utils.Map) (CreditService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("CreditService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := creditBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// creditBaseService - Close all the services
+func (p *creditBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *creditBaseService) initializeService() {
+	log.Printf("CreditService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.ledger = NewCreditLedger(p.dbRegion.GetClient(), p.businessId)
+	p.daoAccount = sales_repository.NewCreditAccountDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoInvoice = sales_repository.NewCreditInvoiceDao(p.dbRegion.GetClient(), p.businessId)
+}
+
+// SetCreditTerms - Set the currency, credit limit and payment terms days of the Customer or Dealer
+func (p *creditBaseService) SetCreditTerms(partyType string, partyId string, currency string, creditLimit primitive.Decimal128, termsDays int) (_ utils.Map, err error) {
+
+	log.Println("CreditService::SetCreditTerms - Begin ", partyType, partyId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "SetCreditTerms", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.SetTerms(ctx, partyType, partyId, currency, creditLimit, termsDays)
+
+	log.Println("CreditService::SetCreditTerms - End ", err)
+	return data, err
+}
+
+// GetAccount - Get the Credit Account with the outstanding balance of the Customer or Dealer
+func (p *creditBaseService) GetAccount(partyType string, partyId string) (_ utils.Map, err error) {
+
+	log.Println("CreditService::GetAccount - Begin ", partyType, partyId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "GetAccount", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.GetAccount(ctx, partyType, partyId)
+
+	log.Println("CreditService::GetAccount - End ", err)
+	return data, err
+}
+
+// ListAccounts - List the Credit Accounts
+func (p *creditBaseService) ListAccounts(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CreditService::ListAccounts - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "ListAccounts", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoAccount.List(ctx, filter, sort, skip, limit)
+
+	log.Println("CreditService::ListAccounts - End ", err)
+	return listdata, err
+}
+
+// ListInvoices - List the Invoices
+func (p *creditBaseService) ListInvoices(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CreditService::ListInvoices - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "ListInvoices", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoInvoice.List(ctx, filter, sort, skip, limit)
+
+	log.Println("CreditService::ListInvoices - End ", err)
+	return listdata, err
+}
+
+// GetInvoice - Get the Invoice with its payments
+func (p *creditBaseService) GetInvoice(invoiceId string) (_ utils.Map, err error) {
+
+	log.Println("CreditService::GetInvoice - Begin ", invoiceId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "GetInvoice", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoInvoice.Get(ctx, invoiceId)
+
+	log.Println("CreditService::GetInvoice - End ", err)
+	return data, err
+}
+
+// RecordPayment - Record the payment against the Invoice, in the currency of the Invoice
+func (p *creditBaseService) RecordPayment(invoiceId string, amount primitive.Decimal128, reference string) (_ utils.Map, err error) {
+
+	log.Println("CreditService::RecordPayment - Begin ", invoiceId, amount)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "RecordPayment", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.Pay(ctx, invoiceId, amount, reference)
+
+	log.Println("CreditService::RecordPayment - End ", err)
+	return data, err
+}
+
+// AgingReport - Due amount of the open Invoices by days past due, of one Account if partyId is given
+func (p *creditBaseService) AgingReport(partyType string, partyId string) (_ utils.Map, err error) {
+
+	log.Println("CreditService::AgingReport - Begin ", partyType, partyId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "credit", "AgingReport", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.Aging(ctx, partyType, partyId)
+
+	log.Println("CreditService::AgingReport - End ", err)
+	return data, err
+}
+
+func (p *creditBaseService) errorReturn(err error) (CreditService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -0,0 +1,472 @@
+package customer_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerAddressService - Address book of the Customer.
+//
+// Each Address is for shipping, billing or both and its pincode must fall in a sales State, the
+// matching StateId and RegionId (if any) are stored with the Address. One Address of the Customer
+// can be the default for shipping and one for billing, the first Address of a type becomes its default.
+type CustomerAddressService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(addressId string) (utils.Map, error)
+	// Find - Find the item
+	Find(filter string) (utils.Map, error)
+	// Create - Create Service
+	Create(indata utils.Map) (utils.Map, error)
+	// Update - Update Service
+	Update(addressId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Service
+	Delete(addressId string, delete_permanent bool) error
+
+	// GetDefault - Get the default Address for the type (shipping/billing)
+	GetDefault(addressType string) (utils.Map, error)
+
+	EndService()
+}
+
+type customerAddressBaseService struct {
+	db_utils.DatabaseService
+	dbRegion           db_utils.DatabaseService
+	daoCustomerAddress customer_repository.CustomerAddressDao
+	daoBusiness        platform_repository.BusinessDao
+	daoCustomer        sales_repository.CustomerDao
+	daoStates          sales_repository.StatesDao
+	daoRegion          sales_repository.RegionDao
+
+	child      CustomerAddressService
+	businessId string
+	customerId string
+	ctx        context.Context
+}
+
+// NewCustomerAddressService - Construct CustomerAddress
+func NewCustomerAddressService(props utils.Map) (CustomerAddressService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("CustomerAddressService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := customerAddressBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Verify whether the User id data passed, this is optional parameter
+	customerId, _ := utils.GetMemberDataStr(props, sales_common.FLD_CUSTOMER_ID)
+	// if err != nil {
+	// 	return p.errorReturn(err)
+	// }
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.customerId = customerId
+	p.initializeService()
+
+	// Verify the Business Exists
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	// Verify the Customer Exist
+	if len(customerId) > 0 {
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
+		if err != nil {
+			err := &utils.AppError{
+				ErrorCode:   funcode + "01",
+				ErrorMsg:    "Invalid CustomerId",
+				ErrorDetail: "Given CustomerId is not exist"}
+			return p.errorReturn(err)
+		}
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// customerAddressBaseService - Close all the services
+func (p *customerAddressBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *customerAddressBaseService) initializeService() {
+	log.Printf("CustomerAddressService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoStates = sales_repository.NewStatesDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoRegion = sales_repository.NewRegionDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomerAddress = customer_repository.NewCustomerAddressDao(p.dbRegion.GetClient(), p.businessId, p.customerId)
+}
+
+// List - List All records
+func (p *customerAddressBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("customerAddressBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerAddress.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("customerAddressBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *customerAddressBaseService) Get(addressId string) (_ utils.Map, err error) {
+	log.Printf("customerAddressBaseService::Get::  Begin %v", addressId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerAddress.Get(ctx, addressId)
+
+	log.Println("customerAddressBaseService::Get:: End ", err)
+	return data, err
+}
+
+func (p *customerAddressBaseService) Find(filter string) (_ utils.Map, err error) {
+	fmt.Println("CustomerAddressService::FindByCode::  Begin ", filter)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerAddress.Find(ctx, filter)
+	log.Println("CustomerAddressService::FindByCode:: End ", err)
+	return data, err
+}
+
+// Create - Create Service
+func (p *customerAddressBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("CustomerAddressService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "Create", p.businessId)
+	defer span.EndWith(&err)
+
+	var addressId string
+
+	if len(p.customerId) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340160", ErrorMsg: "Missing CustomerId", ErrorDetail: "Address can be created only for a Customer"}
+		return utils.Map{}, err
+	}
+
+	dataval, dataok := indata[sales_common.FLD_ADDRESS_ID]
+	if dataok {
+		addressId = strings.ToLower(dataval.(string))
+	} else {
+		addressId = utils.GenerateUniqueId("addr")
+		log.Println("Unique CustomerAddress ID", addressId)
+	}
+
+	// Assign BusinessId
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_CUSTOMER_ID] = p.customerId
+	indata[sales_common.FLD_ADDRESS_ID] = addressId
+
+	if _, ok := indata[sales_common.FLD_ADDRESS_TYPE]; !ok {
+		indata[sales_common.FLD_ADDRESS_TYPE] = sales_common.ADDRESS_TYPE_BOTH
+	}
+	err = p.validateAddress(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// First Address of the type becomes the default
+	for _, defaultField := range addressDefaultFields(indata) {
+		if _, ok := indata[defaultField]; !ok {
+			_, errDefault := p.daoCustomerAddress.Find(ctx, `{"`+defaultField+`": true}`)
+			indata[defaultField] = errDefault != nil
+		}
+	}
+
+	data, err := p.daoCustomerAddress.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	err = p.clearOtherDefaults(ctx, addressId, data)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("CustomerAddressService::Create - End ")
+	return data, nil
+}
+
+// Update - Update Service
+func (p *customerAddressBaseService) Update(addressId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("CustomerAddressService::Update - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Delete Key values
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_CUSTOMER_ID)
+	delete(indata, sales_common.FLD_ADDRESS_ID)
+	// Region is derived from the pincode only
+	delete(indata, sales_common.FLD_REGION_ID)
+
+	// Validate the Address along with the existing values, if Address fields are changed
+	_, pincodeChanged := indata[sales_common.FLD_ADDRESS_PINCODE]
+	_, stateChanged := indata[sales_common.FLD_STATE_ID]
+	_, typeChanged := indata[sales_common.FLD_ADDRESS_TYPE]
+	_, shippingChanged := indata[sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING]
+	_, billingChanged := indata[sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING]
+	if pincodeChanged || stateChanged || typeChanged || shippingChanged || billingChanged {
+		dataAddress, err := p.daoCustomerAddress.Get(ctx, addressId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		// State and Region are derived from the pincode again
+		if pincodeChanged && !stateChanged {
+			delete(dataAddress, sales_common.FLD_STATE_ID)
+		}
+		delete(dataAddress, sales_common.FLD_REGION_ID)
+		for key, value := range indata {
+			dataAddress[key] = value
+		}
+		// Type changed, the Address is no longer default for the type it does not have
+		if typeChanged {
+			for _, defaultField := range []string{sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING, sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING} {
+				if _, ok := indata[defaultField]; !ok && !isDefaultAllowed(dataAddress, defaultField) {
+					dataAddress[defaultField] = false
+					indata[defaultField] = false
+				}
+			}
+		}
+		err = p.validateAddress(ctx, dataAddress)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		indata[sales_common.FLD_STATE_ID] = dataAddress[sales_common.FLD_STATE_ID]
+		indata[sales_common.FLD_REGION_ID] = dataAddress[sales_common.FLD_REGION_ID]
+		indata[sales_common.FLD_ADDRESS_PINCODE] = dataAddress[sales_common.FLD_ADDRESS_PINCODE]
+	}
+
+	data, err := p.daoCustomerAddress.Update(ctx, addressId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	err = p.clearOtherDefaults(ctx, addressId, data)
+
+	log.Println("CustomerAddressService::Update - End ")
+	return data, err
+}
+
+// Delete - Delete Service
+func (p *customerAddressBaseService) Delete(addressId string, delete_permanent bool) (err error) {
+
+	log.Println("CustomerAddressService::Delete - Begin", addressId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	if delete_permanent {
+		result, err := p.daoCustomerAddress.Delete(ctx, addressId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.Update(addressId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("CustomerAddressService::Delete - End")
+	return nil
+}
+
+// GetDefault - Get the default Address for the type (shipping/billing)
+func (p *customerAddressBaseService) GetDefault(addressType string) (_ utils.Map, err error) {
+
+	log.Println("CustomerAddressService::GetDefault - Begin", addressType)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_address", "GetDefault", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := getDefaultAddress(ctx, p.daoCustomerAddress, addressType)
+
+	log.Println("CustomerAddressService::GetDefault - End ", err)
+	return data, err
+}
+
+// validateAddress - Verify the type and default flags, and the pincode is within a State (and the
+// given StateId if any). StateId and RegionId of the pincode are set in indata
+func (p *customerAddressBaseService) validateAddress(ctx context.Context, indata utils.Map) error {
+
+	addressType, _ := utils.GetMemberDataStr(indata, sales_common.FLD_ADDRESS_TYPE)
+	switch addressType {
+	case sales_common.ADDRESS_TYPE_SHIPPING, sales_common.ADDRESS_TYPE_BILLING, sales_common.ADDRESS_TYPE_BOTH:
+	default:
+		err := &utils.AppError{ErrorCode: "S30340161", ErrorMsg: "Invalid Address Type", ErrorDetail: "Address type should be shipping, billing or both"}
+		return err
+	}
+
+	// Address can be default only for its own type
+	for _, defaultField := range []string{sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING, sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING} {
+		isDefault, _ := indata[defaultField].(bool)
+		if isDefault && !isDefaultAllowed(indata, defaultField) {
+			err := &utils.AppError{ErrorCode: "S30340162", ErrorMsg: "Invalid Default Address", ErrorDetail: addressType + " Address can not be " + defaultField}
+			return err
+		}
+	}
+
+	pincode := strings.TrimSpace(fmt.Sprint(indata[sales_common.FLD_ADDRESS_PINCODE]))
+	if _, ok := indata[sales_common.FLD_ADDRESS_PINCODE]; !ok || len(pincode) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340163", ErrorMsg: "Missing Pincode", ErrorDetail: "Address pincode should be sent"}
+		return err
+	}
+	if err := sales_common.ValidatePincode(pincode); err != nil {
+		err := &utils.AppError{ErrorCode: "S30340164", ErrorMsg: "Invalid Pincode", ErrorDetail: "Pincode " + pincode + " should be " + strconv.Itoa(sales_common.PINCODE_LENGTH) + " digits"}
+		return err
+	}
+	indata[sales_common.FLD_ADDRESS_PINCODE] = pincode
+
+	dataState, err := p.daoStates.FindByPincode(ctx, pincode)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340164", ErrorMsg: "Invalid Pincode", ErrorDetail: "Pincode " + pincode + " is not in any State"}
+		return err
+	}
+	stateId, _ := utils.GetMemberDataStr(dataState, sales_common.FLD_STATE_ID)
+	if givenStateId, _ := indata[sales_common.FLD_STATE_ID].(string); len(givenStateId) > 0 && givenStateId != stateId {
+		err := &utils.AppError{ErrorCode: "S30340165", ErrorMsg: "Pincode State mismatch", ErrorDetail: "Pincode " + pincode + " is not in the State " + givenStateId}
+		return err
+	}
+	indata[sales_common.FLD_STATE_ID] = stateId
+
+	// Region is optional, not all the businesses define Regions
+	dataRegion, err := p.daoRegion.FindByPincode(ctx, pincode)
+	regionId := ""
+	if err == nil {
+		regionId, _ = utils.GetMemberDataStr(dataRegion, sales_common.FLD_REGION_ID)
+	}
+	indata[sales_common.FLD_REGION_ID] = regionId
+
+	return nil
+}
+
+// clearOtherDefaults - Only one Address can be the default of a type, clear the flag in the others
+func (p *customerAddressBaseService) clearOtherDefaults(ctx context.Context, addressId string, data utils.Map) error {
+	for _, defaultField := range []string{sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING, sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING} {
+		if isDefault, _ := data[defaultField].(bool); !isDefault {
+			continue
+		}
+		_, err := p.daoCustomerAddress.ClearDefault(ctx, defaultField, addressId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addressDefaultFields - Default flags allowed for the Address type
+func addressDefaultFields(data utils.Map) []string {
+	addressType, _ := utils.GetMemberDataStr(data, sales_common.FLD_ADDRESS_TYPE)
+	switch addressType {
+	case sales_common.ADDRESS_TYPE_SHIPPING:
+		return []string{sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING}
+	case sales_common.ADDRESS_TYPE_BILLING:
+		return []string{sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING}
+	}
+	return []string{sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING, sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING}
+}
+
+// isDefaultAllowed - Whether the Address can have the default flag for its type
+func isDefaultAllowed(data utils.Map, defaultField string) bool {
+	for _, allowedField := range addressDefaultFields(data) {
+		if allowedField == defaultField {
+			return true
+		}
+	}
+	return false
+}
+
+// getDefaultAddress - Get the default Address of the Customer for the type (shipping/billing)
+func getDefaultAddress(ctx context.Context, daoAddress customer_repository.CustomerAddressDao, addressType string) (utils.Map, error) {
+	defaultField := sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING
+	if addressType == sales_common.ADDRESS_TYPE_BILLING {
+		defaultField = sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING
+	}
+	return daoAddress.Find(ctx, `{"`+defaultField+`": true}`)
+}
+
+// getOrderAddress - Get the Address for the Order, it must be usable for the type (shipping/billing).
+// Only the Address fields are returned, to be copied into the Order
+func getOrderAddress(ctx context.Context, daoAddress customer_repository.CustomerAddressDao, addressId string, addressType string) (utils.Map, error) {
+	dataAddress, err := daoAddress.Get(ctx, addressId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340166", ErrorMsg: "Invalid Address", ErrorDetail: "Given " + addressType + " Address " + addressId + " is not exist"}
+		return nil, err
+	}
+
+	storedType, _ := utils.GetMemberDataStr(dataAddress, sales_common.FLD_ADDRESS_TYPE)
+	if storedType != addressType && storedType != sales_common.ADDRESS_TYPE_BOTH {
+		err := &utils.AppError{ErrorCode: "S30340167", ErrorMsg: "Invalid Address Type", ErrorDetail: "Address " + addressId + " is not a " + addressType + " Address"}
+		return nil, err
+	}
+
+	for _, key := range []string{sales_common.FLD_BUSINESS_ID, sales_common.FLD_CUSTOMER_ID,
+		sales_common.FLD_ADDRESS_IS_DEFAULT_SHIPPING, sales_common.FLD_ADDRESS_IS_DEFAULT_BILLING,
+		db_common.FLD_CREATED_AT, db_common.FLD_UPDATED_AT} {
+		delete(dataAddress, key)
+	}
+	return dataAddress, nil
+}
+
+func (p *customerAddressBaseService) errorReturn(err error) (CustomerAddressService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -14,14 +14,15 @@ import (
 
 func ValidateAuthCredential(dbProps utils.Map, dataAuth utils.Map) (utils.Map, error) {
 
-	log.Printf("ValidateAppAuth %v", dataAuth)
+	// Only the grant type is logged, the grant has the credentials
+	log.Println("ValidateAppAuth ", dataAuth[auth_common.GRANT_TYPE])
 
 	// Authenticate with Clients tables
 	clientData, err := auth_services.ValidateAuthCredential(dbProps, dataAuth)
 	if err != nil {
 		return nil, err
 	}
-	log.Println("Auth Client Record ", clientData, err)
+	log.Println("Auth Client Record ", clientData[platform_common.FLD_CLIENT_TYPE])
 
 	// Get clientType and clientScope from the clientData
 	clientType := clientData[platform_common.FLD_CLIENT_TYPE].(string)
@@ -86,15 +87,72 @@ func ValidateAuthCredential(dbProps utils.Map, dataAuth utils.Map) (utils.Map, e
 		// Update UserId to AuthData
 		dataAuth[auth_common.USER_ID] = custData[sales_common.FLD_CUSTOMER_ID].(string)
 
+		// Issue Refresh Token, so the Customer need not login again
+		err = issueRefreshToken(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		// Move the Products added as Guest
+		mergeGuestCart(dbProps, businessId, dataAuth)
+
+	//
+	// ============[ Grant_Type: OTP ] =======================================================
+	case sales_common.GRANT_TYPE_OTP:
+
+		// Authenticate Customer with OTP
+		custData, err := authenticateCustomerOTP(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		// Update UserId to AuthData
+		dataAuth[auth_common.USER_ID] = custData[sales_common.FLD_CUSTOMER_ID].(string)
+
+		// Issue Refresh Token, so the Customer need not login again
+		err = issueRefreshToken(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		// Move the Products added as Guest
+		mergeGuestCart(dbProps, businessId, dataAuth)
+
+	//
+	// ============[ Grant_Type: ID Token ] ==================================================
+	case sales_common.GRANT_TYPE_ID_TOKEN:
+
+		// Authenticate Customer with the identity provider's ID Token
+		custData, err := authenticateCustomerIdToken(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		// Update UserId to AuthData
+		dataAuth[auth_common.USER_ID] = custData[sales_common.FLD_CUSTOMER_ID].(string)
+
+		// Issue Refresh Token, so the Customer need not login again
+		err = issueRefreshToken(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		// Move the Products added as Guest
+		mergeGuestCart(dbProps, businessId, dataAuth)
+
 	//
 	// ============[ Grant_Type: REFRESH ] ========================================
 	case auth_common.GRANT_TYPE_REFRESH:
-		/* Need to Implement Refersh Token */
-		//dataAuth.RefreshToken = ctx.FormValue("refresh_token")
+
+		// Rotate the Refresh Token
+		customerId, err := refreshCustomerToken(dbProps, businessId, dataAuth)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		// Update UserId to AuthData
+		dataAuth[auth_common.USER_ID] = customerId
 
 	}
 
-	log.Printf("Auth Values %v", dataAuth)
+	log.Printf("Authenticated customer %v", dataAuth[auth_common.USER_ID])
 	return dataAuth, nil
 }
 
@@ -111,26 +169,61 @@ func authenticateCustomer(dbProps utils.Map, businessId string, dataAuth utils.M
 	}
 	defer svcCustomer.EndService()
 
-	// Set default authKey as Customer LoginId
-	authKey := sales_common.FLD_CUSTOMER_LOGIN_ID
-	// if loginType, loginTypeOK := mapScopes[auth_common.LOGIN_TYPE]; loginTypeOK {
-
-	// 	loginType = loginType.(string)
-
-	// 	if loginType == auth_common.LOGIN_TYPE_PHONE {
-	// 		authKey = platform_common.FLD_APP_USER_PHONE
-	// 	} else if loginType == auth_common.LOGIN_TYPE_EMAIL {
-	// 		authKey = platform_common.FLD_APP_USER_EMAILID
-	// 	}
-	// }
-
 	authKeyValue := dataAuth[auth_common.USERNAME].(string)
 	authPassword := dataAuth[auth_common.PASSWORD].(string)
 
+	// Login with LoginId, Email or Phone as enabled for the business
+	authKey, err := getLoginKey(svcCustomer, dataAuth, authKeyValue)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
 	log.Println("Business::Auth:: Parameter Value ", authKey, authKeyValue)
 	appUserData, err := svcCustomer.Authenticate(authKey, authKeyValue, authPassword)
 	if err != nil {
+		if isLoginLocked(err) {
+			return utils.Map{}, err
+		}
+		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
+		return utils.Map{}, err
+	}
+
+	return appUserData, nil
+}
+
+func authenticateCustomerOTP(dbProps utils.Map, businessId string, dataAuth utils.Map) (utils.Map, error) {
+
+	// Append Business Id
+	dbProps[sales_common.FLD_BUSINESS_ID] = businessId
+
+	// User Validation
+	svcCustomer, err := sales_services.NewCustomerService(dbProps)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 417, ErrorMsg: "Status Expectation Failed", ErrorDetail: "Authentication Failure"}
+		return utils.Map{}, err
+	}
+	defer svcCustomer.EndService()
+
+	authKeyValue, err := utils.GetMemberDataStr(dataAuth, auth_common.USERNAME)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	// Login with LoginId, Email or Phone as enabled for the business
+	authKey, err := getLoginKey(svcCustomer, dataAuth, authKeyValue)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	authOTP, err := utils.GetMemberDataStr(dataAuth, sales_common.AUTH_OTP)
+	if err != nil {
+		return utils.Map{}, err
+	}
 
+	log.Println("Business::AuthOTP:: Parameter Value ", authKey, authKeyValue)
+	appUserData, err := svcCustomer.AuthenticateOTP(authKey, authKeyValue, authOTP)
+	if err != nil {
+		if isLoginLocked(err) {
+			return utils.Map{}, err
+		}
 		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
 		return utils.Map{}, err
 	}
@@ -138,6 +231,170 @@ func authenticateCustomer(dbProps utils.Map, businessId string, dataAuth utils.M
 	return appUserData, nil
 }
 
+// authenticateCustomerIdToken - Authenticate with the ID Token, the Customer is created on the first sign in
+func authenticateCustomerIdToken(dbProps utils.Map, businessId string, dataAuth utils.Map) (utils.Map, error) {
+
+	// Append Business Id
+	dbProps[sales_common.FLD_BUSINESS_ID] = businessId
+
+	svcIdentity, err := NewCustomerIdentityService(dbProps)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 417, ErrorMsg: "Status Expectation Failed", ErrorDetail: "Authentication Failure"}
+		return utils.Map{}, err
+	}
+	defer svcIdentity.EndService()
+
+	provider, err := utils.GetMemberDataStr(dataAuth, sales_common.AUTH_IDENTITY_PROVIDER)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	idToken, err := utils.GetMemberDataStr(dataAuth, sales_common.AUTH_ID_TOKEN)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	nonce, _ := dataAuth[sales_common.AUTH_NONCE].(string)
+
+	custData, err := svcIdentity.AuthenticateIdToken(provider, idToken, nonce)
+	if err != nil {
+		log.Println("authenticateCustomerIdToken Failed ", err)
+		appErr, _ := err.(*utils.AppError)
+		if appErr != nil && appErr.ErrorStatus == 503 {
+			// Keys of the provider could not be fetched, client can try again
+			return utils.Map{}, err
+		}
+		errAuth := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
+		if appErr != nil && appErr.ErrorCode == "S30340259" {
+			// Customer needs to link the identity
+			errAuth.ErrorDetail = appErr.ErrorDetail
+		}
+		return utils.Map{}, errAuth
+	}
+	return custData, nil
+}
+
+// isLoginLocked - Login delayed or locked out, client needs to know when to try again
+func isLoginLocked(err error) bool {
+	appErr, ok := err.(*utils.AppError)
+	return ok && appErr.ErrorCode == "S30340106"
+}
+
+// getLoginKey - Get the Customer field to authenticate with, for the login type passed in AuthData
+// or detected from the login
+func getLoginKey(svcCustomer sales_services.CustomerService, dataAuth utils.Map, login string) (string, error) {
+
+	loginType, _ := dataAuth[sales_common.AUTH_LOGIN_TYPE].(string)
+
+	authKey, err := svcCustomer.LoginKey(loginType, login)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
+		return "", err
+	}
+	return authKey, nil
+}
+
+// issueRefreshToken - Issue Refresh Token for the authenticated Customer and add it and the SessionId to AuthData
+func issueRefreshToken(dbProps utils.Map, businessId string, dataAuth utils.Map) error {
+
+	// Append Business Id
+	dbProps[sales_common.FLD_BUSINESS_ID] = businessId
+
+	svcToken, err := NewCustomerTokenService(dbProps)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 417, ErrorMsg: "Status Expectation Failed", ErrorDetail: "Authentication Failure"}
+		return err
+	}
+	defer svcToken.EndService()
+
+	customerId := dataAuth[auth_common.USER_ID].(string)
+	clientId, _ := dataAuth[sales_common.AUTH_CLIENT_ID].(string)
+	device, _ := dataAuth[sales_common.AUTH_DEVICE].(string)
+
+	refreshToken, sessionId, err := svcToken.IssueRefreshToken(customerId, clientId, device)
+	if err != nil {
+		return err
+	}
+	dataAuth[sales_common.AUTH_REFRESH_TOKEN] = refreshToken
+	dataAuth[sales_common.AUTH_SESSION_ID] = sessionId
+
+	return nil
+}
+
+// mergeGuestCart - Merge the Guest cart into the Customer's cart if the guest_cart_token is in AuthData,
+// the login does not fail if the merge fails
+func mergeGuestCart(dbProps utils.Map, businessId string, dataAuth utils.Map) {
+	guestToken, _ := dataAuth[sales_common.AUTH_GUEST_CART_TOKEN].(string)
+	if len(guestToken) == 0 {
+		return
+	}
+
+	customerId := dataAuth[auth_common.USER_ID].(string)
+	report, err := MergeGuestCart(dbProps, businessId, customerId, guestToken)
+	log.Println("mergeGuestCart ", customerId, report, err)
+}
+
+// MergeGuestCart - Merge the Guest cart into the Customer's cart, done on login when the guest_cart_token
+// is in AuthData and to be called after the Customer signup
+func MergeGuestCart(dbProps utils.Map, businessId string, customerId string, guestToken string) (utils.Map, error) {
+	props := utils.Map{}
+	for key, value := range dbProps {
+		props[key] = value
+	}
+	props[sales_common.FLD_BUSINESS_ID] = businessId
+	props[sales_common.FLD_CUSTOMER_ID] = customerId
+
+	svcCart, err := NewCustomerCartService(props)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	defer svcCart.EndService()
+
+	return svcCart.MergeGuestCart(guestToken)
+}
+
+// refreshCustomerToken - Rotate the Refresh Token in AuthData, returns the CustomerId
+func refreshCustomerToken(dbProps utils.Map, businessId string, dataAuth utils.Map) (string, error) {
+
+	// Append Business Id
+	dbProps[sales_common.FLD_BUSINESS_ID] = businessId
+
+	refreshToken, err := utils.GetMemberDataStr(dataAuth, sales_common.AUTH_REFRESH_TOKEN)
+	if err != nil {
+		return "", err
+	}
+	clientId, _ := dataAuth[sales_common.AUTH_CLIENT_ID].(string)
+
+	svcToken, err := NewCustomerTokenService(dbProps)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 417, ErrorMsg: "Status Expectation Failed", ErrorDetail: "Authentication Failure"}
+		return "", err
+	}
+	defer svcToken.EndService()
+
+	customerId, newRefreshToken, err := svcToken.RotateRefreshToken(refreshToken, clientId)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
+		return "", err
+	}
+
+	// Customer could have been deleted after the Token was issued
+	svcCustomer, err := sales_services.NewCustomerService(dbProps)
+	if err != nil {
+		err := &utils.AppError{ErrorStatus: 417, ErrorMsg: "Status Expectation Failed", ErrorDetail: "Authentication Failure"}
+		return "", err
+	}
+	defer svcCustomer.EndService()
+
+	_, err = svcCustomer.Get(customerId)
+	if err != nil {
+		_ = svcToken.RevokeAllSessions(customerId)
+		err := &utils.AppError{ErrorStatus: 401, ErrorMsg: "Status Unauthorized", ErrorDetail: "Authentication Failure"}
+		return "", err
+	}
+
+	dataAuth[sales_common.AUTH_REFRESH_TOKEN] = newRefreshToken
+	return customerId, nil
+}
+
 func isBusinessExist(dbProps utils.Map, businessId string) (utils.Map, error) {
 	// User Validation
 	bizService, err := platform_service.NewBusinessService(dbProps)
@@ -1,8 +1,10 @@
 package customer_services
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
@@ -12,9 +14,13 @@ import (
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
 	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_services"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
+// CustomerCartService - Cart of the Customer, or of the Guest when the guest_cart_token is given in
+// place of the customer_id. Guest carts are merged into the Customer's cart with MergeGuestCart
 type CustomerCartService interface {
 	// List - List All records
 	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
@@ -29,6 +35,10 @@ type CustomerCartService interface {
 	// Delete - Delete Service
 	Delete(cartId string, delete_permanent bool) error
 
+	// MergeGuestCart - Move the Products of the Guest cart into the Customer's cart, quantities of the
+	// Products in both are merged with the cart_merge_rule of the business
+	MergeGuestCart(guestToken string) (utils.Map, error)
+
 	EndService()
 }
 
@@ -41,7 +51,9 @@ type customerCartBaseService struct {
 
 	child      CustomerCartService
 	businessId string
-	customerId string
+	customerId string // Guest Id for the Guest cart
+	isGuest    bool
+	ctx        context.Context
 }
 
 // NewCustomerCartService - Construct CustomerCart
@@ -75,9 +87,21 @@ func NewCustomerCartService(props utils.Map) (CustomerCartService, error) {
 	// 	return p.errorReturn(err)
 	// }
 
+	// Cart of the Guest, if no Customer
+	isGuest := false
+	if guestToken, _ := props[sales_common.FLD_CART_GUEST_TOKEN].(string); len(customerId) == 0 && len(guestToken) > 0 {
+		customerId, err = guestCartId(guestToken)
+		if err != nil {
+			return p.errorReturn(err)
+		}
+		isGuest = true
+	}
+
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.customerId = customerId
+	p.isGuest = isGuest
 	p.initializeService()
 
 	// Verify the Business Exists
@@ -91,8 +115,8 @@ func NewCustomerCartService(props utils.Map) (CustomerCartService, error) {
 	}
 
 	// Verify the Customer Exist
-	if len(customerId) > 0 {
-		_, err = p.daoCustomer.Get(customerId)
+	if len(customerId) > 0 && !isGuest {
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
 		if err != nil {
 			err := &utils.AppError{
 				ErrorCode:   funcode + "01",
@@ -122,11 +146,14 @@ func (p *customerCartBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *customerCartBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *customerCartBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("customerCartBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomerCart.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerCart.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -136,27 +163,37 @@ func (p *customerCartBaseService) List(filter string, sort string, skip int64, l
 }
 
 // Get - Find By Code
-func (p *customerCartBaseService) Get(cartId string) (utils.Map, error) {
+func (p *customerCartBaseService) Get(cartId string) (_ utils.Map, err error) {
 	log.Printf("customerCartBaseService::Get::  Begin %v", cartId)
 
-	data, err := p.daoCustomerCart.Get(cartId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerCart.Get(ctx, cartId)
 
 	log.Println("customerCartBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *customerCartBaseService) Find(filter string) (utils.Map, error) {
+func (p *customerCartBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("CustomerCartService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomerCart.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerCart.Find(ctx, filter)
 	log.Println("CustomerCartService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *customerCartBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *customerCartBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerCartService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var cartId string
 
 	dataval, dataok := indata[sales_common.FLD_CART_ID]
@@ -171,9 +208,25 @@ func (p *customerCartBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CUSTOMER_ID] = p.customerId
 	indata[sales_common.FLD_CART_ID] = cartId
+	indata[sales_common.FLD_CART_IS_GUEST] = p.isGuest
 
-	data, err := p.daoCustomerCart.Create(indata)
+	// Price the Product for the Customer's type and hold its stock
+	stock := sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+	delete(indata, sales_common.FLD_RESERVATION_ID)
+	if _, ok := indata[sales_common.FLD_PRODUCT_ID]; ok {
+		err := p.priceCartItem(ctx, indata)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		err = p.reserveCartItem(ctx, stock, cartId, indata)
+		if err != nil {
+			return utils.Map{}, err
+		}
+	}
+
+	data, err := p.daoCustomerCart.Create(ctx, indata)
 	if err != nil {
+		p.releaseCartItem(ctx, stock, indata)
 		return utils.Map{}, err
 	}
 
@@ -182,28 +235,92 @@ func (p *customerCartBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *customerCartBaseService) Update(cartId string, indata utils.Map) (utils.Map, error) {
+func (p *customerCartBaseService) Update(cartId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerCartService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete Key values
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ID)
 	delete(indata, sales_common.FLD_CART_ID)
+	delete(indata, sales_common.FLD_CART_IS_GUEST)
+	delete(indata, sales_common.FLD_RESERVATION_ID)
+	// Prices are only set by priceCartItem, a new currency reprices the cart
+	for _, key := range cartPriceFields {
+		if key != sales_common.FLD_CURRENCY {
+			delete(indata, key)
+		}
+	}
+
+	// Reprice and hold the stock again when the Product, its Variant, its quantity or the currency changed
+	_, productOk := indata[sales_common.FLD_PRODUCT_ID]
+	_, variantOk := indata[sales_common.FLD_VARIANT_ID]
+	_, quantityOk := indata[sales_common.FLD_QUANTITY]
+	_, currencyOk := indata[sales_common.FLD_CURRENCY]
+	if productOk || variantOk || quantityOk || currencyOk {
+		dataCart, err := p.daoCustomerCart.Get(ctx, cartId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		for key, value := range indata {
+			dataCart[key] = value
+		}
+		err = p.priceCartItem(ctx, dataCart)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		for _, key := range cartPriceFields {
+			indata[key] = dataCart[key]
+		}
 
-	data, err := p.daoCustomerCart.Update(cartId, indata)
+		// Hold the new quantity before the old one is released, so the cart never loses its stock. When both
+		// can not be held, the old is released and the new tried again, else the old is held back
+		stock := sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+		oldReservationId, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_RESERVATION_ID)
+		oldCart := utils.Map{sales_common.FLD_RESERVATION_ID: oldReservationId}
+		err = p.reserveCartItem(ctx, stock, cartId, dataCart)
+		if err == nil {
+			p.releaseCartItem(ctx, stock, oldCart)
+		} else if len(oldReservationId) == 0 {
+			return utils.Map{}, err
+		} else {
+			p.releaseCartItem(ctx, stock, oldCart)
+			err = p.reserveCartItem(ctx, stock, cartId, dataCart)
+			if err != nil {
+				if errRestore := stock.Restore(ctx, oldReservationId); errRestore != nil {
+					log.Println("CustomerCartService::Update - Restore failed ", oldReservationId, errRestore)
+				}
+				return utils.Map{}, err
+			}
+		}
+		indata[sales_common.FLD_RESERVATION_ID] = dataCart[sales_common.FLD_RESERVATION_ID]
+	}
+
+	data, err := p.daoCustomerCart.Update(ctx, cartId, indata)
 
 	log.Println("CustomerCartService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *customerCartBaseService) Delete(cartId string, delete_permanent bool) error {
+func (p *customerCartBaseService) Delete(cartId string, delete_permanent bool) (err error) {
 
 	log.Println("CustomerCartService::Delete - Begin", cartId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	// Release the stock held by the cart
+	dataCart, err := p.daoCustomerCart.Get(ctx, cartId)
+	if err == nil {
+		p.releaseCartItem(ctx, sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId), dataCart)
+	}
+
 	if delete_permanent {
-		result, err := p.daoCustomerCart.Delete(cartId)
+		result, err := p.daoCustomerCart.Delete(ctx, cartId)
 		if err != nil {
 			return err
 		}
@@ -221,6 +338,236 @@ func (p *customerCartBaseService) Delete(cartId string, delete_permanent bool) e
 	return nil
 }
 
+// mergeCartItem - Set the merged quantity in the Customer's cart, holding it before the Guest cart's stock is
+// released. When both can not be held, the Guest cart's stock is released and the merged quantity tried again,
+// else the Guest cart's stock is held back
+func (p *customerCartBaseService) mergeCartItem(ctx context.Context, stock *sales_services.StockLedger, customerCartId string, quantity float64, guestCart utils.Map) error {
+	_, err := p.Update(customerCartId, utils.Map{sales_common.FLD_QUANTITY: quantity})
+	guestReservationId, _ := utils.GetMemberDataStr(guestCart, sales_common.FLD_RESERVATION_ID)
+	if err != nil && len(guestReservationId) > 0 {
+		p.releaseCartItem(ctx, stock, guestCart)
+		_, err = p.Update(customerCartId, utils.Map{sales_common.FLD_QUANTITY: quantity})
+		if err != nil {
+			if errRestore := stock.Restore(ctx, guestReservationId); errRestore != nil {
+				log.Println("CustomerCartService::mergeCartItem - Restore failed ", guestReservationId, errRestore)
+			}
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	p.releaseCartItem(ctx, stock, guestCart)
+	return nil
+}
+
+// Cart fields set by priceCartItem
+var cartPriceFields = []string{sales_common.FLD_VARIANT_SKU, sales_common.FLD_CURRENCY, sales_common.FLD_BASE_PRICE, sales_common.FLD_UNIT_PRICE,
+	sales_common.FLD_PRICE_TIER_ID, sales_common.FLD_PRICE_LIST_ID, sales_common.FLD_LINE_TOTAL}
+
+// priceCartItem - Set the prices of the cart's Product, or its Variant, for the Customer from the Price Lists of
+// the cart's currency and sales_region_id, quantity defaults to 1
+func (p *customerCartBaseService) priceCartItem(ctx context.Context, dataCart utils.Map) error {
+	if _, ok := dataCart[sales_common.FLD_QUANTITY]; !ok {
+		dataCart[sales_common.FLD_QUANTITY] = 1
+	}
+
+	// Guests get the price without Customer type
+	customerId := p.customerId
+	if p.isGuest {
+		customerId = ""
+	}
+
+	regionId, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_REGION_ID)
+	currency, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_CURRENCY)
+	resolver := sales_services.NewPriceListResolver(p.dbRegion.GetClient(), p.businessId)
+	_, _, err := resolver.PriceItems(ctx, customerId, regionId, currency, []utils.Map{dataCart})
+	return err
+}
+
+// reserveCartItem - Hold the stock of the cart's Product for the cart_reservation_minutes, sets the reservation_id,
+// empty if the stock of the Product is not tracked
+func (p *customerCartBaseService) reserveCartItem(ctx context.Context, stock *sales_services.StockLedger, cartId string, dataCart utils.Map) error {
+	productId, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_PRODUCT_ID)
+	variantId, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_VARIANT_ID)
+	quantity, _ := sales_common.GetMemberDataFloat(dataCart, sales_common.FLD_QUANTITY)
+
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: p.customerId, sales_common.FLD_CART_ID: cartId}
+	minutes := stock.ReservationMinutes(ctx, sales_common.FLD_CART_RESERVATION_MINUTES, sales_common.DEFAULT_CART_RESERVATION_MINUTES)
+	dataReservation, err := stock.Reserve(ctx, productId, variantId, quantity, ref, minutes)
+	if err != nil {
+		return err
+	}
+
+	dataCart[sales_common.FLD_RESERVATION_ID] = ""
+	if dataReservation != nil {
+		dataCart[sales_common.FLD_RESERVATION_ID] = dataReservation[sales_common.FLD_RESERVATION_ID]
+	}
+	return nil
+}
+
+// releaseCartItem - Release the stock held by the cart. Failure is only logged, the Reservation expires anyway
+func (p *customerCartBaseService) releaseCartItem(ctx context.Context, stock *sales_services.StockLedger, dataCart utils.Map) {
+	reservationId, _ := utils.GetMemberDataStr(dataCart, sales_common.FLD_RESERVATION_ID)
+	if len(reservationId) == 0 {
+		return
+	}
+
+	err := stock.Release(ctx, reservationId)
+	if err != nil {
+		log.Println("CustomerCartService::releaseCartItem - Failed ", reservationId, err)
+	}
+}
+
+// MergeGuestCart - Move the Products of the Guest cart into the Customer's cart
+func (p *customerCartBaseService) MergeGuestCart(guestToken string) (_ utils.Map, err error) {
+
+	log.Println("CustomerCartService::MergeGuestCart - Begin ", p.customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_cart", "MergeGuestCart", p.businessId)
+	defer span.EndWith(&err)
+
+	if len(p.customerId) == 0 || p.isGuest {
+		err := &utils.AppError{ErrorCode: "S30340193", ErrorMsg: "Missing CustomerId", ErrorDetail: "Guest cart can be merged only into a Customer's cart"}
+		return utils.Map{}, err
+	}
+	guestId, err := guestCartId(guestToken)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	daoGuestCart := customer_repository.NewCustomerCartDao(p.dbRegion.GetClient(), p.businessId, guestId)
+	listdata, err := daoGuestCart.List(ctx, "", "", 0, 0)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	guestCarts, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	mergeRule := p.getCartMergeRule(ctx)
+	stock := sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+
+	// Stock held by the Guest carts is held for the Customer, so the Order of the Customer takes it over
+	_, err = stock.ReassignCustomer(ctx, guestId, p.customerId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	moved, merged := 0, 0
+	for _, guestCart := range guestCarts {
+		cartId, _ := utils.GetMemberDataStr(guestCart, sales_common.FLD_CART_ID)
+		productId, _ := utils.GetMemberDataStr(guestCart, sales_common.FLD_PRODUCT_ID)
+		variantId, _ := utils.GetMemberDataStr(guestCart, sales_common.FLD_VARIANT_ID)
+
+		// Same Variant of the Product, carts without Variant match only carts without Variant
+		filterData := utils.Map{sales_common.FLD_PRODUCT_ID: productId, sales_common.FLD_VARIANT_ID: variantId}
+		if len(variantId) == 0 {
+			filterData[sales_common.FLD_VARIANT_ID] = utils.Map{"$in": []interface{}{nil, ""}}
+		}
+		filter, err := sales_common.BuildFilter(filterData)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		customerCart, errFind := p.daoCustomerCart.Find(ctx, filter)
+
+		if len(productId) == 0 || errFind != nil {
+			// Not in the Customer's cart, move the Guest cart with the Customer's price
+			indata := utils.Map{
+				sales_common.FLD_CUSTOMER_ID:   p.customerId,
+				sales_common.FLD_CART_IS_GUEST: false,
+			}
+			if len(productId) > 0 {
+				err = p.priceCartItem(ctx, guestCart)
+				if err != nil {
+					return utils.Map{}, err
+				}
+				indata[sales_common.FLD_QUANTITY] = guestCart[sales_common.FLD_QUANTITY]
+				for _, key := range cartPriceFields {
+					indata[key] = guestCart[key]
+				}
+			}
+			_, err = p.daoCustomerCart.Update(ctx, cartId, indata)
+			if err != nil {
+				return utils.Map{}, err
+			}
+			moved++
+			continue
+		}
+
+		customerCartId, _ := utils.GetMemberDataStr(customerCart, sales_common.FLD_CART_ID)
+		quantity := mergeCartQuantity(mergeRule, customerCart, guestCart)
+		err = p.mergeCartItem(ctx, stock, customerCartId, quantity, guestCart)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		_, err = daoGuestCart.Delete(ctx, cartId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		merged++
+	}
+
+	report := utils.Map{
+		sales_common.CART_MERGE_REPORT_MOVED:  moved,
+		sales_common.CART_MERGE_REPORT_MERGED: merged,
+	}
+
+	log.Println("CustomerCartService::MergeGuestCart - End ", report)
+	return report, nil
+}
+
+// getCartMergeRule - Rule from the cart_merge Preference, add the quantities if not configured
+func (p *customerCartBaseService) getCartMergeRule(ctx context.Context) string {
+	daoPreference := sales_repository.NewPreferenceDao(p.dbRegion.GetClient(), p.businessId)
+
+	dataPref, err := daoPreference.Get(ctx, sales_common.PREFERENCE_CART_MERGE)
+	if err != nil {
+		return sales_common.CART_MERGE_RULE_SUM
+	}
+
+	mergeRule, _ := utils.GetMemberDataStr(dataPref, sales_common.FLD_CART_MERGE_RULE)
+	return mergeRule
+}
+
+// mergeCartQuantity - Quantity of the Product in both the carts as per the merge rule
+func mergeCartQuantity(mergeRule string, customerCart utils.Map, guestCart utils.Map) float64 {
+	customerQty, err := sales_common.GetMemberDataFloat(customerCart, sales_common.FLD_QUANTITY)
+	if err != nil {
+		customerQty = 1
+	}
+	guestQty, err := sales_common.GetMemberDataFloat(guestCart, sales_common.FLD_QUANTITY)
+	if err != nil {
+		guestQty = 1
+	}
+
+	switch mergeRule {
+	case sales_common.CART_MERGE_RULE_MAX:
+		return math.Max(customerQty, guestQty)
+	case sales_common.CART_MERGE_RULE_CUSTOMER:
+		return customerQty
+	case sales_common.CART_MERGE_RULE_GUEST:
+		return guestQty
+	default:
+		return customerQty + guestQty
+	}
+}
+
+// NewGuestCartToken - Create the Token for a new Guest cart, to be kept by the client in place of the
+// session and passed as guest_cart_token
+func NewGuestCartToken() (string, error) {
+	guestId := utils.GenerateUniqueId("guest")
+	return sales_common.SignToken(guestId, sales_common.GUEST_CART_TOKEN_PURPOSE)
+}
+
+// guestCartId - Verify the Guest cart Token and get the Guest Id
+func guestCartId(guestToken string) (string, error) {
+	values, err := sales_common.ParseSignedToken(guestToken)
+	if err != nil || len(values) != 2 || values[1] != sales_common.GUEST_CART_TOKEN_PURPOSE {
+		err := &utils.AppError{ErrorCode: "S30340192", ErrorMsg: "Invalid Guest Token", ErrorDetail: "Given Guest cart Token is not valid"}
+		return "", err
+	}
+	return values[0], nil
+}
+
 func (p *customerCartBaseService) errorReturn(err error) (CustomerCartService, error) {
 	// Close the Database Connection
 	p.EndService()
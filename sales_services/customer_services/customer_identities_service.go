@@ -0,0 +1,409 @@
+package customer_services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_services"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// CustomerIdentityService - Identities of the external providers (Google, Apple..) linked to the Customer.
+//
+// Customer signs in with the OpenID Connect ID Token of the provider. The identity is linked to the
+// Customer with the same Email, if both the provider and the Customer verified it, or to a new Customer,
+// on the first sign in. Signed in Customers
+// can link more identities with LinkIdToken. Providers are configured in the oidc_providers Preference.
+type CustomerIdentityService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(identityId string) (utils.Map, error)
+	// Find - Find the item
+	Find(filter string) (utils.Map, error)
+	// Delete - Delete Service, unlinks the identity
+	Delete(identityId string, delete_permanent bool) error
+
+	// AuthenticateIdToken - Verify the provider's ID Token and get the Customer of the identity, the
+	// identity is linked to the Customer with the same verified Email or to a new Customer if not linked.
+	// Fails if the Customer with the Email has not verified it, the Customer should link with LinkIdToken
+	AuthenticateIdToken(provider string, idToken string, nonce string) (utils.Map, error)
+	// LinkIdToken - Verify the provider's ID Token and link the identity to the Customer
+	LinkIdToken(provider string, idToken string, nonce string) (utils.Map, error)
+
+	EndService()
+}
+
+type customerIdentityBaseService struct {
+	db_utils.DatabaseService
+	dbRegion            db_utils.DatabaseService
+	daoCustomerIdentity customer_repository.CustomerIdentityDao
+	daoBusiness         platform_repository.BusinessDao
+	daoCustomer         sales_repository.CustomerDao
+	daoPreference       sales_repository.PreferenceDao
+	daoIdentityAll      customer_repository.CustomerIdentityDao // Identities of all the Customers
+	svcCustomer         sales_services.CustomerService
+
+	child      CustomerIdentityService
+	businessId string
+	customerId string
+	ctx        context.Context
+}
+
+// NewCustomerIdentityService - Construct CustomerIdentity
+func NewCustomerIdentityService(props utils.Map) (CustomerIdentityService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("CustomerIdentityService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := customerIdentityBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Verify whether the User id data passed, this is optional parameter
+	customerId, _ := utils.GetMemberDataStr(props, sales_common.FLD_CUSTOMER_ID)
+	// if err != nil {
+	// 	return nil, err
+	// }
+
+	// Customer Service to find and create the Customers
+	p.svcCustomer, err = sales_services.NewCustomerService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		p.dbRegion.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.customerId = customerId
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	// Verify the Customer Exist
+	if len(customerId) > 0 {
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
+		if err != nil {
+			err := &utils.AppError{
+				ErrorCode:   funcode + "01",
+				ErrorMsg:    "Invalid CustomerId",
+				ErrorDetail: "Given CustomerId is not exist"}
+			return p.errorReturn(err)
+		}
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// customerIdentityBaseService - Close all the services
+func (p *customerIdentityBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+	p.svcCustomer.EndService()
+}
+
+func (p *customerIdentityBaseService) initializeService() {
+	log.Printf("CustomerIdentityService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomerIdentity = customer_repository.NewCustomerIdentityDao(p.dbRegion.GetClient(), p.businessId, p.customerId)
+	p.daoPreference = sales_repository.NewPreferenceDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoIdentityAll = customer_repository.NewCustomerIdentityDao(p.dbRegion.GetClient(), p.businessId, "")
+}
+
+// List - List All records
+func (p *customerIdentityBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("customerIdentityBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerIdentity.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("customerIdentityBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *customerIdentityBaseService) Get(identityId string) (_ utils.Map, err error) {
+	log.Printf("customerIdentityBaseService::Get::  Begin %v", identityId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerIdentity.Get(ctx, identityId)
+
+	log.Println("customerIdentityBaseService::Get:: End ", err)
+	return data, err
+}
+
+func (p *customerIdentityBaseService) Find(filter string) (_ utils.Map, err error) {
+	fmt.Println("customerIdentityBaseService::FindByCode::  Begin ", filter)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerIdentity.Find(ctx, filter)
+	log.Println("customerIdentityBaseService::FindByCode:: End ", err)
+	return data, err
+}
+
+// Delete - Delete Service
+func (p *customerIdentityBaseService) Delete(identityId string, delete_permanent bool) (err error) {
+
+	log.Println("CustomerIdentityService::Delete - Begin", identityId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	if delete_permanent {
+		result, err := p.daoCustomerIdentity.Delete(ctx, identityId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.daoCustomerIdentity.Update(ctx, identityId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("CustomerIdentityService::Delete - End")
+	return nil
+}
+
+// AuthenticateIdToken - Verify the provider's ID Token and get the Customer of the identity
+func (p *customerIdentityBaseService) AuthenticateIdToken(provider string, idToken string, nonce string) (_ utils.Map, err error) {
+
+	log.Println("CustomerIdentityService::AuthenticateIdToken - Begin ", provider)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "AuthenticateIdToken", p.businessId)
+	defer span.EndWith(&err)
+
+	claims, err := p.verifyIdToken(ctx, provider, idToken, nonce)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	subject, _ := claims["sub"].(string)
+
+	dataIdentity, err := p.findIdentity(ctx, provider, subject)
+	if err == nil {
+		customerId, _ := utils.GetMemberDataStr(dataIdentity, sales_common.FLD_CUSTOMER_ID)
+		log.Println("CustomerIdentityService::AuthenticateIdToken - End Linked ", customerId)
+		return p.svcCustomer.Get(customerId)
+	}
+
+	// First sign in, find the Customer by the Email only if the provider verified it
+	email, _ := claims["email"].(string)
+	email, errEmail := sales_common.NormalizeEmail(email)
+	emailVerified := errEmail == nil && sales_common.IsClaimTrue(claims, "email_verified")
+
+	var customerId string
+	if emailVerified {
+		filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CUSTOMER_EMAIL: email})
+		if err != nil {
+			return utils.Map{}, err
+		}
+		dataCustomer, err := p.svcCustomer.Find(filter)
+		if err == nil {
+			// Anyone could have registered the Email with a password, link only if the Customer verified it
+			if verified, _ := dataCustomer[sales_common.FLD_CUSTOMER_EMAIL_VERIFIED].(bool); !verified {
+				err := &utils.AppError{ErrorCode: "S30340259", ErrorMsg: "Identity not linked", ErrorDetail: "Sign in to the Customer with the Email and link the identity"}
+				return utils.Map{}, err
+			}
+			customerId, _ = utils.GetMemberDataStr(dataCustomer, sales_common.FLD_CUSTOMER_ID)
+		}
+	}
+
+	if len(customerId) == 0 {
+		indata := utils.Map{}
+		if name, ok := claims["name"].(string); ok && len(name) > 0 {
+			indata[sales_common.FLD_CUSTOMER_NAME] = name
+		}
+		if emailVerified {
+			indata[sales_common.FLD_CUSTOMER_EMAIL] = email
+			indata[sales_common.FLD_CUSTOMER_EMAIL_VERIFIED] = true
+		}
+		dataCustomer, err := p.svcCustomer.Create(indata)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		customerId, _ = utils.GetMemberDataStr(dataCustomer, sales_common.FLD_CUSTOMER_ID)
+	}
+
+	_, err = p.linkIdentity(ctx, customerId, provider, claims)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("CustomerIdentityService::AuthenticateIdToken - End Linked now ", customerId)
+	return p.svcCustomer.Get(customerId)
+}
+
+// LinkIdToken - Verify the provider's ID Token and link the identity to the Customer
+func (p *customerIdentityBaseService) LinkIdToken(provider string, idToken string, nonce string) (_ utils.Map, err error) {
+
+	log.Println("CustomerIdentityService::LinkIdToken - Begin ", p.customerId, provider)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_identity", "LinkIdToken", p.businessId)
+	defer span.EndWith(&err)
+
+	if len(p.customerId) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340194", ErrorMsg: "Missing CustomerId", ErrorDetail: "Identity can be linked only to a Customer"}
+		return utils.Map{}, err
+	}
+
+	claims, err := p.verifyIdToken(ctx, provider, idToken, nonce)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	subject, _ := claims["sub"].(string)
+
+	dataIdentity, err := p.findIdentity(ctx, provider, subject)
+	if err == nil {
+		linkedId, _ := utils.GetMemberDataStr(dataIdentity, sales_common.FLD_CUSTOMER_ID)
+		if linkedId != p.customerId {
+			err := &utils.AppError{ErrorCode: "S30340197", ErrorMsg: "Identity already linked", ErrorDetail: "Identity is linked to another Customer"}
+			return utils.Map{}, err
+		}
+		return dataIdentity, nil
+	}
+
+	data, err := p.linkIdentity(ctx, p.customerId, provider, claims)
+
+	log.Println("CustomerIdentityService::LinkIdToken - End ", err)
+	return data, err
+}
+
+// verifyIdToken - Verify the ID Token with the provider configured for the business
+func (p *customerIdentityBaseService) verifyIdToken(ctx context.Context, provider string, idToken string, nonce string) (utils.Map, error) {
+	oidcProvider, err := p.getOIDCProvider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := sales_common.VerifyIDToken(idToken, oidcProvider, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if subject, _ := claims["sub"].(string); len(subject) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340196", ErrorMsg: "Invalid ID Token", ErrorDetail: "ID Token has no subject"}
+		return nil, err
+	}
+	return claims, nil
+}
+
+// getOIDCProvider - Get the provider from the oidc_providers Preference
+func (p *customerIdentityBaseService) getOIDCProvider(ctx context.Context, provider string) (sales_common.OIDCProvider, error) {
+	errProvider := &utils.AppError{ErrorCode: "S30340195", ErrorMsg: "Invalid Provider", ErrorDetail: "Identity provider " + provider + " is not enabled for the business"}
+
+	dataPref, err := p.daoPreference.Get(ctx, sales_common.PREFERENCE_OIDC_PROVIDERS)
+	if err != nil {
+		return sales_common.OIDCProvider{}, errProvider
+	}
+
+	providers, _ := sales_common.GetMemberDataMapArray(dataPref, sales_common.FLD_OIDC_PROVIDERS)
+	for _, dataProvider := range providers {
+		name, _ := utils.GetMemberDataStr(dataProvider, sales_common.FLD_OIDC_PROVIDER)
+		if name != provider {
+			continue
+		}
+
+		oidcProvider := sales_common.OIDCProvider{Provider: name}
+		oidcProvider.Issuer, _ = utils.GetMemberDataStr(dataProvider, sales_common.FLD_OIDC_ISSUER)
+		oidcProvider.ClientIds, _ = sales_common.GetMemberDataStrArray(dataProvider, sales_common.FLD_OIDC_CLIENT_IDS)
+		oidcProvider.JWKSUri, _ = utils.GetMemberDataStr(dataProvider, sales_common.FLD_OIDC_JWKS_URI)
+		switch jwks := dataProvider[sales_common.FLD_OIDC_JWKS].(type) {
+		case string:
+			oidcProvider.JWKS = []byte(jwks)
+		case nil:
+		default:
+			oidcProvider.JWKS, _ = json.Marshal(jwks)
+		}
+		return oidcProvider, nil
+	}
+
+	return sales_common.OIDCProvider{}, errProvider
+}
+
+// findIdentity - Find the identity of the provider linked to any Customer
+func (p *customerIdentityBaseService) findIdentity(ctx context.Context, provider string, subject string) (utils.Map, error) {
+	filter, err := sales_common.BuildFilter(utils.Map{
+		sales_common.FLD_IDENTITY_PROVIDER: provider,
+		sales_common.FLD_IDENTITY_SUBJECT:  subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := p.daoIdentityAll.Find(ctx, filter)
+	return data, err
+}
+
+// linkIdentity - Create the identity of the provider for the Customer
+func (p *customerIdentityBaseService) linkIdentity(ctx context.Context, customerId string, provider string, claims utils.Map) (utils.Map, error) {
+	indata := utils.Map{
+		sales_common.FLD_BUSINESS_ID:       p.businessId,
+		sales_common.FLD_CUSTOMER_ID:       customerId,
+		sales_common.FLD_IDENTITY_ID:       utils.GenerateUniqueId("idn"),
+		sales_common.FLD_IDENTITY_PROVIDER: provider,
+		sales_common.FLD_IDENTITY_SUBJECT:  claims["sub"],
+	}
+	if email, ok := claims["email"].(string); ok {
+		indata[sales_common.FLD_IDENTITY_EMAIL] = email
+	}
+
+	data, err := p.daoIdentityAll.Create(ctx, indata)
+	return data, err
+}
+
+func (p *customerIdentityBaseService) errorReturn(err error) (CustomerIdentityService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -1,8 +1,10 @@
 package customer_services
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"strings"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
@@ -12,7 +14,10 @@ import (
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
 	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_services"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CustomerOrderService interface {
@@ -32,16 +37,48 @@ type CustomerOrderService interface {
 	EndService()
 }
 
+// Fields of the Order priced on Create, the order_items have the unit_price and line_total
+var orderPricedFields = []string{
+	sales_common.FLD_ORDER_ITEMS,
+	sales_common.FLD_CURRENCY,
+	sales_common.FLD_ORDER_TOTAL,
+	sales_common.FLD_ORDER_REDEEM_POINTS,
+	sales_common.FLD_ORDER_REDEEMED_VALUE,
+	sales_common.FLD_ORDER_PAYABLE,
+	sales_common.FLD_UNIT_PRICE,
+	sales_common.FLD_LINE_TOTAL,
+}
+
+// Fields of the Order set when its stock is reserved on Create, the order_items have the allocations
+var orderStockFields = []string{
+	sales_common.FLD_ALLOW_SPLIT,
+	sales_common.FLD_ITEM_ALLOCATIONS,
+	sales_common.FLD_RESERVATION_ID,
+}
+
+// Allowed changes of the order_status, an Order moves forward, possibly skipping a status, or fails till
+// delivered. Delivered and failed are final
+var orderStatusTransitions = map[string][]string{
+	sales_common.ORDER_STATUS_ORDERED:   {sales_common.ORDER_STATUS_CONFIRMED, sales_common.ORDER_STATUS_FULFILLED, sales_common.ORDER_STATUS_DELIVERED, sales_common.ORDER_STATUS_FAILED},
+	sales_common.ORDER_STATUS_CONFIRMED: {sales_common.ORDER_STATUS_FULFILLED, sales_common.ORDER_STATUS_DELIVERED, sales_common.ORDER_STATUS_FAILED},
+	sales_common.ORDER_STATUS_FULFILLED: {sales_common.ORDER_STATUS_DELIVERED, sales_common.ORDER_STATUS_FAILED},
+}
+
 type customerOrderBaseService struct {
 	db_utils.DatabaseService
 	dbRegion         db_utils.DatabaseService
 	daoCustomerOrder customer_repository.CustomerOrderDao
 	daoBusiness      platform_repository.BusinessDao
 	daoCustomer      sales_repository.CustomerDao
+	daoAddress       customer_repository.CustomerAddressDao
+	daoDealer        sales_repository.DealerDao
+	daoRegion        sales_repository.RegionDao
 
 	child      CustomerOrderService
 	businessId string
 	customerId string
+	dealerId   string
+	ctx        context.Context
 }
 
 // NewCustomerOrderService - Construct CustomerOrder
@@ -75,9 +112,15 @@ func NewCustomerOrderService(props utils.Map) (CustomerOrderService, error) {
 	// 	return p.errorReturn(err)
 	// }
 
+	// Dealer placing the Orders, set only by the Dealer's authenticated session, optional parameter.
+	// Orders are charged on the Dealer's credit only then
+	dealerId, _ := utils.GetMemberDataStr(props, sales_common.FLD_DEALER_ID)
+
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.customerId = customerId
+	p.dealerId = dealerId
 	p.initializeService()
 
 	// Verify the Business Exists
@@ -92,7 +135,7 @@ func NewCustomerOrderService(props utils.Map) (CustomerOrderService, error) {
 
 	// Verify the Customer Exist
 	if len(customerId) > 0 {
-		_, err = p.daoCustomer.Get(customerId)
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
 		if err != nil {
 			err := &utils.AppError{
 				ErrorCode:   funcode + "01",
@@ -102,6 +145,18 @@ func NewCustomerOrderService(props utils.Map) (CustomerOrderService, error) {
 		}
 	}
 
+	// Verify the Dealer Exist
+	if len(dealerId) > 0 {
+		_, err = p.daoDealer.Get(p.ctx, dealerId)
+		if err != nil {
+			err := &utils.AppError{
+				ErrorCode:   funcode + "01",
+				ErrorMsg:    "Invalid DealerId",
+				ErrorDetail: "Given DealerId is not exist"}
+			return p.errorReturn(err)
+		}
+	}
+
 	p.child = &p
 
 	return &p, err
@@ -119,14 +174,20 @@ func (p *customerOrderBaseService) initializeService() {
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
 	p.daoCustomerOrder = customer_repository.NewCustomerOrderDao(p.GetClient(), p.businessId, p.customerId)
+	p.daoAddress = customer_repository.NewCustomerAddressDao(p.dbRegion.GetClient(), p.businessId, p.customerId)
+	p.daoDealer = sales_repository.NewDealerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoRegion = sales_repository.NewRegionDao(p.dbRegion.GetClient(), p.businessId)
 }
 
 // List - List All records
-func (p *customerOrderBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *customerOrderBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("customerOrderBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomerOrder.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerOrder.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -136,27 +197,37 @@ func (p *customerOrderBaseService) List(filter string, sort string, skip int64,
 }
 
 // Get - Find By Code
-func (p *customerOrderBaseService) Get(custOrderId string) (utils.Map, error) {
+func (p *customerOrderBaseService) Get(custOrderId string) (_ utils.Map, err error) {
 	log.Printf("customerOrderBaseService::Get::  Begin %v", custOrderId)
 
-	data, err := p.daoCustomerOrder.Get(custOrderId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerOrder.Get(ctx, custOrderId)
 
 	log.Println("customerOrderBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *customerOrderBaseService) Find(filter string) (utils.Map, error) {
+func (p *customerOrderBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("customerOrderBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomerOrder.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerOrder.Find(ctx, filter)
 	log.Println("customerOrderBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *customerOrderBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *customerOrderBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("customerOrderBaseService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var custOrderId string
 
 	dataval, dataok := indata[sales_common.FLD_CUSTOMER_ORDER_ID]
@@ -171,39 +242,130 @@ func (p *customerOrderBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CUSTOMER_ID] = p.customerId
 	indata[sales_common.FLD_CUSTOMER_ORDER_ID] = custOrderId
+	indata[sales_common.FLD_CUSTOMER_ORDER_STATUS] = sales_common.ORDER_STATUS_ORDERED
+	if len(p.dealerId) > 0 {
+		indata[sales_common.FLD_DEALER_ID] = p.dealerId
+	}
+
+	// Copy the selected or default Addresses of the Customer into the Order
+	err = p.setOrderAddresses(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
 
-	data, err := p.daoCustomerOrder.Create(indata)
+	// Price the items for the Customer's type
+	err = p.setOrderPrices(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
 
+	// Hold the stock of the items
+	stock := sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+	cartReservationIds, err := p.reserveOrderStock(ctx, stock, custOrderId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Redeem the Loyalty points of the Customer
+	ledger := sales_services.NewLoyaltyLedger(p.dbRegion.GetClient(), p.businessId)
+	err = p.redeemOrderPoints(ctx, ledger, custOrderId, indata)
+	if err != nil {
+		p.releaseOrderStock(ctx, stock, indata, cartReservationIds)
+		return utils.Map{}, err
+	}
+
+	// Raise the Invoice if bought on credit, within the credit limit
+	credit := sales_services.NewCreditLedger(p.dbRegion.GetClient(), p.businessId)
+	err = p.chargeOrderCredit(ctx, credit, custOrderId, indata)
+	if err != nil {
+		ledger.Refund(ctx, p.customerId, custOrderId)
+		p.releaseOrderStock(ctx, stock, indata, cartReservationIds)
+		return utils.Map{}, err
+	}
+
+	data, err := p.daoCustomerOrder.Create(ctx, indata)
+	if err != nil {
+		credit.Void(ctx, custOrderId)
+		ledger.Refund(ctx, p.customerId, custOrderId)
+		p.releaseOrderStock(ctx, stock, indata, cartReservationIds)
+		return utils.Map{}, err
+	}
+
+	// The Order holds the stock now, release what the Customer's carts held for the same Products
+	p.releaseCartStock(ctx, stock, indata)
+
 	log.Println("customerOrderBaseService::Create - End ")
 	return data, nil
 }
 
 // Update - Update Service
-func (p *customerOrderBaseService) Update(custOrderId string, indata utils.Map) (utils.Map, error) {
+func (p *customerOrderBaseService) Update(custOrderId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("customerOrderService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete Key values
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ORDER_ID)
+	delete(indata, sales_common.FLD_INVOICE_ID)
+
+	// Prices are resolved and the stock reserved on Create, they can not be sent, neither any field inside
+	// the order_items, e.g. the allocations and their reservation_id
+	for _, field := range orderPricedFields {
+		delete(indata, field)
+	}
+	for _, field := range orderStockFields {
+		delete(indata, field)
+	}
+	for key := range indata {
+		if strings.HasPrefix(key, sales_common.FLD_ORDER_ITEMS+".") {
+			delete(indata, key)
+		}
+	}
 
-	data, err := p.daoCustomerOrder.Update(custOrderId, indata)
+	if _, ok := indata[sales_common.FLD_CUSTOMER_ORDER_STATUS]; !ok {
+		data, err := p.daoCustomerOrder.Update(ctx, custOrderId, indata)
+
+		log.Println("customerOrderService::Update - End ")
+		return data, err
+	}
+
+	fromStatus, toStatus, err := p.validateStatusChange(ctx, custOrderId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	data, err := p.daoCustomerOrder.Transition(ctx, custOrderId, fromStatus, indata)
+	if err == mongo.ErrNoDocuments {
+		err := &utils.AppError{ErrorCode: "S30340258", ErrorMsg: "Order Status changed", ErrorDetail: "Order " + custOrderId + " status was changed in between, retry"}
+		return utils.Map{}, err
+	} else if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Earn the Loyalty points when delivered, return the redeemed points, the credit and the stock when failed
+	if toStatus != orderStatus(fromStatus) {
+		p.applyOrderPoints(ctx, custOrderId, data)
+		p.voidOrderCredit(ctx, custOrderId, data)
+		p.applyOrderStock(ctx, custOrderId, data)
+	}
 
 	log.Println("customerOrderService::Update - End ")
-	return data, err
+	return data, nil
 }
 
 // Delete - Delete Service
-func (p *customerOrderBaseService) Delete(custOrderId string, delete_permanent bool) error {
+func (p *customerOrderBaseService) Delete(custOrderId string, delete_permanent bool) (err error) {
 
 	log.Println("customerOrderService::Delete - Begin", custOrderId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_order", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCustomerOrder.Delete(custOrderId)
+		result, err := p.daoCustomerOrder.Delete(ctx, custOrderId)
 		if err != nil {
 			return err
 		}
@@ -221,6 +383,339 @@ func (p *customerOrderBaseService) Delete(custOrderId string, delete_permanent b
 	return nil
 }
 
+// validateStatusChange - Verify the order_status can change to the given one, returns the stored status and the
+// new status
+func (p *customerOrderBaseService) validateStatusChange(ctx context.Context, custOrderId string, indata utils.Map) (string, string, error) {
+	toStatus, err := utils.GetMemberDataStr(indata, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+	if err != nil {
+		return "", "", err
+	}
+
+	dataOrder, err := p.daoCustomerOrder.Get(ctx, custOrderId)
+	if err != nil {
+		return "", "", err
+	}
+	fromStatus, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+	if toStatus == orderStatus(fromStatus) {
+		return fromStatus, toStatus, nil
+	}
+
+	for _, allowedStatus := range orderStatusTransitions[orderStatus(fromStatus)] {
+		if allowedStatus == toStatus {
+			return fromStatus, toStatus, nil
+		}
+	}
+	err = &utils.AppError{ErrorCode: "S30340257", ErrorMsg: "Invalid Status change", ErrorDetail: "Order status can not change from " + orderStatus(fromStatus) + " to " + toStatus}
+	return "", "", err
+}
+
+// orderStatus - Status of the Order, ordered for the Orders created without status
+func orderStatus(status string) string {
+	if len(status) == 0 {
+		return sales_common.ORDER_STATUS_ORDERED
+	}
+	return status
+}
+
+// setOrderAddresses - Copy the shipping and billing Addresses from the Customer address book. Address given
+// by Id is used, otherwise the default Address of the Customer if the Order has no Address already. The region_id
+// of an Address given with the Order is derived from its pincode, since it chooses the Price Lists and the stock
+func (p *customerOrderBaseService) setOrderAddresses(ctx context.Context, indata utils.Map) error {
+	orderAddresses := []struct{ addressType, idField, addressField string }{
+		{sales_common.ADDRESS_TYPE_SHIPPING, sales_common.FLD_ORDER_SHIPPING_ADDRESS_ID, sales_common.FLD_ORDER_SHIPPING_ADDRESS},
+		{sales_common.ADDRESS_TYPE_BILLING, sales_common.FLD_ORDER_BILLING_ADDRESS_ID, sales_common.FLD_ORDER_BILLING_ADDRESS},
+	}
+
+	for _, orderAddress := range orderAddresses {
+		addressId, _ := indata[orderAddress.idField].(string)
+		if len(addressId) == 0 || len(p.customerId) == 0 {
+			if _, ok := indata[orderAddress.addressField]; ok {
+				// Address given with the Order
+				delete(indata, orderAddress.idField)
+				err := p.setAddressRegion(ctx, indata, orderAddress.addressField)
+				if err != nil {
+					return err
+				}
+				continue
+			} else if len(p.customerId) == 0 {
+				continue
+			}
+			dataDefault, err := getDefaultAddress(ctx, p.daoAddress, orderAddress.addressType)
+			if err != nil {
+				continue
+			}
+			addressId, _ = utils.GetMemberDataStr(dataDefault, sales_common.FLD_ADDRESS_ID)
+		}
+
+		dataAddress, err := getOrderAddress(ctx, p.daoAddress, addressId, orderAddress.addressType)
+		if err != nil {
+			return err
+		}
+		indata[orderAddress.idField] = addressId
+		indata[orderAddress.addressField] = dataAddress
+	}
+
+	return nil
+}
+
+// setAddressRegion - Set the region_id of the Address given with the Order from its pincode, empty if the pincode
+// is not in any Region. The region_id sent is never used
+func (p *customerOrderBaseService) setAddressRegion(ctx context.Context, indata utils.Map, addressField string) error {
+	dataAddress, err := sales_common.GetMemberDataMap(indata, addressField)
+	if err != nil {
+		return err
+	}
+
+	regionId := ""
+	pincode := strings.TrimSpace(fmt.Sprint(dataAddress[sales_common.FLD_ADDRESS_PINCODE]))
+	if _, ok := dataAddress[sales_common.FLD_ADDRESS_PINCODE]; ok && sales_common.ValidatePincode(pincode) == nil {
+		if dataRegion, err := p.daoRegion.FindByPincode(ctx, pincode); err == nil {
+			regionId, _ = utils.GetMemberDataStr(dataRegion, sales_common.FLD_REGION_ID)
+		}
+	}
+	dataAddress[sales_common.FLD_REGION_ID] = regionId
+	indata[addressField] = dataAddress
+	return nil
+}
+
+// setOrderPrices - Resolve the price of each of the order_items from the Price Lists of the Order's currency and
+// the shipping Region, and set the currency and the order_total
+func (p *customerOrderBaseService) setOrderPrices(ctx context.Context, indata utils.Map) error {
+	if _, ok := indata[sales_common.FLD_ORDER_ITEMS]; !ok {
+		return nil
+	}
+
+	items, err := sales_common.GetMemberDataMapArray(indata, sales_common.FLD_ORDER_ITEMS)
+	if err != nil {
+		return err
+	}
+
+	regionId := ""
+	if shippingAddress, err := sales_common.GetMemberDataMap(indata, sales_common.FLD_ORDER_SHIPPING_ADDRESS); err == nil {
+		regionId, _ = utils.GetMemberDataStr(shippingAddress, sales_common.FLD_REGION_ID)
+	}
+	currency, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CURRENCY)
+
+	resolver := sales_services.NewPriceListResolver(p.dbRegion.GetClient(), p.businessId)
+	currency, total, err := resolver.PriceItems(ctx, p.customerId, regionId, currency, items)
+	if err != nil {
+		return err
+	}
+
+	indata[sales_common.FLD_ORDER_ITEMS] = items
+	indata[sales_common.FLD_CURRENCY] = currency
+	indata[sales_common.FLD_ORDER_TOTAL] = total
+	return nil
+}
+
+// redeemOrderPoints - Redeem the redeem_points of the Customer if given, and set the redeemed_value and the
+// order_payable
+func (p *customerOrderBaseService) redeemOrderPoints(ctx context.Context, ledger *sales_services.LoyaltyLedger, custOrderId string, indata utils.Map) error {
+	if _, ok := indata[sales_common.FLD_ORDER_REDEEM_POINTS]; !ok {
+		return nil
+	}
+
+	if len(p.customerId) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340187", ErrorMsg: "Missing CustomerId", ErrorDetail: "Points can be redeemed only for a Customer"}
+		return err
+	}
+	points, err := sales_common.GetMemberDataFloat(indata, sales_common.FLD_ORDER_REDEEM_POINTS)
+	if err != nil {
+		return err
+	}
+	currency, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CURRENCY)
+	total, err := sales_common.GetMemberDataDecimal(indata, sales_common.FLD_ORDER_TOTAL)
+	if err != nil {
+		// Order without items
+		total = sales_common.RatToDecimal(new(big.Rat), 0)
+	}
+
+	redeemedValue, err := ledger.Redeem(ctx, p.customerId, custOrderId, int64(points), total, currency)
+	if err != nil {
+		return err
+	}
+
+	// Payable in the decimals of the Order's currency, never below 0
+	scale := sales_common.CurrencyScale(currency)
+	totalRat, _ := sales_common.DecimalToRat(total)
+	redeemedRat, _ := sales_common.DecimalToRat(redeemedValue)
+	payable := new(big.Rat).Sub(totalRat, redeemedRat)
+	if payable.Sign() < 0 {
+		payable.SetInt64(0)
+	}
+
+	indata[sales_common.FLD_ORDER_REDEEMED_VALUE] = redeemedValue
+	indata[sales_common.FLD_ORDER_PAYABLE] = sales_common.RatToDecimal(payable, scale)
+	return nil
+}
+
+// applyOrderPoints - Earn the Loyalty points for the delivered Order or return the points redeemed for
+// the failed Order. Failures are only logged, since the Order is already updated, the points can be
+// earned later with LoyaltyService
+func (p *customerOrderBaseService) applyOrderPoints(ctx context.Context, custOrderId string, dataOrder utils.Map) {
+	customerId, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ID)
+	status, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+
+	var err error
+	ledger := sales_services.NewLoyaltyLedger(p.dbRegion.GetClient(), p.businessId)
+	switch status {
+	case sales_common.ORDER_STATUS_DELIVERED:
+		_, err = ledger.Earn(ctx, customerId, custOrderId, sales_services.OrderPayable(dataOrder))
+	case sales_common.ORDER_STATUS_FAILED:
+		_, err = ledger.Refund(ctx, customerId, custOrderId)
+	}
+	if err != nil {
+		log.Println("customerOrderService::applyOrderPoints - Failed ", custOrderId, err)
+	}
+}
+
+// chargeOrderCredit - Charge the order_payable on the credit Account if the payment_mode is credit, the
+// Dealer's Account if the service is for the Dealer else the Customer's. The dealer_id sent in the Order
+// is never charged. Sets the invoice_id and invoice_due_at
+func (p *customerOrderBaseService) chargeOrderCredit(ctx context.Context, credit *sales_services.CreditLedger, custOrderId string, indata utils.Map) error {
+	if paymentMode, _ := indata[sales_common.FLD_ORDER_PAYMENT_MODE].(string); paymentMode != sales_common.PAYMENT_MODE_CREDIT {
+		return nil
+	}
+
+	partyType, partyId := sales_common.CREDIT_PARTY_CUSTOMER, p.customerId
+	if len(p.dealerId) > 0 {
+		partyType, partyId = sales_common.CREDIT_PARTY_DEALER, p.dealerId
+	}
+	if len(partyId) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340216", ErrorMsg: "Missing Buyer", ErrorDetail: "Order on credit should be for a Customer or a Dealer"}
+		return err
+	}
+
+	currency, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CURRENCY)
+	dataInvoice, err := credit.Charge(ctx, partyType, partyId, custOrderId, currency, sales_services.OrderPayable(indata))
+	if err != nil {
+		return err
+	}
+
+	indata[sales_common.FLD_INVOICE_ID] = dataInvoice[sales_common.FLD_INVOICE_ID]
+	indata[sales_common.FLD_INVOICE_DUE_AT] = dataInvoice[sales_common.FLD_INVOICE_DUE_AT]
+	return nil
+}
+
+// voidOrderCredit - Void the Invoice of the failed Order to release the credit. Failure is only logged,
+// since the Order is already updated
+func (p *customerOrderBaseService) voidOrderCredit(ctx context.Context, custOrderId string, dataOrder utils.Map) {
+	status, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+	if _, ok := dataOrder[sales_common.FLD_INVOICE_ID]; !ok || status != sales_common.ORDER_STATUS_FAILED {
+		return
+	}
+
+	credit := sales_services.NewCreditLedger(p.dbRegion.GetClient(), p.businessId)
+	err := credit.Void(ctx, custOrderId)
+	if err != nil {
+		log.Println("customerOrderService::voidOrderCredit - Failed ", custOrderId, err)
+	}
+}
+
+// reserveOrderStock - Hold the stock of the order_items for the order_reservation_minutes, till confirmed or failed
+// if not set. When the stock is short, the stock held by the Customer's carts for the same Products is released
+// and tried again, so the Order placed from the carts can take it, and held back by the carts if still short.
+// Returns the cart Reservations released, to be restored if the Order is not created. The stock is taken from
+// the locations nearest to the shipping address, from more than one if allow_split of the Order, or of the
+// inventory preference if not sent
+func (p *customerOrderBaseService) reserveOrderStock(ctx context.Context, stock *sales_services.StockLedger, custOrderId string, indata utils.Map) ([]string, error) {
+	if _, ok := indata[sales_common.FLD_ORDER_ITEMS]; !ok {
+		return nil, nil
+	}
+
+	items, err := sales_common.GetMemberDataMapArray(indata, sales_common.FLD_ORDER_ITEMS)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: p.customerId, sales_common.FLD_CUSTOMER_ORDER_ID: custOrderId}
+	minutes := stock.ReservationMinutes(ctx, sales_common.FLD_ORDER_RESERVATION_MINUTES, 0)
+
+	sourcing := sales_services.NewFulfilmentSourcing(p.dbRegion.GetClient(), p.businessId)
+	allowSplit, err := utils.GetMemberDataBool(indata, sales_common.FLD_ALLOW_SPLIT)
+	if err != nil {
+		allowSplit = sourcing.AllowSplit(ctx)
+	}
+	indata[sales_common.FLD_ALLOW_SPLIT] = allowSplit
+
+	pincode, regionId := "", ""
+	if shippingAddress, err := sales_common.GetMemberDataMap(indata, sales_common.FLD_ORDER_SHIPPING_ADDRESS); err == nil {
+		pincode, _ = utils.GetMemberDataStr(shippingAddress, sales_common.FLD_ADDRESS_PINCODE)
+		regionId, _ = utils.GetMemberDataStr(shippingAddress, sales_common.FLD_REGION_ID)
+	}
+	err = sourcing.ReserveItems(ctx, items, pincode, regionId, allowSplit, ref, minutes)
+	if err == nil {
+		indata[sales_common.FLD_ORDER_ITEMS] = items
+		return nil, nil
+	}
+
+	cartReservationIds, errRelease := stock.ReleaseCartReservations(ctx, p.customerId, items)
+	if errRelease != nil || len(cartReservationIds) == 0 {
+		p.restoreCartStock(ctx, stock, cartReservationIds)
+		return nil, err
+	}
+	err = sourcing.ReserveItems(ctx, items, pincode, regionId, allowSplit, ref, minutes)
+	if err != nil {
+		p.restoreCartStock(ctx, stock, cartReservationIds)
+		return nil, err
+	}
+
+	indata[sales_common.FLD_ORDER_ITEMS] = items
+	return cartReservationIds, nil
+}
+
+// releaseOrderStock - Release the stock held for the Order not created, and hold back the stock of the cart
+// Reservations released for it. Failure is only logged
+func (p *customerOrderBaseService) releaseOrderStock(ctx context.Context, stock *sales_services.StockLedger, indata utils.Map, cartReservationIds []string) {
+	items, _ := sales_common.GetMemberDataMapArray(indata, sales_common.FLD_ORDER_ITEMS)
+	err := stock.ReleaseItems(ctx, items)
+	if err != nil {
+		log.Println("customerOrderService::releaseOrderStock - Failed ", err)
+	}
+	p.restoreCartStock(ctx, stock, cartReservationIds)
+}
+
+// releaseCartStock - Release the stock held by the Customer's carts for the Products of the created Order. Failure
+// is only logged, the cart Reservations expire anyway
+func (p *customerOrderBaseService) releaseCartStock(ctx context.Context, stock *sales_services.StockLedger, indata utils.Map) {
+	items, _ := sales_common.GetMemberDataMapArray(indata, sales_common.FLD_ORDER_ITEMS)
+	_, err := stock.ReleaseCartReservations(ctx, p.customerId, items)
+	if err != nil {
+		log.Println("customerOrderService::releaseCartStock - Failed ", err)
+	}
+}
+
+// restoreCartStock - Hold back the stock of the cart Reservations released for the Order. Failure is only logged
+func (p *customerOrderBaseService) restoreCartStock(ctx context.Context, stock *sales_services.StockLedger, cartReservationIds []string) {
+	err := stock.RestoreReservations(ctx, cartReservationIds)
+	if err != nil {
+		log.Println("customerOrderService::restoreCartStock - Failed ", err)
+	}
+}
+
+// applyOrderStock - Take the stock of the items from the on hand when the Order is confirmed, or later. When failed
+// release the stock held, and put the stock taken back on hand if it failed after confirmed. Failure is only
+// logged, since the Order is already updated, the stock can be adjusted with InventoryService
+func (p *customerOrderBaseService) applyOrderStock(ctx context.Context, custOrderId string, dataOrder utils.Map) {
+	status, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+	items, _ := sales_common.GetMemberDataMapArray(dataOrder, sales_common.FLD_ORDER_ITEMS)
+
+	var err error
+	stock := sales_services.NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+	switch status {
+	case sales_common.ORDER_STATUS_CONFIRMED, sales_common.ORDER_STATUS_FULFILLED, sales_common.ORDER_STATUS_DELIVERED:
+		err = stock.CommitItems(ctx, items)
+	case sales_common.ORDER_STATUS_FAILED:
+		err = stock.ReleaseItems(ctx, items)
+		if errRestock := stock.RestockItems(ctx, items); err == nil {
+			err = errRestock
+		}
+	}
+	if err != nil {
+		log.Println("customerOrderService::applyOrderStock - Failed ", custOrderId, err)
+	}
+}
+
 func (p *customerOrderBaseService) errorReturn(err error) (CustomerOrderService, error) {
 	// Close the Database Connection
 	p.EndService()
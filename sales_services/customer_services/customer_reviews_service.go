@@ -1,6 +1,7 @@
 package customer_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
 	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -43,6 +45,7 @@ type customerReviewBaseService struct {
 	child      CustomerReviewService
 	businessId string
 	customerId string
+	ctx        context.Context
 }
 
 // NewCustomerReviewService - Construct CustomerReview
@@ -78,6 +81,7 @@ func NewCustomerReviewService(props utils.Map) (CustomerReviewService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.customerId = customerId
 	p.initializeService()
 
@@ -92,7 +96,7 @@ func NewCustomerReviewService(props utils.Map) (CustomerReviewService, error) {
 
 	// Verify the Customer Exist
 	if len(customerId) > 0 {
-		_, err = p.daoCustomer.Get(customerId)
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
 		if err != nil {
 			err := &utils.AppError{
 				ErrorCode:   funcode + "01",
@@ -122,11 +126,14 @@ func (p *customerReviewBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *customerReviewBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *customerReviewBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("customerReviewBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomerReview.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerReview.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -136,27 +143,37 @@ func (p *customerReviewBaseService) List(filter string, sort string, skip int64,
 }
 
 // Get - Find By Code
-func (p *customerReviewBaseService) Get(reviewId string) (utils.Map, error) {
+func (p *customerReviewBaseService) Get(reviewId string) (_ utils.Map, err error) {
 	log.Printf("customerReviewBaseService::Get::  Begin %v", reviewId)
 
-	data, err := p.daoCustomerReview.Get(reviewId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerReview.Get(ctx, reviewId)
 
 	log.Println("customerReviewBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *customerReviewBaseService) Find(filter string) (utils.Map, error) {
+func (p *customerReviewBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("customerReviewBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomerReview.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerReview.Find(ctx, filter)
 	log.Println("customerReviewBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *customerReviewBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *customerReviewBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerReviewService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var reviewId string
 
 	dataval, dataok := indata[sales_common.FLD_REVIEW_ID]
@@ -172,7 +189,7 @@ func (p *customerReviewBaseService) Create(indata utils.Map) (utils.Map, error)
 	indata[sales_common.FLD_CUSTOMER_ID] = p.customerId
 	indata[sales_common.FLD_REVIEW_ID] = reviewId
 
-	data, err := p.daoCustomerReview.Create(indata)
+	data, err := p.daoCustomerReview.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -182,28 +199,34 @@ func (p *customerReviewBaseService) Create(indata utils.Map) (utils.Map, error)
 }
 
 // Update - Update Service
-func (p *customerReviewBaseService) Update(reviewId string, indata utils.Map) (utils.Map, error) {
+func (p *customerReviewBaseService) Update(reviewId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerReviewService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete Key values
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ID)
 	delete(indata, sales_common.FLD_REVIEW_ID)
 
-	data, err := p.daoCustomerReview.Update(reviewId, indata)
+	data, err := p.daoCustomerReview.Update(ctx, reviewId, indata)
 
 	log.Println("CustomerReviewService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *customerReviewBaseService) Delete(reviewId string, delete_permanent bool) error {
+func (p *customerReviewBaseService) Delete(reviewId string, delete_permanent bool) (err error) {
 
 	log.Println("CustomerReviewService::Delete - Begin", reviewId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_review", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCustomerReview.Delete(reviewId)
+		result, err := p.daoCustomerReview.Delete(ctx, reviewId)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,463 @@
+package customer_services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CustomerTokenService - Refresh Tokens of the Customers.
+//
+// Refresh Token is given to the client as "<token_id>.<secret>" and only the hash of the secret is stored.
+// Each refresh rotates the Token, the used one is kept to detect reuse. Using a rotated Token again means
+// it was leaked, so the whole family of Tokens issued from that login is revoked.
+//
+// Each login is also registered as a Session, with the token_family_id as the session_id, so the Customer
+// or the admin can see the devices logged in and end them. Refresh fails once the Session is revoked.
+type CustomerTokenService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(tokenId string) (utils.Map, error)
+
+	// IssueRefreshToken - Issue new Refresh Token for the Customer and Client, starting a new family and
+	// Session. Returns the Refresh Token and SessionId
+	IssueRefreshToken(customerId string, clientId string, device string) (string, string, error)
+	// RotateRefreshToken - Exchange the Refresh Token for a new one, returns the CustomerId and new Refresh Token
+	RotateRefreshToken(refreshToken string, clientId string) (string, string, error)
+	// RevokeTokenFamily - Revoke all the Tokens issued from the same login
+	RevokeTokenFamily(familyId string) error
+	// RevokeAllTokens - Revoke all the Tokens of the Customer
+	RevokeAllTokens(customerId string) error
+
+	// ListSessions - List the Sessions, only of the Customer if the Service is for a Customer
+	ListSessions(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// GetSession - Get the Session
+	GetSession(sessionId string) (utils.Map, error)
+	// RevokeSession - End the Session, its Refresh Tokens can not be used anymore
+	RevokeSession(sessionId string) error
+	// RevokeAllSessions - End all the Sessions of the Customer
+	RevokeAllSessions(customerId string) error
+
+	EndService()
+}
+
+type customerTokenBaseService struct {
+	db_utils.DatabaseService
+	dbRegion         db_utils.DatabaseService
+	daoCustomerToken customer_repository.CustomerTokenDao
+	daoSession       customer_repository.CustomerSessionDao
+	daoBusiness      platform_repository.BusinessDao
+	daoCustomer      sales_repository.CustomerDao
+
+	child      CustomerTokenService
+	businessId string
+	customerId string
+	ctx        context.Context
+}
+
+// NewCustomerTokenService - Construct CustomerToken
+func NewCustomerTokenService(props utils.Map) (CustomerTokenService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("CustomerTokenService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := customerTokenBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Verify whether the User id data passed, this is optional parameter
+	customerId, _ := utils.GetMemberDataStr(props, sales_common.FLD_CUSTOMER_ID)
+	// if err != nil {
+	// 	return p.errorReturn(err)
+	// }
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.customerId = customerId
+	p.initializeService()
+
+	// Verify the Business Exists
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	// Verify the Customer Exist
+	if len(customerId) > 0 {
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
+		if err != nil {
+			err := &utils.AppError{
+				ErrorCode:   funcode + "01",
+				ErrorMsg:    "Invalid CustomerId",
+				ErrorDetail: "Given CustomerId is not exist"}
+			return p.errorReturn(err)
+		}
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// customerTokenBaseService - Close all the services
+func (p *customerTokenBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *customerTokenBaseService) initializeService() {
+	log.Printf("CustomerTokenService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomerToken = customer_repository.NewCustomerTokenDao(p.dbRegion.GetClient(), p.businessId, p.customerId)
+	p.daoSession = customer_repository.NewCustomerSessionDao(p.dbRegion.GetClient(), p.businessId, p.customerId)
+}
+
+// List - List All records
+func (p *customerTokenBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("customerTokenBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerToken.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never expose the Token hash
+	results, _ := listdata[db_common.LIST_RESULT].([]utils.Map)
+	for _, value := range results {
+		delete(value, sales_common.FLD_TOKEN_HASH)
+	}
+
+	log.Println("customerTokenBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *customerTokenBaseService) Get(tokenId string) (_ utils.Map, err error) {
+	log.Printf("customerTokenBaseService::Get::  Begin %v", tokenId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerToken.Get(ctx, tokenId)
+
+	// Never expose the Token hash
+	delete(data, sales_common.FLD_TOKEN_HASH)
+
+	log.Println("customerTokenBaseService::Get:: End ", err)
+	return data, err
+}
+
+// IssueRefreshToken - Issue new Refresh Token for the Customer and Client, starting a new family and
+// Session. Returns the Refresh Token and SessionId
+func (p *customerTokenBaseService) IssueRefreshToken(customerId string, clientId string, device string) (_ string, _ string, err error) {
+
+	log.Println("CustomerTokenService::IssueRefreshToken - Begin", customerId, clientId, device)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "IssueRefreshToken", p.businessId)
+	defer span.EndWith(&err)
+
+	familyId := utils.GenerateUniqueId("rtfm")
+
+	indata := utils.Map{
+		sales_common.FLD_BUSINESS_ID:          p.businessId,
+		sales_common.FLD_CUSTOMER_ID:          customerId,
+		sales_common.FLD_SESSION_ID:           familyId,
+		sales_common.FLD_SESSION_CLIENT_ID:    clientId,
+		sales_common.FLD_SESSION_DEVICE:       device,
+		sales_common.FLD_SESSION_LAST_USED_AT: time.Now(),
+		sales_common.FLD_SESSION_IS_REVOKED:   false,
+	}
+
+	_, err = p.daoSession.Create(ctx, indata)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, _, err := p.createToken(ctx, customerId, clientId, familyId)
+
+	log.Println("CustomerTokenService::IssueRefreshToken - End ", err)
+	return refreshToken, familyId, err
+}
+
+// RotateRefreshToken - Exchange the Refresh Token for a new one, returns the CustomerId and new Refresh Token
+func (p *customerTokenBaseService) RotateRefreshToken(refreshToken string, clientId string) (_ string, _ string, err error) {
+
+	log.Println("CustomerTokenService::RotateRefreshToken - Begin", clientId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "RotateRefreshToken", p.businessId)
+	defer span.EndWith(&err)
+
+	errInvalid := &utils.AppError{ErrorStatus: 401, ErrorCode: "S30340120", ErrorMsg: "Invalid Refresh Token", ErrorDetail: "Refresh Token is invalid or expired"}
+
+	tokenId, secret, found := strings.Cut(refreshToken, ".")
+	if !found || len(tokenId) == 0 || len(secret) == 0 {
+		return "", "", errInvalid
+	}
+
+	tokenData, err := p.daoCustomerToken.Get(ctx, tokenId)
+	if err != nil {
+		return "", "", errInvalid
+	}
+
+	tokenHash, _ := utils.GetMemberDataStr(tokenData, sales_common.FLD_TOKEN_HASH)
+	if subtle.ConstantTimeCompare([]byte(tokenHash), []byte(utils.SHA(secret))) != 1 {
+		return "", "", errInvalid
+	}
+
+	customerId, _ := utils.GetMemberDataStr(tokenData, sales_common.FLD_CUSTOMER_ID)
+	familyId, _ := utils.GetMemberDataStr(tokenData, sales_common.FLD_TOKEN_FAMILY_ID)
+	tokenClientId, _ := tokenData[sales_common.FLD_TOKEN_CLIENT_ID].(string)
+	isRevoked, _ := tokenData[sales_common.FLD_TOKEN_IS_REVOKED].(bool)
+	isUsed, _ := tokenData[sales_common.FLD_TOKEN_IS_USED].(bool)
+	expiresAt, _ := sales_common.GetMemberDataTime(tokenData, sales_common.FLD_TOKEN_EXPIRES_AT)
+
+	if isRevoked || time.Now().After(expiresAt) {
+		return "", "", errInvalid
+	}
+
+	if isUsed || tokenClientId != clientId {
+		// Token leaked, revoke everything issued from that login
+		log.Println("CustomerTokenService::RotateRefreshToken - Token reused, revoking family ", familyId)
+		_ = p.RevokeTokenFamily(familyId)
+		return "", "", errInvalid
+	}
+
+	// Session could have been ended by the Customer or the admin
+	active, err := p.isSessionActive(ctx, familyId)
+	if err != nil {
+		// Session could not be verified, so the Token is not rotated
+		return "", "", err
+	}
+	if !active {
+		log.Println("CustomerTokenService::RotateRefreshToken - Session revoked ", familyId)
+		_ = p.RevokeTokenFamily(familyId)
+		return "", "", errInvalid
+	}
+
+	newToken, newTokenId, err := p.createToken(ctx, customerId, clientId, familyId)
+	if err != nil {
+		return "", "", err
+	}
+
+	marked, err := p.daoCustomerToken.MarkUsed(ctx, tokenId, newTokenId)
+	if err != nil {
+		return "", "", err
+	} else if !marked {
+		// Used by a parallel request in the meantime
+		log.Println("CustomerTokenService::RotateRefreshToken - Token reused, revoking family ", familyId)
+		_ = p.RevokeTokenFamily(familyId)
+		return "", "", errInvalid
+	}
+
+	log.Println("CustomerTokenService::RotateRefreshToken - End ", customerId)
+	return customerId, newToken, nil
+}
+
+// RevokeTokenFamily - Revoke all the Tokens issued from the same login
+func (p *customerTokenBaseService) RevokeTokenFamily(familyId string) (err error) {
+
+	log.Println("CustomerTokenService::RevokeTokenFamily - Begin", familyId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "RevokeTokenFamily", p.businessId)
+	defer span.EndWith(&err)
+
+	result, err := p.daoCustomerToken.RevokeFamily(ctx, familyId)
+
+	log.Println("CustomerTokenService::RevokeTokenFamily - End ", result, err)
+	return err
+}
+
+// RevokeAllTokens - Revoke all the Tokens of the Customer
+func (p *customerTokenBaseService) RevokeAllTokens(customerId string) (err error) {
+
+	log.Println("CustomerTokenService::RevokeAllTokens - Begin", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "RevokeAllTokens", p.businessId)
+	defer span.EndWith(&err)
+
+	result, err := p.daoCustomerToken.RevokeByCustomer(ctx, customerId)
+
+	log.Println("CustomerTokenService::RevokeAllTokens - End ", result, err)
+	return err
+}
+
+// ListSessions - List the Sessions, only of the Customer if the Service is for a Customer
+func (p *customerTokenBaseService) ListSessions(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CustomerTokenService::ListSessions - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "ListSessions", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoSession.List(ctx, filter, sort, skip, limit)
+
+	log.Println("CustomerTokenService::ListSessions - End ", err)
+	return listdata, err
+}
+
+// GetSession - Get the Session
+func (p *customerTokenBaseService) GetSession(sessionId string) (_ utils.Map, err error) {
+
+	log.Println("CustomerTokenService::GetSession - Begin", sessionId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "GetSession", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoSession.Get(ctx, sessionId)
+
+	log.Println("CustomerTokenService::GetSession - End ", err)
+	return data, err
+}
+
+// RevokeSession - End the Session, its Refresh Tokens can not be used anymore
+func (p *customerTokenBaseService) RevokeSession(sessionId string) (err error) {
+
+	log.Println("CustomerTokenService::RevokeSession - Begin", sessionId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "RevokeSession", p.businessId)
+	defer span.EndWith(&err)
+
+	// Get is limited to the Customer's Sessions if the Service is for a Customer
+	_, err = p.daoSession.Get(ctx, sessionId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340198", ErrorMsg: "Invalid SessionId", ErrorDetail: "Given SessionId is not exist"}
+		return err
+	}
+
+	_, err = p.daoSession.Revoke(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	err = p.RevokeTokenFamily(sessionId)
+
+	log.Println("CustomerTokenService::RevokeSession - End ", err)
+	return err
+}
+
+// RevokeAllSessions - End all the Sessions of the Customer
+func (p *customerTokenBaseService) RevokeAllSessions(customerId string) (err error) {
+
+	log.Println("CustomerTokenService::RevokeAllSessions - Begin", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_token", "RevokeAllSessions", p.businessId)
+	defer span.EndWith(&err)
+
+	if len(p.customerId) > 0 && customerId != p.customerId {
+		err := &utils.AppError{ErrorCode: "S30340199", ErrorMsg: "Invalid CustomerId", ErrorDetail: "Sessions of other Customers can not be revoked"}
+		return err
+	}
+
+	result, err := p.daoSession.RevokeByCustomer(ctx, customerId)
+	if err != nil {
+		return err
+	}
+
+	err = p.RevokeAllTokens(customerId)
+
+	log.Println("CustomerTokenService::RevokeAllSessions - End ", result, err)
+	return err
+}
+
+// isSessionActive - Set the last used time of the Session, false if it was revoked. Tokens issued before
+// the Sessions were registered have no Session and are allowed till they expire. Fails if the Session
+// can not be read, so a revoked Session is never taken as active
+func (p *customerTokenBaseService) isSessionActive(ctx context.Context, sessionId string) (bool, error) {
+
+	touched, err := p.daoSession.Touch(ctx, sessionId)
+	if err != nil {
+		return false, err
+	}
+	if touched {
+		return true, nil
+	}
+
+	_, err = p.daoSession.Get(ctx, sessionId)
+	if err == mongo.ErrNoDocuments {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// createToken - Create the Token record, returns the Refresh Token and TokenId
+func (p *customerTokenBaseService) createToken(ctx context.Context, customerId string, clientId string, familyId string) (string, string, error) {
+
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", "", err
+	}
+	secretStr := base64.RawURLEncoding.EncodeToString(secret)
+	tokenId := utils.GenerateUniqueId("rtok")
+
+	indata := utils.Map{
+		sales_common.FLD_BUSINESS_ID:       p.businessId,
+		sales_common.FLD_CUSTOMER_ID:       customerId,
+		sales_common.FLD_TOKEN_ID:          tokenId,
+		sales_common.FLD_TOKEN_FAMILY_ID:   familyId,
+		sales_common.FLD_TOKEN_CLIENT_ID:   clientId,
+		sales_common.FLD_TOKEN_HASH:        utils.SHA(secretStr),
+		sales_common.FLD_TOKEN_EXPIRES_AT:  time.Now().AddDate(0, 0, sales_common.REFRESH_TOKEN_VALIDITY_DAYS),
+		sales_common.FLD_TOKEN_IS_USED:     false,
+		sales_common.FLD_TOKEN_IS_REVOKED:  false,
+		sales_common.FLD_TOKEN_REPLACED_BY: "",
+	}
+
+	_, err = p.daoCustomerToken.Create(ctx, indata)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenId + "." + secretStr, tokenId, nil
+}
+
+func (p *customerTokenBaseService) errorReturn(err error) (CustomerTokenService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -1,6 +1,7 @@
 package customer_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
 	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -43,6 +45,7 @@ type customerWishlistBaseService struct {
 	child      CustomerWishlistService
 	businessId string
 	customerId string
+	ctx        context.Context
 }
 
 // NewCustomerWishlistService - Construct CustomerWishlist
@@ -78,6 +81,7 @@ func NewCustomerWishlistService(props utils.Map) (CustomerWishlistService, error
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.customerId = customerId
 	p.initializeService()
 
@@ -92,7 +96,7 @@ func NewCustomerWishlistService(props utils.Map) (CustomerWishlistService, error
 
 	// Verify the Customer Exist
 	if len(customerId) > 0 {
-		_, err = p.daoCustomer.Get(customerId)
+		_, err = p.daoCustomer.Get(p.ctx, customerId)
 		if err != nil {
 			err := &utils.AppError{
 				ErrorCode:   funcode + "01",
@@ -122,11 +126,14 @@ func (p *customerWishlistBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *customerWishlistBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *customerWishlistBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("customerWishlistBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomerWishlist.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerWishlist.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -136,27 +143,37 @@ func (p *customerWishlistBaseService) List(filter string, sort string, skip int6
 }
 
 // Get - Find By Code
-func (p *customerWishlistBaseService) Get(wishlistId string) (utils.Map, error) {
+func (p *customerWishlistBaseService) Get(wishlistId string) (_ utils.Map, err error) {
 	log.Printf("customerWishlistBaseService::Get::  Begin %v", wishlistId)
 
-	data, err := p.daoCustomerWishlist.Get(wishlistId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerWishlist.Get(ctx, wishlistId)
 
 	log.Println("customerWishlistBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *customerWishlistBaseService) Find(filter string) (utils.Map, error) {
+func (p *customerWishlistBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("customerWishlistBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomerWishlist.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerWishlist.Find(ctx, filter)
 	log.Println("customerWishlistBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *customerWishlistBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *customerWishlistBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerWishlistService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var wishlistId string
 
 	dataval, dataok := indata[sales_common.FLD_WISHLIST_ID]
@@ -172,7 +189,7 @@ func (p *customerWishlistBaseService) Create(indata utils.Map) (utils.Map, error
 	indata[sales_common.FLD_CUSTOMER_ID] = p.customerId
 	indata[sales_common.FLD_WISHLIST_ID] = wishlistId
 
-	data, err := p.daoCustomerWishlist.Create(indata)
+	data, err := p.daoCustomerWishlist.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -182,28 +199,34 @@ func (p *customerWishlistBaseService) Create(indata utils.Map) (utils.Map, error
 }
 
 // Update - Update Service
-func (p *customerWishlistBaseService) Update(wishlistId string, indata utils.Map) (utils.Map, error) {
+func (p *customerWishlistBaseService) Update(wishlistId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerWishlistService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete Key values
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ID)
 	delete(indata, sales_common.FLD_WISHLIST_ID)
 
-	data, err := p.daoCustomerWishlist.Update(wishlistId, indata)
+	data, err := p.daoCustomerWishlist.Update(ctx, wishlistId, indata)
 
 	log.Println("CustomerWishlistService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *customerWishlistBaseService) Delete(wishlistId string, delete_permanent bool) error {
+func (p *customerWishlistBaseService) Delete(wishlistId string, delete_permanent bool) (err error) {
 
 	log.Println("CustomerWishlistService::Delete - Begin", wishlistId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_wishlist", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCustomerWishlist.Delete(wishlistId)
+		result, err := p.daoCustomerWishlist.Delete(ctx, wishlistId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type CustomerTypeBaseService struct {
 	daoBusiness     platform_repository.BusinessDao
 	child           CustomerTypeService
 	businessId      string
+	ctx             context.Context
 }
 
 // NewCustomerTypeService - Construct CustomerType
@@ -67,6 +70,7 @@ func NewCustomerTypeService(props utils.Map) (CustomerTypeService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *CustomerTypeBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *CustomerTypeBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *CustomerTypeBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("CustomerTypeBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomerType.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomerType.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,10 +118,13 @@ func (p *CustomerTypeBaseService) List(filter string, sort string, skip int64, l
 }
 
 // Get - Find By Code
-func (p *CustomerTypeBaseService) Get(CustomerTypeId string) (utils.Map, error) {
+func (p *CustomerTypeBaseService) Get(CustomerTypeId string) (_ utils.Map, err error) {
 	log.Printf("CustomerTypeBaseService::Get::  Begin %v", CustomerTypeId)
 
-	data, err := p.daoCustomerType.Get(CustomerTypeId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerType.Get(ctx, CustomerTypeId)
 	if err != nil {
 		return nil, err
 	}
@@ -122,18 +132,25 @@ func (p *CustomerTypeBaseService) Get(CustomerTypeId string) (utils.Map, error)
 	return data, err
 }
 
-func (p *CustomerTypeBaseService) Find(filter string) (utils.Map, error) {
+func (p *CustomerTypeBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("CustomerTypeService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomerType.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomerType.Find(ctx, filter)
 	log.Println("CustomerTypeService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *CustomerTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *CustomerTypeBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerTypeService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var CustomerTypeId string
 
 	dataval, dataok := indata[sales_common.FLD_CUSTOMER_TYPE_ID]
@@ -148,7 +165,7 @@ func (p *CustomerTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CUSTOMER_TYPE_ID] = CustomerTypeId
 
-	data, err := p.daoCustomerType.Create(indata)
+	data, err := p.daoCustomerType.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -158,27 +175,33 @@ func (p *CustomerTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *CustomerTypeBaseService) Update(CustomerTypeId string, indata utils.Map) (utils.Map, error) {
+func (p *CustomerTypeBaseService) Update(CustomerTypeId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerTypeService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete the Key fields if exist
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_TYPE_ID)
 
-	data, err := p.daoCustomerType.Update(CustomerTypeId, indata)
+	data, err := p.daoCustomerType.Update(ctx, CustomerTypeId, indata)
 
 	log.Println("CustomerTypeService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *CustomerTypeBaseService) Delete(CustomerTypeId string, delete_permanent bool) error {
+func (p *CustomerTypeBaseService) Delete(CustomerTypeId string, delete_permanent bool) (err error) {
 
 	log.Println("CustomerTypeService::Delete - Begin", CustomerTypeId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer_type", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCustomerType.Delete(CustomerTypeId)
+		result, err := p.daoCustomerType.Delete(ctx, CustomerTypeId)
 		if err != nil {
 			return err
 		}
@@ -1,16 +1,23 @@
 package sales_services
 
 import (
+	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zapscloud/golib-dbutils/db_common"
 	"github.com/zapscloud/golib-dbutils/db_utils"
 	"github.com/zapscloud/golib-platform/platform_repository"
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_notifier"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -28,21 +35,105 @@ type CustomerService interface {
 	// Delete - Delete Service
 	Delete(customerId string, delete_permanent bool) error
 
+	// LoginKey - Get the Customer field to authenticate with for the login type (loginid/email/phone),
+	// the login type is detected from the login when empty. Fails if the login type is not enabled for the business
+	LoginKey(loginType string, login string) (string, error)
 	// Authenticate Customer
 	Authenticate(auth_key string, auth_login string, auth_pwd string) (utils.Map, error)
 	// Change Password
 	ChangePassword(userid string, newpwd string) (utils.Map, error)
+	// ChangePasswordVerified - Change the Password after verifying the current one, all the refresh tokens
+	// of the Customer are revoked so other devices need to login again
+	ChangePasswordVerified(userid string, oldpwd string, newpwd string) (utils.Map, error)
+	// RequestPasswordReset - Send the Password reset Token through the channel (sms/email), does not report
+	// whether the Customer exist
+	RequestPasswordReset(auth_key string, auth_login string, channel string) error
+	// ResetPassword - Set the new Password with the reset Token, all the refresh tokens of the Customer are revoked
+	ResetPassword(token string, newpwd string) error
+
+	// UnlockCustomer - Clear the failed login attempts and lockout of the Customer and its login identifiers
+	UnlockCustomer(customerId string) error
+	// ListLoginEvents - List the login security events like lockouts
+	ListLoginEvents(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+
+	// FindDuplicates - Report the groups of Customers with the same Email, Phone or Name
+	FindDuplicates() ([]utils.Map, error)
+	// MergeCustomers - Move the orders, carts, wishlists, reviews and loyalty points of the merged Customers
+	// to the surviving Customer and delete the merged Customers
+	MergeCustomers(customerId string, mergeCustomerIds []string) (utils.Map, error)
+	// ListMerges - List the Customer merges done
+	ListMerges(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+
+	// SendVerification - Send the verification Token (email) or Code (sms) to the Customer
+	SendVerification(customerId string, channel string) error
+	// ConfirmEmailVerification - Verify the Email with the Token sent
+	ConfirmEmailVerification(token string) (utils.Map, error)
+	// ConfirmPhoneVerification - Verify the Phone with the Code sent
+	ConfirmPhoneVerification(customerId string, code string) (utils.Map, error)
+
+	// SendOTP - Generate OTP for the Customer and send it through the channel (sms/email), returns nil whether
+	// or not the OTP is sent
+	SendOTP(auth_key string, auth_login string, channel string) error
+	// AuthenticateOTP - Authenticate Customer with the OTP sent
+	AuthenticateOTP(auth_key string, auth_login string, otp string) (utils.Map, error)
 
 	EndService()
 }
 
 type customerBaseService struct {
 	db_utils.DatabaseService
-	dbRegion    db_utils.DatabaseService
-	daoCustomer sales_repository.CustomerDao
-	daoBusiness platform_repository.BusinessDao
-	child       CustomerService
-	businessId  string
+	dbRegion      db_utils.DatabaseService
+	daoCustomer   sales_repository.CustomerDao
+	daoPreference sales_repository.PreferenceDao
+	daoAttempt    sales_repository.LoginAttemptDao
+	daoLoginEvent sales_repository.LoginEventDao
+	daoMerge      sales_repository.CustomerMergeDao
+	daoToken      customer_repository.CustomerTokenDao
+	daoSession    customer_repository.CustomerSessionDao
+	daoBusiness   platform_repository.BusinessDao
+	child         CustomerService
+	businessId    string
+	ctx           context.Context
+}
+
+// customerVerifyFields - Customer fields for the verification through a channel
+type customerVerifyFields struct {
+	sendTo   string
+	verified string
+	code     string
+	expiry   string
+	attempts string
+	sentAt   string
+	sends    string
+}
+
+// Customer fields for each verification channel
+var customerVerifyChannels = map[string]customerVerifyFields{
+	sales_notifier.CHANNEL_EMAIL: {
+		sendTo:   sales_common.FLD_CUSTOMER_EMAIL,
+		verified: sales_common.FLD_CUSTOMER_EMAIL_VERIFIED,
+		code:     sales_common.FLD_CUSTOMER_EMAIL_VERIFY_CODE,
+		expiry:   sales_common.FLD_CUSTOMER_EMAIL_VERIFY_EXPIRY,
+		attempts: sales_common.FLD_CUSTOMER_EMAIL_VERIFY_ATTEMPTS,
+		sentAt:   sales_common.FLD_CUSTOMER_EMAIL_VERIFY_SENT_AT,
+		sends:    sales_common.FLD_CUSTOMER_EMAIL_VERIFY_SENDS,
+	},
+	sales_notifier.CHANNEL_SMS: {
+		sendTo:   sales_common.FLD_CUSTOMER_PHONE,
+		verified: sales_common.FLD_CUSTOMER_PHONE_VERIFIED,
+		code:     sales_common.FLD_CUSTOMER_PHONE_VERIFY_CODE,
+		expiry:   sales_common.FLD_CUSTOMER_PHONE_VERIFY_EXPIRY,
+		attempts: sales_common.FLD_CUSTOMER_PHONE_VERIFY_ATTEMPTS,
+		sentAt:   sales_common.FLD_CUSTOMER_PHONE_VERIFY_SENT_AT,
+		sends:    sales_common.FLD_CUSTOMER_PHONE_VERIFY_SENDS,
+	},
+}
+
+// Customer field for each login type
+var customerLoginKeys = map[string]string{
+	sales_common.LOGIN_TYPE_LOGINID: sales_common.FLD_CUSTOMER_LOGIN_ID,
+	sales_common.LOGIN_TYPE_EMAIL:   sales_common.FLD_CUSTOMER_EMAIL,
+	sales_common.LOGIN_TYPE_PHONE:   sales_common.FLD_CUSTOMER_PHONE,
 }
 
 // NewCustomerService - Construct Customer
@@ -72,6 +163,7 @@ func NewCustomerService(props utils.Map) (CustomerService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -99,47 +191,76 @@ func (p *customerBaseService) initializeService() {
 	log.Printf("CustomerService:: GetBusinessDao ")
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoCustomer = sales_repository.NewCustomerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoPreference = sales_repository.NewPreferenceDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoAttempt = sales_repository.NewLoginAttemptDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoLoginEvent = sales_repository.NewLoginEventDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoMerge = sales_repository.NewCustomerMergeDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoToken = customer_repository.NewCustomerTokenDao(p.dbRegion.GetClient(), p.businessId, "")
+	p.daoSession = customer_repository.NewCustomerSessionDao(p.dbRegion.GetClient(), p.businessId, "")
 }
 
 // List - List All records
-func (p *customerBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *customerBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("customerBaseService::FindAll - Begin")
 
-	listdata, err := p.daoCustomer.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoCustomer.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
 
+	// Delete the Password and OTP
+	customers, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	for _, customer := range customers {
+		removeSecrets(customer)
+	}
+
 	log.Println("customerBaseService::FindAll - End ")
 	return listdata, nil
 }
 
 // Get - Find By Code
-func (p *customerBaseService) Get(customerId string) (utils.Map, error) {
+func (p *customerBaseService) Get(customerId string) (_ utils.Map, err error) {
 	log.Printf("customerBaseService::Get::  Begin %v", customerId)
 
-	data, err := p.daoCustomer.Get(customerId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Get", p.businessId)
+	defer span.EndWith(&err)
 
-	// Delete the Password
-	delete(data, sales_common.FLD_CUSTOMER_PASSWORD)
+	data, err := p.daoCustomer.Get(ctx, customerId)
+
+	// Delete the Password and OTP
+	removeSecrets(data)
 
 	log.Println("customerBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *customerBaseService) Find(filter string) (utils.Map, error) {
+func (p *customerBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("CustomerService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoCustomer.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoCustomer.Find(ctx, filter)
+
+	// Delete the Password and OTP
+	removeSecrets(data)
+
 	log.Println("CustomerService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *customerBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *customerBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var customerId string
 
 	dataval, dataok := indata[sales_common.FLD_CUSTOMER_ID]
@@ -154,47 +275,93 @@ func (p *customerBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_CUSTOMER_ID] = customerId
 
+	// Normalize the login identifiers and verify those are not used by others
+	err = p.normalizeLogins(ctx, customerId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Email and Phone need to be verified
+	for _, vf := range customerVerifyChannels {
+		_, hasSendTo := indata[vf.sendTo]
+		_, hasVerified := indata[vf.verified]
+		if hasSendTo && !hasVerified {
+			indata[vf.verified] = false
+		}
+	}
+
 	// Hash the password if passed
-	if dataVal, dataOk := indata[sales_common.FLD_CUSTOMER_PASSWORD]; dataOk {
-		indata[sales_common.FLD_CUSTOMER_PASSWORD] = utils.SHA(dataVal.(string))
+	err = p.hashPassword(indata)
+	if err != nil {
+		return utils.Map{}, err
 	}
 
-	data, err := p.daoCustomer.Create(indata)
+	data, err := p.daoCustomer.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
+	removeSecrets(data)
 
 	log.Println("CustomerService::Create - End ")
 	return data, nil
 }
 
 // Update - Update Service
-func (p *customerBaseService) Update(customerId string, indata utils.Map) (utils.Map, error) {
+func (p *customerBaseService) Update(customerId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("CustomerService::Update - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Update", p.businessId)
+	defer span.EndWith(&err)
+
 	// Delete the Key fields if exist
 	delete(indata, sales_common.FLD_BUSINESS_ID)
 	delete(indata, sales_common.FLD_CUSTOMER_ID)
 
+	// Normalize the login identifiers and verify those are not used by others
+	err = p.normalizeLogins(ctx, customerId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Changed Email/Phone need to be verified again
+	changedChannels, err := p.resetChangedVerification(ctx, customerId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
 	// Hash the password if passed
-	if dataVal, dataOk := indata[sales_common.FLD_CUSTOMER_PASSWORD]; dataOk {
-		indata[sales_common.FLD_CUSTOMER_PASSWORD] = utils.SHA(dataVal.(string))
+	err = p.hashPassword(indata)
+	if err != nil {
+		return utils.Map{}, err
 	}
 
-	data, err := p.daoCustomer.Update(customerId, indata)
+	data, err := p.daoCustomer.Update(ctx, customerId, indata)
+	removeSecrets(data)
+
+	if err == nil {
+		for _, channel := range changedChannels {
+			errSend := p.SendVerification(customerId, channel)
+			if errSend != nil {
+				log.Println("CustomerService::Update - Verification not sent ", channel, errSend)
+			}
+		}
+	}
 
 	log.Println("CustomerService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *customerBaseService) Delete(customerId string, delete_permanent bool) error {
+func (p *customerBaseService) Delete(customerId string, delete_permanent bool) (err error) {
 
 	log.Println("CustomerService::Delete - Begin", customerId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoCustomer.Delete(customerId)
+		result, err := p.daoCustomer.Delete(ctx, customerId)
 		if err != nil {
 			return err
 		}
@@ -212,49 +379,1280 @@ func (p *customerBaseService) Delete(customerId string, delete_permanent bool) e
 	return nil
 }
 
-// Authenticate - Authenticate User
-func (p *customerBaseService) Authenticate(auth_key string, auth_login string, auth_pwd string) (utils.Map, error) {
-	log.Println("Authenticate::  Begin ", auth_key, auth_login, auth_pwd)
+// LoginKey - Get the Customer field to authenticate with for the login type
+func (p *customerBaseService) LoginKey(loginType string, login string) (_ string, err error) {
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "LoginKey", p.businessId)
+	defer span.EndWith(&err)
+
+	loginTypes, _ := p.getLoginConfig(ctx)
+	if len(loginType) == 0 {
+		loginType = sales_common.DetectLoginType(login, loginTypes)
+	}
 
-	log.Println("User Password from API", auth_pwd)
-	encpwd := utils.SHA(auth_pwd)
-	dataUser, err := p.daoCustomer.Authenticate(auth_key, auth_login, encpwd)
+	for _, enabledType := range loginTypes {
+		if enabledType == loginType {
+			return customerLoginKeys[loginType], nil
+		}
+	}
+
+	err = &utils.AppError{ErrorCode: "S30340131", ErrorMsg: "Login Type not allowed", ErrorDetail: "Login with " + loginType + " is not enabled"}
+	return "", err
+}
 
-	log.Println("Length of dataUser :", dataUser)
+// Authenticate - Authenticate User
+func (p *customerBaseService) Authenticate(auth_key string, auth_login string, auth_pwd string) (_ utils.Map, err error) {
+	log.Println("Authenticate::  Begin ", auth_key, auth_login)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "Authenticate", p.businessId)
+	defer span.EndWith(&err)
 
+	login := p.normalizeLogin(ctx, auth_key, auth_login)
+	err = p.checkLoginLocked(ctx, loginAttemptId(auth_key, login))
 	if err != nil {
-		err := &utils.AppError{ErrorCode: "S30340101", ErrorMsg: "Wrong Credentials", ErrorDetail: "Authenticate credentials is wrong !!"}
 		return utils.Map{}, err
 	}
 
-	dataval, dataok := dataUser[db_common.FLD_IS_DELETED]
-	if dataok && !dataval.(bool) {
-		err := &utils.AppError{ErrorCode: "S30340102", ErrorMsg: "User not in Active Mode. Contact Admin!", ErrorDetail: "User not in Active Mode. Contact Admin!"}
+	dataUser, err := p.getByLogin(ctx, "Authenticate", auth_key, auth_login)
+
+	var matched, needsRehash bool
+	var customerId string
+	if err == nil {
+		customerId, _ = utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_ID)
+		err = p.checkLoginLocked(ctx, customerAttemptId(customerId))
+		if err != nil {
+			return utils.Map{}, err
+		}
+
+		storedHash, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_PASSWORD)
+		matched, needsRehash = sales_common.VerifyPassword(storedHash, auth_pwd)
+	} else {
+		// Same time as a wrong password, so the response does not reveal whether the login exist
+		sales_common.VerifyDummyPassword(auth_pwd)
+	}
+
+	if !matched {
+		p.recordLoginFailure(ctx, auth_key, login, customerId)
+		err := &utils.AppError{ErrorCode: "S30340101", ErrorMsg: "Wrong Credentials", ErrorDetail: "Authenticate credentials is wrong !!"}
 		return utils.Map{}, err
 	}
+	p.clearLoginFailures(ctx, loginAttemptId(auth_key, login), customerAttemptId(customerId))
 
-	dataval, dataok = dataUser[db_common.FLD_IS_VERIFIED]
-	if dataok && !dataval.(bool) {
-		err := &utils.AppError{ErrorCode: "S30340103", ErrorMsg: "User not yet verified!", ErrorDetail: "User not yet verified!!"}
+	// Never return the Password hash
+	removeSecrets(dataUser)
+
+	err = checkCustomerStatus(dataUser)
+	if err != nil {
 		return utils.Map{}, err
 	}
 
+	// Upgrade the legacy Password hash, login should not fail even if this fails
+	if needsRehash {
+		_, err = p.ChangePassword(customerId, auth_pwd)
+		if err != nil {
+			log.Println("Authenticate:: Password rehash failed ", customerId, err)
+		}
+	}
+
 	return dataUser, nil
 }
 
 // Change Password - Change Customer Password
-func (p *customerBaseService) ChangePassword(userid string, newpwd string) (utils.Map, error) {
+func (p *customerBaseService) ChangePassword(userid string, newpwd string) (_ utils.Map, err error) {
 
 	log.Println("AppUserService::ChangePassword - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ChangePassword", p.businessId)
+	defer span.EndWith(&err)
+
 	indata := utils.Map{
-		sales_common.FLD_CUSTOMER_PASSWORD: utils.SHA(newpwd),
+		sales_common.FLD_CUSTOMER_PASSWORD: newpwd,
+	}
+	err = p.hashPassword(indata)
+	if err != nil {
+		return utils.Map{}, err
 	}
-	data, err := p.daoCustomer.Update(userid, indata)
+
+	data, err := p.daoCustomer.Update(ctx, userid, indata)
+
+	// Delete the Password
+	removeSecrets(data)
 
 	log.Println("AppUserService::ChangePassword - End ")
 	return data, err
 }
 
+// UnlockCustomer - Clear the failed login attempts and lockout of the Customer and its login identifiers
+func (p *customerBaseService) UnlockCustomer(customerId string) (err error) {
+
+	log.Println("CustomerService::UnlockCustomer - Begin", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "UnlockCustomer", p.businessId)
+	defer span.EndWith(&err)
+
+	dataUser, err := p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return err
+	}
+
+	p.clearLoginFailures(ctx, customerAttemptIds(customerId, dataUser)...)
+
+	p.createLoginEvent(ctx, sales_common.LOGIN_EVENT_UNLOCKED, utils.Map{sales_common.FLD_CUSTOMER_ID: customerId})
+
+	log.Println("CustomerService::UnlockCustomer - End")
+	return nil
+}
+
+// ListLoginEvents - List the login security events like lockouts
+func (p *customerBaseService) ListLoginEvents(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::ListLoginEvents - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ListLoginEvents", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoLoginEvent.List(ctx, filter, sort, skip, limit)
+
+	log.Println("CustomerService::ListLoginEvents - End ", err)
+	return listdata, err
+}
+
+// FindDuplicates - Report the groups of Customers with the same Email, Phone or Name. Email and Phone are
+// stored normalized, the values are compared ignoring the case and the surrounding spaces
+func (p *customerBaseService) FindDuplicates() (_ []utils.Map, err error) {
+
+	log.Println("CustomerService::FindDuplicates - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "FindDuplicates", p.businessId)
+	defer span.EndWith(&err)
+
+	matchFields := []string{sales_common.FLD_CUSTOMER_EMAIL, sales_common.FLD_CUSTOMER_PHONE, sales_common.FLD_CUSTOMER_NAME}
+
+	report := []utils.Map{}
+	for _, matchField := range matchFields {
+		groups, err := p.daoCustomer.FindDuplicates(ctx, matchField)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, groups...)
+	}
+
+	log.Println("CustomerService::FindDuplicates - End ", len(report))
+	return report, nil
+}
+
+// MergeCustomers - Move the records of the merged Customers to the surviving Customer, the merged
+// Customers are deleted and their refresh tokens revoked. The merge is recorded with the counts moved.
+// The merge is recorded before moving, so a merge stopped by a failure is resumed by the next merge to
+// the same Customer
+func (p *customerBaseService) MergeCustomers(customerId string, mergeCustomerIds []string) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::MergeCustomers - Begin", customerId, mergeCustomerIds)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "MergeCustomers", p.businessId)
+	defer span.EndWith(&err)
+
+	_, err = p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	if len(mergeCustomerIds) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340190", ErrorMsg: "Missing Customers", ErrorDetail: "Customers to merge should be given"}
+		return utils.Map{}, err
+	}
+
+	dataMerge, err := p.pendingMerge(ctx, customerId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	mergeIds := []string{}
+	doneIds := []string{}
+	counts := utils.Map{}
+	if dataMerge != nil {
+		mergeIds, _ = sales_common.GetMemberDataStrArray(dataMerge, sales_common.FLD_MERGED_CUSTOMER_IDS)
+		doneIds, _ = sales_common.GetMemberDataStrArray(dataMerge, sales_common.FLD_MERGE_DONE_IDS)
+		counts, _ = sales_common.GetMemberDataMap(dataMerge, sales_common.FLD_MERGE_COUNTS)
+		if counts == nil {
+			counts = utils.Map{}
+		}
+	}
+	for _, mergeId := range mergeCustomerIds {
+		if !containsId(mergeIds, mergeId) {
+			mergeIds = append(mergeIds, mergeId)
+		}
+	}
+
+	for _, mergeId := range mergeIds {
+		if mergeId == customerId {
+			err := &utils.AppError{ErrorCode: "S30340190", ErrorMsg: "Invalid Customers", ErrorDetail: "Customer can not be merged to itself"}
+			return utils.Map{}, err
+		}
+		if containsId(doneIds, mergeId) {
+			continue
+		}
+		_, err := p.daoCustomer.Get(ctx, mergeId)
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340191", ErrorMsg: "Invalid CustomerId", ErrorDetail: "Customer " + mergeId + " is not exist"}
+			return utils.Map{}, err
+		}
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_MERGED_CUSTOMER_IDS: mergeIds,
+		sales_common.FLD_MERGE_DONE_IDS:      doneIds,
+		sales_common.FLD_MERGE_COUNTS:        counts,
+		sales_common.FLD_MERGE_STATUS:        sales_common.MERGE_STATUS_IN_PROGRESS,
+	}
+	if dataMerge == nil {
+		indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+		indata[sales_common.FLD_CUSTOMER_MERGE_ID] = utils.GenerateUniqueId("mrg")
+		indata[sales_common.FLD_CUSTOMER_ID] = customerId
+		dataMerge, err = p.daoMerge.Create(ctx, indata)
+	} else {
+		customerMergeId, _ := utils.GetMemberDataStr(dataMerge, sales_common.FLD_CUSTOMER_MERGE_ID)
+		dataMerge, err = p.daoMerge.Update(ctx, customerMergeId, indata)
+	}
+	if err != nil {
+		return utils.Map{}, err
+	}
+	customerMergeId, _ := utils.GetMemberDataStr(dataMerge, sales_common.FLD_CUSTOMER_MERGE_ID)
+
+	for _, mergeId := range mergeIds {
+		if !containsId(doneIds, mergeId) {
+			err := p.mergeRecords(ctx, customerId, mergeId, counts)
+			if err != nil {
+				return utils.Map{}, err
+			}
+			doneIds = append(doneIds, mergeId)
+			_, err = p.daoMerge.Update(ctx, customerMergeId, utils.Map{
+				sales_common.FLD_MERGE_DONE_IDS: doneIds,
+				sales_common.FLD_MERGE_COUNTS:   counts,
+			})
+			if err != nil {
+				return utils.Map{}, err
+			}
+		}
+
+		// Run again for the Customers done before a failure, nothing to do if already deleted
+		err := p.deleteMerged(ctx, customerId, mergeId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+	}
+
+	data, err := p.daoMerge.Update(ctx, customerMergeId, utils.Map{sales_common.FLD_MERGE_STATUS: sales_common.MERGE_STATUS_COMPLETED})
+
+	log.Println("CustomerService::MergeCustomers - End ", counts, err)
+	return data, err
+}
+
+// ListMerges - List the Customer merges done
+func (p *customerBaseService) ListMerges(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::ListMerges - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ListMerges", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoMerge.List(ctx, filter, sort, skip, limit)
+
+	log.Println("CustomerService::ListMerges - End ", err)
+	return listdata, err
+}
+
+// pendingMerge - Merge to the Customer stopped by a failure, nil if none
+func (p *customerBaseService) pendingMerge(ctx context.Context, customerId string) (utils.Map, error) {
+	filter, err := sales_common.BuildFilter(utils.Map{
+		sales_common.FLD_CUSTOMER_ID:  customerId,
+		sales_common.FLD_MERGE_STATUS: sales_common.MERGE_STATUS_IN_PROGRESS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := p.daoMerge.List(ctx, filter, "", 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	merges, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	if len(merges) == 0 {
+		return nil, nil
+	}
+	return merges[0], nil
+}
+
+// mergeRecords - Move the records of the Customer to the surviving Customer, adds the moved records to
+// counts. Each step moves only the records still with the Customer, so it is safe to run again
+func (p *customerBaseService) mergeRecords(ctx context.Context, customerId string, mergeId string, counts utils.Map) error {
+
+	addCount := func(countKey string, moved int64) {
+		count, _ := counts[countKey].(int64)
+		counts[countKey] = count + moved
+	}
+
+	// Orders, Wishlists and Reviews are in the business database, the others in the region database.
+	// All the loyalty transactions move with the Orders, so the Refunds and Earns of the Orders find them
+	reassigners := map[string]interface {
+		ReassignCustomer(ctx context.Context, toCustomerId string) (int64, error)
+	}{
+		sales_common.MERGE_COUNT_ORDERS:         customer_repository.NewCustomerOrderDao(p.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_CARTS:          customer_repository.NewCustomerCartDao(p.dbRegion.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_WISHLISTS:      customer_repository.NewCustomerWishlistDao(p.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_REVIEWS:        customer_repository.NewCustomerReviewDao(p.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_IDENTITIES:     customer_repository.NewCustomerIdentityDao(p.dbRegion.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_ADDRESSES:      customer_repository.NewCustomerAddressDao(p.dbRegion.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_CONSENT_EVENTS: customer_repository.NewCustomerConsentEventDao(p.dbRegion.GetClient(), p.businessId, mergeId),
+		sales_common.MERGE_COUNT_LOYALTY_TXNS:   customer_repository.NewCustomerLoyaltyTxnDao(p.dbRegion.GetClient(), p.businessId, mergeId),
+	}
+	for countKey, dao := range reassigners {
+		moved, err := dao.ReassignCustomer(ctx, customerId)
+		if err != nil {
+			return err
+		}
+		addCount(countKey, moved)
+	}
+
+	daoReservation := sales_repository.NewStockReservationDao(p.dbRegion.GetClient(), p.businessId)
+	moved, err := daoReservation.ReassignCustomer(ctx, mergeId, customerId)
+	if err != nil {
+		return err
+	}
+	addCount(sales_common.MERGE_COUNT_RESERVATIONS, moved)
+
+	moved, err = p.mergeConsents(ctx, customerId, mergeId)
+	if err != nil {
+		return err
+	}
+	addCount(sales_common.MERGE_COUNT_CONSENTS, moved)
+
+	credit := NewCreditLedger(p.dbRegion.GetClient(), p.businessId)
+	moved, err = credit.MergeAccounts(ctx, sales_common.CREDIT_PARTY_CUSTOMER, mergeId, customerId)
+	if err != nil {
+		return err
+	}
+	addCount(sales_common.MERGE_COUNT_CREDIT_INVOICES, moved)
+
+	// Balances are rebuilt from the moved transactions
+	ledger := NewLoyaltyLedger(p.dbRegion.GetClient(), p.businessId)
+	for _, balanceId := range []string{customerId, mergeId} {
+		_, err := ledger.Rebuild(ctx, balanceId)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Println("CustomerService::mergeRecords - Moved ", mergeId, customerId)
+	return nil
+}
+
+// mergeConsents - Move the Consents of the channels the surviving Customer has not given, the other
+// Consents of the Customer are deleted. Their history stays in the moved Consent events
+func (p *customerBaseService) mergeConsents(ctx context.Context, customerId string, mergeId string) (int64, error) {
+	daoMergeConsent := customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, mergeId)
+	daoConsent := customer_repository.NewCustomerConsentDao(p.dbRegion.GetClient(), p.businessId, customerId)
+
+	listdata, err := daoMergeConsent.List(ctx, "", "", 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	consents, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	var moved int64
+	for _, consent := range consents {
+		consentId, _ := utils.GetMemberDataStr(consent, sales_common.FLD_CONSENT_ID)
+		channel, _ := utils.GetMemberDataStr(consent, sales_common.FLD_CONSENT_CHANNEL)
+
+		filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CONSENT_CHANNEL: channel})
+		if err != nil {
+			return moved, err
+		}
+		if _, err := daoConsent.Find(ctx, filter); err == nil {
+			_, err = daoMergeConsent.Delete(ctx, consentId)
+			if err != nil {
+				return moved, err
+			}
+			continue
+		}
+
+		_, err = daoConsent.Update(ctx, consentId, utils.Map{sales_common.FLD_CUSTOMER_ID: customerId})
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// deleteMerged - Revoke the tokens and delete the merged Customer, nothing to do if already deleted
+func (p *customerBaseService) deleteMerged(ctx context.Context, customerId string, mergeId string) error {
+
+	p.revokeTokens(ctx, mergeId)
+
+	indata := utils.Map{
+		sales_common.FLD_CUSTOMER_MERGED_INTO: customerId,
+		db_common.FLD_IS_DELETED:              true,
+	}
+	_, err := p.daoCustomer.Update(ctx, mergeId, indata)
+	if err != nil {
+		// Update returns the record, which is not found once deleted
+		if _, errGet := p.daoCustomer.Get(ctx, mergeId); errGet == nil {
+			return err
+		}
+	}
+
+	log.Println("CustomerService::deleteMerged - Merged ", mergeId, customerId)
+	return nil
+}
+
+// containsId - Whether the id is in the ids
+func containsId(ids []string, id string) bool {
+	for _, value := range ids {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangePasswordVerified - Change the Password after verifying the current one
+func (p *customerBaseService) ChangePasswordVerified(userid string, oldpwd string, newpwd string) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::ChangePasswordVerified - Begin", userid)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ChangePasswordVerified", p.businessId)
+	defer span.EndWith(&err)
+
+	dataUser, err := p.daoCustomer.Get(ctx, userid)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Same lockout as the login, otherwise the current Password could be guessed here
+	err = p.checkLoginLocked(ctx, customerAttemptId(userid))
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	storedHash, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_PASSWORD)
+	matched, _ := sales_common.VerifyPassword(storedHash, oldpwd)
+	if !matched {
+		p.recordLoginFailure(ctx, "", "", userid)
+		err := &utils.AppError{ErrorCode: "S30340151", ErrorMsg: "Wrong Password", ErrorDetail: "Current password is wrong !!"}
+		return utils.Map{}, err
+	}
+	p.clearLoginFailures(ctx, customerAttemptId(userid))
+
+	data, err := p.ChangePassword(userid, newpwd)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	p.revokeTokens(ctx, userid)
+
+	log.Println("CustomerService::ChangePasswordVerified - End")
+	return data, nil
+}
+
+// RequestPasswordReset - Send the Password reset Token through the channel (sms/email)
+func (p *customerBaseService) RequestPasswordReset(auth_key string, auth_login string, channel string) (err error) {
+
+	log.Println("CustomerService::RequestPasswordReset - Begin", auth_key, auth_login, channel)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "RequestPasswordReset", p.businessId)
+	defer span.EndWith(&err)
+
+	vf, ok := customerVerifyChannels[channel]
+	if !ok {
+		err := &utils.AppError{ErrorCode: "S30340110", ErrorMsg: "Invalid Channel", ErrorDetail: "Password reset can be sent only through sms or email"}
+		return err
+	}
+
+	dataUser, err := p.getByLogin(ctx, "RequestPasswordReset", auth_key, auth_login)
+	if err != nil {
+		// Do not reveal whether the Customer exist
+		log.Println("RequestPasswordReset:: Customer not found ", auth_login)
+		return nil
+	}
+	customerId, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_ID)
+
+	sendTo, _ := utils.GetMemberDataStr(dataUser, vf.sendTo)
+	if len(sendTo) == 0 {
+		log.Println("RequestPasswordReset:: Customer has no ", vf.sendTo, customerId)
+		return nil
+	}
+
+	now := time.Now()
+	sentAt, err := sales_common.GetMemberDataTime(dataUser, sales_common.FLD_CUSTOMER_RESET_SENT_AT)
+	if err == nil && now.Sub(sentAt) < sales_common.VERIFY_RESEND_SECONDS*time.Second {
+		log.Println("RequestPasswordReset:: Requested again too soon ", customerId)
+		return nil
+	}
+
+	expiry := now.Add(sales_common.PASSWORD_RESET_VALIDITY_MINUTES * time.Minute)
+	nonce := utils.GenerateUniqueId("rst")
+	token, err := sales_common.SignToken(customerId, sales_common.PASSWORD_RESET_TOKEN_PURPOSE, strconv.FormatInt(expiry.Unix(), 10), nonce)
+	if err != nil {
+		return err
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_CUSTOMER_RESET_CODE:    utils.SHA(nonce),
+		sales_common.FLD_CUSTOMER_RESET_EXPIRY:  expiry,
+		sales_common.FLD_CUSTOMER_RESET_SENT_AT: now,
+	}
+	_, err = p.daoCustomer.Update(ctx, customerId, indata)
+	if err != nil {
+		return err
+	}
+
+	err = sales_notifier.Send(sales_notifier.Message{
+		Channel:    channel,
+		To:         sendTo,
+		Purpose:    sales_notifier.PURPOSE_PASSWORD_RESET,
+		BusinessId: p.businessId,
+		Subject:    "Reset your password",
+		Body:       fmt.Sprintf("Use the link sent to reset your password. It is valid for %d minutes.", sales_common.PASSWORD_RESET_VALIDITY_MINUTES),
+		Data:       utils.Map{sales_notifier.DATA_TOKEN: token, sales_common.FLD_CUSTOMER_ID: customerId},
+	})
+
+	log.Println("CustomerService::RequestPasswordReset - End ", err)
+	return err
+}
+
+// ResetPassword - Set the new Password with the reset Token
+func (p *customerBaseService) ResetPassword(token string, newpwd string) (err error) {
+
+	log.Println("CustomerService::ResetPassword - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ResetPassword", p.businessId)
+	defer span.EndWith(&err)
+
+	errInvalid := &utils.AppError{ErrorCode: "S30340150", ErrorMsg: "Invalid Reset Token", ErrorDetail: "Password reset is invalid or expired, request a new one"}
+
+	values, err := sales_common.ParseSignedToken(token)
+	if err != nil || len(values) != 4 || values[1] != sales_common.PASSWORD_RESET_TOKEN_PURPOSE {
+		return errInvalid
+	}
+	customerId, nonce := values[0], values[3]
+	expiryUnix, err := strconv.ParseInt(values[2], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiryUnix, 0)) {
+		return errInvalid
+	}
+
+	// Hash the new Password before using up the Token, so a bad Password does not need a new Token
+	indata := utils.Map{
+		sales_common.FLD_CUSTOMER_PASSWORD: newpwd,
+	}
+	err = p.hashPassword(indata)
+	if err != nil {
+		return err
+	}
+
+	// Fails when the Token is used already or a newer one was sent
+	dataUser, err := p.daoCustomer.ConsumeResetCode(ctx, customerId, utils.SHA(nonce))
+	if err != nil {
+		return errInvalid
+	}
+
+	_, err = p.daoCustomer.Update(ctx, customerId, indata)
+	if err != nil {
+		return err
+	}
+
+	// Whoever had the old Password should not stay logged in, the Customer can login again now
+	p.revokeTokens(ctx, customerId)
+	p.clearLoginFailures(ctx, customerAttemptIds(customerId, dataUser)...)
+
+	log.Println("CustomerService::ResetPassword - End ", customerId)
+	return nil
+}
+
+// revokeTokens - End all the Sessions and revoke all the refresh tokens of the Customer, failure is only logged
+func (p *customerBaseService) revokeTokens(ctx context.Context, customerId string) {
+	sessions, errSession := p.daoSession.RevokeByCustomer(ctx, customerId)
+
+	revoked, err := p.daoToken.RevokeByCustomer(ctx, customerId)
+
+	log.Println("CustomerService::revokeTokens ", customerId, sessions, errSession, revoked, err)
+}
+
+// SendVerification - Send the verification Token (email) or Code (sms) to the Customer
+func (p *customerBaseService) SendVerification(customerId string, channel string) (err error) {
+
+	log.Println("CustomerService::SendVerification - Begin", customerId, channel)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "SendVerification", p.businessId)
+	defer span.EndWith(&err)
+
+	vf, ok := customerVerifyChannels[channel]
+	if !ok {
+		err := &utils.AppError{ErrorCode: "S30340110", ErrorMsg: "Invalid Channel", ErrorDetail: "Verification can be sent only through sms or email"}
+		return err
+	}
+
+	dataUser, err := p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return err
+	}
+
+	sendTo, _ := utils.GetMemberDataStr(dataUser, vf.sendTo)
+	if len(sendTo) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340141", ErrorMsg: "Channel not available", ErrorDetail: "Customer has no " + vf.sendTo + " to verify"}
+		return err
+	}
+	if verified, _ := dataUser[vf.verified].(bool); verified {
+		err := &utils.AppError{ErrorCode: "S30340142", ErrorMsg: "Already verified", ErrorDetail: vf.sendTo + " is already verified"}
+		return err
+	}
+
+	// Rate limit the sends
+	now := time.Now()
+	sends, _ := utils.GetMemberDataInt(dataUser, vf.sends, true)
+	sentAt, err := sales_common.GetMemberDataTime(dataUser, vf.sentAt)
+	if err == nil {
+		if now.Sub(sentAt) < sales_common.VERIFY_RESEND_SECONDS*time.Second {
+			err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340143", ErrorMsg: "Verification already sent",
+				ErrorDetail: fmt.Sprintf("Verification can be resent after %d seconds", sales_common.VERIFY_RESEND_SECONDS)}
+			return err
+		}
+		if now.Sub(sentAt) >= 24*time.Hour {
+			// Start counting again after a day without sends
+			sends = 0
+		}
+	}
+	if sends >= sales_common.VERIFY_MAX_SENDS_PER_DAY {
+		err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340144", ErrorMsg: "Too many verifications sent", ErrorDetail: "Verification can be resent after a day"}
+		return err
+	}
+
+	var msg sales_notifier.Message
+	var codeHash string
+	var expiry time.Time
+	if channel == sales_notifier.CHANNEL_EMAIL {
+		// Signed link Token, the nonce makes only the latest Token valid
+		expiry = now.Add(sales_common.VERIFY_TOKEN_VALIDITY_HOURS * time.Hour)
+		nonce := utils.GenerateUniqueId("vrf")
+		token, err := sales_common.SignToken(customerId, channel, strconv.FormatInt(expiry.Unix(), 10), nonce)
+		if err != nil {
+			return err
+		}
+		codeHash = utils.SHA(nonce)
+		msg = sales_notifier.Message{
+			Subject: "Verify your email",
+			Body:    "Use the link sent to verify your email. It is valid for " + strconv.Itoa(sales_common.VERIFY_TOKEN_VALIDITY_HOURS) + " hours.",
+			Data:    utils.Map{sales_notifier.DATA_TOKEN: token, sales_common.FLD_CUSTOMER_ID: customerId},
+		}
+	} else {
+		expiry = now.Add(sales_common.VERIFY_CODE_VALIDITY_MINUTES * time.Minute)
+		code := utils.GetRandomOTP(sales_common.OTP_LENGTH)
+		codeHash, err = sales_common.HashPassword(code)
+		if err != nil {
+			return err
+		}
+		msg = sales_notifier.Message{
+			Subject: "Verify your phone",
+			Body:    fmt.Sprintf("%s is your code to verify the phone. It is valid for %d minutes.", code, sales_common.VERIFY_CODE_VALIDITY_MINUTES),
+			Data:    utils.Map{sales_notifier.DATA_CODE: code, sales_common.FLD_CUSTOMER_ID: customerId},
+		}
+	}
+
+	indata := utils.Map{
+		vf.code:     codeHash,
+		vf.expiry:   expiry,
+		vf.attempts: 0,
+		vf.sentAt:   now,
+		vf.sends:    sends + 1,
+	}
+	_, err = p.daoCustomer.Update(ctx, customerId, indata)
+	if err != nil {
+		return err
+	}
+
+	msg.Channel = channel
+	msg.To = sendTo
+	msg.Purpose = sales_notifier.PURPOSE_VERIFY
+	msg.BusinessId = p.businessId
+	err = sales_notifier.Send(msg)
+
+	log.Println("CustomerService::SendVerification - End ", err)
+	return err
+}
+
+// ConfirmEmailVerification - Verify the Email with the Token sent
+func (p *customerBaseService) ConfirmEmailVerification(token string) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::ConfirmEmailVerification - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ConfirmEmailVerification", p.businessId)
+	defer span.EndWith(&err)
+
+	errInvalid := &utils.AppError{ErrorCode: "S30340145", ErrorMsg: "Invalid Verification", ErrorDetail: "Verification is invalid or expired, request a new one"}
+	vf := customerVerifyChannels[sales_notifier.CHANNEL_EMAIL]
+
+	values, err := sales_common.ParseSignedToken(token)
+	if err != nil || len(values) != 4 || values[1] != sales_notifier.CHANNEL_EMAIL {
+		return utils.Map{}, errInvalid
+	}
+	customerId, nonce := values[0], values[3]
+	expiryUnix, err := strconv.ParseInt(values[2], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiryUnix, 0)) {
+		return utils.Map{}, errInvalid
+	}
+
+	dataUser, err := p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return utils.Map{}, errInvalid
+	}
+
+	// Token is used already or a newer one was sent
+	codeHash, _ := utils.GetMemberDataStr(dataUser, vf.code)
+	if len(codeHash) == 0 || subtle.ConstantTimeCompare([]byte(codeHash), []byte(utils.SHA(nonce))) != 1 {
+		return utils.Map{}, errInvalid
+	}
+
+	data, err := p.markVerified(ctx, customerId, dataUser, vf)
+
+	log.Println("CustomerService::ConfirmEmailVerification - End ", err)
+	return data, err
+}
+
+// ConfirmPhoneVerification - Verify the Phone with the Code sent
+func (p *customerBaseService) ConfirmPhoneVerification(customerId string, code string) (_ utils.Map, err error) {
+
+	log.Println("CustomerService::ConfirmPhoneVerification - Begin", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "ConfirmPhoneVerification", p.businessId)
+	defer span.EndWith(&err)
+
+	vf := customerVerifyChannels[sales_notifier.CHANNEL_SMS]
+
+	_, err = p.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Take the attempt before verifying, so that parallel requests can not try more than the allowed attempts
+	errInvalid := &utils.AppError{ErrorCode: "S30340145", ErrorMsg: "Invalid Verification", ErrorDetail: "Verification is invalid or expired, request a new one"}
+	dataUser, err := p.daoCustomer.IncrementAttempts(ctx, customerId, vf.attempts, sales_common.VERIFY_CODE_MAX_ATTEMPTS)
+	if err != nil {
+		return utils.Map{}, errInvalid
+	}
+	codeHash, _ := utils.GetMemberDataStr(dataUser, vf.code)
+	expiry, _ := sales_common.GetMemberDataTime(dataUser, vf.expiry)
+	if len(codeHash) == 0 || time.Now().After(expiry) {
+		return utils.Map{}, errInvalid
+	}
+
+	matched, _ := sales_common.VerifyPassword(codeHash, code)
+	if !matched {
+		err := &utils.AppError{ErrorCode: "S30340146", ErrorMsg: "Wrong Code", ErrorDetail: "Given verification code is wrong !!"}
+		return utils.Map{}, err
+	}
+
+	data, err := p.markVerified(ctx, customerId, dataUser, vf)
+
+	log.Println("CustomerService::ConfirmPhoneVerification - End ", err)
+	return data, err
+}
+
+// markVerified - Mark the channel and the Customer as verified, the Token/Code can not be used again
+func (p *customerBaseService) markVerified(ctx context.Context, customerId string, dataUser utils.Map, vf customerVerifyFields) (utils.Map, error) {
+	indata := utils.Map{
+		vf.verified:               true,
+		vf.code:                   "",
+		vf.expiry:                 nil,
+		vf.attempts:               0,
+		db_common.FLD_IS_VERIFIED: true,
+	}
+
+	data, err := p.daoCustomer.Update(ctx, customerId, indata)
+	removeSecrets(data)
+
+	return data, err
+}
+
+// resetChangedVerification - Mark the changed Email/Phone in indata as not verified, returns the changed channels.
+// Customer stays verified only if the other channel is verified
+func (p *customerBaseService) resetChangedVerification(ctx context.Context, customerId string, indata utils.Map) ([]string, error) {
+	changedChannels := []string{}
+	anyChanged := false
+
+	var dataUser utils.Map
+	for channel, vf := range customerVerifyChannels {
+		sendTo, ok := indata[vf.sendTo].(string)
+		if !ok {
+			continue
+		}
+
+		if dataUser == nil {
+			data, err := p.daoCustomer.Get(ctx, customerId)
+			if err != nil {
+				return nil, err
+			}
+			dataUser = data
+		}
+
+		current, _ := utils.GetMemberDataStr(dataUser, vf.sendTo)
+		if sendTo == current {
+			continue
+		}
+
+		indata[vf.verified] = false
+		indata[vf.code] = ""
+		indata[vf.expiry] = nil
+		indata[vf.attempts] = 0
+		anyChanged = true
+		if len(sendTo) > 0 {
+			changedChannels = append(changedChannels, channel)
+		}
+	}
+
+	if anyChanged {
+		if _, ok := indata[db_common.FLD_IS_VERIFIED]; !ok {
+			verified := false
+			for _, vf := range customerVerifyChannels {
+				channelVerified, ok := indata[vf.verified].(bool)
+				if !ok {
+					channelVerified, _ = dataUser[vf.verified].(bool)
+				}
+				verified = verified || channelVerified
+			}
+			indata[db_common.FLD_IS_VERIFIED] = verified
+		}
+	}
+
+	return changedChannels, nil
+}
+
+// SendOTP - Generate OTP for the Customer and send it through the channel (sms/email). Returns nil whether or
+// not the OTP is sent, so it does not reveal the Customer or the channels. The OTP can be sent again after
+// VERIFY_RESEND_SECONDS and at most VERIFY_MAX_SENDS_PER_DAY times
+func (p *customerBaseService) SendOTP(auth_key string, auth_login string, channel string) (err error) {
+	log.Println("SendOTP::  Begin ", auth_key, auth_login, channel)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "SendOTP", p.businessId)
+	defer span.EndWith(&err)
+
+	errSend := p.sendOTP(ctx, auth_key, auth_login, channel)
+	if errSend != nil {
+		log.Println("SendOTP:: Not sent ", auth_login, errSend)
+	}
+
+	log.Println("SendOTP::  End ")
+	return nil
+}
+
+func (p *customerBaseService) sendOTP(ctx context.Context, auth_key string, auth_login string, channel string) error {
+	dataUser, err := p.getByLogin(ctx, "SendOTP", auth_key, auth_login)
+	if err != nil {
+		return err
+	}
+
+	var sendTo string
+	switch channel {
+	case sales_notifier.CHANNEL_SMS:
+		sendTo, _ = utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_PHONE)
+	case sales_notifier.CHANNEL_EMAIL:
+		sendTo, _ = utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_EMAIL)
+	default:
+		err := &utils.AppError{ErrorCode: "S30340110", ErrorMsg: "Invalid Channel", ErrorDetail: "OTP can be sent only through sms or email"}
+		return err
+	}
+	if len(sendTo) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340111", ErrorMsg: "Channel not available", ErrorDetail: "Customer has no " + channel + " to send the OTP"}
+		return err
+	}
+
+	// Rate limit the sends. The attempts are counted across the OTPs sent in a day, so sending again does not
+	// give more attempts
+	now := time.Now()
+	sends, _ := utils.GetMemberDataInt(dataUser, sales_common.FLD_CUSTOMER_OTP_SENDS, true)
+	sentAt, err := sales_common.GetMemberDataTime(dataUser, sales_common.FLD_CUSTOMER_OTP_SENT_AT)
+	newDay := err != nil || now.Sub(sentAt) >= 24*time.Hour
+	if !newDay && now.Sub(sentAt) < sales_common.VERIFY_RESEND_SECONDS*time.Second {
+		err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340112", ErrorMsg: "OTP already sent",
+			ErrorDetail: fmt.Sprintf("OTP can be sent again after %d seconds", sales_common.VERIFY_RESEND_SECONDS)}
+		return err
+	}
+	if newDay {
+		// Start counting again after a day without sends
+		sends = 0
+	}
+	if sends >= sales_common.VERIFY_MAX_SENDS_PER_DAY {
+		err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340113", ErrorMsg: "Too many OTPs sent", ErrorDetail: "OTP can be sent again after a day"}
+		return err
+	}
+
+	otp := utils.GetRandomOTP(sales_common.OTP_LENGTH)
+	otpHash, err := sales_common.HashPassword(otp)
+	if err != nil {
+		return err
+	}
+
+	// Replace the earlier OTP if any, so only the latest one is valid
+	customerId, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_ID)
+	indata := utils.Map{
+		sales_common.FLD_CUSTOMER_OTP:         otpHash,
+		sales_common.FLD_CUSTOMER_OTP_EXPIRY:  now.Add(sales_common.OTP_VALIDITY_MINUTES * time.Minute),
+		sales_common.FLD_CUSTOMER_OTP_SENT_AT: now,
+		sales_common.FLD_CUSTOMER_OTP_SENDS:   sends + 1,
+	}
+	if newDay {
+		indata[sales_common.FLD_CUSTOMER_OTP_ATTEMPTS] = 0
+	}
+	_, err = p.daoCustomer.Update(ctx, customerId, indata)
+	if err != nil {
+		return err
+	}
+
+	return sales_notifier.Send(sales_notifier.Message{
+		Channel:    channel,
+		To:         sendTo,
+		Purpose:    sales_notifier.PURPOSE_OTP,
+		BusinessId: p.businessId,
+		Subject:    "Your login OTP",
+		Body:       fmt.Sprintf("%s is your OTP to login. It is valid for %d minutes.", otp, sales_common.OTP_VALIDITY_MINUTES),
+		Data:       utils.Map{sales_common.AUTH_OTP: otp, sales_common.FLD_CUSTOMER_ID: customerId},
+	})
+}
+
+// AuthenticateOTP - Authenticate Customer with the OTP sent
+func (p *customerBaseService) AuthenticateOTP(auth_key string, auth_login string, otp string) (_ utils.Map, err error) {
+	log.Println("AuthenticateOTP::  Begin ", auth_key, auth_login)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "customer", "AuthenticateOTP", p.businessId)
+	defer span.EndWith(&err)
+
+	login := p.normalizeLogin(ctx, auth_key, auth_login)
+	err = p.checkLoginLocked(ctx, loginAttemptId(auth_key, login))
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	dataUser, err := p.getByLogin(ctx, "AuthenticateOTP", auth_key, auth_login)
+	if err != nil {
+		p.recordLoginFailure(ctx, auth_key, login, "")
+		err := &utils.AppError{ErrorCode: "S30340101", ErrorMsg: "Wrong Credentials", ErrorDetail: "Authenticate credentials is wrong !!"}
+		return utils.Map{}, err
+	}
+
+	customerId, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_ID)
+	err = p.checkLoginLocked(ctx, customerAttemptId(customerId))
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Take the attempt before verifying, so that parallel requests can not try more than the allowed attempts
+	errExpired := &utils.AppError{ErrorCode: "S30340104", ErrorMsg: "OTP Expired", ErrorDetail: "OTP is expired or not requested, request a new OTP"}
+	dataUser, err = p.daoCustomer.IncrementAttempts(ctx, customerId, sales_common.FLD_CUSTOMER_OTP_ATTEMPTS, sales_common.OTP_MAX_ATTEMPTS)
+	if err != nil {
+		return utils.Map{}, errExpired
+	}
+	otpHash, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_OTP)
+	otpExpiry, _ := sales_common.GetMemberDataTime(dataUser, sales_common.FLD_CUSTOMER_OTP_EXPIRY)
+	if len(otpHash) == 0 || time.Now().After(otpExpiry) {
+		return utils.Map{}, errExpired
+	}
+
+	matched, _ := sales_common.VerifyPassword(otpHash, otp)
+	if !matched {
+		p.recordLoginFailure(ctx, auth_key, login, customerId)
+		err := &utils.AppError{ErrorCode: "S30340105", ErrorMsg: "Wrong OTP", ErrorDetail: "Given OTP is wrong !!"}
+		return utils.Map{}, err
+	}
+
+	// OTP is single use, clear it
+	err = p.clearOTP(ctx, customerId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	p.clearLoginFailures(ctx, loginAttemptId(auth_key, login), customerAttemptId(customerId))
+
+	removeSecrets(dataUser)
+
+	err = checkCustomerStatus(dataUser)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("AuthenticateOTP::  End ", customerId)
+	return dataUser, nil
+}
+
+func (p *customerBaseService) clearOTP(ctx context.Context, customerId string) error {
+	indata := utils.Map{
+		sales_common.FLD_CUSTOMER_OTP:          "",
+		sales_common.FLD_CUSTOMER_OTP_EXPIRY:   nil,
+		sales_common.FLD_CUSTOMER_OTP_ATTEMPTS: 0,
+	}
+	_, err := p.daoCustomer.Update(ctx, customerId, indata)
+	return err
+}
+
+// getLoginConfig - Get the login types enabled and the default phone country code for the business
+func (p *customerBaseService) getLoginConfig(ctx context.Context) ([]string, string) {
+	loginTypes := []string{sales_common.LOGIN_TYPE_LOGINID}
+
+	dataPref, err := p.daoPreference.Get(ctx, sales_common.PREFERENCE_CUSTOMER_LOGIN)
+	if err != nil {
+		// Not configured, only LoginId is allowed
+		return loginTypes, ""
+	}
+
+	prefTypes, err := sales_common.GetMemberDataStrArray(dataPref, sales_common.FLD_LOGIN_TYPES)
+	if err == nil && len(prefTypes) > 0 {
+		loginTypes = prefTypes
+	}
+	countryCode, _ := utils.GetMemberDataStr(dataPref, sales_common.FLD_LOGIN_PHONE_COUNTRY_CODE)
+
+	return loginTypes, countryCode
+}
+
+// normalizeLogins - Normalize the LoginId, Email and Phone in indata if passed, and verify
+// that no other Customer of the business has the same
+func (p *customerBaseService) normalizeLogins(ctx context.Context, customerId string, indata utils.Map) error {
+	_, countryCode := p.getLoginConfig(ctx)
+
+	for loginType, loginKey := range customerLoginKeys {
+		dataVal, dataOk := indata[loginKey].(string)
+		if !dataOk || len(strings.TrimSpace(dataVal)) == 0 {
+			continue
+		}
+
+		login, err := sales_common.NormalizeLogin(loginType, dataVal, countryCode)
+		if err != nil {
+			return err
+		}
+		indata[loginKey] = login
+
+		dataUser, err := p.daoCustomer.GetByLogin(ctx, loginKey, login)
+		if err != nil {
+			continue
+		}
+		otherId, _ := utils.GetMemberDataStr(dataUser, sales_common.FLD_CUSTOMER_ID)
+		if otherId != customerId {
+			err := &utils.AppError{ErrorCode: "S30340130", ErrorMsg: "Login already exist", ErrorDetail: "Given " + loginType + " " + login + " is already used by another Customer"}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeLogin - Normalize the login for the Customer field, the login is returned as is
+// if it cannot be normalized
+func (p *customerBaseService) normalizeLogin(ctx context.Context, auth_key string, auth_login string) string {
+	for loginType, loginKey := range customerLoginKeys {
+		if loginKey == auth_key {
+			_, countryCode := p.getLoginConfig(ctx)
+			normalized, err := sales_common.NormalizeLogin(loginType, auth_login, countryCode)
+			if err == nil {
+				return normalized
+			}
+			break
+		}
+	}
+	return auth_login
+}
+
+// getByLogin - Get the Customer by the login, normalized as stored. Falls back to the login
+// as entered for the Customers stored before the normalization
+func (p *customerBaseService) getByLogin(ctx context.Context, operation string, auth_key string, auth_login string) (utils.Map, error) {
+
+	login := p.normalizeLogin(ctx, auth_key, auth_login)
+
+	dataUser, err := p.daoCustomer.GetByLogin(ctx, auth_key, login)
+	if err != nil && login != auth_login {
+		dataUser, err = p.daoCustomer.GetByLogin(ctx, auth_key, auth_login)
+	}
+
+	return dataUser, err
+}
+
+// loginAttemptId - Id of the failed attempts record for the login identifier
+func loginAttemptId(auth_key string, login string) string {
+	return auth_key + ":" + login
+}
+
+// customerAttemptId - Id of the failed attempts record for the Customer, empty if the Customer is unknown
+func customerAttemptId(customerId string) string {
+	if len(customerId) == 0 {
+		return ""
+	}
+	return sales_common.FLD_CUSTOMER_ID + ":" + customerId
+}
+
+// customerAttemptIds - Ids of the failed attempts records for the Customer and all its login identifiers
+func customerAttemptIds(customerId string, dataUser utils.Map) []string {
+	attemptIds := []string{customerAttemptId(customerId)}
+	for _, loginKey := range customerLoginKeys {
+		login, _ := utils.GetMemberDataStr(dataUser, loginKey)
+		if len(login) > 0 {
+			attemptIds = append(attemptIds, loginAttemptId(loginKey, login))
+		}
+	}
+	return attemptIds
+}
+
+// checkLoginLocked - Verify the login is not delayed or locked out because of the earlier failures
+func (p *customerBaseService) checkLoginLocked(ctx context.Context, attemptId string) error {
+	if len(attemptId) == 0 {
+		return nil
+	}
+
+	dataAttempt, err := p.daoAttempt.Get(ctx, attemptId)
+	if err != nil {
+		// No failures recorded
+		return nil
+	}
+
+	lockedUntil, err := sales_common.GetMemberDataTime(dataAttempt, sales_common.FLD_LOGIN_LOCKED_UNTIL)
+	if err == nil && time.Now().Before(lockedUntil) {
+		waitSeconds := int(time.Until(lockedUntil).Seconds()) + 1
+		err := &utils.AppError{ErrorStatus: 429, ErrorCode: "S30340106", ErrorMsg: "Too many failed attempts",
+			ErrorDetail: fmt.Sprintf("Login is locked, try again after %d seconds", waitSeconds)}
+		return err
+	}
+
+	return nil
+}
+
+// recordLoginFailure - Count the failed attempt for the login identifier if given and the Customer if known,
+// and delay or lock out the next attempts
+func (p *customerBaseService) recordLoginFailure(ctx context.Context, auth_key string, login string, customerId string) {
+
+	attemptIds := []string{}
+	if len(login) > 0 {
+		attemptIds = append(attemptIds, loginAttemptId(auth_key, login))
+	}
+	if len(customerId) > 0 {
+		attemptIds = append(attemptIds, customerAttemptId(customerId))
+	}
+
+	for _, attemptId := range attemptIds {
+		now := time.Now()
+		indata := utils.Map{
+			sales_common.FLD_LOGIN_KEY:            auth_key,
+			sales_common.FLD_LOGIN_VALUE:          login,
+			sales_common.FLD_CUSTOMER_ID:          customerId,
+			sales_common.FLD_LOGIN_LAST_FAILED_AT: now,
+		}
+
+		dataAttempt, err := p.daoAttempt.RecordFailure(ctx, attemptId, indata)
+		if err != nil {
+			log.Println("recordLoginFailure:: Failed ", attemptId, err)
+			continue
+		}
+
+		failures, _ := utils.GetMemberDataInt(dataAttempt, sales_common.FLD_LOGIN_FAILED_ATTEMPTS, true)
+		if failures < sales_common.LOGIN_DELAY_AFTER_ATTEMPTS {
+			continue
+		}
+
+		indata = utils.Map{}
+		if failures >= sales_common.LOGIN_LOCKOUT_ATTEMPTS {
+			// Lock out and start counting again once the lockout is over
+			indata[sales_common.FLD_LOGIN_LOCKED_UNTIL] = now.Add(sales_common.LOGIN_LOCKOUT_MINUTES * time.Minute)
+			indata[sales_common.FLD_LOGIN_FAILED_ATTEMPTS] = 0
+
+			p.createLoginEvent(ctx, sales_common.LOGIN_EVENT_LOCKED, utils.Map{
+				sales_common.FLD_LOGIN_ATTEMPT_ID:      attemptId,
+				sales_common.FLD_LOGIN_KEY:             auth_key,
+				sales_common.FLD_LOGIN_VALUE:           login,
+				sales_common.FLD_CUSTOMER_ID:           customerId,
+				sales_common.FLD_LOGIN_FAILED_ATTEMPTS: failures,
+				sales_common.FLD_LOGIN_LOCKED_UNTIL:    indata[sales_common.FLD_LOGIN_LOCKED_UNTIL],
+			})
+		} else {
+			// Progressive delay 1, 2, 4.. seconds
+			delay := 1 << (failures - sales_common.LOGIN_DELAY_AFTER_ATTEMPTS)
+			if delay > sales_common.LOGIN_DELAY_MAX_SECONDS {
+				delay = sales_common.LOGIN_DELAY_MAX_SECONDS
+			}
+			indata[sales_common.FLD_LOGIN_LOCKED_UNTIL] = now.Add(time.Duration(delay) * time.Second)
+		}
+
+		_, err = p.daoAttempt.Update(ctx, attemptId, indata)
+		if err != nil {
+			log.Println("recordLoginFailure:: Failed to lock ", attemptId, err)
+		}
+	}
+}
+
+// clearLoginFailures - Remove the failed attempts records
+func (p *customerBaseService) clearLoginFailures(ctx context.Context, attemptIds ...string) {
+	for _, attemptId := range attemptIds {
+		if len(attemptId) == 0 {
+			continue
+		}
+		_, err := p.daoAttempt.Delete(ctx, attemptId)
+		if err != nil {
+			log.Println("clearLoginFailures:: Failed ", attemptId, err)
+		}
+	}
+}
+
+// createLoginEvent - Record the login security event, failure is only logged
+func (p *customerBaseService) createLoginEvent(ctx context.Context, eventType string, indata utils.Map) {
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_LOGIN_EVENT_ID] = utils.GenerateUniqueId("lgev")
+	indata[sales_common.FLD_LOGIN_EVENT_TYPE] = eventType
+
+	log.Println("CustomerService::LoginEvent ", indata)
+	_, err := p.daoLoginEvent.Create(ctx, indata)
+	if err != nil {
+		log.Println("createLoginEvent:: Failed ", eventType, err)
+	}
+}
+
+// checkCustomerStatus - Verify the Customer is allowed to login
+func checkCustomerStatus(dataUser utils.Map) error {
+
+	dataval, dataok := dataUser[db_common.FLD_IS_DELETED]
+	if dataok && !dataval.(bool) {
+		err := &utils.AppError{ErrorCode: "S30340102", ErrorMsg: "User not in Active Mode. Contact Admin!", ErrorDetail: "User not in Active Mode. Contact Admin!"}
+		return err
+	}
+
+	dataval, dataok = dataUser[db_common.FLD_IS_VERIFIED]
+	if dataok && !dataval.(bool) {
+		err := &utils.AppError{ErrorCode: "S30340103", ErrorMsg: "User not yet verified!", ErrorDetail: "User not yet verified!!"}
+		return err
+	}
+
+	return nil
+}
+
+// removeSecrets - Remove the Password and OTP details from the Customer data
+func removeSecrets(data utils.Map) {
+	delete(data, sales_common.FLD_CUSTOMER_PASSWORD)
+	delete(data, sales_common.FLD_CUSTOMER_OTP)
+	delete(data, sales_common.FLD_CUSTOMER_OTP_EXPIRY)
+	delete(data, sales_common.FLD_CUSTOMER_OTP_ATTEMPTS)
+	delete(data, sales_common.FLD_CUSTOMER_OTP_SENT_AT)
+	delete(data, sales_common.FLD_CUSTOMER_OTP_SENDS)
+	delete(data, sales_common.FLD_CUSTOMER_RESET_CODE)
+	delete(data, sales_common.FLD_CUSTOMER_RESET_EXPIRY)
+	for _, vf := range customerVerifyChannels {
+		delete(data, vf.code)
+		delete(data, vf.expiry)
+		delete(data, vf.attempts)
+	}
+}
+
+// hashPassword - Replace the plain Password in indata with its hash, if passed
+func (p *customerBaseService) hashPassword(indata utils.Map) error {
+	dataVal, dataOk := indata[sales_common.FLD_CUSTOMER_PASSWORD]
+	if !dataOk {
+		return nil
+	}
+
+	hash, err := sales_common.HashPassword(fmt.Sprint(dataVal))
+	if err != nil {
+		return err
+	}
+	indata[sales_common.FLD_CUSTOMER_PASSWORD] = hash
+	return nil
+}
+
 func (p *customerBaseService) errorReturn(err error) (CustomerService, error) {
 	// Close the Database Connection
 	p.EndService()
@@ -0,0 +1,316 @@
+package sales_services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_notifier"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeCustomerDao - Single Customer in memory, the methods not used by the tests are left to the nil interface
+type fakeCustomerDao struct {
+	sales_repository.CustomerDao
+	mutex    sync.Mutex
+	customer utils.Map
+}
+
+func (f *fakeCustomerDao) copyCustomer() utils.Map {
+	data := utils.Map{}
+	for key, value := range f.customer {
+		data[key] = value
+	}
+	return data
+}
+
+func (f *fakeCustomerDao) Get(ctx context.Context, customerId string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copyCustomer(), nil
+}
+
+func (f *fakeCustomerDao) GetByLogin(ctx context.Context, auth_key string, auth_login string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.customer[auth_key] != auth_login {
+		return nil, mongo.ErrNoDocuments
+	}
+	return f.copyCustomer(), nil
+}
+
+func (f *fakeCustomerDao) Update(ctx context.Context, customerId string, indata utils.Map) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for key, value := range indata {
+		f.customer[key] = value
+	}
+	return f.copyCustomer(), nil
+}
+
+func (f *fakeCustomerDao) IncrementAttempts(ctx context.Context, customerId string, attemptsField string, maxAttempts int) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	attempts, _ := f.customer[attemptsField].(int)
+	if attempts >= maxAttempts {
+		return nil, mongo.ErrNoDocuments
+	}
+	f.customer[attemptsField] = attempts + 1
+	return f.copyCustomer(), nil
+}
+
+func (f *fakeCustomerDao) ConsumeResetCode(ctx context.Context, customerId string, resetCode string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.customer[sales_common.FLD_CUSTOMER_RESET_CODE] != resetCode {
+		return nil, mongo.ErrNoDocuments
+	}
+	f.customer[sales_common.FLD_CUSTOMER_RESET_CODE] = ""
+	f.customer[sales_common.FLD_CUSTOMER_RESET_EXPIRY] = nil
+	return f.copyCustomer(), nil
+}
+
+// fakeLoginAttemptDao - No lockouts, failures are only counted
+type fakeLoginAttemptDao struct {
+	sales_repository.LoginAttemptDao
+}
+
+func (f *fakeLoginAttemptDao) Get(ctx context.Context, attemptId string) (utils.Map, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeLoginAttemptDao) RecordFailure(ctx context.Context, attemptId string, indata utils.Map) (utils.Map, error) {
+	return utils.Map{sales_common.FLD_LOGIN_FAILED_ATTEMPTS: 1}, nil
+}
+
+func (f *fakeLoginAttemptDao) Delete(ctx context.Context, attemptId string) (int64, error) {
+	return 1, nil
+}
+
+// fakePreferenceDao - Only the given Preferences are configured
+type fakePreferenceDao struct {
+	sales_repository.PreferenceDao
+	preferences map[string]utils.Map
+}
+
+func (f *fakePreferenceDao) Get(ctx context.Context, preferenceId string) (utils.Map, error) {
+	if dataPref, ok := f.preferences[preferenceId]; ok {
+		return dataPref, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// fakeSessionDao, fakeTokenDao - Nothing to revoke
+type fakeSessionDao struct {
+	customer_repository.CustomerSessionDao
+}
+
+func (f *fakeSessionDao) RevokeByCustomer(ctx context.Context, customerId string) (int64, error) {
+	return 0, nil
+}
+
+type fakeTokenDao struct {
+	customer_repository.CustomerTokenDao
+}
+
+func (f *fakeTokenDao) RevokeByCustomer(ctx context.Context, customerId string) (int64, error) {
+	return 0, nil
+}
+
+func newOTPTestService(t *testing.T, otp string, attempts int, expiry time.Time) (*customerBaseService, *fakeCustomerDao) {
+	otpHash, err := sales_common.HashPassword(otp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daoCustomer := &fakeCustomerDao{customer: utils.Map{
+		sales_common.FLD_CUSTOMER_ID:           "cust1",
+		sales_common.FLD_CUSTOMER_LOGIN_ID:     "john",
+		sales_common.FLD_CUSTOMER_OTP:          otpHash,
+		sales_common.FLD_CUSTOMER_OTP_EXPIRY:   expiry,
+		sales_common.FLD_CUSTOMER_OTP_ATTEMPTS: attempts,
+	}}
+	p := &customerBaseService{
+		daoCustomer:   daoCustomer,
+		daoAttempt:    &fakeLoginAttemptDao{},
+		daoPreference: &fakePreferenceDao{},
+		businessId:    "biz1",
+		ctx:           context.Background(),
+	}
+	return p, daoCustomer
+}
+
+func errorCode(err error) string {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr.ErrorCode
+	}
+	return ""
+}
+
+func TestAuthenticateOTP(t *testing.T) {
+	validTill := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		attempts int
+		expiry   time.Time
+		otps     []string
+		wantCode string
+	}{
+		{"correct", 0, validTill, []string{"123456"}, ""},
+		{"wrong then correct", 0, validTill, []string{"000000", "123456"}, ""},
+		{"last attempt", sales_common.OTP_MAX_ATTEMPTS - 1, validTill, []string{"123456"}, ""},
+		{"attempts used up", sales_common.OTP_MAX_ATTEMPTS, validTill, []string{"123456"}, "S30340104"},
+		{"expired", 0, time.Now().Add(-time.Minute), []string{"123456"}, "S30340104"},
+		{"wrong", 0, validTill, []string{"000000"}, "S30340105"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newOTPTestService(t, "123456", tt.attempts, tt.expiry)
+
+			var err error
+			for _, otp := range tt.otps {
+				_, err = p.AuthenticateOTP(sales_common.FLD_CUSTOMER_LOGIN_ID, "john", otp)
+			}
+			if got := errorCode(err); got != tt.wantCode {
+				t.Errorf("AuthenticateOTP() error = %v, want code %q", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAuthenticateOTPParallelAttempts(t *testing.T) {
+	p, daoCustomer := newOTPTestService(t, "123456", 0, time.Now().Add(time.Minute))
+
+	// Twice the allowed wrong attempts at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < 2*sales_common.OTP_MAX_ATTEMPTS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.AuthenticateOTP(sales_common.FLD_CUSTOMER_LOGIN_ID, "john", "000000")
+		}()
+	}
+	wg.Wait()
+
+	if attempts := daoCustomer.customer[sales_common.FLD_CUSTOMER_OTP_ATTEMPTS]; attempts != sales_common.OTP_MAX_ATTEMPTS {
+		t.Errorf("attempts = %v, want %d", attempts, sales_common.OTP_MAX_ATTEMPTS)
+	}
+
+	// Correct OTP is not accepted any more
+	_, err := p.AuthenticateOTP(sales_common.FLD_CUSTOMER_LOGIN_ID, "john", "123456")
+	if got := errorCode(err); got != "S30340104" {
+		t.Errorf("AuthenticateOTP() after the attempts error = %v, want code S30340104", err)
+	}
+}
+
+// countSender - Counts the Messages sent
+type countSender struct {
+	mutex sync.Mutex
+	sent  int
+}
+
+func (s *countSender) Send(msg sales_notifier.Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sent++
+	return nil
+}
+
+func TestSendOTP(t *testing.T) {
+	now := time.Now()
+	resendAt := now.Add(-(sales_common.VERIFY_RESEND_SECONDS + 1) * time.Second)
+
+	tests := []struct {
+		name         string
+		login        string
+		channel      string
+		customer     utils.Map
+		wantSent     int
+		wantAttempts int
+	}{
+		{"first send", "john", sales_notifier.CHANNEL_SMS, utils.Map{}, 1, 0},
+		{"unknown login", "jane", sales_notifier.CHANNEL_SMS, utils.Map{}, 0, 0},
+		{"invalid channel", "john", "fax", utils.Map{}, 0, 0},
+		{"no email", "john", sales_notifier.CHANNEL_EMAIL, utils.Map{}, 0, 0},
+		{"resend too soon", "john", sales_notifier.CHANNEL_SMS,
+			utils.Map{sales_common.FLD_CUSTOMER_OTP_SENT_AT: now, sales_common.FLD_CUSTOMER_OTP_SENDS: 1}, 0, 3},
+		{"resend keeps attempts", "john", sales_notifier.CHANNEL_SMS,
+			utils.Map{sales_common.FLD_CUSTOMER_OTP_SENT_AT: resendAt, sales_common.FLD_CUSTOMER_OTP_SENDS: 1}, 1, 3},
+		{"sends used up", "john", sales_notifier.CHANNEL_SMS,
+			utils.Map{sales_common.FLD_CUSTOMER_OTP_SENT_AT: resendAt, sales_common.FLD_CUSTOMER_OTP_SENDS: sales_common.VERIFY_MAX_SENDS_PER_DAY}, 0, 3},
+		{"next day", "john", sales_notifier.CHANNEL_SMS,
+			utils.Map{sales_common.FLD_CUSTOMER_OTP_SENT_AT: now.Add(-25 * time.Hour), sales_common.FLD_CUSTOMER_OTP_SENDS: sales_common.VERIFY_MAX_SENDS_PER_DAY}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, daoCustomer := newOTPTestService(t, "123456", 3, now.Add(time.Minute))
+			daoCustomer.customer[sales_common.FLD_CUSTOMER_PHONE] = "919876543210"
+			for key, value := range tt.customer {
+				daoCustomer.customer[key] = value
+			}
+			sender := &countSender{}
+			sales_notifier.SetSender(sender)
+			defer sales_notifier.SetSender(nil)
+
+			if err := p.SendOTP(sales_common.FLD_CUSTOMER_LOGIN_ID, tt.login, tt.channel); err != nil {
+				t.Errorf("SendOTP() error = %v, want nil", err)
+			}
+			if sender.sent != tt.wantSent {
+				t.Errorf("SendOTP() sent %d, want %d", sender.sent, tt.wantSent)
+			}
+			if tt.wantSent > 0 {
+				if got := daoCustomer.customer[sales_common.FLD_CUSTOMER_OTP_ATTEMPTS]; got != tt.wantAttempts {
+					t.Errorf("attempts = %v, want %d", got, tt.wantAttempts)
+				}
+			}
+		})
+	}
+}
+
+func TestResetPasswordParallel(t *testing.T) {
+	sales_common.SetTokenSigningKey([]byte("0123456789abcdef0123456789abcdef"))
+
+	p, daoCustomer := newOTPTestService(t, "123456", 0, time.Now().Add(time.Minute))
+	p.daoSession = &fakeSessionDao{}
+	p.daoToken = &fakeTokenDao{}
+
+	expiry := time.Now().Add(sales_common.PASSWORD_RESET_VALIDITY_MINUTES * time.Minute)
+	token, err := sales_common.SignToken("cust1", sales_common.PASSWORD_RESET_TOKEN_PURPOSE, strconv.FormatInt(expiry.Unix(), 10), "nonce1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daoCustomer.customer[sales_common.FLD_CUSTOMER_RESET_CODE] = utils.SHA("nonce1")
+
+	// Same Token used at the same time
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p.ResetPassword(token, "NewPassword@1") == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("ResetPassword() succeeded %d times, want once", succeeded)
+	}
+	storedHash, _ := daoCustomer.customer[sales_common.FLD_CUSTOMER_PASSWORD].(string)
+	if matched, _ := sales_common.VerifyPassword(storedHash, "NewPassword@1"); !matched {
+		t.Errorf("Password was not changed")
+	}
+}
@@ -0,0 +1,596 @@
+package dataio_services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_services"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Number of records fetched per List call during Export
+const exportBatchSize = 500
+
+// Error codes of the Import/Export are funcode + 01..04
+var funcode = sales_common.GetServiceModuleCode() + "M" + "02"
+
+// Types of the fields, values of the typed fields are converted on Import as CSV has only strings
+const (
+	fieldTypeNumber  = "number"
+	fieldTypeDecimal = "decimal" // primitive.Decimal128, the amounts
+	fieldTypeBool    = "bool"
+	fieldTypeTime    = "time" // RFC3339 as written by Export
+	fieldTypeJSON    = "json" // Array or object, written as JSON by Export
+)
+
+// Typed fields common to all the entities
+var commonFieldTypes = map[string]string{
+	db_common.FLD_CREATED_AT: fieldTypeTime,
+	db_common.FLD_UPDATED_AT: fieldTypeTime,
+	db_common.FLD_IS_DELETED: fieldTypeBool,
+}
+
+// ImportOptions - Options for Import
+type ImportOptions struct {
+	Format    string            // sales_common.DATAIO_FORMAT_CSV or sales_common.DATAIO_FORMAT_JSONL
+	ColumnMap map[string]string // Source column => Entity field, unmapped columns are taken as it is
+	Upsert    bool              // Update the record if the id already exist, otherwise report as error
+	DryRun    bool              // Validate the rows only, nothing will be written
+	Workers   int               // Number of parallel workers, default 1
+}
+
+// ExportOptions - Options for Export
+type ExportOptions struct {
+	Format  string   // sales_common.DATAIO_FORMAT_CSV or sales_common.DATAIO_FORMAT_JSONL
+	Filter  string   // Same filter as accepted by List
+	Sort    string   // Same sort as accepted by List, default by the id field so the batches do not overlap
+	Columns []string // Columns to export in CSV, default all the fields of all the records
+}
+
+type CatalogueDataIOService interface {
+	// Import - Import the records of entity from reader and return the report
+	Import(entity string, reader io.Reader, opts ImportOptions) (utils.Map, error)
+	// Export - Export the records of entity to writer
+	Export(entity string, writer io.Writer, opts ExportOptions) (utils.Map, error)
+
+	EndService()
+}
+
+// catalogueEntityService - Methods common to all the catalogue services
+type catalogueEntityService interface {
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	Get(id string) (utils.Map, error)
+	Create(indata utils.Map) (utils.Map, error)
+	Update(id string, indata utils.Map) (utils.Map, error)
+	EndService()
+}
+
+// catalogueValidator - Implemented by the services which validate the record before writing it
+type catalogueValidator interface {
+	Validate(indata utils.Map) error
+}
+
+type catalogueEntity struct {
+	idField        string
+	fieldTypes     map[string]string // Typed fields other than commonFieldTypes
+	readOnlyFields []string          // Maintained by the service, dropped on Import
+	newService     func(props utils.Map) (catalogueEntityService, error)
+}
+
+var catalogueEntities = map[string]catalogueEntity{
+	sales_common.DATAIO_ENTITY_PRODUCTS: {
+		idField: sales_common.FLD_PRODUCT_ID,
+		fieldTypes: map[string]string{
+			sales_common.FLD_PRODUCT_PRICE:   fieldTypeDecimal,
+			sales_common.FLD_PRODUCT_OPTIONS: fieldTypeJSON,
+		},
+		newService: func(props utils.Map) (catalogueEntityService, error) {
+			return sales_services.NewProductService(props)
+		}},
+	sales_common.DATAIO_ENTITY_BRANDS: {
+		idField: sales_common.FLD_BRAND_ID,
+		newService: func(props utils.Map) (catalogueEntityService, error) {
+			return sales_services.NewBrandService(props)
+		}},
+	sales_common.DATAIO_ENTITY_CATEGORIES: {
+		idField: sales_common.FLD_CATEGORY_ID,
+		// Derived from the parent, the Category is moved with the parent_category_id
+		readOnlyFields: []string{sales_common.FLD_CATEGORY_ANCESTORS, sales_common.FLD_CATEGORY_PATH},
+		newService: func(props utils.Map) (catalogueEntityService, error) {
+			return sales_services.NewCategoryService(props)
+		}},
+	sales_common.DATAIO_ENTITY_DEALERS: {
+		idField: sales_common.FLD_DEALER_ID,
+		newService: func(props utils.Map) (catalogueEntityService, error) {
+			return sales_services.NewDealerService(props)
+		}},
+}
+
+type catalogueDataIOBaseService struct {
+	props      utils.Map
+	services   map[string]catalogueEntityService
+	businessId string
+}
+
+// importRow - Single row read from the import source
+type importRow struct {
+	rowNo int
+	data  utils.Map
+	err   error
+}
+
+// NewCatalogueDataIOService - Construct Catalogue Import/Export Service
+func NewCatalogueDataIOService(props utils.Map) (CatalogueDataIOService, error) {
+
+	log.Printf("CatalogueDataIOService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := catalogueDataIOBaseService{
+		props:      props,
+		services:   map[string]catalogueEntityService{},
+		businessId: businessId,
+	}
+
+	return &p, nil
+}
+
+// EndService - Close all the services opened for Import/Export
+func (p *catalogueDataIOBaseService) EndService() {
+	log.Printf("EndService ")
+	for _, svc := range p.services {
+		svc.EndService()
+	}
+	p.services = map[string]catalogueEntityService{}
+}
+
+// Import - Import the records of entity from reader and return the report
+func (p *catalogueDataIOBaseService) Import(entity string, reader io.Reader, opts ImportOptions) (utils.Map, error) {
+
+	log.Println("CatalogueDataIOService::Import - Begin", entity, opts.Format, opts.DryRun)
+
+	entityInfo, svc, err := p.getEntityService(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(chan importRow)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		readErr <- p.readRows(reader, opts, entityInfo.fieldTypes, rows)
+	}()
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mutex   sync.Mutex
+		wg      sync.WaitGroup
+		total   int
+		created int
+		updated int
+		errList = []utils.Map{}
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				var id string
+				var isUpdate bool
+				err := row.err
+				if err == nil {
+					for _, field := range entityInfo.readOnlyFields {
+						delete(row.data, field)
+					}
+					id, isUpdate, err = p.importRecord(svc, entityInfo.idField, row.data, opts)
+				}
+
+				mutex.Lock()
+				total++
+				if err != nil {
+					errList = append(errList, utils.Map{
+						sales_common.DATAIO_REPORT_ROW:   row.rowNo,
+						sales_common.DATAIO_REPORT_ID:    id,
+						sales_common.DATAIO_REPORT_ERROR: err.Error(),
+					})
+				} else if isUpdate {
+					updated++
+				} else {
+					created++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Rows could have been partially imported even if the reader failed,
+	// so return the report along with the error
+	err = <-readErr
+
+	// Keep the error report in source order irrespective of the workers
+	sort.Slice(errList, func(i, j int) bool {
+		return errList[i][sales_common.DATAIO_REPORT_ROW].(int) < errList[j][sales_common.DATAIO_REPORT_ROW].(int)
+	})
+
+	report := utils.Map{
+		sales_common.DATAIO_REPORT_TOTAL:   total,
+		sales_common.DATAIO_REPORT_CREATED: created,
+		sales_common.DATAIO_REPORT_UPDATED: updated,
+		sales_common.DATAIO_REPORT_FAILED:  len(errList),
+		sales_common.DATAIO_REPORT_DRY_RUN: opts.DryRun,
+		sales_common.DATAIO_REPORT_ERRORS:  errList,
+	}
+
+	log.Println("CatalogueDataIOService::Import - End", total, created, updated, len(errList))
+	return report, err
+}
+
+// Export - Export the records of entity to writer
+func (p *catalogueDataIOBaseService) Export(entity string, writer io.Writer, opts ExportOptions) (utils.Map, error) {
+
+	log.Println("CatalogueDataIOService::Export - Begin", entity, opts.Format)
+
+	entityInfo, svc, err := p.getEntityService(entity)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Sort) == 0 {
+		opts.Sort = fmt.Sprintf(`{"%s": 1}`, entityInfo.idField)
+	}
+
+	var csvWriter *csv.Writer
+	var exportFn func(record utils.Map) error
+	switch opts.Format {
+	case sales_common.DATAIO_FORMAT_CSV:
+		csvWriter = csv.NewWriter(writer)
+	case sales_common.DATAIO_FORMAT_JSONL:
+		jsonEncoder := json.NewEncoder(writer)
+		exportFn = func(record utils.Map) error {
+			return jsonEncoder.Encode(record)
+		}
+	default:
+		return nil, invalidFormatError(opts.Format)
+	}
+
+	if csvWriter != nil {
+		// Records may have different fields, so the header needs a pass over all of them
+		columns := opts.Columns
+		if columns == nil {
+			fields := map[string]bool{}
+			err = listRecords(svc, opts, func(record utils.Map) error {
+				for key := range record {
+					fields[key] = true
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			columns = sortedKeys(fields)
+		}
+		if len(columns) > 0 {
+			err = csvWriter.Write(columns)
+			if err != nil {
+				return nil, err
+			}
+		}
+		exportFn = func(record utils.Map) error {
+			return csvWriter.Write(toCSVRecord(record, columns))
+		}
+	}
+
+	exported := 0
+	err = listRecords(svc, opts, func(record utils.Map) error {
+		exported++
+		return exportFn(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Println("CatalogueDataIOService::Export - End", exported)
+	return utils.Map{sales_common.DATAIO_REPORT_EXPORTED: exported}, nil
+}
+
+// listRecords - Pass each of the records matching the filter to fn, in batches of exportBatchSize
+func listRecords(svc catalogueEntityService, opts ExportOptions, fn func(record utils.Map) error) error {
+	for skip := int64(0); ; skip += exportBatchSize {
+		listdata, err := svc.List(opts.Filter, opts.Sort, skip, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		results, _ := listdata[db_common.LIST_RESULT].([]utils.Map)
+		for _, record := range results {
+			err = fn(record)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(results) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func (p *catalogueDataIOBaseService) getEntityService(entity string) (catalogueEntity, catalogueEntityService, error) {
+	entityInfo, ok := catalogueEntities[entity]
+	if !ok {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid Entity",
+			ErrorDetail: fmt.Sprintf("Import/Export is not supported for %s", entity)}
+		return entityInfo, nil, err
+	}
+
+	svc, ok := p.services[entity]
+	if !ok {
+		var err error
+		svc, err = entityInfo.newService(p.props)
+		if err != nil {
+			return entityInfo, nil, err
+		}
+		p.services[entity] = svc
+	}
+
+	return entityInfo, svc, nil
+}
+
+// importRecord - Create or Update the record, returns id and whether it is an update
+func (p *catalogueDataIOBaseService) importRecord(svc catalogueEntityService, idField string, data utils.Map, opts ImportOptions) (string, bool, error) {
+	var id string
+	if dataVal, dataOk := data[idField]; dataOk && dataVal != nil {
+		// Services always store the id in lower case
+		id = strings.ToLower(fmt.Sprint(dataVal))
+	}
+
+	if len(id) > 0 {
+		data[idField] = id
+		if _, err := svc.Get(id); err == nil {
+			if !opts.Upsert {
+				err := &utils.AppError{
+					ErrorCode:   funcode + "02",
+					ErrorMsg:    "Record already exist",
+					ErrorDetail: fmt.Sprintf("%s %s already exist", idField, id)}
+				return id, true, err
+			}
+
+			if err := validateRecord(svc, data); err != nil || opts.DryRun {
+				return id, true, err
+			}
+			delete(data, idField)
+			_, err = svc.Update(id, data)
+			return id, true, err
+		}
+	} else {
+		delete(data, idField)
+	}
+
+	if err := validateRecord(svc, data); err != nil || opts.DryRun {
+		return id, false, err
+	}
+	_, err := svc.Create(data)
+	return id, false, err
+}
+
+// validateRecord - Validate the record by the service, so that DryRun reports the same failures as the Import
+func validateRecord(svc catalogueEntityService, data utils.Map) error {
+	if validator, ok := svc.(catalogueValidator); ok {
+		return validator.Validate(data)
+	}
+	return nil
+}
+
+// readRows - Read the rows from the reader and pass it to the channel
+func (p *catalogueDataIOBaseService) readRows(reader io.Reader, opts ImportOptions, fieldTypes map[string]string, rows chan<- importRow) error {
+	switch opts.Format {
+	case sales_common.DATAIO_FORMAT_CSV:
+		return readCSVRows(reader, opts.ColumnMap, fieldTypes, rows)
+	case sales_common.DATAIO_FORMAT_JSONL:
+		return readJSONLRows(reader, opts.ColumnMap, fieldTypes, rows)
+	}
+	return invalidFormatError(opts.Format)
+}
+
+func readCSVRows(reader io.Reader, columnMap map[string]string, fieldTypes map[string]string, rows chan<- importRow) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		// Empty file, nothing to import
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for idx, column := range header {
+		header[idx] = mapColumn(columnMap, strings.TrimSpace(column))
+	}
+
+	// Header is the row 1
+	for rowNo := 2; ; rowNo++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows <- importRow{rowNo: rowNo, err: err}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		data := utils.Map{}
+		for idx, value := range record {
+			// Skip empty cells, so that Upsert will not clear the existing values
+			if idx < len(header) && len(header[idx]) > 0 && len(value) > 0 {
+				data[header[idx]] = value
+			}
+		}
+		rows <- importRow{rowNo: rowNo, data: data, err: convertFields(fieldTypes, data)}
+	}
+}
+
+func readJSONLRows(reader io.Reader, columnMap map[string]string, fieldTypes map[string]string, rows chan<- importRow) error {
+	bufReader := bufio.NewReader(reader)
+
+	for rowNo := 1; ; rowNo++ {
+		line, err := bufReader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			record := utils.Map{}
+			jsonErr := json.Unmarshal(line, &record)
+			if jsonErr != nil {
+				rows <- importRow{rowNo: rowNo, err: jsonErr}
+			} else {
+				data := utils.Map{}
+				for key, value := range record {
+					data[mapColumn(columnMap, key)] = value
+				}
+				rows <- importRow{rowNo: rowNo, data: data, err: convertFields(fieldTypes, data)}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func mapColumn(columnMap map[string]string, column string) string {
+	if field, ok := columnMap[column]; ok {
+		return field
+	}
+	return column
+}
+
+// convertFields - Convert the string values of the typed fields to their type, values already of the type are
+// kept as it is
+func convertFields(fieldTypes map[string]string, data utils.Map) error {
+	for field, value := range data {
+		fieldType, ok := fieldTypes[field]
+		if !ok {
+			fieldType, ok = commonFieldTypes[field]
+		}
+		strValue, isStr := value.(string)
+		if !ok || value == nil {
+			continue
+		}
+		if fieldType == fieldTypeDecimal {
+			// JSON Lines could have the amount as number
+			decValue, err := sales_common.GetMemberDataDecimal(data, field)
+			if err != nil {
+				return invalidValueError(field, fieldType, value)
+			}
+			data[field] = decValue
+			continue
+		}
+		if !isStr {
+			continue
+		}
+
+		var err error
+		switch fieldType {
+		case fieldTypeNumber:
+			data[field], err = strconv.ParseFloat(strValue, 64)
+		case fieldTypeBool:
+			data[field], err = strconv.ParseBool(strValue)
+		case fieldTypeTime:
+			data[field], err = time.Parse(time.RFC3339, strValue)
+		case fieldTypeJSON:
+			var jsonVal interface{}
+			err = json.Unmarshal([]byte(strValue), &jsonVal)
+			data[field] = jsonVal
+		}
+		if err != nil {
+			return invalidValueError(field, fieldType, strValue)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(fields map[string]bool) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toCSVRecord(record utils.Map, columns []string) []string {
+	values := make([]string, len(columns))
+	for idx, column := range columns {
+		switch value := record[column].(type) {
+		case nil:
+			values[idx] = ""
+		case string:
+			values[idx] = value
+		case time.Time:
+			values[idx] = value.Format(time.RFC3339)
+		case primitive.Decimal128:
+			values[idx] = value.String()
+		default:
+			// Nested and non string values are exported as JSON
+			jsonVal, err := json.Marshal(value)
+			if err != nil {
+				values[idx] = fmt.Sprint(value)
+			} else {
+				values[idx] = string(jsonVal)
+			}
+		}
+	}
+	return values
+}
+
+func invalidFormatError(format string) error {
+	return &utils.AppError{
+		ErrorCode:   funcode + "03",
+		ErrorMsg:    "Invalid Format",
+		ErrorDetail: fmt.Sprintf("Format %s is not supported, use csv or jsonl", format)}
+}
+
+func invalidValueError(field string, fieldType string, value interface{}) error {
+	return &utils.AppError{
+		ErrorCode:   funcode + "04",
+		ErrorMsg:    "Invalid Value",
+		ErrorDetail: fmt.Sprintf("%s should be %s, given %v", field, fieldType, value)}
+}
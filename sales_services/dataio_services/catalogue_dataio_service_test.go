@@ -0,0 +1,257 @@
+package dataio_services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeEntityService - In memory catalogueEntityService keyed by idField
+type fakeEntityService struct {
+	idField string
+	records map[string]utils.Map
+	writes  int
+	sorts   []string
+}
+
+func newFakeEntityService(idField string, records ...utils.Map) *fakeEntityService {
+	svc := &fakeEntityService{idField: idField, records: map[string]utils.Map{}}
+	for _, record := range records {
+		svc.records[record[idField].(string)] = record
+	}
+	return svc
+}
+
+func (f *fakeEntityService) List(filter string, sortBy string, skip int64, limit int64) (utils.Map, error) {
+	f.sorts = append(f.sorts, sortBy)
+	ids := []string{}
+	for id := range f.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	results := []utils.Map{}
+	for idx := skip; idx < int64(len(ids)) && idx < skip+limit; idx++ {
+		results = append(results, f.records[ids[idx]])
+	}
+	return utils.Map{db_common.LIST_RESULT: results}, nil
+}
+
+func (f *fakeEntityService) Get(id string) (utils.Map, error) {
+	if record, ok := f.records[id]; ok {
+		return record, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeEntityService) Create(indata utils.Map) (utils.Map, error) {
+	f.writes++
+	id, _ := indata[f.idField].(string)
+	f.records[id] = indata
+	return indata, nil
+}
+
+func (f *fakeEntityService) Update(id string, indata utils.Map) (utils.Map, error) {
+	f.writes++
+	for key, value := range indata {
+		f.records[id][key] = value
+	}
+	return f.records[id], nil
+}
+
+func (f *fakeEntityService) EndService() {}
+
+// fakeValidatingService - Rejects the records with negative price
+type fakeValidatingService struct {
+	*fakeEntityService
+}
+
+func (f fakeValidatingService) Validate(indata utils.Map) error {
+	if price, _ := indata[sales_common.FLD_PRODUCT_PRICE].(primitive.Decimal128); strings.HasPrefix(price.String(), "-") {
+		return errors.New("product_price can not be negative")
+	}
+	return nil
+}
+
+func newTestService(entity string, svc catalogueEntityService) *catalogueDataIOBaseService {
+	return &catalogueDataIOBaseService{
+		props:      utils.Map{},
+		services:   map[string]catalogueEntityService{entity: svc},
+		businessId: "biz1",
+	}
+}
+
+func mustDecimal(t *testing.T, value string) primitive.Decimal128 {
+	decVal, err := primitive.ParseDecimal128(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decVal
+}
+
+func TestImportCSVConvertsTypes(t *testing.T) {
+	store := newFakeEntityService(sales_common.FLD_PRODUCT_ID)
+	p := newTestService(sales_common.DATAIO_ENTITY_PRODUCTS, store)
+
+	input := "product_id,product_name,product_price,product_options,is_deleted,created_at,pincode\n" +
+		`p1,Shirt,12.5,"[{""option_name"":""size"",""option_values"":[""S"",""M""]}]",false,2023-11-20T10:00:00Z,007001` + "\n" +
+		"p2,Cap,cheap,,,,\n"
+
+	report, err := p.Import(sales_common.DATAIO_ENTITY_PRODUCTS, strings.NewReader(input), ImportOptions{Format: sales_common.DATAIO_FORMAT_CSV})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report[sales_common.DATAIO_REPORT_CREATED] != 1 || report[sales_common.DATAIO_REPORT_FAILED] != 1 {
+		t.Fatalf("Import() report = %v, want 1 created and 1 failed", report)
+	}
+
+	record := store.records["p1"]
+	tests := []struct {
+		field string
+		want  interface{}
+	}{
+		{sales_common.FLD_PRODUCT_PRICE, mustDecimal(t, "12.5")},
+		{db_common.FLD_IS_DELETED, false},
+		{db_common.FLD_CREATED_AT, time.Date(2023, 11, 20, 10, 0, 0, 0, time.UTC)},
+		{sales_common.FLD_PRODUCT_OPTIONS, []interface{}{map[string]interface{}{"option_name": "size", "option_values": []interface{}{"S", "M"}}}},
+		// Untyped fields are kept as given, with the leading zeros
+		{"pincode", "007001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := record[tt.field]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.field, got, tt.want)
+			}
+		})
+	}
+
+	errList := report[sales_common.DATAIO_REPORT_ERRORS].([]utils.Map)
+	if errList[0][sales_common.DATAIO_REPORT_ROW] != 3 {
+		t.Errorf("failed row = %v, want 3", errList[0][sales_common.DATAIO_REPORT_ROW])
+	}
+}
+
+func TestImportDryRunValidates(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantCreated int
+		wantUpdated int
+		wantFailed  int
+	}{
+		{"valid new", `{"product_id": "p2", "product_price": 5}`, 1, 0, 0},
+		{"invalid new", `{"product_id": "p2", "product_price": -5}`, 0, 0, 1},
+		{"valid update", `{"product_id": "p1", "product_price": "3"}`, 0, 1, 0},
+		{"invalid update", `{"product_id": "p1", "product_price": -3}`, 0, 0, 1},
+		{"invalid type", `{"product_id": "p2", "product_price": "ten"}`, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeEntityService(sales_common.FLD_PRODUCT_ID, utils.Map{sales_common.FLD_PRODUCT_ID: "p1", sales_common.FLD_PRODUCT_PRICE: 2.0})
+			p := newTestService(sales_common.DATAIO_ENTITY_PRODUCTS, fakeValidatingService{store})
+
+			opts := ImportOptions{Format: sales_common.DATAIO_FORMAT_JSONL, DryRun: true, Upsert: true}
+			report, err := p.Import(sales_common.DATAIO_ENTITY_PRODUCTS, strings.NewReader(tt.input), opts)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if report[sales_common.DATAIO_REPORT_CREATED] != tt.wantCreated ||
+				report[sales_common.DATAIO_REPORT_UPDATED] != tt.wantUpdated ||
+				report[sales_common.DATAIO_REPORT_FAILED] != tt.wantFailed {
+				t.Errorf("Import() report = %v", report)
+			}
+			if store.writes != 0 {
+				t.Errorf("DryRun wrote %d records", store.writes)
+			}
+		})
+	}
+}
+
+func TestExportCSVHeaderFromAllRecords(t *testing.T) {
+	records := []utils.Map{}
+	for idx := 0; idx < exportBatchSize+1; idx++ {
+		records = append(records, utils.Map{sales_common.FLD_BRAND_ID: fmt.Sprintf("b%04d", idx)})
+	}
+	// Only the last record, in the second batch, has the name
+	records[len(records)-1][sales_common.FLD_BRAND_NAME] = "Acme"
+
+	store := newFakeEntityService(sales_common.FLD_BRAND_ID, records...)
+	p := newTestService(sales_common.DATAIO_ENTITY_BRANDS, store)
+
+	var out bytes.Buffer
+	report, err := p.Export(sales_common.DATAIO_ENTITY_BRANDS, &out, ExportOptions{Format: sales_common.DATAIO_FORMAT_CSV})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if report[sales_common.DATAIO_REPORT_EXPORTED] != len(records) {
+		t.Errorf("exported = %v, want %d", report[sales_common.DATAIO_REPORT_EXPORTED], len(records))
+	}
+
+	lines, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV is invalid: %v", err)
+	}
+	wantHeader := []string{sales_common.FLD_BRAND_ID, sales_common.FLD_BRAND_NAME}
+	if !reflect.DeepEqual(lines[0], wantHeader) {
+		t.Errorf("header = %v, want %v", lines[0], wantHeader)
+	}
+	if len(lines) != len(records)+1 {
+		t.Errorf("lines = %d, want %d", len(lines), len(records)+1)
+	}
+
+	// Batches are fetched in the order of the id when no sort is given
+	for _, sortBy := range store.sorts {
+		if sortBy != `{"brand_id": 1}` {
+			t.Errorf("sort = %q, want by brand_id", sortBy)
+		}
+	}
+}
+
+func TestExportCSVDecimal(t *testing.T) {
+	store := newFakeEntityService(sales_common.FLD_PRODUCT_ID,
+		utils.Map{sales_common.FLD_PRODUCT_ID: "p1", sales_common.FLD_PRODUCT_PRICE: mustDecimal(t, "12.50")})
+	p := newTestService(sales_common.DATAIO_ENTITY_PRODUCTS, store)
+
+	var out bytes.Buffer
+	_, err := p.Export(sales_common.DATAIO_ENTITY_PRODUCTS, &out, ExportOptions{Format: sales_common.DATAIO_FORMAT_CSV})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := "product_id,product_price\np1,12.50\n"
+	if out.String() != want {
+		t.Errorf("exported = %q, want %q", out.String(), want)
+	}
+}
+
+func TestImportCategoryReadOnlyFields(t *testing.T) {
+	store := newFakeEntityService(sales_common.FLD_CATEGORY_ID)
+	p := newTestService(sales_common.DATAIO_ENTITY_CATEGORIES, store)
+
+	input := `{"category_id": "c2", "parent_category_id": "c1", "category_ancestors": ["x"], "category_path": "x/c2"}`
+	report, err := p.Import(sales_common.DATAIO_ENTITY_CATEGORIES, strings.NewReader(input), ImportOptions{Format: sales_common.DATAIO_FORMAT_JSONL})
+	if err != nil || report[sales_common.DATAIO_REPORT_CREATED] != 1 {
+		t.Fatalf("Import() report = %v, error = %v", report, err)
+	}
+
+	record := store.records["c2"]
+	for _, field := range []string{sales_common.FLD_CATEGORY_ANCESTORS, sales_common.FLD_CATEGORY_PATH} {
+		if value, ok := record[field]; ok {
+			t.Errorf("%s = %v imported, want dropped", field, value)
+		}
+	}
+	if record[sales_common.FLD_CATEGORY_PARENT_ID] != "c1" {
+		t.Errorf("%s = %v, want c1", sales_common.FLD_CATEGORY_PARENT_ID, record[sales_common.FLD_CATEGORY_PARENT_ID])
+	}
+}
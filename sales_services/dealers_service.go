@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -36,9 +38,12 @@ type dealerBaseService struct {
 	db_utils.DatabaseService
 	dbRegion    db_utils.DatabaseService
 	daoDealer   sales_repository.DealerDao
+	daoRegion   sales_repository.RegionDao
+	ledger      *StockLedger
 	daoBusiness platform_repository.BusinessDao
 	child       DealerService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewDealerService - Construct Dealer
@@ -68,6 +73,7 @@ func NewDealerService(props utils.Map) (DealerService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -95,14 +101,19 @@ func (p *dealerBaseService) initializeService() {
 	log.Printf("DealerService:: GetBusinessDao ")
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoDealer = sales_repository.NewDealerDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoRegion = sales_repository.NewRegionDao(p.dbRegion.GetClient(), p.businessId)
+	p.ledger = NewStockLedger(p.dbRegion.GetClient(), p.businessId)
 }
 
 // List - List All records
-func (p *dealerBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *dealerBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("dealerBaseService::FindAll - Begin")
 
-	listdata, err := p.daoDealer.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoDealer.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +123,37 @@ func (p *dealerBaseService) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Find By Code
-func (p *dealerBaseService) Get(dealerId string) (utils.Map, error) {
+func (p *dealerBaseService) Get(dealerId string) (_ utils.Map, err error) {
 	log.Printf("dealerBaseService::Get::  Begin %v", dealerId)
 
-	data, err := p.daoDealer.Get(dealerId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoDealer.Get(ctx, dealerId)
 
 	log.Println("dealerBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *dealerBaseService) Find(filter string) (utils.Map, error) {
+func (p *dealerBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("dealerService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoDealer.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoDealer.Find(ctx, filter)
 	log.Println("dealerService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *dealerBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *dealerBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("DealerService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var dealerId string
 
 	dataval, dataok := indata[sales_common.FLD_DEALER_ID]
@@ -147,7 +168,10 @@ func (p *dealerBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_DEALER_ID] = dealerId
 
-	data, err := p.daoDealer.Create(indata)
+	// Region of the location for sourcing the Orders
+	setLocationRegion(ctx, p.daoRegion, p.businessId, indata)
+
+	data, err := p.daoDealer.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +181,41 @@ func (p *dealerBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *dealerBaseService) Update(dealerId string, indata utils.Map) (utils.Map, error) {
+func (p *dealerBaseService) Update(dealerId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("DealerService::Update - Begin")
 
-	data, err := p.daoDealer.Update(dealerId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	setLocationRegion(ctx, p.daoRegion, p.businessId, indata)
+
+	data, err := p.daoDealer.Update(ctx, dealerId, indata)
 
 	log.Println("DealerService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *dealerBaseService) Delete(dealerId string, delete_permanent bool) error {
+func (p *dealerBaseService) Delete(dealerId string, delete_permanent bool) (err error) {
 
 	log.Println("DealerService::Delete - Begin", dealerId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "dealer", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	// Stock at the location should be moved before
+	hasStock, err := p.ledger.LocationHasStock(ctx, sales_common.LOCATION_TYPE_DEALER, dealerId)
+	if err != nil {
+		return err
+	}
+	if hasStock {
+		err := &utils.AppError{ErrorCode: "S30340256", ErrorMsg: "Location has Stock", ErrorDetail: "Dealer " + dealerId + " has stock on hand, adjust it to other locations before delete"}
+		return err
+	}
+
 	if delete_permanent {
-		result, err := p.daoDealer.Delete(dealerId)
+		result, err := p.daoDealer.Delete(ctx, dealerId)
 		if err != nil {
 			return err
 		}
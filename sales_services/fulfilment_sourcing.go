@@ -0,0 +1,365 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// FulfilmentSourcing - Pick the locations fulfilling the items of an Order.
+//
+// The locations having the Inventory of the items are ranked by the proximity to the shipping address, the ones
+// in its Region first, then by the nearest pincode. The nearest location having all the items fulfils the Order.
+// If none has, and the split is allowed, each item is taken from the locations already picked for the other
+// items first, then from the nearest ones, so the Order is shipped from as few locations as possible.
+type FulfilmentSourcing struct {
+	businessId    string
+	ledger        *StockLedger
+	daoWarehouse  sales_repository.WarehouseDao
+	daoDealer     sales_repository.DealerDao
+	daoRegion     sales_repository.RegionDao
+	daoPreference sales_repository.PreferenceDao
+}
+
+// stockLocation - A location having the Inventory of the items and its proximity to the shipping address
+type stockLocation struct {
+	locationType string
+	locationId   string
+	sameRegion   bool
+	distance     int64
+	inventories  map[string]utils.Map // by the product_id and variant_id
+}
+
+// NewFulfilmentSourcing - Construct FulfilmentSourcing on the Region database client
+func NewFulfilmentSourcing(client utils.Map, businessId string) *FulfilmentSourcing {
+	return &FulfilmentSourcing{
+		businessId:    businessId,
+		ledger:        NewStockLedger(client, businessId),
+		daoWarehouse:  sales_repository.NewWarehouseDao(client, businessId),
+		daoDealer:     sales_repository.NewDealerDao(client, businessId),
+		daoRegion:     sales_repository.NewRegionDao(client, businessId),
+		daoPreference: sales_repository.NewPreferenceDao(client, businessId),
+	}
+}
+
+// PlanItems - Set the allocations of each of the items having product_id, variant_id and quantity to the locations,
+// for the shipping address pincode and its Region, resolved from the pincode if not given. Items not tracked have
+// no allocations. Nothing is reserved
+func (s *FulfilmentSourcing) PlanItems(ctx context.Context, items []utils.Map, pincode string, regionId string, allowSplit bool) (err error) {
+
+	log.Println("FulfilmentSourcing::PlanItems - Begin ", pincode, regionId, allowSplit)
+
+	ctx, span := sales_telemetry.StartService(ctx, "fulfilment_sourcing", "PlanItems", s.businessId)
+	defer span.EndWith(&err)
+
+	if len(regionId) == 0 && len(pincode) > 0 {
+		dataRegion, err := s.daoRegion.FindByPincode(ctx, pincode)
+		if err == nil {
+			regionId, _ = utils.GetMemberDataStr(dataRegion, sales_common.FLD_REGION_ID)
+		}
+	}
+
+	locations, tracked, err := s.getLocations(ctx, items, pincode, regionId)
+	if err != nil {
+		return err
+	}
+	if len(locations) == 0 {
+		log.Println("FulfilmentSourcing::PlanItems - End Not tracked")
+		return nil
+	}
+
+	// The nearest location having all the items
+	for _, location := range locations {
+		if hasAllItems(location, items, tracked) {
+			for _, item := range items {
+				if dataInventory, ok := location.inventories[itemStockKey(item)]; ok {
+					quantity, _ := sales_common.GetMemberDataFloat(item, sales_common.FLD_QUANTITY)
+					item[sales_common.FLD_ITEM_ALLOCATIONS] = []utils.Map{newAllocation(dataInventory, quantity)}
+				}
+			}
+			log.Println("FulfilmentSourcing::PlanItems - End ", location.locationType, location.locationId)
+			return nil
+		}
+	}
+
+	err = s.splitItems(locations, items)
+	if err != nil {
+		return err
+	}
+	if !allowSplit {
+		for _, item := range items {
+			delete(item, sales_common.FLD_ITEM_ALLOCATIONS)
+		}
+		err := &utils.AppError{ErrorCode: "S30340255", ErrorMsg: "No Fulfilment Location", ErrorDetail: "No single location has all the items, " + sales_common.FLD_ALLOW_SPLIT + " to fulfil from more than one location"}
+		return err
+	}
+
+	log.Println("FulfilmentSourcing::PlanItems - End Split")
+	return nil
+}
+
+// ReserveItems - Plan the allocations of the items and reserve each of them for the Order given in ref, none is
+// reserved if any of them is not available
+func (s *FulfilmentSourcing) ReserveItems(ctx context.Context, items []utils.Map, pincode string, regionId string, allowSplit bool, ref utils.Map, minutes int) (err error) {
+
+	log.Println("FulfilmentSourcing::ReserveItems - Begin ", ref)
+
+	ctx, span := sales_telemetry.StartService(ctx, "fulfilment_sourcing", "ReserveItems", s.businessId)
+	defer span.EndWith(&err)
+
+	err = s.PlanItems(ctx, items, pincode, regionId, allowSplit)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		allocations, _ := sales_common.GetMemberDataMapArray(item, sales_common.FLD_ITEM_ALLOCATIONS)
+		for _, allocation := range allocations {
+			inventoryId, _ := utils.GetMemberDataStr(allocation, sales_common.FLD_INVENTORY_ID)
+			quantity, _ := sales_common.GetMemberDataFloat(allocation, sales_common.FLD_QUANTITY)
+
+			dataInventory := utils.Map{
+				sales_common.FLD_INVENTORY_ID:  inventoryId,
+				sales_common.FLD_PRODUCT_ID:    item[sales_common.FLD_PRODUCT_ID],
+				sales_common.FLD_VARIANT_ID:    item[sales_common.FLD_VARIANT_ID],
+				sales_common.FLD_LOCATION_TYPE: allocation[sales_common.FLD_LOCATION_TYPE],
+				sales_common.FLD_LOCATION_ID:   allocation[sales_common.FLD_LOCATION_ID],
+			}
+
+			dataReservation, err := s.ledger.ReserveInventory(ctx, dataInventory, quantity, ref, minutes)
+			if err != nil {
+				s.ledger.ReleaseItems(ctx, items)
+				for _, item := range items {
+					delete(item, sales_common.FLD_ITEM_ALLOCATIONS)
+				}
+				return err
+			}
+			allocation[sales_common.FLD_RESERVATION_ID] = dataReservation[sales_common.FLD_RESERVATION_ID]
+		}
+	}
+
+	log.Println("FulfilmentSourcing::ReserveItems - End ")
+	return nil
+}
+
+// AllowSplit - Whether the Orders can be fulfilled from more than one location as per the inventory preference,
+// not by default
+func (s *FulfilmentSourcing) AllowSplit(ctx context.Context) bool {
+	ctx, span := sales_telemetry.StartService(ctx, "fulfilment_sourcing", "AllowSplit", s.businessId)
+	defer span.End(nil)
+
+	dataPref, err := s.daoPreference.Get(ctx, sales_common.PREFERENCE_INVENTORY)
+	if err != nil {
+		return false
+	}
+
+	allowSplit, _ := utils.GetMemberDataBool(dataPref, sales_common.FLD_ALLOW_SPLIT)
+	return allowSplit
+}
+
+// getLocations - Get the locations having the Inventory of the items, the nearest to the shipping address first,
+// and the Products tracked at any of them
+func (s *FulfilmentSourcing) getLocations(ctx context.Context, items []utils.Map, pincode string, regionId string) ([]*stockLocation, map[string]bool, error) {
+	locationMap := map[string]*stockLocation{}
+	locations := []*stockLocation{}
+	tracked := map[string]bool{}
+	listed := map[string]bool{}
+
+	for _, item := range items {
+		delete(item, sales_common.FLD_RESERVATION_ID)
+		delete(item, sales_common.FLD_ITEM_ALLOCATIONS)
+
+		productId, _ := utils.GetMemberDataStr(item, sales_common.FLD_PRODUCT_ID)
+		variantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+		if quantity, _ := sales_common.GetMemberDataFloat(item, sales_common.FLD_QUANTITY); quantity <= 0 {
+			err := &utils.AppError{ErrorCode: "S30340172", ErrorMsg: "Invalid Quantity", ErrorDetail: "Quantity of Product " + productId + " should be greater than 0"}
+			return nil, nil, err
+		}
+		if listed[itemStockKey(item)] {
+			continue
+		}
+		listed[itemStockKey(item)] = true
+
+		inventories, err := s.ledger.ListInventories(ctx, productId, variantId)
+		if err != nil {
+			return nil, nil, err
+		}
+		tracked[itemStockKey(item)] = len(inventories) > 0
+		for _, dataInventory := range inventories {
+			locationType, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_TYPE)
+			locationId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_ID)
+			locationKey := locationType + "/" + locationId
+
+			location, ok := locationMap[locationKey]
+			if !ok {
+				location = &stockLocation{locationType: locationType, locationId: locationId, distance: math.MaxInt64, inventories: map[string]utils.Map{}}
+				s.setProximity(ctx, location, pincode, regionId)
+				locationMap[locationKey] = location
+				locations = append(locations, location)
+			}
+			location.inventories[itemStockKey(item)] = dataInventory
+		}
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		if locations[i].sameRegion != locations[j].sameRegion {
+			return locations[i].sameRegion
+		}
+		if locations[i].distance != locations[j].distance {
+			return locations[i].distance < locations[j].distance
+		}
+		return locations[i].locationType+"/"+locations[i].locationId < locations[j].locationType+"/"+locations[j].locationId
+	})
+	return locations, tracked, nil
+}
+
+// setProximity - Set whether the Warehouse or Dealer of the location is in the Region and its pincode distance
+// to the shipping address. The default stock has no address, so is the farthest
+func (s *FulfilmentSourcing) setProximity(ctx context.Context, location *stockLocation, pincode string, regionId string) {
+	var dataLocation utils.Map
+	var err error
+	switch location.locationType {
+	case sales_common.LOCATION_TYPE_WAREHOUSE:
+		dataLocation, err = s.daoWarehouse.Get(ctx, location.locationId)
+	case sales_common.LOCATION_TYPE_DEALER:
+		dataLocation, err = s.daoDealer.Get(ctx, location.locationId)
+	default:
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	locationRegionId, _ := utils.GetMemberDataStr(dataLocation, sales_common.FLD_REGION_ID)
+	location.sameRegion = len(regionId) > 0 && locationRegionId == regionId
+
+	locationPincode := strings.TrimSpace(fmt.Sprint(dataLocation[sales_common.FLD_ADDRESS_PINCODE]))
+	from, errFrom := strconv.ParseInt(locationPincode, 10, 64)
+	to, errTo := strconv.ParseInt(pincode, 10, 64)
+	if errFrom == nil && errTo == nil {
+		location.distance = from - to
+		if location.distance < 0 {
+			location.distance = -location.distance
+		}
+	}
+}
+
+// splitItems - Allocate each of the items from the locations already picked, then from the nearest ones
+func (s *FulfilmentSourcing) splitItems(locations []*stockLocation, items []utils.Map) error {
+	available := map[string]float64{}
+	picked := map[*stockLocation]bool{}
+
+	for _, item := range items {
+		productId, _ := utils.GetMemberDataStr(item, sales_common.FLD_PRODUCT_ID)
+		variantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+		quantity, _ := sales_common.GetMemberDataFloat(item, sales_common.FLD_QUANTITY)
+
+		candidates := []*stockLocation{}
+		for _, location := range locations {
+			if _, ok := location.inventories[itemStockKey(item)]; ok && picked[location] {
+				candidates = append(candidates, location)
+			}
+		}
+		for _, location := range locations {
+			if _, ok := location.inventories[itemStockKey(item)]; ok && !picked[location] {
+				candidates = append(candidates, location)
+			}
+		}
+		if len(candidates) == 0 {
+			// Not tracked
+			continue
+		}
+
+		remaining := quantity
+		allocations := []utils.Map{}
+		for _, location := range candidates {
+			dataInventory := location.inventories[itemStockKey(item)]
+			inventoryId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_INVENTORY_ID)
+			if _, ok := available[inventoryId]; !ok {
+				available[inventoryId] = dataInventory[sales_common.FLD_STOCK_AVAILABLE].(float64)
+			}
+
+			take := math.Min(remaining, available[inventoryId])
+			if take <= 0 {
+				continue
+			}
+			allocations = append(allocations, newAllocation(dataInventory, take))
+			available[inventoryId] -= take
+			picked[location] = true
+			remaining -= take
+			if remaining <= 0 {
+				break
+			}
+		}
+		if remaining > 0 {
+			err := &utils.AppError{ErrorCode: "S30340252", ErrorMsg: "Insufficient Stock", ErrorDetail: fmt.Sprintf("Only %v of the Product %s %s is available", quantity-remaining, productId, variantId)}
+			return err
+		}
+		item[sales_common.FLD_ITEM_ALLOCATIONS] = allocations
+	}
+	return nil
+}
+
+// hasAllItems - Whether the location has the available stock of all the tracked items
+func hasAllItems(location *stockLocation, items []utils.Map, tracked map[string]bool) bool {
+	required := map[string]float64{}
+	for _, item := range items {
+		if tracked[itemStockKey(item)] {
+			quantity, _ := sales_common.GetMemberDataFloat(item, sales_common.FLD_QUANTITY)
+			required[itemStockKey(item)] += quantity
+		}
+	}
+
+	for stockKey, quantity := range required {
+		dataInventory, ok := location.inventories[stockKey]
+		if !ok || dataInventory[sales_common.FLD_STOCK_AVAILABLE].(float64) < quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// newAllocation - Allocation of the quantity from the Inventory of a location
+func newAllocation(dataInventory utils.Map, quantity float64) utils.Map {
+	locationType, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_TYPE)
+	locationId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_ID)
+	return utils.Map{
+		sales_common.FLD_LOCATION_TYPE: locationType,
+		sales_common.FLD_LOCATION_ID:   locationId,
+		sales_common.FLD_INVENTORY_ID:  dataInventory[sales_common.FLD_INVENTORY_ID],
+		sales_common.FLD_QUANTITY:      quantity,
+	}
+}
+
+// itemStockKey - Key of the Product, or its Variant, of the item
+func itemStockKey(item utils.Map) string {
+	productId, _ := utils.GetMemberDataStr(item, sales_common.FLD_PRODUCT_ID)
+	variantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+	return productId + "/" + variantId
+}
+
+// setLocationRegion - Set the sales_region_id of the Warehouse or Dealer from its address_pincode, if not given
+func setLocationRegion(ctx context.Context, daoRegion sales_repository.RegionDao, businessId string, indata utils.Map) {
+	if _, ok := indata[sales_common.FLD_ADDRESS_PINCODE]; !ok {
+		return
+	}
+	pincode := strings.TrimSpace(fmt.Sprint(indata[sales_common.FLD_ADDRESS_PINCODE]))
+	indata[sales_common.FLD_ADDRESS_PINCODE] = pincode
+	if regionId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_REGION_ID); len(regionId) > 0 {
+		return
+	}
+
+	dataRegion, err := daoRegion.FindByPincode(ctx, pincode)
+	if err == nil {
+		indata[sales_common.FLD_REGION_ID] = dataRegion[sales_common.FLD_REGION_ID]
+	}
+}
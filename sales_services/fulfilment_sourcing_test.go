@@ -0,0 +1,261 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// testLocations - Warehouses and Dealers by their Id, wh1 is in r1, wh2 and d1 in r2, by the pincode d1 is
+// nearer to 600001 than wh2
+var testLocations = map[string]utils.Map{
+	"wh1": {sales_common.FLD_REGION_ID: "r1", sales_common.FLD_ADDRESS_PINCODE: "600001"},
+	"wh2": {sales_common.FLD_REGION_ID: "r2", sales_common.FLD_ADDRESS_PINCODE: "600050"},
+	"d1":  {sales_common.FLD_REGION_ID: "r2", sales_common.FLD_ADDRESS_PINCODE: "600010"},
+}
+
+func getTestLocation(locationId string) (utils.Map, error) {
+	location, ok := testLocations[locationId]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return location, nil
+}
+
+type fakeWarehouseDao struct {
+	sales_repository.WarehouseDao
+}
+
+func (f *fakeWarehouseDao) Get(ctx context.Context, warehouseId string) (utils.Map, error) {
+	return getTestLocation(warehouseId)
+}
+
+type fakeDealerDao struct {
+	sales_repository.DealerDao
+}
+
+func (f *fakeDealerDao) Get(ctx context.Context, dealerId string) (utils.Map, error) {
+	return getTestLocation(dealerId)
+}
+
+// fakeRegionDao - Regions by the pincode
+type fakeRegionDao struct {
+	sales_repository.RegionDao
+	regionIds map[string]string
+}
+
+func (f *fakeRegionDao) FindByPincode(ctx context.Context, pincode string) (utils.Map, error) {
+	regionId, ok := f.regionIds[pincode]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return utils.Map{sales_common.FLD_REGION_ID: regionId}, nil
+}
+
+// testInventory - Stock of a Product at a location, the default stock without location
+type testInventory struct {
+	productId string
+	location  string
+	onHand    float64
+	reserved  float64
+}
+
+func newTestSourcing(t *testing.T, inventories []testInventory) *FulfilmentSourcing {
+	daoInventory := &fakeInventoryDao{t: t, inventories: map[string]utils.Map{}}
+	for i, inventory := range inventories {
+		inventoryId := fmt.Sprintf("inv%d", i+1)
+		dataInventory := utils.Map{
+			sales_common.FLD_INVENTORY_ID:   inventoryId,
+			sales_common.FLD_PRODUCT_ID:     inventory.productId,
+			sales_common.FLD_VARIANT_ID:     "",
+			sales_common.FLD_STOCK_ON_HAND:  inventory.onHand,
+			sales_common.FLD_STOCK_RESERVED: inventory.reserved,
+		}
+		if len(inventory.location) > 0 {
+			locationType := sales_common.LOCATION_TYPE_WAREHOUSE
+			if inventory.location[0] == 'd' {
+				locationType = sales_common.LOCATION_TYPE_DEALER
+			}
+			dataInventory[sales_common.FLD_LOCATION_TYPE] = locationType
+			dataInventory[sales_common.FLD_LOCATION_ID] = inventory.location
+		}
+		daoInventory.inventories[inventoryId] = dataInventory
+	}
+
+	return &FulfilmentSourcing{
+		businessId:    "biz1",
+		ledger:        &StockLedger{businessId: "biz1", daoInventory: daoInventory},
+		daoWarehouse:  &fakeWarehouseDao{},
+		daoDealer:     &fakeDealerDao{},
+		daoRegion:     &fakeRegionDao{regionIds: map[string]string{"600050": "r2"}},
+		daoPreference: &fakePreferenceDao{},
+	}
+}
+
+// itemAllocations - Allocations of each item as "location:quantity"
+func itemAllocations(items []utils.Map) [][]string {
+	result := [][]string{}
+	for _, item := range items {
+		allocations, _ := sales_common.GetMemberDataMapArray(item, sales_common.FLD_ITEM_ALLOCATIONS)
+		itemResult := []string{}
+		for _, allocation := range allocations {
+			itemResult = append(itemResult, fmt.Sprintf("%v:%v", allocation[sales_common.FLD_LOCATION_ID], allocation[sales_common.FLD_QUANTITY]))
+		}
+		result = append(result, itemResult)
+	}
+	return result
+}
+
+func TestPlanItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		inventories []testInventory
+		quantities  map[string]float64
+		pincode     string
+		regionId    string
+		allowSplit  bool
+		want        [][]string
+		wantErr     string
+	}{
+		{
+			name:        "nearest pincode",
+			inventories: []testInventory{{"prod1", "wh2", 5, 0}, {"prod1", "d1", 5, 0}, {"prod1", "wh1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			want:        [][]string{{"wh1:2"}},
+		},
+		{
+			name:        "same region before nearer pincode",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "wh2", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			regionId:    "r2",
+			want:        [][]string{{"wh2:2"}},
+		},
+		{
+			name:        "nearest in the region",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "wh2", 5, 0}, {"prod1", "d1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			regionId:    "r2",
+			want:        [][]string{{"d1:2"}},
+		},
+		{
+			name:        "region resolved from the pincode",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "d1", 5, 0}, {"prod1", "wh2", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600050",
+			want:        [][]string{{"wh2:2"}},
+		},
+		{
+			name:        "default stock is the farthest",
+			inventories: []testInventory{{"prod1", "", 5, 0}, {"prod1", "wh2", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			want:        [][]string{{"wh2:2"}},
+		},
+		{
+			name:        "default stock without address",
+			inventories: []testInventory{{"prod1", "", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			want:        [][]string{{":2"}},
+		},
+		{
+			name:        "nearest having all the items",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "d1", 5, 0}, {"prod2", "d1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2, "prod2": 1},
+			pincode:     "600001",
+			want:        [][]string{{"d1:2"}, {"d1:1"}},
+		},
+		{
+			name:        "reserved stock is not available",
+			inventories: []testInventory{{"prod1", "wh1", 5, 4}, {"prod1", "d1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			want:        [][]string{{"d1:2"}},
+		},
+		{
+			name:        "split over the nearest",
+			inventories: []testInventory{{"prod1", "wh2", 5, 0}, {"prod1", "d1", 5, 0}, {"prod1", "wh1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 8},
+			pincode:     "600001",
+			allowSplit:  true,
+			want:        [][]string{{"wh1:5", "d1:3"}},
+		},
+		{
+			name:        "split from the picked locations first",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "wh2", 5, 0}, {"prod2", "d1", 5, 0}, {"prod2", "wh2", 5, 0}},
+			quantities:  map[string]float64{"prod1": 8, "prod2": 2},
+			pincode:     "600001",
+			allowSplit:  true,
+			want:        [][]string{{"wh1:5", "wh2:3"}, {"wh2:2"}},
+		},
+		{
+			name:        "split not allowed",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "d1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 8},
+			pincode:     "600001",
+			want:        [][]string{{}},
+			wantErr:     "S30340255",
+		},
+		{
+			name:        "insufficient stock",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}, {"prod1", "d1", 5, 2}},
+			quantities:  map[string]float64{"prod1": 9},
+			pincode:     "600001",
+			allowSplit:  true,
+			wantErr:     "S30340252",
+		},
+		{
+			name:        "not tracked",
+			inventories: []testInventory{{"prod2", "wh1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2},
+			pincode:     "600001",
+			want:        [][]string{{}},
+		},
+		{
+			name:        "not tracked item with a tracked one",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 2, "prod3": 1},
+			pincode:     "600001",
+			want:        [][]string{{"wh1:2"}, {}},
+		},
+		{
+			name:        "invalid quantity",
+			inventories: []testInventory{{"prod1", "wh1", 5, 0}},
+			quantities:  map[string]float64{"prod1": 0},
+			wantErr:     "S30340172",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourcing := newTestSourcing(t, tt.inventories)
+
+			// Items in the order of the Product
+			items := []utils.Map{}
+			for _, productId := range []string{"prod1", "prod2", "prod3"} {
+				if quantity, ok := tt.quantities[productId]; ok {
+					items = append(items, utils.Map{sales_common.FLD_PRODUCT_ID: productId, sales_common.FLD_VARIANT_ID: "", sales_common.FLD_QUANTITY: quantity})
+				}
+			}
+
+			err := sourcing.PlanItems(context.Background(), items, tt.pincode, tt.regionId, tt.allowSplit)
+			if errorCode(err) != tt.wantErr || (len(tt.wantErr) == 0 && err != nil) {
+				t.Fatalf("PlanItems() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if got := itemAllocations(items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanItems() allocations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
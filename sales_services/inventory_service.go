@@ -0,0 +1,229 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// InventoryService - Stock of the Products and Variants and its Reservations by the Carts and Orders,
+// see StockLedger for the rules
+type InventoryService interface {
+	// GetStock - Get the on hand, reserved and available stock of the Product or its Variant, over all the locations
+	GetStock(productId string, variantId string) (utils.Map, error)
+	// AdjustStock - Add the quantity to the on hand stock at the Warehouse or Dealer location, or the default stock
+	// if no location given, negative to remove, starts tracking the stock if not yet
+	AdjustStock(productId string, variantId string, locationType string, locationId string, quantity float64) (utils.Map, error)
+	// PlanFulfilment - Get the allocations of the items to the locations nearest to the shipping pincode, without
+	// reserving, see FulfilmentSourcing
+	PlanFulfilment(items []utils.Map, pincode string, allowSplit bool) ([]utils.Map, error)
+	// ListStock - List the Inventory
+	ListStock(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// ListReservations - List the Stock Reservations
+	ListReservations(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// ReleaseReservation - Release the stock of the active Reservation
+	ReleaseReservation(reservationId string) error
+	// ExpireReservations - Release the stock of the Reservations expired by now, to be run periodically
+	ExpireReservations() (int, error)
+
+	EndService()
+}
+
+type inventoryBaseService struct {
+	db_utils.DatabaseService
+	dbRegion       db_utils.DatabaseService
+	ledger         *StockLedger
+	sourcing       *FulfilmentSourcing
+	daoInventory   sales_repository.InventoryDao
+	daoReservation sales_repository.StockReservationDao
+	daoBusiness    platform_repository.BusinessDao
+	child          InventoryService
+	businessId     string
+	ctx            context.Context
+}
+
+// NewInventoryService - Construct Inventory
+func NewInventoryService(props utils.Map) (InventoryService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("InventoryService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := inventoryBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// inventoryBaseService - Close all the services
+func (p *inventoryBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *inventoryBaseService) initializeService() {
+	log.Printf("InventoryService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.ledger = NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+	p.sourcing = NewFulfilmentSourcing(p.dbRegion.GetClient(), p.businessId)
+	p.daoInventory = sales_repository.NewInventoryDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoReservation = sales_repository.NewStockReservationDao(p.dbRegion.GetClient(), p.businessId)
+}
+
+// GetStock - Get the on hand, reserved and available stock of the Product or its Variant, over all the locations
+func (p *inventoryBaseService) GetStock(productId string, variantId string) (_ utils.Map, err error) {
+
+	log.Println("InventoryService::GetStock - Begin ", productId, variantId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "GetStock", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.GetStock(ctx, productId, variantId)
+
+	log.Println("InventoryService::GetStock - End ", err)
+	return data, err
+}
+
+// AdjustStock - Add the quantity to the on hand stock at the Warehouse or Dealer location, or the default stock
+// if no location given, negative to remove, starts tracking the stock if not yet
+func (p *inventoryBaseService) AdjustStock(productId string, variantId string, locationType string, locationId string, quantity float64) (_ utils.Map, err error) {
+
+	log.Println("InventoryService::AdjustStock - Begin ", productId, variantId, locationType, locationId, quantity)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "AdjustStock", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.AdjustStock(ctx, productId, variantId, locationType, locationId, quantity)
+
+	log.Println("InventoryService::AdjustStock - End ", err)
+	return data, err
+}
+
+// PlanFulfilment - Get the allocations of the items to the locations nearest to the shipping pincode, without
+// reserving, see FulfilmentSourcing
+func (p *inventoryBaseService) PlanFulfilment(items []utils.Map, pincode string, allowSplit bool) (_ []utils.Map, err error) {
+
+	log.Println("InventoryService::PlanFulfilment - Begin ", pincode, allowSplit)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "PlanFulfilment", p.businessId)
+	defer span.EndWith(&err)
+
+	err = p.sourcing.PlanItems(ctx, items, pincode, "", allowSplit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("InventoryService::PlanFulfilment - End ")
+	return items, nil
+}
+
+// ListStock - List the Inventory
+func (p *inventoryBaseService) ListStock(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("InventoryService::ListStock - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "ListStock", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoInventory.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	inventories, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	for _, inventory := range inventories {
+		setStockAvailable(inventory)
+	}
+
+	log.Println("InventoryService::ListStock - End ")
+	return listdata, nil
+}
+
+// ListReservations - List the Stock Reservations
+func (p *inventoryBaseService) ListReservations(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("InventoryService::ListReservations - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "ListReservations", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoReservation.List(ctx, filter, sort, skip, limit)
+
+	log.Println("InventoryService::ListReservations - End ", err)
+	return listdata, err
+}
+
+// ReleaseReservation - Release the stock of the active Reservation
+func (p *inventoryBaseService) ReleaseReservation(reservationId string) (err error) {
+
+	log.Println("InventoryService::ReleaseReservation - Begin ", reservationId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "ReleaseReservation", p.businessId)
+	defer span.EndWith(&err)
+
+	err = p.ledger.Release(ctx, reservationId)
+
+	log.Println("InventoryService::ReleaseReservation - End ", err)
+	return err
+}
+
+// ExpireReservations - Release the stock of the Reservations expired by now, to be run periodically
+func (p *inventoryBaseService) ExpireReservations() (_ int, err error) {
+
+	log.Println("InventoryService::ExpireReservations - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "inventory", "ExpireReservations", p.businessId)
+	defer span.EndWith(&err)
+
+	expired, err := p.ledger.ExpireReservations(ctx, time.Now())
+
+	log.Println("InventoryService::ExpireReservations - End ", expired, err)
+	return expired, err
+}
+
+func (p *inventoryBaseService) errorReturn(err error) (InventoryService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -0,0 +1,514 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoyaltyLedger - Loyalty Points of the Customers.
+//
+// Every change of the points is appended as a transaction and then applied to the Customer's balance,
+// transactions are never updated. Points are earned for delivered Orders, redeemed at checkout, refunded
+// when the Order failed, expired after the points_expiry_days and adjusted by admins, as configured in
+// the loyalty Preference of the business. Deductions are taken from the oldest points first. Amounts are
+// computed as big.Rat, points redeemed are worth at most the order_total.
+type LoyaltyLedger struct {
+	businessId    string
+	daoPreference sales_repository.PreferenceDao
+	daoBalance    sales_repository.LoyaltyBalanceDao
+	newTxnDao     func(customerId string) customer_repository.CustomerLoyaltyTxnDao // Transactions are per Customer
+}
+
+// loyaltyRules - Earn and redeem rules from the loyalty Preference
+type loyaltyRules struct {
+	enabled          bool
+	earnRate         *big.Rat
+	earnMinOrder     *big.Rat
+	redeemValue      *big.Rat
+	redeemMinPoints  int64
+	redeemMaxPercent *big.Rat
+	expiryDays       int
+}
+
+// NewLoyaltyLedger - Construct LoyaltyLedger on the Region database client
+func NewLoyaltyLedger(client utils.Map, businessId string) *LoyaltyLedger {
+	return &LoyaltyLedger{
+		businessId:    businessId,
+		daoPreference: sales_repository.NewPreferenceDao(client, businessId),
+		daoBalance:    sales_repository.NewLoyaltyBalanceDao(client, businessId),
+		newTxnDao: func(customerId string) customer_repository.CustomerLoyaltyTxnDao {
+			return customer_repository.NewCustomerLoyaltyTxnDao(client, businessId, customerId)
+		},
+	}
+}
+
+// GetBalance - Get the balance of the Customer, zero if the Customer has no transactions
+func (l *LoyaltyLedger) GetBalance(ctx context.Context, customerId string) (_ utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "GetBalance", l.businessId)
+	defer span.EndWith(&err)
+
+	data, err := l.daoBalance.Get(ctx, customerId)
+	if err != nil {
+		data = utils.Map{
+			sales_common.FLD_CUSTOMER_ID:             customerId,
+			sales_common.FLD_LOYALTY_POINTS_BALANCE:  0,
+			sales_common.FLD_LOYALTY_POINTS_EARNED:   0,
+			sales_common.FLD_LOYALTY_POINTS_REDEEMED: 0,
+			sales_common.FLD_LOYALTY_POINTS_EXPIRED:  0,
+			sales_common.FLD_LOYALTY_POINTS_ADJUSTED: 0,
+		}
+	}
+	return data, nil
+}
+
+// Earn - Add the points for the delivered Order, once for each Order. Returns nil if loyalty is not
+// enabled or the Order earns no points
+func (l *LoyaltyLedger) Earn(ctx context.Context, customerId string, custOrderId string, orderTotal primitive.Decimal128) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyLedger::Earn - Begin ", customerId, custOrderId, orderTotal)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Earn", l.businessId)
+	defer span.EndWith(&err)
+
+	totalRat, err := sales_common.DecimalToRat(orderTotal)
+	if err != nil {
+		return nil, err
+	}
+	rules := l.getRules(ctx)
+	if !rules.enabled || totalRat.Cmp(rules.earnMinOrder) < 0 {
+		return nil, nil
+	}
+	// Whole points, rounded down
+	earned := new(big.Rat).Mul(totalRat, rules.earnRate)
+	points := new(big.Int).Quo(earned.Num(), earned.Denom()).Int64()
+	if points <= 0 {
+		return nil, nil
+	}
+
+	daoTxn := l.newTxnDao(customerId)
+	dataTxn, created, err := l.appendOrderTxn(ctx, daoTxn, customerId, utils.Map{
+		sales_common.FLD_CUSTOMER_ORDER_ID:  custOrderId,
+		sales_common.FLD_LOYALTY_TXN_TYPE:   sales_common.LOYALTY_TXN_EARN,
+		sales_common.FLD_LOYALTY_POINTS:     points,
+		sales_common.FLD_LOYALTY_EXPIRES_AT: rules.expiresAt(),
+	})
+	if err != nil || !created {
+		// Already earned if not created
+		return dataTxn, err
+	}
+	_, err = l.applyBalance(ctx, customerId, points, sales_common.FLD_LOYALTY_POINTS_EARNED, points)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("LoyaltyLedger::Earn - End ", points)
+	return dataTxn, nil
+}
+
+// Redeem - Deduct the points for the Order at checkout, returns the amount off the order_total in the decimals of
+// the currency. The amount is never more than the order_total
+func (l *LoyaltyLedger) Redeem(ctx context.Context, customerId string, custOrderId string, points int64, orderTotal primitive.Decimal128, currency string) (_ primitive.Decimal128, err error) {
+
+	log.Println("LoyaltyLedger::Redeem - Begin ", customerId, custOrderId, points)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Redeem", l.businessId)
+	defer span.EndWith(&err)
+
+	rules := l.getRules(ctx)
+	if !rules.enabled {
+		err := &utils.AppError{ErrorCode: "S30340180", ErrorMsg: "Loyalty not enabled", ErrorDetail: "Loyalty points are not enabled for the business"}
+		return primitive.Decimal128{}, err
+	} else if points <= 0 {
+		err := &utils.AppError{ErrorCode: "S30340181", ErrorMsg: "Invalid Points", ErrorDetail: "Points should be greater than 0"}
+		return primitive.Decimal128{}, err
+	} else if points < rules.redeemMinPoints {
+		err := &utils.AppError{ErrorCode: "S30340183", ErrorMsg: "Too few Points", ErrorDetail: "At least " + strconv.FormatInt(rules.redeemMinPoints, 10) + " points should be redeemed"}
+		return primitive.Decimal128{}, err
+	}
+
+	totalRat, err := sales_common.DecimalToRat(orderTotal)
+	if err != nil {
+		return primitive.Decimal128{}, err
+	}
+	scale := sales_common.CurrencyScale(currency)
+	value := sales_common.RatToDecimal(new(big.Rat).Mul(big.NewRat(points, 1), rules.redeemValue), scale)
+	valueRat, _ := sales_common.DecimalToRat(value)
+	if valueRat.Cmp(totalRat) > 0 {
+		err := &utils.AppError{ErrorCode: "S30340184", ErrorMsg: "Too many Points", ErrorDetail: "Points worth " + valueRat.FloatString(scale) + " can not be redeemed for the order of " + totalRat.FloatString(scale)}
+		return primitive.Decimal128{}, err
+	}
+	maxValue := new(big.Rat).Mul(totalRat, rules.redeemMaxPercent)
+	maxValue.Quo(maxValue, big.NewRat(100, 1))
+	if rules.redeemMaxPercent.Sign() > 0 && valueRat.Cmp(maxValue) > 0 {
+		err := &utils.AppError{ErrorCode: "S30340184", ErrorMsg: "Too many Points", ErrorDetail: "Points can be redeemed for at most " + rules.redeemMaxPercent.RatString() + "% of the order"}
+		return primitive.Decimal128{}, err
+	}
+
+	// Deduct first, so that the balance is verified atomically
+	_, err = l.applyBalance(ctx, customerId, -points, sales_common.FLD_LOYALTY_POINTS_REDEEMED, points)
+	if err != nil {
+		return primitive.Decimal128{}, err
+	}
+
+	daoTxn := l.newTxnDao(customerId)
+	_, err = l.appendTxn(ctx, daoTxn, customerId, utils.Map{
+		sales_common.FLD_CUSTOMER_ORDER_ID: custOrderId,
+		sales_common.FLD_LOYALTY_TXN_TYPE:  sales_common.LOYALTY_TXN_REDEEM,
+		sales_common.FLD_LOYALTY_POINTS:    -points,
+	})
+	if err != nil {
+		// Return the deducted points
+		l.applyBalance(ctx, customerId, points, sales_common.FLD_LOYALTY_POINTS_REDEEMED, -points)
+		return primitive.Decimal128{}, err
+	}
+
+	log.Println("LoyaltyLedger::Redeem - End ", value)
+	return value, nil
+}
+
+// Refund - Return the points redeemed for the Order, once for each Order. Returns nil if no points
+// were redeemed
+func (l *LoyaltyLedger) Refund(ctx context.Context, customerId string, custOrderId string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyLedger::Refund - Begin ", customerId, custOrderId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Refund", l.businessId)
+	defer span.EndWith(&err)
+
+	daoTxn := l.newTxnDao(customerId)
+	dataRedeem, err := l.findOrderTxn(ctx, daoTxn, custOrderId, sales_common.LOYALTY_TXN_REDEEM)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Returned points expire like the newly added points
+	points := -txnPoints(dataRedeem)
+	dataTxn, created, err := l.appendOrderTxn(ctx, daoTxn, customerId, utils.Map{
+		sales_common.FLD_CUSTOMER_ORDER_ID:  custOrderId,
+		sales_common.FLD_LOYALTY_TXN_TYPE:   sales_common.LOYALTY_TXN_REFUND,
+		sales_common.FLD_LOYALTY_POINTS:     points,
+		sales_common.FLD_LOYALTY_EXPIRES_AT: l.getRules(ctx).expiresAt(),
+	})
+	if err != nil || !created {
+		// Already refunded if not created
+		return dataTxn, err
+	}
+	_, err = l.applyBalance(ctx, customerId, points, sales_common.FLD_LOYALTY_POINTS_REDEEMED, -points)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("LoyaltyLedger::Refund - End ", points)
+	return dataTxn, nil
+}
+
+// Adjust - Add or deduct the points by admin, the remarks are required
+func (l *LoyaltyLedger) Adjust(ctx context.Context, customerId string, points int64, remarks string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyLedger::Adjust - Begin ", customerId, points)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Adjust", l.businessId)
+	defer span.EndWith(&err)
+
+	if points == 0 {
+		err := &utils.AppError{ErrorCode: "S30340181", ErrorMsg: "Invalid Points", ErrorDetail: "Points should not be 0"}
+		return nil, err
+	} else if len(remarks) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340185", ErrorMsg: "Missing Remarks", ErrorDetail: "Reason for the adjustment should be given"}
+		return nil, err
+	}
+
+	txn := utils.Map{
+		sales_common.FLD_LOYALTY_TXN_TYPE: sales_common.LOYALTY_TXN_ADJUST,
+		sales_common.FLD_LOYALTY_POINTS:   points,
+		sales_common.FLD_LOYALTY_REMARKS:  remarks,
+	}
+	if points > 0 {
+		txn[sales_common.FLD_LOYALTY_EXPIRES_AT] = l.getRules(ctx).expiresAt()
+	} else {
+		// Deduct first, so that the balance is verified atomically
+		_, err := l.applyBalance(ctx, customerId, points, sales_common.FLD_LOYALTY_POINTS_ADJUSTED, points)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	daoTxn := l.newTxnDao(customerId)
+	dataTxn, err := l.appendTxn(ctx, daoTxn, customerId, txn)
+	if err != nil {
+		if points < 0 {
+			l.applyBalance(ctx, customerId, -points, sales_common.FLD_LOYALTY_POINTS_ADJUSTED, -points)
+		}
+		return nil, err
+	}
+	if points > 0 {
+		_, err = l.applyBalance(ctx, customerId, points, sales_common.FLD_LOYALTY_POINTS_ADJUSTED, points)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Println("LoyaltyLedger::Adjust - End ")
+	return dataTxn, nil
+}
+
+// Expire - Expire the points of the Customer not deducted before their expiry, returns the points expired
+func (l *LoyaltyLedger) Expire(ctx context.Context, customerId string) (_ int64, err error) {
+
+	log.Println("LoyaltyLedger::Expire - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Expire", l.businessId)
+	defer span.EndWith(&err)
+
+	daoTxn := l.newTxnDao(customerId)
+	txns, err := l.listTxns(ctx, daoTxn)
+	if err != nil {
+		return 0, err
+	}
+
+	// Take all the deductions from the oldest points, what remains of the points past expiry are expired
+	var deducted int64
+	for _, txn := range txns {
+		if points := txnPoints(txn); points < 0 {
+			deducted -= points
+		}
+	}
+
+	now := time.Now()
+	var expired int64
+	for _, txn := range txns {
+		points := txnPoints(txn)
+		if points <= 0 {
+			continue
+		}
+		used := points
+		if deducted < points {
+			used = deducted
+		}
+		deducted -= used
+		remaining := points - used
+
+		expiresAt, err := sales_common.GetMemberDataTime(txn, sales_common.FLD_LOYALTY_EXPIRES_AT)
+		if remaining == 0 || err != nil || expiresAt.After(now) {
+			continue
+		}
+
+		_, err = l.applyBalance(ctx, customerId, -remaining, sales_common.FLD_LOYALTY_POINTS_EXPIRED, remaining)
+		if err != nil {
+			return expired, err
+		}
+		refTxnId, _ := utils.GetMemberDataStr(txn, sales_common.FLD_LOYALTY_TXN_ID)
+		_, err = l.appendTxn(ctx, daoTxn, customerId, utils.Map{
+			sales_common.FLD_LOYALTY_TXN_TYPE:   sales_common.LOYALTY_TXN_EXPIRE,
+			sales_common.FLD_LOYALTY_POINTS:     -remaining,
+			sales_common.FLD_LOYALTY_REF_TXN_ID: refTxnId,
+		})
+		if err != nil {
+			l.applyBalance(ctx, customerId, remaining, sales_common.FLD_LOYALTY_POINTS_EXPIRED, -remaining)
+			return expired, err
+		}
+		expired += remaining
+	}
+
+	log.Println("LoyaltyLedger::Expire - End ", expired)
+	return expired, nil
+}
+
+// ExpireAll - Expire the points of all the Customers having balance, to be run on schedule. Returns the
+// points expired
+func (l *LoyaltyLedger) ExpireAll(ctx context.Context) (_ int64, err error) {
+
+	log.Println("LoyaltyLedger::ExpireAll - Begin ")
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "ExpireAll", l.businessId)
+	defer span.EndWith(&err)
+
+	filter := `{"` + sales_common.FLD_LOYALTY_POINTS_BALANCE + `": {"$gt": 0}}`
+	listdata, err := l.daoBalance.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	balances, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	var expired int64
+	for _, balance := range balances {
+		customerId, _ := utils.GetMemberDataStr(balance, sales_common.FLD_CUSTOMER_ID)
+		points, err := l.Expire(ctx, customerId)
+		expired += points
+		if err != nil {
+			return expired, err
+		}
+	}
+
+	log.Println("LoyaltyLedger::ExpireAll - End ", expired)
+	return expired, nil
+}
+
+// Rebuild - Recompute the balance of the Customer from the transactions
+func (l *LoyaltyLedger) Rebuild(ctx context.Context, customerId string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyLedger::Rebuild - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "loyalty_ledger", "Rebuild", l.businessId)
+	defer span.EndWith(&err)
+
+	daoTxn := l.newTxnDao(customerId)
+	txns, err := l.listTxns(ctx, daoTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance, earned, redeemed, expired, adjusted int64
+	for _, txn := range txns {
+		points := txnPoints(txn)
+		balance += points
+
+		txnType, _ := utils.GetMemberDataStr(txn, sales_common.FLD_LOYALTY_TXN_TYPE)
+		switch txnType {
+		case sales_common.LOYALTY_TXN_EARN:
+			earned += points
+		case sales_common.LOYALTY_TXN_REDEEM, sales_common.LOYALTY_TXN_REFUND:
+			redeemed -= points
+		case sales_common.LOYALTY_TXN_EXPIRE:
+			expired -= points
+		case sales_common.LOYALTY_TXN_ADJUST:
+			adjusted += points
+		}
+	}
+
+	data, err := l.daoBalance.Save(ctx, customerId, utils.Map{
+		sales_common.FLD_LOYALTY_POINTS_BALANCE:  balance,
+		sales_common.FLD_LOYALTY_POINTS_EARNED:   earned,
+		sales_common.FLD_LOYALTY_POINTS_REDEEMED: redeemed,
+		sales_common.FLD_LOYALTY_POINTS_EXPIRED:  expired,
+		sales_common.FLD_LOYALTY_POINTS_ADJUSTED: adjusted,
+	})
+
+	log.Println("LoyaltyLedger::Rebuild - End ", balance)
+	return data, err
+}
+
+// getRules - Rules from the loyalty Preference, loyalty is disabled if not configured
+func (l *LoyaltyLedger) getRules(ctx context.Context) loyaltyRules {
+	rules := loyaltyRules{earnRate: new(big.Rat), earnMinOrder: new(big.Rat), redeemValue: new(big.Rat), redeemMaxPercent: new(big.Rat)}
+
+	dataPref, err := l.daoPreference.Get(ctx, sales_common.PREFERENCE_LOYALTY)
+	if err != nil {
+		return rules
+	}
+
+	rules.enabled, _ = utils.GetMemberDataBool(dataPref, sales_common.FLD_LOYALTY_ENABLED)
+	for field, rate := range map[string]*big.Rat{
+		sales_common.FLD_LOYALTY_EARN_RATE:          rules.earnRate,
+		sales_common.FLD_LOYALTY_EARN_MIN_ORDER:     rules.earnMinOrder,
+		sales_common.FLD_LOYALTY_REDEEM_VALUE:       rules.redeemValue,
+		sales_common.FLD_LOYALTY_REDEEM_MAX_PERCENT: rules.redeemMaxPercent,
+	} {
+		if value, err := getMemberDataRat(dataPref, field); err == nil {
+			rate.Set(value)
+		}
+	}
+	redeemMinPoints, _ := sales_common.GetMemberDataFloat(dataPref, sales_common.FLD_LOYALTY_REDEEM_MIN_POINTS)
+	rules.redeemMinPoints = int64(redeemMinPoints)
+	expiryDays, _ := sales_common.GetMemberDataFloat(dataPref, sales_common.FLD_LOYALTY_EXPIRY_DAYS)
+	rules.expiryDays = int(expiryDays)
+
+	return rules
+}
+
+// expiresAt - Expiry of the points added now, nil if the points never expire
+func (r loyaltyRules) expiresAt() interface{} {
+	if r.expiryDays <= 0 {
+		return nil
+	}
+	return time.Now().AddDate(0, 0, r.expiryDays)
+}
+
+// findOrderTxn - Find the transaction of the type for the Order
+func (l *LoyaltyLedger) findOrderTxn(ctx context.Context, daoTxn customer_repository.CustomerLoyaltyTxnDao, custOrderId string, txnType string) (utils.Map, error) {
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CUSTOMER_ORDER_ID: custOrderId, sales_common.FLD_LOYALTY_TXN_TYPE: txnType})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := daoTxn.Find(ctx, filter)
+	return data, err
+}
+
+// listTxns - All the transactions of the Customer, oldest first
+func (l *LoyaltyLedger) listTxns(ctx context.Context, daoTxn customer_repository.CustomerLoyaltyTxnDao) ([]utils.Map, error) {
+	sort := `{"` + db_common.FLD_CREATED_AT + `": 1}`
+
+	listdata, err := daoTxn.List(ctx, "", sort, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	txns, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	return txns, nil
+}
+
+// appendTxn - Append the transaction of the Customer
+func (l *LoyaltyLedger) appendTxn(ctx context.Context, daoTxn customer_repository.CustomerLoyaltyTxnDao, customerId string, indata utils.Map) (utils.Map, error) {
+	indata[sales_common.FLD_BUSINESS_ID] = l.businessId
+	indata[sales_common.FLD_CUSTOMER_ID] = customerId
+	indata[sales_common.FLD_LOYALTY_TXN_ID] = utils.GenerateUniqueId("lyt")
+
+	data, err := daoTxn.Create(ctx, indata)
+	return data, err
+}
+
+// appendOrderTxn - Append the transaction of the Customer for the Order, unless the Order has one of the same
+// type. Returns the existing transaction and false then
+func (l *LoyaltyLedger) appendOrderTxn(ctx context.Context, daoTxn customer_repository.CustomerLoyaltyTxnDao, customerId string, indata utils.Map) (utils.Map, bool, error) {
+	txnId := utils.GenerateUniqueId("lyt")
+	indata[sales_common.FLD_BUSINESS_ID] = l.businessId
+	indata[sales_common.FLD_CUSTOMER_ID] = customerId
+	indata[sales_common.FLD_LOYALTY_TXN_ID] = txnId
+
+	data, err := daoTxn.CreateForOrder(ctx, indata)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, data[sales_common.FLD_LOYALTY_TXN_ID] == txnId, nil
+}
+
+// applyBalance - Add the points to the balance and the counter
+func (l *LoyaltyLedger) applyBalance(ctx context.Context, customerId string, points int64, counter string, counterPoints int64) (utils.Map, error) {
+
+	data, err := l.daoBalance.Apply(ctx, customerId, points, utils.Map{counter: counterPoints})
+	if err != nil && points < 0 {
+		err := &utils.AppError{ErrorCode: "S30340182", ErrorMsg: "Insufficient Points", ErrorDetail: "Customer does not have " + strconv.FormatInt(-points, 10) + " points"}
+		return nil, err
+	}
+	return data, err
+}
+
+// OrderPayable - Amount paid for the Order, the order_total less the value of the redeemed points, zero if the Order
+// has no total
+func OrderPayable(dataOrder utils.Map) primitive.Decimal128 {
+	if payable, err := sales_common.GetMemberDataDecimal(dataOrder, sales_common.FLD_ORDER_PAYABLE); err == nil {
+		return payable
+	}
+	if total, err := sales_common.GetMemberDataDecimal(dataOrder, sales_common.FLD_ORDER_TOTAL); err == nil {
+		return total
+	}
+	return sales_common.RatToDecimal(new(big.Rat), 0)
+}
+
+// txnPoints - Signed points of the transaction
+func txnPoints(txn utils.Map) int64 {
+	points, _ := sales_common.GetMemberDataFloat(txn, sales_common.FLD_LOYALTY_POINTS)
+	return int64(points)
+}
@@ -0,0 +1,265 @@
+package sales_services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeLoyaltyBalanceDao - Balance of a single Customer, deductions fail without enough points like the Mongo DAO
+type fakeLoyaltyBalanceDao struct {
+	sales_repository.LoyaltyBalanceDao
+	mutex   sync.Mutex
+	balance utils.Map
+}
+
+func (f *fakeLoyaltyBalanceDao) Get(ctx context.Context, customerId string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return copyMap(f.balance), nil
+}
+
+func (f *fakeLoyaltyBalanceDao) Apply(ctx context.Context, customerId string, points int64, counters utils.Map) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	balance, _ := f.balance[sales_common.FLD_LOYALTY_POINTS_BALANCE].(int64)
+	if points < 0 && balance+points < 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	f.balance[sales_common.FLD_LOYALTY_POINTS_BALANCE] = balance + points
+	for counter, value := range counters {
+		current, _ := f.balance[counter].(int64)
+		f.balance[counter] = current + value.(int64)
+	}
+	return copyMap(f.balance), nil
+}
+
+// fakeLoyaltyTxnDao - Transactions in the order appended, one of each type for an Order
+type fakeLoyaltyTxnDao struct {
+	customer_repository.CustomerLoyaltyTxnDao
+	t    *testing.T
+	txns []utils.Map
+}
+
+func (f *fakeLoyaltyTxnDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	return utils.Map{db_common.LIST_RESULT: append([]utils.Map{}, f.txns...)}, nil
+}
+
+func (f *fakeLoyaltyTxnDao) Find(ctx context.Context, filter string) (utils.Map, error) {
+	for _, txn := range f.txns {
+		if matchFilter(f.t, txn, filter) {
+			return txn, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeLoyaltyTxnDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	f.txns = append(f.txns, copyMap(indata))
+	return indata, nil
+}
+
+func (f *fakeLoyaltyTxnDao) CreateForOrder(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	for _, txn := range f.txns {
+		if txn[sales_common.FLD_CUSTOMER_ORDER_ID] == indata[sales_common.FLD_CUSTOMER_ORDER_ID] &&
+			txn[sales_common.FLD_LOYALTY_TXN_TYPE] == indata[sales_common.FLD_LOYALTY_TXN_TYPE] {
+			return txn, nil
+		}
+	}
+	return f.Create(ctx, indata)
+}
+
+// newTestLoyaltyLedger - Points worth 0.5 each, at least 10 and for at most 50% of the order
+func newTestLoyaltyLedger(t *testing.T, balance int64, txns ...utils.Map) (*LoyaltyLedger, *fakeLoyaltyBalanceDao, *fakeLoyaltyTxnDao) {
+	daoBalance := &fakeLoyaltyBalanceDao{balance: utils.Map{sales_common.FLD_LOYALTY_POINTS_BALANCE: balance}}
+	daoTxn := &fakeLoyaltyTxnDao{t: t, txns: txns}
+	l := &LoyaltyLedger{
+		businessId: "biz1",
+		daoPreference: &fakePreferenceDao{preferences: map[string]utils.Map{
+			sales_common.PREFERENCE_LOYALTY: {
+				sales_common.FLD_LOYALTY_ENABLED:            true,
+				sales_common.FLD_LOYALTY_REDEEM_VALUE:       "0.5",
+				sales_common.FLD_LOYALTY_REDEEM_MIN_POINTS:  10,
+				sales_common.FLD_LOYALTY_REDEEM_MAX_PERCENT: 50,
+				sales_common.FLD_LOYALTY_EXPIRY_DAYS:        30,
+			},
+		}},
+		daoBalance: daoBalance,
+		newTxnDao: func(customerId string) customer_repository.CustomerLoyaltyTxnDao {
+			return daoTxn
+		},
+	}
+	return l, daoBalance, daoTxn
+}
+
+func TestLoyaltyRedeem(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    int64
+		total     string
+		currency  string
+		wantValue string
+		wantCode  string
+	}{
+		{"redeemed", 100, "100.00", "USD", "50.00", ""},
+		// 0.5 * 21 = 10.5 rounded for the currency
+		{"rounded scale 0", 21, "100", "JPY", "11", ""},
+		{"rounded scale 3", 21, "100", "KWD", "10.500", ""},
+		{"zero points", 0, "100.00", "USD", "", "S30340181"},
+		{"below minimum", 5, "100.00", "USD", "", "S30340183"},
+		// Order payable would go below 0
+		{"worth more than the order", 100, "40.00", "USD", "", "S30340184"},
+		{"over max percent", 100, "60.00", "USD", "", "S30340184"},
+		{"insufficient balance", 300, "1000.00", "USD", "", "S30340182"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, daoBalance, daoTxn := newTestLoyaltyLedger(t, 200)
+
+			value, err := l.Redeem(context.Background(), "cust1", "ord1", tt.points, decimalOf(t, tt.total), tt.currency)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("Redeem() error = %v, want code %q", err, tt.wantCode)
+			}
+
+			wantBalance := int64(200)
+			if err == nil {
+				if value.String() != tt.wantValue {
+					t.Errorf("Redeem() = %s, want %s", value.String(), tt.wantValue)
+				}
+				wantBalance -= tt.points
+			} else if len(daoTxn.txns) != 0 {
+				t.Errorf("Redeem() failed but appended %v", daoTxn.txns)
+			}
+			if got := daoBalance.balance[sales_common.FLD_LOYALTY_POINTS_BALANCE]; got != wantBalance {
+				t.Errorf("balance = %v, want %d", got, wantBalance)
+			}
+		})
+	}
+}
+
+func TestLoyaltyRedeemDisabled(t *testing.T) {
+	l, _, _ := newTestLoyaltyLedger(t, 200)
+	l.daoPreference = &fakePreferenceDao{}
+
+	_, err := l.Redeem(context.Background(), "cust1", "ord1", 100, decimalOf(t, "100"), "USD")
+	if got := errorCode(err); got != "S30340180" {
+		t.Errorf("Redeem() error = %v, want code S30340180", err)
+	}
+}
+
+func TestLoyaltyRefund(t *testing.T) {
+	l, daoBalance, daoTxn := newTestLoyaltyLedger(t, 200)
+	ctx := context.Background()
+
+	// Nothing redeemed for the Order
+	dataTxn, err := l.Refund(ctx, "cust1", "ord1")
+	if err != nil || dataTxn != nil {
+		t.Fatalf("Refund() without redeem = %v, %v, want nil", dataTxn, err)
+	}
+
+	if _, err := l.Redeem(ctx, "cust1", "ord1", 80, decimalOf(t, "100.00"), "USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refunded once even if called again
+	for i := 0; i < 2; i++ {
+		if _, err := l.Refund(ctx, "cust1", "ord1"); err != nil {
+			t.Fatalf("Refund() error = %v", err)
+		}
+	}
+	if got := daoBalance.balance[sales_common.FLD_LOYALTY_POINTS_BALANCE]; got != int64(200) {
+		t.Errorf("balance = %v, want 200", got)
+	}
+	if got := daoBalance.balance[sales_common.FLD_LOYALTY_POINTS_REDEEMED]; got != int64(0) {
+		t.Errorf("redeemed = %v, want 0", got)
+	}
+
+	refund := daoTxn.txns[len(daoTxn.txns)-1]
+	if refund[sales_common.FLD_LOYALTY_TXN_TYPE] != sales_common.LOYALTY_TXN_REFUND || txnPoints(refund) != 80 {
+		t.Errorf("last txn = %v, want refund of 80", refund)
+	}
+	if _, err := sales_common.GetMemberDataTime(refund, sales_common.FLD_LOYALTY_EXPIRES_AT); err != nil {
+		t.Errorf("refund has no expiry")
+	}
+}
+
+func TestLoyaltyExpire(t *testing.T) {
+	past := time.Now().AddDate(0, 0, -1)
+	future := time.Now().AddDate(0, 0, 1)
+
+	txn := func(txnType string, points int64, expiresAt interface{}) utils.Map {
+		return utils.Map{
+			sales_common.FLD_LOYALTY_TXN_ID:     utils.GenerateUniqueId("lyt"),
+			sales_common.FLD_LOYALTY_TXN_TYPE:   txnType,
+			sales_common.FLD_LOYALTY_POINTS:     points,
+			sales_common.FLD_LOYALTY_EXPIRES_AT: expiresAt,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		txns        []utils.Map
+		wantExpired int64
+	}{
+		{"nothing expired", []utils.Map{txn("earn", 100, future)}, 0},
+		{"never expire", []utils.Map{txn("earn", 100, nil)}, 0},
+		{"expired", []utils.Map{txn("earn", 100, past), txn("earn", 50, future)}, 100},
+		// Deductions are taken from the oldest points first
+		{"oldest used up", []utils.Map{txn("earn", 100, past), txn("earn", 50, future), txn("redeem", -120, nil)}, 0},
+		{"rest of oldest", []utils.Map{txn("earn", 100, past), txn("redeem", -30, nil), txn("earn", 50, past)}, 120},
+		{"newer expired", []utils.Map{txn("earn", 100, future), txn("earn", 50, past), txn("redeem", -120, nil)}, 30},
+		{"expired once", []utils.Map{txn("earn", 100, past), txn("expire", -100, nil)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var balance int64
+			for _, txn := range tt.txns {
+				balance += txnPoints(txn)
+			}
+			l, daoBalance, _ := newTestLoyaltyLedger(t, balance, tt.txns...)
+
+			expired, err := l.Expire(context.Background(), "cust1")
+			if err != nil {
+				t.Fatalf("Expire() error = %v", err)
+			}
+			if expired != tt.wantExpired {
+				t.Errorf("Expire() = %d, want %d", expired, tt.wantExpired)
+			}
+			if got := daoBalance.balance[sales_common.FLD_LOYALTY_POINTS_BALANCE]; got != balance-tt.wantExpired {
+				t.Errorf("balance = %v, want %d", got, balance-tt.wantExpired)
+			}
+
+			// Running again expires nothing more
+			if expired, _ := l.Expire(context.Background(), "cust1"); expired != 0 {
+				t.Errorf("Expire() again = %d, want 0", expired)
+			}
+		})
+	}
+}
+
+func TestOrderPayable(t *testing.T) {
+	tests := []struct {
+		name  string
+		order utils.Map
+		want  string
+	}{
+		{"payable", utils.Map{sales_common.FLD_ORDER_TOTAL: decimalOf(t, "100.00"), sales_common.FLD_ORDER_PAYABLE: decimalOf(t, "60.00")}, "60.00"},
+		{"total", utils.Map{sales_common.FLD_ORDER_TOTAL: decimalOf(t, "100.00")}, "100.00"},
+		{"no total", utils.Map{}, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderPayable(tt.order); got.String() != tt.want {
+				t.Errorf("OrderPayable() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
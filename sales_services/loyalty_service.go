@@ -0,0 +1,203 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository/customer_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// LoyaltyService - Loyalty Points of the Customers, see LoyaltyLedger for the earn and redeem rules
+type LoyaltyService interface {
+	// GetBalance - Get the points balance of the Customer
+	GetBalance(customerId string) (utils.Map, error)
+	// ListTransactions - List the points transactions of the Customer
+	ListTransactions(customerId string, filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// EarnForOrder - Add the points for the delivered Order, once for each Order
+	EarnForOrder(customerId string, custOrderId string) (utils.Map, error)
+	// Adjust - Add or deduct the points of the Customer, the remarks are required
+	Adjust(customerId string, points int64, remarks string) (utils.Map, error)
+	// ExpirePoints - Expire the points past their expiry of all the Customers, to be run on schedule
+	ExpirePoints() (int64, error)
+	// RebuildBalance - Recompute the balance of the Customer from the transactions
+	RebuildBalance(customerId string) (utils.Map, error)
+
+	EndService()
+}
+
+type loyaltyBaseService struct {
+	db_utils.DatabaseService
+	dbRegion    db_utils.DatabaseService
+	ledger      *LoyaltyLedger
+	daoBusiness platform_repository.BusinessDao
+	child       LoyaltyService
+	businessId  string
+	ctx         context.Context
+}
+
+// NewLoyaltyService - Construct Loyalty
+func NewLoyaltyService(props utils.Map) (LoyaltyService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("LoyaltyService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := loyaltyBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// loyaltyBaseService - Close all the services
+func (p *loyaltyBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *loyaltyBaseService) initializeService() {
+	log.Printf("LoyaltyService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.ledger = NewLoyaltyLedger(p.dbRegion.GetClient(), p.businessId)
+}
+
+// GetBalance - Get the points balance of the Customer
+func (p *loyaltyBaseService) GetBalance(customerId string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyService::GetBalance - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "GetBalance", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.GetBalance(ctx, customerId)
+
+	log.Println("LoyaltyService::GetBalance - End ", err)
+	return data, err
+}
+
+// ListTransactions - List the points transactions of the Customer
+func (p *loyaltyBaseService) ListTransactions(customerId string, filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyService::ListTransactions - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "ListTransactions", p.businessId)
+	defer span.EndWith(&err)
+
+	daoTxn := customer_repository.NewCustomerLoyaltyTxnDao(p.dbRegion.GetClient(), p.businessId, customerId)
+	listdata, err := daoTxn.List(ctx, filter, sort, skip, limit)
+
+	log.Println("LoyaltyService::ListTransactions - End ", err)
+	return listdata, err
+}
+
+// EarnForOrder - Add the points for the delivered Order, once for each Order
+func (p *loyaltyBaseService) EarnForOrder(customerId string, custOrderId string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyService::EarnForOrder - Begin ", customerId, custOrderId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "EarnForOrder", p.businessId)
+	defer span.EndWith(&err)
+
+	daoOrder := customer_repository.NewCustomerOrderDao(p.GetClient(), p.businessId, customerId)
+	dataOrder, err := daoOrder.Get(ctx, custOrderId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	status, _ := utils.GetMemberDataStr(dataOrder, sales_common.FLD_CUSTOMER_ORDER_STATUS)
+	if status != sales_common.ORDER_STATUS_DELIVERED {
+		err := &utils.AppError{ErrorCode: "S30340186", ErrorMsg: "Order not delivered", ErrorDetail: "Points are earned only for delivered Orders"}
+		return utils.Map{}, err
+	}
+
+	data, err := p.ledger.Earn(ctx, customerId, custOrderId, OrderPayable(dataOrder))
+
+	log.Println("LoyaltyService::EarnForOrder - End ", err)
+	return data, err
+}
+
+// Adjust - Add or deduct the points of the Customer, the remarks are required
+func (p *loyaltyBaseService) Adjust(customerId string, points int64, remarks string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyService::Adjust - Begin ", customerId, points)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "Adjust", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.Adjust(ctx, customerId, points, remarks)
+
+	log.Println("LoyaltyService::Adjust - End ", err)
+	return data, err
+}
+
+// ExpirePoints - Expire the points past their expiry of all the Customers, to be run on schedule
+func (p *loyaltyBaseService) ExpirePoints() (_ int64, err error) {
+
+	log.Println("LoyaltyService::ExpirePoints - Begin ")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "ExpirePoints", p.businessId)
+	defer span.EndWith(&err)
+
+	expired, err := p.ledger.ExpireAll(ctx)
+
+	log.Println("LoyaltyService::ExpirePoints - End ", expired, err)
+	return expired, err
+}
+
+// RebuildBalance - Recompute the balance of the Customer from the transactions
+func (p *loyaltyBaseService) RebuildBalance(customerId string) (_ utils.Map, err error) {
+
+	log.Println("LoyaltyService::RebuildBalance - Begin ", customerId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "loyalty", "RebuildBalance", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.ledger.Rebuild(ctx, customerId)
+
+	log.Println("LoyaltyService::RebuildBalance - End ", err)
+	return data, err
+}
+
+func (p *loyaltyBaseService) errorReturn(err error) (LoyaltyService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -40,6 +42,7 @@ type materialTypeBaseService struct {
 	daoBusiness     platform_repository.BusinessDao
 	child           MaterialTypeService
 	businessId      string
+	ctx             context.Context
 }
 
 // NewMaterialTypeService - Construct MaterialType
@@ -69,6 +72,7 @@ func NewMaterialTypeService(props utils.Map) (MaterialTypeService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -99,11 +103,14 @@ func (p *materialTypeBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *materialTypeBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *materialTypeBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("materialTypeBaseService::FindAll - Begin")
 
-	listdata, err := p.daoMaterialType.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoMaterialType.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -113,28 +120,37 @@ func (p *materialTypeBaseService) List(filter string, sort string, skip int64, l
 }
 
 // Get - Find By Code
-func (p *materialTypeBaseService) Get(materialTypeId string) (utils.Map, error) {
+func (p *materialTypeBaseService) Get(materialTypeId string) (_ utils.Map, err error) {
 	log.Printf("materialTypeBaseService::Get::  Begin %v", materialTypeId)
 
-	data, err := p.daoMaterialType.Get(materialTypeId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMaterialType.Get(ctx, materialTypeId)
 
 	log.Println("BrandService::Get:: End ", err)
 	return data, err
 }
 
-func (p *materialTypeBaseService) Find(filter string) (utils.Map, error) {
+func (p *materialTypeBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("materialTypeBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoMaterialType.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMaterialType.Find(ctx, filter)
 	log.Println("materialTypeBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *materialTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *materialTypeBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("MaterialTypeService::Create - Begin")
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var materialTypeId string
 
 	dataval, dataok := indata[sales_common.FLD_MATERIAL_TYPE_ID]
@@ -149,7 +165,7 @@ func (p *materialTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_MATERIAL_TYPE_ID] = materialTypeId
 
-	data, err := p.daoMaterialType.Create(indata)
+	data, err := p.daoMaterialType.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -159,23 +175,29 @@ func (p *materialTypeBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *materialTypeBaseService) Update(materialTypeId string, indata utils.Map) (utils.Map, error) {
+func (p *materialTypeBaseService) Update(materialTypeId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("MaterialTypeService::Update - Begin")
 
-	data, err := p.daoMaterialType.Update(materialTypeId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMaterialType.Update(ctx, materialTypeId, indata)
 
 	log.Println("MaterialTypeService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *materialTypeBaseService) Delete(materialTypeId string, delete_permanent bool) error {
+func (p *materialTypeBaseService) Delete(materialTypeId string, delete_permanent bool) (err error) {
 
 	log.Println("BrandService::Delete - Begin", materialTypeId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "material_type", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoMaterialType.Delete(materialTypeId)
+		result, err := p.daoMaterialType.Delete(ctx, materialTypeId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -39,6 +41,7 @@ type mediaBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       MediaService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewMediaService - Construct Media
@@ -68,6 +71,7 @@ func NewMediaService(props utils.Map) (MediaService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *mediaBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *mediaBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *mediaBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("mediaBaseService::FindAll - Begin")
 
-	listdata, err := p.daoMedia.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoMedia.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *mediaBaseService) List(filter string, sort string, skip int64, limit in
 }
 
 // Get - Find By Code
-func (p *mediaBaseService) Get(mediaId string) (utils.Map, error) {
+func (p *mediaBaseService) Get(mediaId string) (_ utils.Map, err error) {
 	log.Printf("mediaBaseService::Get::  Begin %v", mediaId)
 
-	data, err := p.daoMedia.Get(mediaId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMedia.Get(ctx, mediaId)
 
 	log.Println("mediaBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *mediaBaseService) Find(filter string) (utils.Map, error) {
+func (p *mediaBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("mediaBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoMedia.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMedia.Find(ctx, filter)
 	log.Println("mediaBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *mediaBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *mediaBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("MediaService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var mediaId string
 
 	dataval, dataok := indata[sales_common.FLD_MEDIA_ID]
@@ -147,7 +164,7 @@ func (p *mediaBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_MEDIA_ID] = mediaId
 
-	data, err := p.daoMedia.Create(indata)
+	data, err := p.daoMedia.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *mediaBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *mediaBaseService) Update(mediaId string, indata utils.Map) (utils.Map, error) {
+func (p *mediaBaseService) Update(mediaId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("MediaService::Update - Begin")
 
-	data, err := p.daoMedia.Update(mediaId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoMedia.Update(ctx, mediaId, indata)
 
 	log.Println("MediaService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *mediaBaseService) Delete(mediaId string, delete_permanent bool) error {
+func (p *mediaBaseService) Delete(mediaId string, delete_permanent bool) (err error) {
 
 	log.Println("MediaService::Delete - Begin", mediaId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "media", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoMedia.Delete(mediaId)
+		result, err := p.daoMedia.Delete(ctx, mediaId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type navigationBaseService struct {
 	daoBusiness   platform_repository.BusinessDao
 	child         NavigationService
 	businessId    string
+	ctx           context.Context
 }
 
 // NewNavigationService - Construct Navigation
@@ -67,6 +70,7 @@ func NewNavigationService(props utils.Map) (NavigationService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *navigationBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *navigationBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *navigationBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("navigationBaseService::FindAll - Begin")
 
-	listdata, err := p.daoNavigation.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoNavigation.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *navigationBaseService) List(filter string, sort string, skip int64, lim
 }
 
 // Get - Find By Code
-func (p *navigationBaseService) Get(navigationId string) (utils.Map, error) {
+func (p *navigationBaseService) Get(navigationId string) (_ utils.Map, err error) {
 	log.Printf("navigationBaseService::Get::  Begin %v", navigationId)
 
-	data, err := p.daoNavigation.Get(navigationId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoNavigation.Get(ctx, navigationId)
 
 	log.Println("navigationBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *navigationBaseService) Find(filter string) (utils.Map, error) {
+func (p *navigationBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("NavigationService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoNavigation.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoNavigation.Find(ctx, filter)
 	log.Println("NavigationService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *navigationBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *navigationBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("NavigationService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var navigationId string
 
 	dataval, dataok := indata[sales_common.FLD_NAVIGATION_ID]
@@ -146,7 +163,7 @@ func (p *navigationBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_NAVIGATION_ID] = navigationId
 
-	data, err := p.daoNavigation.Create(indata)
+	data, err := p.daoNavigation.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *navigationBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *navigationBaseService) Update(navigationId string, indata utils.Map) (utils.Map, error) {
+func (p *navigationBaseService) Update(navigationId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("NavigationService::Update - Begin")
 
-	data, err := p.daoNavigation.Update(navigationId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoNavigation.Update(ctx, navigationId, indata)
 
 	log.Println("NavigationService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *navigationBaseService) Delete(navigationId string, delete_permanent bool) error {
+func (p *navigationBaseService) Delete(navigationId string, delete_permanent bool) (err error) {
 
 	log.Println("NavigationService::Delete - Begin", navigationId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "navigation", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoNavigation.Delete(navigationId)
+		result, err := p.daoNavigation.Delete(ctx, navigationId)
 		if err != nil {
 			return err
 		}
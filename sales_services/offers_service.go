@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type offerBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       OfferService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewOfferService - Construct Offer
@@ -67,6 +70,7 @@ func NewOfferService(props utils.Map) (OfferService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *offerBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *offerBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *offerBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("offerBaseService::FindAll - Begin")
 
-	listdata, err := p.daoOffer.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoOffer.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *offerBaseService) List(filter string, sort string, skip int64, limit in
 }
 
 // Get - Find By Code
-func (p *offerBaseService) Get(offerId string) (utils.Map, error) {
+func (p *offerBaseService) Get(offerId string) (_ utils.Map, err error) {
 	log.Printf("offerBaseService::Get::  Begin %v", offerId)
 
-	data, err := p.daoOffer.Get(offerId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoOffer.Get(ctx, offerId)
 
 	log.Println("offerBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *offerBaseService) Find(filter string) (utils.Map, error) {
+func (p *offerBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("OfferService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoOffer.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoOffer.Find(ctx, filter)
 	log.Println("OfferService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *offerBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *offerBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("OfferService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var offerId string
 
 	dataval, dataok := indata[sales_common.FLD_OFFER_ID]
@@ -146,7 +163,7 @@ func (p *offerBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_OFFER_ID] = offerId
 
-	data, err := p.daoOffer.Create(indata)
+	data, err := p.daoOffer.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *offerBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *offerBaseService) Update(offerId string, indata utils.Map) (utils.Map, error) {
+func (p *offerBaseService) Update(offerId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("OfferService::Update - Begin")
 
-	data, err := p.daoOffer.Update(offerId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoOffer.Update(ctx, offerId, indata)
 
 	log.Println("OfferService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *offerBaseService) Delete(offerId string, delete_permanent bool) error {
+func (p *offerBaseService) Delete(offerId string, delete_permanent bool) (err error) {
 
 	log.Println("OfferService::Delete - Begin", offerId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "offer", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoOffer.Delete(offerId)
+		result, err := p.daoOffer.Delete(ctx, offerId)
 		if err != nil {
 			return err
 		}
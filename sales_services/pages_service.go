@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 
 	"github.com/zapscloud/golib-utils/utils"
 )
@@ -39,6 +41,7 @@ type pageBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       PageService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewPageService - Construct Page
@@ -68,6 +71,7 @@ func NewPageService(props utils.Map) (PageService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *pageBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *pageBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *pageBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("pageBaseService::FindAll - Begin")
 
-	listdata, err := p.daoPage.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPage.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *pageBaseService) List(filter string, sort string, skip int64, limit int
 }
 
 // Get - Find By Code
-func (p *pageBaseService) Get(pageId string) (utils.Map, error) {
+func (p *pageBaseService) Get(pageId string) (_ utils.Map, err error) {
 	log.Printf("pageBaseService::Get::  Begin %v", pageId)
 
-	data, err := p.daoPage.Get(pageId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPage.Get(ctx, pageId)
 
 	log.Println("pageBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *pageBaseService) Find(filter string) (utils.Map, error) {
+func (p *pageBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("pageBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoPage.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPage.Find(ctx, filter)
 	log.Println("pageBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *pageBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *pageBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("PageService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var pageId string
 
 	dataval, dataok := indata[sales_common.FLD_PAGE_ID]
@@ -147,7 +164,7 @@ func (p *pageBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_PAGE_ID] = pageId
 
-	data, err := p.daoPage.Create(indata)
+	data, err := p.daoPage.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *pageBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *pageBaseService) Update(pageId string, indata utils.Map) (utils.Map, error) {
+func (p *pageBaseService) Update(pageId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("PageService::Update - Begin")
 
-	data, err := p.daoPage.Update(pageId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPage.Update(ctx, pageId, indata)
 
 	log.Println("PageService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *pageBaseService) Delete(pageId string, delete_permanent bool) error {
+func (p *pageBaseService) Delete(pageId string, delete_permanent bool) (err error) {
 
 	log.Println("PageService::Delete - Begin", pageId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "page", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoPage.Delete(pageId)
+		result, err := p.daoPage.Delete(ctx, pageId)
 		if err != nil {
 			return err
 		}
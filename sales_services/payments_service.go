@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -40,6 +42,7 @@ type paymentBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       PaymentService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewPaymentService - Construct Payment
@@ -69,6 +72,7 @@ func NewPaymentService(props utils.Map) (PaymentService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *paymentBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *paymentBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *paymentBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("PaymentService::FindAll - Begin")
 
-	listdata, err := p.daoPayment.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPayment.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *paymentBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *paymentBaseService) Get(paymentId string) (utils.Map, error) {
+func (p *paymentBaseService) Get(paymentId string) (_ utils.Map, err error) {
 	log.Printf("PaymentService::Get::  Begin %v", paymentId)
 
-	data, err := p.daoPayment.Get(paymentId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPayment.Get(ctx, paymentId)
 
 	log.Println("PaymentService::Get:: End ", err)
 	return data, err
 }
 
-func (p *paymentBaseService) Find(filter string) (utils.Map, error) {
+func (p *paymentBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("PaymentBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoPayment.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPayment.Find(ctx, filter)
 	log.Println("PaymentBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *paymentBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *paymentBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("PaymentService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var paymentId string
 
 	dataval, dataok := indata[sales_common.FLD_PAYMENT_ID]
@@ -147,7 +164,7 @@ func (p *paymentBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_PAYMENT_ID] = paymentId
 
-	data, err := p.daoPayment.Create(indata)
+	data, err := p.daoPayment.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *paymentBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *paymentBaseService) Update(paymentId string, indata utils.Map) (utils.Map, error) {
+func (p *paymentBaseService) Update(paymentId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BusinessPaymentService::Update - Begin")
 
-	data, err := p.daoPayment.Update(paymentId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPayment.Update(ctx, paymentId, indata)
 
 	log.Println("PaymentService::Update - End")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *paymentBaseService) Delete(paymentId string, delete_permanent bool) error {
+func (p *paymentBaseService) Delete(paymentId string, delete_permanent bool) (err error) {
 
 	log.Println("PaymentService::Delete - Begin", paymentId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "payment", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoPayment.Delete(paymentId)
+		result, err := p.daoPayment.Delete(ctx, paymentId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -40,6 +42,7 @@ type policiesBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       PoliciesService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewPoliciesService - Construct Policies
@@ -69,6 +72,7 @@ func NewPoliciesService(props utils.Map) (PoliciesService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *policiesBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *policiesBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *policiesBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("PoliciesService::FindAll - Begin")
 
-	listdata, err := p.daoPolicies.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPolicies.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *policiesBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *policiesBaseService) Get(policyId string) (utils.Map, error) {
+func (p *policiesBaseService) Get(policyId string) (_ utils.Map, err error) {
 	log.Printf("PoliciesService::Get::  Begin %v", policyId)
 
-	data, err := p.daoPolicies.Get(policyId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPolicies.Get(ctx, policyId)
 
 	log.Println("PoliciesService::Get:: End ", err)
 	return data, err
 }
 
-func (p *policiesBaseService) Find(filter string) (utils.Map, error) {
+func (p *policiesBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("PoliciesBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoPolicies.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPolicies.Find(ctx, filter)
 	log.Println("PoliciesBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *policiesBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *policiesBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("PoliciesService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var policyId string
 
 	dataval, dataok := indata[sales_common.FLD_POLICY_ID]
@@ -153,7 +170,7 @@ func (p *policiesBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_POLICY_ID] = policyId
 
-	data, err := p.daoPolicies.Create(indata)
+	data, err := p.daoPolicies.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -163,23 +180,29 @@ func (p *policiesBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *policiesBaseService) Update(policyId string, indata utils.Map) (utils.Map, error) {
+func (p *policiesBaseService) Update(policyId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BusinessPoliciesService::Update - Begin")
 
-	data, err := p.daoPolicies.Update(policyId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPolicies.Update(ctx, policyId, indata)
 
 	log.Println("PoliciesService::Update - End")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *policiesBaseService) Delete(policyId string, delete_permanent bool) error {
+func (p *policiesBaseService) Delete(policyId string, delete_permanent bool) (err error) {
 
 	log.Println("PoliciesService::Delete - Begin", policyId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "policies", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoPolicies.Delete(policyId)
+		result, err := p.daoPolicies.Delete(ctx, policyId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -40,6 +42,7 @@ type preferenceBaseService struct {
 	daoBusiness   platform_repository.BusinessDao
 	child         PreferenceService
 	businessId    string
+	ctx           context.Context
 }
 
 // NewPreferenceService - Construct Preference
@@ -69,6 +72,7 @@ func NewPreferenceService(props utils.Map) (PreferenceService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *preferenceBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *preferenceBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *preferenceBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("PreferenceService::FindAll - Begin")
 
-	listdata, err := p.daoPreference.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPreference.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *preferenceBaseService) List(filter string, sort string, skip int64, lim
 }
 
 // Get - Find By Code
-func (p *preferenceBaseService) Get(preferenceId string) (utils.Map, error) {
+func (p *preferenceBaseService) Get(preferenceId string) (_ utils.Map, err error) {
 	log.Printf("PreferenceService::Get::  Begin %v", preferenceId)
 
-	data, err := p.daoPreference.Get(preferenceId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPreference.Get(ctx, preferenceId)
 
 	log.Println("PreferenceService::Get:: End ", err)
 	return data, err
 }
 
-func (p *preferenceBaseService) Find(filter string) (utils.Map, error) {
+func (p *preferenceBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("PreferenceBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoPreference.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPreference.Find(ctx, filter)
 	log.Println("PreferenceBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *preferenceBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *preferenceBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("PreferenceService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var preferenceId string
 
 	dataval, dataok := indata[sales_common.FLD_PREFERENCE_ID]
@@ -147,7 +164,7 @@ func (p *preferenceBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_PREFERENCE_ID] = preferenceId
 
-	data, err := p.daoPreference.Create(indata)
+	data, err := p.daoPreference.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *preferenceBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *preferenceBaseService) Update(preferenceId string, indata utils.Map) (utils.Map, error) {
+func (p *preferenceBaseService) Update(preferenceId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BusinessPreferenceService::Update - Begin")
 
-	data, err := p.daoPreference.Update(preferenceId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPreference.Update(ctx, preferenceId, indata)
 
 	log.Println("PreferenceService::Update - End")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *preferenceBaseService) Delete(preferenceId string, delete_permanent bool) error {
+func (p *preferenceBaseService) Delete(preferenceId string, delete_permanent bool) (err error) {
 
 	log.Println("PreferenceService::Delete - Begin", preferenceId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "preference", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoPreference.Delete(preferenceId)
+		result, err := p.daoPreference.Delete(ctx, preferenceId)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,322 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceListResolver - Resolve the effective price of the Products in a currency for a Customer and Region.
+//
+// Price Lists of the currency valid at the time, for the Customer's type and the Region or for all of them,
+// are tried from the most specific one. The first one having the Variant, or else the Product, gives the
+// price. The prices of the Lists are Decimal128. When no List has the Product, the price from the
+// PriceResolver is used in the default currency of the business. A currency is required, so the Lists of
+// other currencies are never mixed.
+type PriceListResolver struct {
+	businessId    string
+	priceResolver *PriceResolver
+	daoProduct    sales_repository.ProductDao
+	daoVariant    sales_repository.ProductVariantDao
+	daoPriceList  sales_repository.PriceListDao
+	daoItem       sales_repository.PriceListItemDao
+}
+
+// NewPriceListResolver - Construct PriceListResolver on the Region database client
+func NewPriceListResolver(client utils.Map, businessId string) *PriceListResolver {
+	return &PriceListResolver{
+		businessId:    businessId,
+		priceResolver: NewPriceResolver(client, businessId),
+		daoProduct:    sales_repository.NewProductDao(client, businessId),
+		daoVariant:    sales_repository.NewProductVariantDao(client, businessId),
+		daoPriceList:  sales_repository.NewPriceListDao(client, businessId),
+		daoItem:       sales_repository.NewPriceListItemDao(client, businessId),
+	}
+}
+
+// ResolvePrice - Resolve the price of the Product, or its Variant, for the Customer and Region at the time. The
+// currency defaults to the default currency of the business, one of them is required. Returns the product_id,
+// variant_id, variant_sku, currency, customer_type_id, sales_region_id, unit_price (Decimal128) and the
+// price_list_id (empty if no List applied)
+func (r *PriceListResolver) ResolvePrice(ctx context.Context, customerId string, regionId string, productId string, variantId string, currency string, at time.Time) (_ utils.Map, err error) {
+
+	log.Println("PriceListResolver::ResolvePrice - Begin ", customerId, regionId, productId, variantId, currency)
+
+	ctx, span := sales_telemetry.StartService(ctx, "price_list_resolver", "ResolvePrice", r.businessId)
+	defer span.EndWith(&err)
+
+	variantSku, err := r.validateProduct(ctx, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	customerTypeId, err := r.priceResolver.GetCustomerType(ctx, customerId)
+	if err != nil {
+		return nil, err
+	}
+	defaultCurrency := r.priceResolver.GetDefaultCurrency(ctx)
+	if len(currency) == 0 {
+		currency = defaultCurrency
+	}
+	if len(currency) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340262", ErrorMsg: "Missing Currency", ErrorDetail: sales_common.FLD_CURRENCY + " value should be sent, or the " + sales_common.FLD_DEFAULT_CURRENCY + " set in the pricing Preference"}
+		return nil, err
+	}
+
+	result := utils.Map{
+		sales_common.FLD_PRODUCT_ID:       productId,
+		sales_common.FLD_VARIANT_ID:       variantId,
+		sales_common.FLD_VARIANT_SKU:      variantSku,
+		sales_common.FLD_CURRENCY:         currency,
+		sales_common.FLD_CUSTOMER_TYPE_ID: customerTypeId,
+		sales_common.FLD_REGION_ID:        regionId,
+		sales_common.FLD_PRICE_LIST_ID:    "",
+	}
+
+	priceLists, err := r.getPriceLists(ctx, customerTypeId, regionId, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	priceList, item, err := r.findItem(ctx, priceLists, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	if item != nil {
+		unitPrice, err := sales_common.GetMemberDataDecimal(item, sales_common.FLD_PRICE_LIST_PRICE)
+		if err != nil {
+			return nil, err
+		}
+		result[sales_common.FLD_CURRENCY] = priceList[sales_common.FLD_CURRENCY]
+		result[sales_common.FLD_PRICE_LIST_ID] = priceList[sales_common.FLD_PRICE_LIST_ID]
+		result[sales_common.FLD_UNIT_PRICE] = unitPrice
+
+		log.Println("PriceListResolver::ResolvePrice - End ", result)
+		return result, nil
+	}
+
+	// No List has the Product, the product_price is in the default currency
+	if currency != defaultCurrency {
+		err := &utils.AppError{ErrorCode: "S30340245", ErrorMsg: "No Price in Currency", ErrorDetail: "Product " + productId + " has no price in " + currency}
+		return nil, err
+	}
+	price, err := r.priceResolver.ResolvePriceForType(ctx, customerTypeId, productId, variantId, currency)
+	if err != nil {
+		return nil, err
+	}
+	result[sales_common.FLD_BASE_PRICE] = price[sales_common.FLD_BASE_PRICE]
+	result[sales_common.FLD_UNIT_PRICE] = price[sales_common.FLD_UNIT_PRICE]
+	result[sales_common.FLD_PRICE_TIER_ID] = price[sales_common.FLD_PRICE_TIER_ID]
+
+	log.Println("PriceListResolver::ResolvePrice - End ", result)
+	return result, nil
+}
+
+// PriceItems - Set the variant_sku, currency, base_price, unit_price, price_tier_id, price_list_id and line_total
+// in each item having product_id, variant_id and quantity, for the Customer and Region now. The currency defaults
+// to the default currency of the business. Returns the currency and the total of the items, all the prices are
+// Decimal128 in the decimals of the currency
+func (r *PriceListResolver) PriceItems(ctx context.Context, customerId string, regionId string, currency string, items []utils.Map) (_ string, _ primitive.Decimal128, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "price_list_resolver", "PriceItems", r.businessId)
+	defer span.EndWith(&err)
+
+	if len(currency) == 0 {
+		currency = r.priceResolver.GetDefaultCurrency(ctx)
+	}
+	scale := sales_common.CurrencyScale(currency)
+
+	now := time.Now()
+	total := new(big.Rat)
+	for _, item := range items {
+		productId, err := utils.GetMemberDataStr(item, sales_common.FLD_PRODUCT_ID)
+		if err != nil {
+			return "", primitive.Decimal128{}, err
+		}
+		quantity, err := sales_common.GetMemberDataDecimal(item, sales_common.FLD_QUANTITY)
+		if err != nil {
+			return "", primitive.Decimal128{}, err
+		}
+		quantityRat, err := sales_common.DecimalToRat(quantity)
+		if err != nil || quantityRat.Sign() <= 0 {
+			err := &utils.AppError{ErrorCode: "S30340172", ErrorMsg: "Invalid Quantity", ErrorDetail: "Quantity of Product " + productId + " should be greater than 0"}
+			return "", primitive.Decimal128{}, err
+		}
+
+		variantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+		price, err := r.ResolvePrice(ctx, customerId, regionId, productId, variantId, currency, now)
+		if err != nil {
+			return "", primitive.Decimal128{}, err
+		}
+		unitPrice, err := sales_common.GetMemberDataDecimal(price, sales_common.FLD_UNIT_PRICE)
+		if err != nil {
+			return "", primitive.Decimal128{}, err
+		}
+		unitRat, err := sales_common.DecimalToRat(unitPrice)
+		if err != nil {
+			return "", primitive.Decimal128{}, err
+		}
+		lineTotal := sales_common.RatToDecimal(new(big.Rat).Mul(unitRat, quantityRat), scale)
+		lineRat, _ := sales_common.DecimalToRat(lineTotal)
+
+		// Price from a List has no other base price
+		basePrice, ok := price[sales_common.FLD_BASE_PRICE]
+		if !ok {
+			basePrice = unitPrice
+		}
+		tierId, _ := utils.GetMemberDataStr(price, sales_common.FLD_PRICE_TIER_ID)
+
+		// SKU is empty when the Variant is cleared, so a stale one is not kept
+		item[sales_common.FLD_VARIANT_SKU] = price[sales_common.FLD_VARIANT_SKU]
+		item[sales_common.FLD_CURRENCY] = currency
+		item[sales_common.FLD_BASE_PRICE] = basePrice
+		item[sales_common.FLD_UNIT_PRICE] = unitPrice
+		item[sales_common.FLD_PRICE_TIER_ID] = tierId
+		item[sales_common.FLD_PRICE_LIST_ID] = price[sales_common.FLD_PRICE_LIST_ID]
+		item[sales_common.FLD_LINE_TOTAL] = lineTotal
+		total.Add(total, lineRat)
+	}
+
+	return currency, sales_common.RatToDecimal(total, scale), nil
+}
+
+// validateProduct - Verify the Product exist, and the Variant is of the Product, which is required for the
+// Products having options. Returns the variant_sku, empty without Variant
+func (r *PriceListResolver) validateProduct(ctx context.Context, productId string, variantId string) (string, error) {
+	dataProduct, err := r.daoProduct.Get(ctx, productId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340170", ErrorMsg: "Invalid ProductId", ErrorDetail: "Given ProductId " + productId + " is not exist"}
+		return "", err
+	}
+
+	if len(variantId) == 0 {
+		if _, ok := dataProduct[sales_common.FLD_PRODUCT_OPTIONS]; ok {
+			err := &utils.AppError{ErrorCode: "S30340220", ErrorMsg: "Missing VariantId", ErrorDetail: "Product " + productId + " has options, " + sales_common.FLD_VARIANT_ID + " value should be sent"}
+			return "", err
+		}
+		return "", nil
+	}
+
+	dataVariant, err := r.daoVariant.Get(ctx, variantId)
+	if err != nil || dataVariant[sales_common.FLD_PRODUCT_ID] != productId {
+		err := &utils.AppError{ErrorCode: "S30340222", ErrorMsg: "Invalid VariantId", ErrorDetail: "Given VariantId " + variantId + " is not exist for the Product " + productId}
+		return "", err
+	}
+	variantSku, _ := utils.GetMemberDataStr(dataVariant, sales_common.FLD_VARIANT_SKU)
+	return variantSku, nil
+}
+
+// getPriceLists - Get the Price Lists in the currency valid at the time for the Customer type and Region, the most
+// specific first, then by higher priority, then by later valid_from
+func (r *PriceListResolver) getPriceLists(ctx context.Context, customerTypeId string, regionId string, currency string, at time.Time) ([]utils.Map, error) {
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CURRENCY: currency})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := r.daoPriceList.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	priceLists, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	type candidate struct {
+		priceList   utils.Map
+		specificity int
+		priority    int
+		validFrom   time.Time
+	}
+	candidates := []candidate{}
+	for _, priceList := range priceLists {
+		validFrom, err := sales_common.GetMemberDataTime(priceList, sales_common.FLD_VALID_FROM)
+		if err != nil || validFrom.After(at) {
+			continue
+		}
+		if validTo, err := sales_common.GetMemberDataTime(priceList, sales_common.FLD_VALID_TO); err == nil && !at.Before(validTo) {
+			continue
+		}
+
+		specificity := 0
+		listTypeId, _ := utils.GetMemberDataStr(priceList, sales_common.FLD_CUSTOMER_TYPE_ID)
+		if len(listTypeId) > 0 {
+			if listTypeId != customerTypeId {
+				continue
+			}
+			specificity += 2
+		}
+		listRegionId, _ := utils.GetMemberDataStr(priceList, sales_common.FLD_REGION_ID)
+		if len(listRegionId) > 0 {
+			if listRegionId != regionId {
+				continue
+			}
+			specificity++
+		}
+
+		priority, _ := utils.GetMemberDataInt(priceList, sales_common.FLD_PRICE_LIST_PRIORITY, true)
+		candidates = append(candidates, candidate{priceList, specificity, priority, validFrom})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].specificity != candidates[j].specificity {
+			return candidates[i].specificity > candidates[j].specificity
+		}
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority > candidates[j].priority
+		}
+		return candidates[i].validFrom.After(candidates[j].validFrom)
+	})
+
+	result := []utils.Map{}
+	for _, c := range candidates {
+		result = append(result, c.priceList)
+	}
+	return result, nil
+}
+
+// findItem - Find the first of the Price Lists having the Variant, or else the Product, returns the Price List and its item
+func (r *PriceListResolver) findItem(ctx context.Context, priceLists []utils.Map, productId string, variantId string) (utils.Map, utils.Map, error) {
+	if len(priceLists) == 0 {
+		return nil, nil, nil
+	}
+
+	priceListIds := []string{}
+	for _, priceList := range priceLists {
+		priceListId, _ := utils.GetMemberDataStr(priceList, sales_common.FLD_PRICE_LIST_ID)
+		priceListIds = append(priceListIds, priceListId)
+	}
+	filter, err := sales_common.BuildFilter(utils.Map{
+		sales_common.FLD_PRODUCT_ID:    productId,
+		sales_common.FLD_PRICE_LIST_ID: utils.Map{"$in": priceListIds}})
+	if err != nil {
+		return nil, nil, err
+	}
+	listdata, err := r.daoItem.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	for _, priceList := range priceLists {
+		var productItem utils.Map
+		for _, item := range items {
+			if item[sales_common.FLD_PRICE_LIST_ID] != priceList[sales_common.FLD_PRICE_LIST_ID] {
+				continue
+			}
+			itemVariantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+			if len(variantId) > 0 && itemVariantId == variantId {
+				return priceList, item, nil
+			} else if len(itemVariantId) == 0 {
+				productItem = item
+			}
+		}
+		if productItem != nil {
+			return priceList, productItem, nil
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,157 @@
+package sales_services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeProductDao - Products in memory by product_id
+type fakeProductDao struct {
+	sales_repository.ProductDao
+	products map[string]utils.Map
+}
+
+func (f *fakeProductDao) Get(ctx context.Context, productId string) (utils.Map, error) {
+	if dataProduct, ok := f.products[productId]; ok {
+		return dataProduct, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// fakePriceListDao, fakePriceListItemDao - List the records matching the plain fields of the filter
+type fakePriceListDao struct {
+	sales_repository.PriceListDao
+	t       *testing.T
+	records []utils.Map
+}
+
+func (f *fakePriceListDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	return listMatching(f.t, f.records, filter), nil
+}
+
+type fakePriceListItemDao struct {
+	sales_repository.PriceListItemDao
+	t       *testing.T
+	records []utils.Map
+}
+
+func (f *fakePriceListItemDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	return listMatching(f.t, f.records, filter), nil
+}
+
+func listMatching(t *testing.T, records []utils.Map, filter string) utils.Map {
+	results := []utils.Map{}
+	for _, record := range records {
+		if matchFilter(t, record, filter) {
+			results = append(results, record)
+		}
+	}
+	return utils.Map{db_common.LIST_RESULT: results}
+}
+
+func decimalOf(t *testing.T, value string) primitive.Decimal128 {
+	decVal, err := primitive.ParseDecimal128(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decVal
+}
+
+func newTestPriceListResolver(t *testing.T, defaultCurrency string) *PriceListResolver {
+	validFrom := time.Now().Add(-time.Hour)
+
+	daoProduct := &fakeProductDao{products: map[string]utils.Map{
+		"p1": {sales_common.FLD_PRODUCT_ID: "p1", sales_common.FLD_PRODUCT_PRICE: decimalOf(t, "10.005")},
+		// Stored as number before the prices were decimal
+		"p2": {sales_common.FLD_PRODUCT_ID: "p2", sales_common.FLD_PRODUCT_PRICE: 3.5},
+	}}
+	daoPreference := &fakePreferenceDao{}
+	if len(defaultCurrency) > 0 {
+		daoPreference.preferences = map[string]utils.Map{
+			sales_common.PREFERENCE_PRICING: {sales_common.FLD_DEFAULT_CURRENCY: defaultCurrency},
+		}
+	}
+
+	return &PriceListResolver{
+		businessId: "biz1",
+		priceResolver: &PriceResolver{
+			businessId:    "biz1",
+			daoProduct:    daoProduct,
+			daoPreference: daoPreference,
+			tiers:         map[string][]utils.Map{},
+		},
+		daoProduct: daoProduct,
+		daoPriceList: &fakePriceListDao{t: t, records: []utils.Map{
+			{sales_common.FLD_PRICE_LIST_ID: "pl-jpy", sales_common.FLD_CURRENCY: "JPY", sales_common.FLD_VALID_FROM: validFrom},
+			{sales_common.FLD_PRICE_LIST_ID: "pl-kwd", sales_common.FLD_CURRENCY: "KWD", sales_common.FLD_VALID_FROM: validFrom},
+		}},
+		daoItem: &fakePriceListItemDao{t: t, records: []utils.Map{
+			{sales_common.FLD_PRICE_LIST_ID: "pl-jpy", sales_common.FLD_PRODUCT_ID: "p1", sales_common.FLD_PRICE_LIST_PRICE: decimalOf(t, "1234")},
+			{sales_common.FLD_PRICE_LIST_ID: "pl-kwd", sales_common.FLD_PRODUCT_ID: "p1", sales_common.FLD_PRICE_LIST_PRICE: decimalOf(t, "1.125")},
+		}},
+	}
+}
+
+func TestPriceItems(t *testing.T) {
+	type line struct {
+		productId string
+		quantity  interface{}
+	}
+
+	tests := []struct {
+		name            string
+		defaultCurrency string
+		currency        string
+		items           []line
+		wantLines       []string
+		wantTotal       string
+		wantCode        string
+	}{
+		// Unit price is rounded to the currency before the quantity, 10.005 to 10.01 and 3.5 to 4
+		{"product price scale 2", "USD", "", []line{{"p1", 3}, {"p2", "2"}}, []string{"30.03", "7.00"}, "37.03", ""},
+		{"product price scale 0", "JPY", "", []line{{"p2", 3}}, []string{"12"}, "12", ""},
+		{"list price before product price", "JPY", "", []line{{"p1", 3}}, []string{"3702"}, "3702", ""},
+		{"list price scale 0", "USD", "JPY", []line{{"p1", 2}}, []string{"2468"}, "2468", ""},
+		// 1.125 * 1.5 = 1.6875
+		{"list price scale 3", "USD", "KWD", []line{{"p1", "1.5"}}, []string{"1.688"}, "1.688", ""},
+		{"no list price in currency", "USD", "KWD", []line{{"p2", 1}}, nil, "", "S30340245"},
+		{"no currency", "", "", []line{{"p1", 1}}, nil, "", "S30340262"},
+		{"zero quantity", "USD", "", []line{{"p1", 0}}, nil, "", "S30340172"},
+		{"negative quantity", "USD", "", []line{{"p1", "-1"}}, nil, "", "S30340172"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestPriceListResolver(t, tt.defaultCurrency)
+
+			items := []utils.Map{}
+			for _, item := range tt.items {
+				items = append(items, utils.Map{sales_common.FLD_PRODUCT_ID: item.productId, sales_common.FLD_QUANTITY: item.quantity})
+			}
+
+			_, total, err := r.PriceItems(context.Background(), "", "", tt.currency, items)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("PriceItems() error = %v, want code %q", err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+
+			if total.String() != tt.wantTotal {
+				t.Errorf("total = %s, want %s", total.String(), tt.wantTotal)
+			}
+			for idx, item := range items {
+				if lineTotal := item[sales_common.FLD_LINE_TOTAL].(primitive.Decimal128); lineTotal.String() != tt.wantLines[idx] {
+					t.Errorf("line %d total = %s, want %s", idx, lineTotal.String(), tt.wantLines[idx])
+				}
+			}
+		})
+	}
+}
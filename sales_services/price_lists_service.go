@@ -0,0 +1,513 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// PriceListService - Price Lists of the Products in a currency for a validity window, Customer type and Region
+type PriceListService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(priceListId string) (utils.Map, error)
+	// Find - Find the item
+	Find(filter string) (utils.Map, error)
+	// Create - Create Service
+	Create(indata utils.Map) (utils.Map, error)
+	// Update - Update Service
+	Update(priceListId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Service
+	Delete(priceListId string, delete_permanent bool) error
+
+	// ListItems - List the prices of the Products in the Price List
+	ListItems(priceListId string, skip int64, limit int64) (utils.Map, error)
+	// SetItem - Set the price of the Product, or its Variant, in the Price List
+	SetItem(priceListId string, indata utils.Map) (utils.Map, error)
+	// RemoveItem - Remove the price of the Product from the Price List
+	RemoveItem(priceListId string, itemId string) error
+	// ResolvePrice - Resolve the effective price of the Product, or its Variant, in the currency for the Customer
+	// and Region now
+	ResolvePrice(customerId string, regionId string, productId string, variantId string, currency string) (utils.Map, error)
+
+	EndService()
+}
+
+type priceListBaseService struct {
+	db_utils.DatabaseService
+	dbRegion        db_utils.DatabaseService
+	daoPriceList    sales_repository.PriceListDao
+	daoItem         sales_repository.PriceListItemDao
+	daoProduct      sales_repository.ProductDao
+	daoVariant      sales_repository.ProductVariantDao
+	daoCustomerType sales_repository.CustomerTypeDao
+	daoRegion       sales_repository.RegionDao
+	daoBusiness     platform_repository.BusinessDao
+	child           PriceListService
+	businessId      string
+	ctx             context.Context
+}
+
+// NewPriceListService - Construct PriceList
+func NewPriceListService(props utils.Map) (PriceListService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("PriceListService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := priceListBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// priceListBaseService - Close all the services
+func (p *priceListBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *priceListBaseService) initializeService() {
+	log.Printf("PriceListService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoPriceList = sales_repository.NewPriceListDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoItem = sales_repository.NewPriceListItemDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoProduct = sales_repository.NewProductDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoVariant = sales_repository.NewProductVariantDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomerType = sales_repository.NewCustomerTypeDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoRegion = sales_repository.NewRegionDao(p.dbRegion.GetClient(), p.businessId)
+}
+
+// List - List All records
+func (p *priceListBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("priceListBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPriceList.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("priceListBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *priceListBaseService) Get(priceListId string) (_ utils.Map, err error) {
+	log.Printf("priceListBaseService::Get::  Begin %v", priceListId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPriceList.Get(ctx, priceListId)
+
+	log.Println("priceListBaseService::Get:: End ", err)
+	return data, err
+}
+
+func (p *priceListBaseService) Find(filter string) (_ utils.Map, err error) {
+	fmt.Println("priceListService::FindByCode::  Begin ", filter)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPriceList.Find(ctx, filter)
+	log.Println("priceListService::FindByCode:: End ", err)
+	return data, err
+}
+
+// Create - Create Service
+func (p *priceListBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("PriceListService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "Create", p.businessId)
+	defer span.EndWith(&err)
+
+	var priceListId string
+
+	dataval, dataok := indata[sales_common.FLD_PRICE_LIST_ID]
+	if dataok {
+		priceListId = strings.ToLower(dataval.(string))
+	} else {
+		priceListId = utils.GenerateUniqueId("prcl")
+		log.Println("Unique PriceList ID", priceListId)
+	}
+
+	// Assign BusinessId
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_PRICE_LIST_ID] = priceListId
+
+	// Valid from now if not given
+	if _, ok := indata[sales_common.FLD_VALID_FROM]; !ok {
+		indata[sales_common.FLD_VALID_FROM] = time.Now()
+	}
+	err = p.validatePriceList(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	data, err := p.daoPriceList.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("PriceListService::Create - End ")
+	return data, nil
+}
+
+// Update - Update Service
+func (p *priceListBaseService) Update(priceListId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("PriceListService::Update - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Delete Key values
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_PRICE_LIST_ID)
+
+	validateFields := []string{sales_common.FLD_CURRENCY, sales_common.FLD_VALID_FROM, sales_common.FLD_VALID_TO,
+		sales_common.FLD_CUSTOMER_TYPE_ID, sales_common.FLD_REGION_ID}
+	for _, field := range validateFields {
+		if _, ok := indata[field]; !ok {
+			continue
+		}
+		dataPriceList, err := p.daoPriceList.Get(ctx, priceListId)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		for key, value := range indata {
+			dataPriceList[key] = value
+		}
+		err = p.validatePriceList(ctx, dataPriceList)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		if _, ok := indata[sales_common.FLD_CURRENCY]; ok {
+			currency, _ := utils.GetMemberDataStr(dataPriceList, sales_common.FLD_CURRENCY)
+			err = p.validateItemPrices(ctx, priceListId, currency)
+			if err != nil {
+				return utils.Map{}, err
+			}
+		}
+		for _, field := range validateFields {
+			if _, ok := indata[field]; ok {
+				indata[field] = dataPriceList[field]
+			}
+		}
+		break
+	}
+
+	data, err := p.daoPriceList.Update(ctx, priceListId, indata)
+
+	log.Println("PriceListService::Update - End ")
+	return data, err
+}
+
+// Delete - Delete Service
+func (p *priceListBaseService) Delete(priceListId string, delete_permanent bool) (err error) {
+
+	log.Println("PriceListService::Delete - Begin", priceListId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	if delete_permanent {
+		result, err := p.daoPriceList.Delete(ctx, priceListId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.Update(priceListId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("PriceListService::Delete - End")
+	return nil
+}
+
+// ListItems - List the prices of the Products in the Price List
+func (p *priceListBaseService) ListItems(priceListId string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("PriceListService::ListItems - Begin ", priceListId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "ListItems", p.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRICE_LIST_ID: priceListId})
+	if err != nil {
+		return nil, err
+	}
+	sort := `{"` + sales_common.FLD_PRODUCT_ID + `": 1, "` + sales_common.FLD_VARIANT_ID + `": 1}`
+	listdata, err := p.daoItem.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("PriceListService::ListItems - End ")
+	return listdata, nil
+}
+
+// SetItem - Set the price of the Product, or its Variant if variant_id given, in the Price List. The existing
+// price of the same Product and Variant is replaced
+func (p *priceListBaseService) SetItem(priceListId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("PriceListService::SetItem - Begin ", priceListId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "SetItem", p.businessId)
+	defer span.EndWith(&err)
+
+	dataPriceList, err := p.daoPriceList.Get(ctx, priceListId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340246", ErrorMsg: "Invalid PriceListId", ErrorDetail: "Given PriceListId " + priceListId + " is not exist"}
+		return utils.Map{}, err
+	}
+
+	productId, err := utils.GetMemberDataStr(indata, sales_common.FLD_PRODUCT_ID)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	_, err = p.daoProduct.Get(ctx, productId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340170", ErrorMsg: "Invalid ProductId", ErrorDetail: "Given ProductId " + productId + " is not exist"}
+		return utils.Map{}, err
+	}
+	variantId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_VARIANT_ID)
+	if len(variantId) > 0 {
+		dataVariant, err := p.daoVariant.Get(ctx, variantId)
+		if err != nil || dataVariant[sales_common.FLD_PRODUCT_ID] != productId {
+			err := &utils.AppError{ErrorCode: "S30340222", ErrorMsg: "Invalid VariantId", ErrorDetail: "Given VariantId " + variantId + " is not exist for the Product " + productId}
+			return utils.Map{}, err
+		}
+	}
+
+	// Price in the decimals of the currency
+	price, err := sales_common.GetMemberDataDecimal(indata, sales_common.FLD_PRICE_LIST_PRICE)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	priceRat, err := sales_common.DecimalToRat(price)
+	if err != nil || priceRat.Sign() < 0 {
+		err := &utils.AppError{ErrorCode: "S30340244", ErrorMsg: "Invalid Price", ErrorDetail: sales_common.FLD_PRICE_LIST_PRICE + " should not be negative"}
+		return utils.Map{}, err
+	}
+	currency, _ := utils.GetMemberDataStr(dataPriceList, sales_common.FLD_CURRENCY)
+	err = validateListPrice(priceRat, currency)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	indata[sales_common.FLD_PRICE_LIST_PRICE] = sales_common.RatToDecimal(priceRat, sales_common.CurrencyScale(currency))
+	indata[sales_common.FLD_VARIANT_ID] = variantId
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRICE_LIST_ID: priceListId, sales_common.FLD_PRODUCT_ID: productId,
+		sales_common.FLD_VARIANT_ID: variantId})
+	if err != nil {
+		return utils.Map{}, err
+	}
+	dataItem, err := p.daoItem.Find(ctx, filter)
+	if err == nil {
+		itemId, _ := utils.GetMemberDataStr(dataItem, sales_common.FLD_PRICE_LIST_ITEM_ID)
+		data, err := p.daoItem.Update(ctx, itemId, utils.Map{sales_common.FLD_PRICE_LIST_PRICE: indata[sales_common.FLD_PRICE_LIST_PRICE]})
+
+		log.Println("PriceListService::SetItem - End ", itemId)
+		return data, err
+	}
+
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_PRICE_LIST_ID] = priceListId
+	indata[sales_common.FLD_PRICE_LIST_ITEM_ID] = utils.GenerateUniqueId("prcli")
+
+	data, err := p.daoItem.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("PriceListService::SetItem - End ")
+	return data, nil
+}
+
+// RemoveItem - Remove the price of the Product from the Price List
+func (p *priceListBaseService) RemoveItem(priceListId string, itemId string) (err error) {
+
+	log.Println("PriceListService::RemoveItem - Begin ", priceListId, itemId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "RemoveItem", p.businessId)
+	defer span.EndWith(&err)
+
+	dataItem, err := p.daoItem.Get(ctx, itemId)
+	if err != nil || dataItem[sales_common.FLD_PRICE_LIST_ID] != priceListId {
+		err := &utils.AppError{ErrorCode: "S30340247", ErrorMsg: "Invalid Price List ItemId", ErrorDetail: "Given ItemId " + itemId + " is not exist in the Price List " + priceListId}
+		return err
+	}
+
+	result, err := p.daoItem.Delete(ctx, itemId)
+	if err != nil {
+		return err
+	}
+
+	log.Println("PriceListService::RemoveItem - End ", result)
+	return nil
+}
+
+// ResolvePrice - Resolve the effective price of the Product, or its Variant, in the currency for the Customer
+// and Region now
+func (p *priceListBaseService) ResolvePrice(customerId string, regionId string, productId string, variantId string, currency string) (_ utils.Map, err error) {
+
+	log.Println("PriceListService::ResolvePrice - Begin", customerId, regionId, productId, variantId, currency)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_list", "ResolvePrice", p.businessId)
+	defer span.EndWith(&err)
+
+	resolver := NewPriceListResolver(p.dbRegion.GetClient(), p.businessId)
+	data, err := resolver.ResolvePrice(ctx, customerId, regionId, productId, variantId, currency, time.Now())
+
+	log.Println("PriceListService::ResolvePrice - End ", err)
+	return data, err
+}
+
+// validatePriceList - Verify the currency, the validity window and the Customer type and Region if given, and
+// set the valid_from and valid_to as time
+func (p *priceListBaseService) validatePriceList(ctx context.Context, indata utils.Map) error {
+
+	currency, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CURRENCY)
+	if !sales_common.IsValidCurrency(currency) {
+		err := &utils.AppError{ErrorCode: "S30340240", ErrorMsg: "Invalid Currency", ErrorDetail: sales_common.FLD_CURRENCY + " should be an ISO 4217 code, e.g. INR"}
+		return err
+	}
+
+	validFrom, err := sales_common.GetMemberDataTime(indata, sales_common.FLD_VALID_FROM)
+	if err != nil {
+		return err
+	}
+	indata[sales_common.FLD_VALID_FROM] = validFrom
+	if _, ok := indata[sales_common.FLD_VALID_TO]; ok {
+		validTo, err := sales_common.GetMemberDataTime(indata, sales_common.FLD_VALID_TO)
+		if err != nil {
+			return err
+		}
+		if !validTo.After(validFrom) {
+			err := &utils.AppError{ErrorCode: "S30340241", ErrorMsg: "Invalid Validity", ErrorDetail: sales_common.FLD_VALID_TO + " should be after " + sales_common.FLD_VALID_FROM}
+			return err
+		}
+		indata[sales_common.FLD_VALID_TO] = validTo
+	}
+
+	if customerTypeId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_CUSTOMER_TYPE_ID); len(customerTypeId) > 0 {
+		_, err = p.daoCustomerType.Get(ctx, customerTypeId)
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340242", ErrorMsg: "Invalid CustomerTypeId", ErrorDetail: "Given CustomerTypeId " + customerTypeId + " is not exist"}
+			return err
+		}
+	}
+
+	if regionId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_REGION_ID); len(regionId) > 0 {
+		_, err = p.daoRegion.Get(ctx, regionId)
+		if err != nil {
+			err := &utils.AppError{ErrorCode: "S30340243", ErrorMsg: "Invalid RegionId", ErrorDetail: "Given RegionId " + regionId + " is not exist"}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateItemPrices - Verify the prices of the Price List fit the decimals of the currency, when it is changed
+func (p *priceListBaseService) validateItemPrices(ctx context.Context, priceListId string, currency string) error {
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRICE_LIST_ID: priceListId})
+	if err != nil {
+		return err
+	}
+	listdata, err := p.daoItem.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	items, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	for _, item := range items {
+		price, err := sales_common.GetMemberDataDecimal(item, sales_common.FLD_PRICE_LIST_PRICE)
+		if err != nil {
+			return err
+		}
+		priceRat, err := sales_common.DecimalToRat(price)
+		if err != nil {
+			return err
+		}
+		err = validateListPrice(priceRat, currency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateListPrice - Verify the price has no more decimals than the minor unit of the currency
+func validateListPrice(price *big.Rat, currency string) error {
+	scale := sales_common.CurrencyScale(currency)
+	if !sales_common.HasScale(price, scale) {
+		err := &utils.AppError{ErrorCode: "S30340261", ErrorMsg: "Invalid Price", ErrorDetail: fmt.Sprintf("%s should have at most %d decimals in %s", sales_common.FLD_PRICE_LIST_PRICE, scale, currency)}
+		return err
+	}
+	return nil
+}
+
+func (p *priceListBaseService) errorReturn(err error) (PriceListService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
@@ -0,0 +1,240 @@
+package sales_services
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// PriceResolver - Resolve the effective price of the Products for a Customer.
+//
+// Price is the product_price of the Product, or the variant_price of its Variant if set, adjusted by the Price Tier of the Customer's type. The Tier
+// for the Product is used if exist, else the one for the Product's Category, else the one for all the
+// Products. Customers without type, or types without Tier, get the base price. Prices are computed as big.Rat
+// and rounded once to the decimals of the currency. Tiers are read once for each Customer type in the life of
+// the resolver, so use a new resolver for each request.
+type PriceResolver struct {
+	businessId    string
+	daoCustomer   sales_repository.CustomerDao
+	daoProduct    sales_repository.ProductDao
+	daoVariant    sales_repository.ProductVariantDao
+	daoPriceTier  sales_repository.PriceTierDao
+	daoPreference sales_repository.PreferenceDao
+	tiers         map[string][]utils.Map
+}
+
+// NewPriceResolver - Construct PriceResolver on the Region database client
+func NewPriceResolver(client utils.Map, businessId string) *PriceResolver {
+	return &PriceResolver{
+		businessId:    businessId,
+		daoCustomer:   sales_repository.NewCustomerDao(client, businessId),
+		daoProduct:    sales_repository.NewProductDao(client, businessId),
+		daoVariant:    sales_repository.NewProductVariantDao(client, businessId),
+		daoPriceTier:  sales_repository.NewPriceTierDao(client, businessId),
+		daoPreference: sales_repository.NewPreferenceDao(client, businessId),
+		tiers:         map[string][]utils.Map{},
+	}
+}
+
+// GetCustomerType - Get the type of the Customer, empty if the Customer has no type
+func (r *PriceResolver) GetCustomerType(ctx context.Context, customerId string) (_ string, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "price_resolver", "GetCustomerType", r.businessId)
+	defer span.EndWith(&err)
+
+	if len(customerId) == 0 {
+		return "", nil
+	}
+
+	dataCustomer, err := r.daoCustomer.Get(ctx, customerId)
+	if err != nil {
+		return "", err
+	}
+
+	customerTypeId, _ := dataCustomer[sales_common.FLD_CUSTOMER_TYPE_ID].(string)
+	return customerTypeId, nil
+}
+
+// ResolvePrice - Resolve the price of the Product, or its Variant if variantId given, for the Customer in the
+// default currency of the business
+func (r *PriceResolver) ResolvePrice(ctx context.Context, customerId string, productId string, variantId string) (_ utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "price_resolver", "ResolvePrice", r.businessId)
+	defer span.EndWith(&err)
+
+	customerTypeId, err := r.GetCustomerType(ctx, customerId)
+	if err != nil {
+		return nil, err
+	}
+	currency := r.GetDefaultCurrency(ctx)
+	if len(currency) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340262", ErrorMsg: "Missing Currency", ErrorDetail: sales_common.FLD_DEFAULT_CURRENCY + " should be set in the pricing Preference"}
+		return nil, err
+	}
+	return r.ResolvePriceForType(ctx, customerTypeId, productId, variantId, currency)
+}
+
+// ResolvePriceForType - Resolve the price of the Product, or its Variant, for the Customer type in the currency,
+// returns the product_id, variant_id, variant_sku, currency, customer_type_id, base_price and unit_price (Decimal128
+// in the decimals of the currency), price_tier_id (empty if no Tier applied), and the price_tier_price (Decimal128)
+// if the Tier has fixed price. The variantId is required for the Products having options
+func (r *PriceResolver) ResolvePriceForType(ctx context.Context, customerTypeId string, productId string, variantId string, currency string) (_ utils.Map, err error) {
+
+	log.Println("PriceResolver::ResolvePriceForType - Begin ", customerTypeId, productId, variantId, currency)
+
+	ctx, span := sales_telemetry.StartService(ctx, "price_resolver", "ResolvePriceForType", r.businessId)
+	defer span.EndWith(&err)
+
+	dataProduct, err := r.daoProduct.Get(ctx, productId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340170", ErrorMsg: "Invalid ProductId", ErrorDetail: "Given ProductId " + productId + " is not exist"}
+		return nil, err
+	}
+
+	basePrice, err := getMemberDataRat(dataProduct, sales_common.FLD_PRODUCT_PRICE)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340171", ErrorMsg: "Product has no Price", ErrorDetail: "Product " + productId + " has no valid " + sales_common.FLD_PRODUCT_PRICE}
+		return nil, err
+	}
+
+	variantSku := ""
+	if len(variantId) > 0 {
+		dataVariant, err := r.daoVariant.Get(ctx, variantId)
+		if err != nil || dataVariant[sales_common.FLD_PRODUCT_ID] != productId {
+			err := &utils.AppError{ErrorCode: "S30340222", ErrorMsg: "Invalid VariantId", ErrorDetail: "Given VariantId " + variantId + " is not exist for the Product " + productId}
+			return nil, err
+		}
+		if variantPrice, err := getMemberDataRat(dataVariant, sales_common.FLD_VARIANT_PRICE); err == nil {
+			basePrice = variantPrice
+		}
+		variantSku, _ = utils.GetMemberDataStr(dataVariant, sales_common.FLD_VARIANT_SKU)
+	} else if _, ok := dataProduct[sales_common.FLD_PRODUCT_OPTIONS]; ok {
+		err := &utils.AppError{ErrorCode: "S30340220", ErrorMsg: "Missing VariantId", ErrorDetail: "Product " + productId + " has options, " + sales_common.FLD_VARIANT_ID + " value should be sent"}
+		return nil, err
+	}
+
+	scale := sales_common.CurrencyScale(currency)
+	result := utils.Map{
+		sales_common.FLD_PRODUCT_ID:       productId,
+		sales_common.FLD_VARIANT_ID:       variantId,
+		sales_common.FLD_VARIANT_SKU:      variantSku,
+		sales_common.FLD_CURRENCY:         currency,
+		sales_common.FLD_CUSTOMER_TYPE_ID: customerTypeId,
+		sales_common.FLD_BASE_PRICE:       sales_common.RatToDecimal(basePrice, scale),
+		sales_common.FLD_UNIT_PRICE:       sales_common.RatToDecimal(basePrice, scale),
+		sales_common.FLD_PRICE_TIER_ID:    "",
+	}
+
+	tier, err := r.findTier(ctx, customerTypeId, productId, productCategories(dataProduct))
+	if err != nil || tier == nil {
+		return result, err
+	}
+
+	unitPrice := basePrice
+	if fixedPrice, err := sales_common.GetMemberDataDecimal(tier, sales_common.FLD_PRICE_TIER_PRICE); err == nil {
+		unitPrice, err = sales_common.DecimalToRat(fixedPrice)
+		if err != nil {
+			return nil, err
+		}
+		result[sales_common.FLD_PRICE_TIER_PRICE] = fixedPrice
+	} else if discount, err := getMemberDataRat(tier, sales_common.FLD_PRICE_TIER_DISCOUNT); err == nil {
+		// basePrice * (100 - discount) / 100
+		unitPrice = new(big.Rat).Sub(big.NewRat(100, 1), discount)
+		unitPrice.Mul(unitPrice, basePrice).Quo(unitPrice, big.NewRat(100, 1))
+	}
+	result[sales_common.FLD_UNIT_PRICE] = sales_common.RatToDecimal(unitPrice, scale)
+	result[sales_common.FLD_PRICE_TIER_ID], _ = utils.GetMemberDataStr(tier, sales_common.FLD_PRICE_TIER_ID)
+
+	log.Println("PriceResolver::ResolvePriceForType - End ", result)
+	return result, nil
+}
+
+// findTier - Find the most specific Tier of the Customer type for the Product
+func (r *PriceResolver) findTier(ctx context.Context, customerTypeId string, productId string, categoryIds []string) (utils.Map, error) {
+	if len(customerTypeId) == 0 {
+		return nil, nil
+	}
+
+	tiers, err := r.getTiers(ctx, customerTypeId)
+	if err != nil {
+		return nil, err
+	}
+
+	var tierAll, tierCategory utils.Map
+	for _, tier := range tiers {
+		scope, _ := utils.GetMemberDataStr(tier, sales_common.FLD_PRICE_TIER_SCOPE)
+		switch scope {
+		case sales_common.PRICE_TIER_SCOPE_PRODUCT:
+			if tierProductId, _ := utils.GetMemberDataStr(tier, sales_common.FLD_PRODUCT_ID); tierProductId == productId {
+				return tier, nil
+			}
+		case sales_common.PRICE_TIER_SCOPE_CATEGORY:
+			tierCategoryId, _ := utils.GetMemberDataStr(tier, sales_common.FLD_CATEGORY_ID)
+			for _, categoryId := range categoryIds {
+				if tierCategory == nil && tierCategoryId == categoryId {
+					tierCategory = tier
+				}
+			}
+		case sales_common.PRICE_TIER_SCOPE_ALL:
+			tierAll = tier
+		}
+	}
+
+	if tierCategory != nil {
+		return tierCategory, nil
+	}
+	return tierAll, nil
+}
+
+// getTiers - Get all the Tiers of the Customer type
+func (r *PriceResolver) getTiers(ctx context.Context, customerTypeId string) ([]utils.Map, error) {
+	if tiers, ok := r.tiers[customerTypeId]; ok {
+		return tiers, nil
+	}
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CUSTOMER_TYPE_ID: customerTypeId})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := r.daoPriceTier.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	r.tiers[customerTypeId] = tiers
+	return tiers, nil
+}
+
+// productCategories - Category of the Product, stored as a single id or an array of ids
+func productCategories(dataProduct utils.Map) []string {
+	if categoryId, err := utils.GetMemberDataStr(dataProduct, sales_common.FLD_CATEGORY_ID); err == nil {
+		return []string{categoryId}
+	}
+	categoryIds, _ := sales_common.GetMemberDataStrArray(dataProduct, sales_common.FLD_CATEGORY_ID)
+	return categoryIds
+}
+
+// GetDefaultCurrency - Currency of the product_price from the pricing preference, empty if not configured
+func (r *PriceResolver) GetDefaultCurrency(ctx context.Context) string {
+	dataPref, err := r.daoPreference.Get(ctx, sales_common.PREFERENCE_PRICING)
+	if err != nil {
+		return ""
+	}
+
+	currency, _ := utils.GetMemberDataStr(dataPref, sales_common.FLD_DEFAULT_CURRENCY)
+	return currency
+}
+
+// getMemberDataRat - Get the decimal value, stored as Decimal128 or a number, as big.Rat
+func getMemberDataRat(data utils.Map, memberName string) (*big.Rat, error) {
+	value, err := sales_common.GetMemberDataDecimal(data, memberName)
+	if err != nil {
+		return nil, err
+	}
+	return sales_common.DecimalToRat(value)
+}
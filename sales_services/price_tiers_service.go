@@ -0,0 +1,343 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// PriceTierService - Prices for each Customer type.
+//
+// Price Tier gives a discount percentage or a fixed price (Product scope only) for a Customer type,
+// applied to all the Products, the Products of a Category or a single Product. See PriceResolver
+// for how the effective price is resolved.
+type PriceTierService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(priceTierId string) (utils.Map, error)
+	// Find - Find the item
+	Find(filter string) (utils.Map, error)
+	// Create - Create Service
+	Create(indata utils.Map) (utils.Map, error)
+	// Update - Update Service
+	Update(priceTierId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Service
+	Delete(priceTierId string, delete_permanent bool) error
+
+	// ResolvePrice - Resolve the effective price of the Product, or its Variant, for the Customer
+	ResolvePrice(customerId string, productId string, variantId string) (utils.Map, error)
+
+	EndService()
+}
+
+type priceTierBaseService struct {
+	db_utils.DatabaseService
+	dbRegion        db_utils.DatabaseService
+	daoPriceTier    sales_repository.PriceTierDao
+	daoBusiness     platform_repository.BusinessDao
+	daoCustomerType sales_repository.CustomerTypeDao
+	child           PriceTierService
+	businessId      string
+	ctx             context.Context
+}
+
+// NewPriceTierService - Construct PriceTier
+func NewPriceTierService(props utils.Map) (PriceTierService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("PriceTierService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := priceTierBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// priceTierBaseService - Close all the services
+func (p *priceTierBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *priceTierBaseService) initializeService() {
+	log.Printf("PriceTierService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoPriceTier = sales_repository.NewPriceTierDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCustomerType = sales_repository.NewCustomerTypeDao(p.dbRegion.GetClient(), p.businessId)
+}
+
+// List - List All records
+func (p *priceTierBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("priceTierBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoPriceTier.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("priceTierBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *priceTierBaseService) Get(priceTierId string) (_ utils.Map, err error) {
+	log.Printf("priceTierBaseService::Get::  Begin %v", priceTierId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPriceTier.Get(ctx, priceTierId)
+
+	log.Println("priceTierBaseService::Get:: End ", err)
+	return data, err
+}
+
+func (p *priceTierBaseService) Find(filter string) (_ utils.Map, err error) {
+	fmt.Println("priceTierService::FindByCode::  Begin ", filter)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoPriceTier.Find(ctx, filter)
+	log.Println("priceTierService::FindByCode:: End ", err)
+	return data, err
+}
+
+// Create - Create Service
+func (p *priceTierBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("PriceTierService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "Create", p.businessId)
+	defer span.EndWith(&err)
+
+	var priceTierId string
+
+	dataval, dataok := indata[sales_common.FLD_PRICE_TIER_ID]
+	if dataok {
+		priceTierId = strings.ToLower(dataval.(string))
+	} else {
+		priceTierId = utils.GenerateUniqueId("prtr")
+		log.Println("Unique PriceTier ID", priceTierId)
+	}
+
+	// Assign BusinessId
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_PRICE_TIER_ID] = priceTierId
+
+	err = p.validatePriceTier(ctx, priceTierId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	data, err := p.daoPriceTier.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("PriceTierService::Create - End ")
+	return data, nil
+}
+
+// Update - Update Service
+func (p *priceTierBaseService) Update(priceTierId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("PriceTierService::Update - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	// Delete the Key fields if exist
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_PRICE_TIER_ID)
+
+	dataTier, err := p.daoPriceTier.Get(ctx, priceTierId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	for key, value := range indata {
+		dataTier[key] = value
+	}
+	err = p.validatePriceTier(ctx, priceTierId, dataTier)
+	if err != nil {
+		return utils.Map{}, err
+	}
+	if _, ok := indata[sales_common.FLD_PRICE_TIER_PRICE]; ok {
+		indata[sales_common.FLD_PRICE_TIER_PRICE] = dataTier[sales_common.FLD_PRICE_TIER_PRICE]
+	}
+
+	data, err := p.daoPriceTier.Update(ctx, priceTierId, indata)
+
+	log.Println("PriceTierService::Update - End ")
+	return data, err
+}
+
+// Delete - Delete Service
+func (p *priceTierBaseService) Delete(priceTierId string, delete_permanent bool) (err error) {
+
+	log.Println("PriceTierService::Delete - Begin", priceTierId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	if delete_permanent {
+		result, err := p.daoPriceTier.Delete(ctx, priceTierId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.Update(priceTierId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("PriceTierService::Delete - End")
+	return nil
+}
+
+// ResolvePrice - Resolve the effective price of the Product, or its Variant, for the Customer
+func (p *priceTierBaseService) ResolvePrice(customerId string, productId string, variantId string) (_ utils.Map, err error) {
+
+	log.Println("PriceTierService::ResolvePrice - Begin", customerId, productId, variantId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "price_tier", "ResolvePrice", p.businessId)
+	defer span.EndWith(&err)
+
+	resolver := NewPriceResolver(p.dbRegion.GetClient(), p.businessId)
+	data, err := resolver.ResolvePrice(ctx, customerId, productId, variantId)
+
+	log.Println("PriceTierService::ResolvePrice - End ", err)
+	return data, err
+}
+
+// validatePriceTier - Verify the Customer type, the scope with its Category/Product and that only one
+// Tier exist for the same scope, converts the fixed price to Decimal128
+func (p *priceTierBaseService) validatePriceTier(ctx context.Context, priceTierId string, indata utils.Map) error {
+
+	customerTypeId, err := utils.GetMemberDataStr(indata, sales_common.FLD_CUSTOMER_TYPE_ID)
+	if err != nil {
+		return err
+	}
+	_, err = p.daoCustomerType.Get(ctx, customerTypeId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340173", ErrorMsg: "Invalid CustomerTypeId", ErrorDetail: "Given CustomerTypeId is not exist"}
+		return err
+	}
+
+	scope, _ := utils.GetMemberDataStr(indata, sales_common.FLD_PRICE_TIER_SCOPE)
+	fields := utils.Map{sales_common.FLD_CUSTOMER_TYPE_ID: customerTypeId, sales_common.FLD_PRICE_TIER_SCOPE: scope}
+	switch scope {
+	case sales_common.PRICE_TIER_SCOPE_ALL:
+	case sales_common.PRICE_TIER_SCOPE_CATEGORY:
+		categoryId, err := utils.GetMemberDataStr(indata, sales_common.FLD_CATEGORY_ID)
+		if err != nil {
+			return err
+		}
+		fields[sales_common.FLD_CATEGORY_ID] = categoryId
+	case sales_common.PRICE_TIER_SCOPE_PRODUCT:
+		productId, err := utils.GetMemberDataStr(indata, sales_common.FLD_PRODUCT_ID)
+		if err != nil {
+			return err
+		}
+		fields[sales_common.FLD_PRODUCT_ID] = productId
+	default:
+		err := &utils.AppError{ErrorCode: "S30340174", ErrorMsg: "Invalid Scope", ErrorDetail: "Price Tier scope should be all, category or product"}
+		return err
+	}
+
+	// Fixed price is stored as Decimal128
+	price, errPrice := sales_common.GetMemberDataDecimal(indata, sales_common.FLD_PRICE_TIER_PRICE)
+	discount, errDiscount := sales_common.GetMemberDataFloat(indata, sales_common.FLD_PRICE_TIER_DISCOUNT)
+	if (errPrice == nil) == (errDiscount == nil) {
+		err := &utils.AppError{ErrorCode: "S30340175", ErrorMsg: "Invalid Price Tier", ErrorDetail: "Either discount or price should be given"}
+		return err
+	} else if errPrice == nil && scope != sales_common.PRICE_TIER_SCOPE_PRODUCT {
+		err := &utils.AppError{ErrorCode: "S30340175", ErrorMsg: "Invalid Price Tier", ErrorDetail: "Fixed price can be given only for a Product"}
+		return err
+	} else if errDiscount == nil && (discount < 0 || discount > 100) {
+		err := &utils.AppError{ErrorCode: "S30340175", ErrorMsg: "Invalid Price Tier", ErrorDetail: "Discount should be between 0 and 100"}
+		return err
+	}
+	if errPrice == nil {
+		priceRat, err := sales_common.DecimalToRat(price)
+		if err != nil || priceRat.Sign() < 0 {
+			err := &utils.AppError{ErrorCode: "S30340175", ErrorMsg: "Invalid Price Tier", ErrorDetail: "Price should not be negative"}
+			return err
+		}
+		indata[sales_common.FLD_PRICE_TIER_PRICE] = price
+	}
+
+	filter, err := sales_common.BuildFilter(fields)
+	if err != nil {
+		return err
+	}
+	dataOther, err := p.daoPriceTier.Find(ctx, filter)
+	if err == nil {
+		otherId, _ := utils.GetMemberDataStr(dataOther, sales_common.FLD_PRICE_TIER_ID)
+		if otherId != priceTierId {
+			err := &utils.AppError{ErrorCode: "S30340176", ErrorMsg: "Price Tier already exist", ErrorDetail: "Price Tier " + otherId + " exist for the same scope"}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *priceTierBaseService) errorReturn(err error) (PriceTierService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
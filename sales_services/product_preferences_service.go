@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -40,6 +42,7 @@ type prodPreferenceBaseService struct {
 	daoBusiness       platform_repository.BusinessDao
 	child             ProdPreferenceService
 	businessId        string
+	ctx               context.Context
 }
 
 // NewProdPreferenceService - Construct ProdPreference
@@ -69,6 +72,7 @@ func NewProdPreferenceService(props utils.Map) (ProdPreferenceService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -98,11 +102,14 @@ func (p *prodPreferenceBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *prodPreferenceBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *prodPreferenceBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("ProdPreferenceService::FindAll - Begin")
 
-	listdata, err := p.daoProdPreference.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoProdPreference.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +119,37 @@ func (p *prodPreferenceBaseService) List(filter string, sort string, skip int64,
 }
 
 // Get - Find By Code
-func (p *prodPreferenceBaseService) Get(preferenceId string) (utils.Map, error) {
+func (p *prodPreferenceBaseService) Get(preferenceId string) (_ utils.Map, err error) {
 	log.Printf("ProdPreferenceService::Get::  Begin %v", preferenceId)
 
-	data, err := p.daoProdPreference.Get(preferenceId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoProdPreference.Get(ctx, preferenceId)
 
 	log.Println("ProdPreferenceService::Get:: End ", err)
 	return data, err
 }
 
-func (p *prodPreferenceBaseService) Find(filter string) (utils.Map, error) {
+func (p *prodPreferenceBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("ProdPreferenceBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoProdPreference.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoProdPreference.Find(ctx, filter)
 	log.Println("ProdPreferenceBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *prodPreferenceBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *prodPreferenceBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("ProdPreferenceService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var preferenceId string
 
 	dataval, dataok := indata[sales_common.FLD_PROD_PREFERENCE_ID]
@@ -147,7 +164,7 @@ func (p *prodPreferenceBaseService) Create(indata utils.Map) (utils.Map, error)
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_PROD_PREFERENCE_ID] = preferenceId
 
-	data, err := p.daoProdPreference.Create(indata)
+	data, err := p.daoProdPreference.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +174,29 @@ func (p *prodPreferenceBaseService) Create(indata utils.Map) (utils.Map, error)
 }
 
 // Update - Update Service
-func (p *prodPreferenceBaseService) Update(preferenceId string, indata utils.Map) (utils.Map, error) {
+func (p *prodPreferenceBaseService) Update(preferenceId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BusinessProdPreferenceService::Update - Begin")
 
-	data, err := p.daoProdPreference.Update(preferenceId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoProdPreference.Update(ctx, preferenceId, indata)
 
 	log.Println("ProdPreferenceService::Update - End")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *prodPreferenceBaseService) Delete(preferenceId string, delete_permanent bool) error {
+func (p *prodPreferenceBaseService) Delete(preferenceId string, delete_permanent bool) (err error) {
 
 	log.Println("ProdPreferenceService::Delete - Begin", preferenceId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "prod_preference", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoProdPreference.Delete(preferenceId)
+		result, err := p.daoProdPreference.Delete(ctx, preferenceId)
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,7 +12,9 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // ProductService - Business Product Service structure
@@ -28,18 +31,49 @@ type ProductService interface {
 	Update(productId string, indata utils.Map) (utils.Map, error)
 	// Delete - Delete Service
 	Delete(productId string, delete_permanent bool) error
+	// Validate - Validate the Product to be created or updated, nothing will be written
+	Validate(indata utils.Map) error
+
+	// ListVariants - List the Variants of the Product
+	ListVariants(productId string) (utils.Map, error)
+	// GetVariant - Get the Variant of the Product
+	GetVariant(productId string, variantId string) (utils.Map, error)
+	// CreateVariant - Create Variant for one value of each option axis of the Product
+	CreateVariant(productId string, indata utils.Map) (utils.Map, error)
+	// UpdateVariant - Update Variant of the Product
+	UpdateVariant(productId string, variantId string, indata utils.Map) (utils.Map, error)
+	// DeleteVariant - Delete Variant of the Product
+	DeleteVariant(productId string, variantId string, delete_permanent bool) error
+
+	// ListByCategory - List the Products in the Category, including the ones in its sub Categories if
+	// includeDescendants is true
+	ListByCategory(categoryId string, includeDescendants bool, sort string, skip int64, limit int64) (utils.Map, error)
 
 	EndService()
 }
 
 // ProductService - Business Product Service structure
+// Fields of the Variant unique in the business, with the unique indexes created by the migration
+// 20231205_01_product_variants_unique
+var variantUniqueFields = []struct {
+	field     string
+	errorCode string
+	errorMsg  string
+}{
+	{sales_common.FLD_VARIANT_SKU, "S30340226", "Duplicate SKU"},
+	{sales_common.FLD_VARIANT_BARCODE, "S30340227", "Duplicate Barcode"},
+}
+
 type productBaseService struct {
 	db_utils.DatabaseService
 	dbRegion    db_utils.DatabaseService
 	daoProduct  sales_repository.ProductDao
+	daoVariant  sales_repository.ProductVariantDao
+	daoCategory sales_repository.CategoryDao
 	daoBusiness platform_repository.BusinessDao
 	child       ProductService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewProductService - Construct Product
@@ -69,6 +103,7 @@ func NewProductService(props utils.Map) (ProductService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -95,14 +130,24 @@ func (p *productBaseService) initializeService() {
 	log.Printf("ProductMongoService:: GetBusinessDao ")
 	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
 	p.daoProduct = sales_repository.NewProductDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoVariant = sales_repository.NewProductVariantDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoCategory = sales_repository.NewCategoryDao(p.dbRegion.GetClient(), p.businessId)
 }
 
 // List - List All records
-func (p *productBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *productBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("ProductService::FindAll - Begin")
 
-	listdata, err := p.daoProduct.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoProduct.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachProductVariants(ctx, p.daoVariant, listdata)
 	if err != nil {
 		return nil, err
 	}
@@ -112,27 +157,40 @@ func (p *productBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *productBaseService) Get(productId string) (utils.Map, error) {
+func (p *productBaseService) Get(productId string) (_ utils.Map, err error) {
 	log.Printf("ProductService::Get::  Begin %v", productId)
 
-	data, err := p.daoProduct.Get(productId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoProduct.Get(ctx, productId)
+	if err == nil {
+		err = attachProductVariants(ctx, p.daoVariant, utils.Map{db_common.LIST_RESULT: []utils.Map{data}})
+	}
 
 	log.Println("ProductService::Get:: End ", err)
 	return data, err
 }
 
-func (p *productBaseService) Find(filter string) (utils.Map, error) {
+func (p *productBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("productBaseService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoProduct.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoProduct.Find(ctx, filter)
 	log.Println("productBaseService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *productBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *productBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("ProductService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var productId string
 
 	dataval, dataok := indata[sales_common.FLD_PRODUCT_ID]
@@ -146,8 +204,14 @@ func (p *productBaseService) Create(indata utils.Map) (utils.Map, error) {
 	//BusinessProduct
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_PRODUCT_ID] = productId
+	delete(indata, sales_common.FLD_PRODUCT_VARIANTS)
 
-	data, err := p.daoProduct.Create(indata)
+	err = p.Validate(indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	data, err := p.daoProduct.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -157,23 +221,43 @@ func (p *productBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *productBaseService) Update(productId string, indata utils.Map) (utils.Map, error) {
+func (p *productBaseService) Update(productId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("BusinessProdcutService::Update - Begin")
 
-	data, err := p.daoProduct.Update(productId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	delete(indata, sales_common.FLD_PRODUCT_VARIANTS)
+	err = p.Validate(indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Existing Variants should still have one of the values of each option axis
+	if _, ok := indata[sales_common.FLD_PRODUCT_OPTIONS]; ok {
+		err = p.validateProductVariants(ctx, productId, indata)
+		if err != nil {
+			return utils.Map{}, err
+		}
+	}
+
+	data, err := p.daoProduct.Update(ctx, productId, indata)
 
 	log.Println("ProductService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *productBaseService) Delete(productId string, delete_permanent bool) error {
+func (p *productBaseService) Delete(productId string, delete_permanent bool) (err error) {
 
 	log.Println("ProductService::Delete - Begin", productId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoProduct.Delete(productId)
+		result, err := p.daoProduct.Delete(ctx, productId)
 		if err != nil {
 			return err
 		}
@@ -191,6 +275,428 @@ func (p *productBaseService) Delete(productId string, delete_permanent bool) err
 	return nil
 }
 
+// ListByCategory - List the Products in the Category, including the ones in its sub Categories if
+// includeDescendants is true
+func (p *productBaseService) ListByCategory(categoryId string, includeDescendants bool, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("ProductService::ListByCategory - Begin ", categoryId, includeDescendants)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "ListByCategory", p.businessId)
+	defer span.EndWith(&err)
+
+	_, err = p.daoCategory.Get(ctx, categoryId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340232", ErrorMsg: "Invalid CategoryId", ErrorDetail: "Given CategoryId " + categoryId + " is not exist"}
+		return nil, err
+	}
+
+	filter, err := categoryProductsFilter(ctx, p.daoCategory, categoryId, includeDescendants)
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := p.List(filter, sort, skip, limit)
+
+	log.Println("ProductService::ListByCategory - End ", err)
+	return listdata, err
+}
+
+// Validate - Validate the Product to be created or updated, nothing will be written
+func (p *productBaseService) Validate(indata utils.Map) error {
+	if _, ok := indata[sales_common.FLD_PRODUCT_OPTIONS]; ok {
+		_, err := productOptions(indata)
+		return err
+	}
+	return nil
+}
+
+// ListVariants - List the Variants of the Product
+func (p *productBaseService) ListVariants(productId string) (_ utils.Map, err error) {
+
+	log.Println("ProductService::ListVariants - Begin ", productId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "ListVariants", p.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRODUCT_ID: productId})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := p.daoVariant.List(ctx, filter, `{"`+sales_common.FLD_VARIANT_SKU+`": 1}`, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("ProductService::ListVariants - End ")
+	return listdata, nil
+}
+
+// GetVariant - Get the Variant of the Product
+func (p *productBaseService) GetVariant(productId string, variantId string) (_ utils.Map, err error) {
+
+	log.Println("ProductService::GetVariant - Begin ", productId, variantId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "GetVariant", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoVariant.Get(ctx, variantId)
+	if err != nil || data[sales_common.FLD_PRODUCT_ID] != productId {
+		err := &utils.AppError{ErrorCode: "S30340222", ErrorMsg: "Invalid VariantId", ErrorDetail: "Given VariantId " + variantId + " is not exist for the Product " + productId}
+		return nil, err
+	}
+
+	log.Println("ProductService::GetVariant - End ")
+	return data, nil
+}
+
+// CreateVariant - Create Variant for one value of each option axis of the Product
+func (p *productBaseService) CreateVariant(productId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("ProductService::CreateVariant - Begin ", productId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "CreateVariant", p.businessId)
+	defer span.EndWith(&err)
+
+	variantId, _ := utils.GetMemberDataStr(indata, sales_common.FLD_VARIANT_ID)
+	if len(variantId) > 0 {
+		variantId = strings.ToLower(variantId)
+	} else {
+		variantId = utils.GenerateUniqueId("var")
+		log.Println("Unique Variant ID", variantId)
+	}
+
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_PRODUCT_ID] = productId
+	indata[sales_common.FLD_VARIANT_ID] = variantId
+
+	err = p.validateVariant(ctx, productId, variantId, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	data, err := p.daoVariant.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, variantWriteError(err)
+	}
+
+	log.Println("ProductService::CreateVariant - End ")
+	return data, nil
+}
+
+// UpdateVariant - Update Variant of the Product
+func (p *productBaseService) UpdateVariant(productId string, variantId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("ProductService::UpdateVariant - Begin ", productId, variantId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "UpdateVariant", p.businessId)
+	defer span.EndWith(&err)
+
+	dataVariant, err := p.GetVariant(productId, variantId)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	// Delete Key values
+	delete(indata, sales_common.FLD_BUSINESS_ID)
+	delete(indata, sales_common.FLD_PRODUCT_ID)
+	delete(indata, sales_common.FLD_VARIANT_ID)
+
+	_, optionsOk := indata[sales_common.FLD_VARIANT_OPTIONS]
+	_, skuOk := indata[sales_common.FLD_VARIANT_SKU]
+	_, barcodeOk := indata[sales_common.FLD_VARIANT_BARCODE]
+	if optionsOk || skuOk || barcodeOk {
+		for key, value := range indata {
+			dataVariant[key] = value
+		}
+		err = p.validateVariant(ctx, productId, variantId, dataVariant)
+		if err != nil {
+			return utils.Map{}, err
+		}
+		if optionsOk {
+			indata[sales_common.FLD_VARIANT_OPTIONS] = dataVariant[sales_common.FLD_VARIANT_OPTIONS]
+		}
+	}
+
+	data, err := p.daoVariant.Update(ctx, variantId, indata)
+	if err != nil {
+		return utils.Map{}, variantWriteError(err)
+	}
+
+	log.Println("ProductService::UpdateVariant - End ")
+	return data, nil
+}
+
+// DeleteVariant - Delete Variant of the Product
+func (p *productBaseService) DeleteVariant(productId string, variantId string, delete_permanent bool) (err error) {
+
+	log.Println("ProductService::DeleteVariant - Begin ", productId, variantId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "product", "DeleteVariant", p.businessId)
+	defer span.EndWith(&err)
+
+	_, err = p.GetVariant(productId, variantId)
+	if err != nil {
+		return err
+	}
+
+	if delete_permanent {
+		result, err := p.daoVariant.Delete(ctx, variantId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.daoVariant.Update(ctx, variantId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("ProductService::DeleteVariant - End")
+	return nil
+}
+
+// validateVariant - Verify the variant_options has one of the values of each option axis of the Product
+// and no other Variant of the Product has the same options, and that the variant_sku is given and the
+// variant_sku and variant_barcode are not used by other Variant
+func (p *productBaseService) validateVariant(ctx context.Context, productId string, variantId string, indata utils.Map) error {
+
+	dataProduct, err := p.daoProduct.Get(ctx, productId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340170", ErrorMsg: "Invalid ProductId", ErrorDetail: "Given ProductId " + productId + " is not exist"}
+		return err
+	}
+	axes, err := productOptions(dataProduct)
+	if err != nil {
+		return err
+	}
+
+	variantOptions := utils.Map{}
+	if _, ok := indata[sales_common.FLD_VARIANT_OPTIONS]; ok {
+		variantOptions, err = sales_common.GetMemberDataMap(indata, sales_common.FLD_VARIANT_OPTIONS)
+		if err != nil {
+			return err
+		}
+	}
+	if detail := checkVariantOptions(axes, variantOptions); len(detail) > 0 {
+		err := &utils.AppError{ErrorCode: "S30340223", ErrorMsg: "Invalid Variant Options", ErrorDetail: detail}
+		return err
+	}
+	indata[sales_common.FLD_VARIANT_OPTIONS] = variantOptions
+
+	sku, _ := utils.GetMemberDataStr(indata, sales_common.FLD_VARIANT_SKU)
+	if len(sku) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340224", ErrorMsg: "Missing SKU", ErrorDetail: sales_common.FLD_VARIANT_SKU + " value should be sent"}
+		return err
+	}
+
+	variants, err := p.listProductVariants(ctx, productId)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if variant[sales_common.FLD_VARIANT_ID] == variantId {
+			continue
+		}
+		options, _ := sales_common.GetMemberDataMap(variant, sales_common.FLD_VARIANT_OPTIONS)
+		if sameVariantOptions(options, variantOptions) {
+			err := &utils.AppError{ErrorCode: "S30340225", ErrorMsg: "Duplicate Variant", ErrorDetail: "Product " + productId + " already has a Variant with the same variant_options"}
+			return err
+		}
+	}
+
+	// Checked here for the error, the unique indexes reject the Variants written in parallel
+	for _, unique := range variantUniqueFields {
+		value, _ := utils.GetMemberDataStr(indata, unique.field)
+		if len(value) == 0 {
+			continue
+		}
+		filter, err := sales_common.BuildFilter(utils.Map{unique.field: value})
+		if err != nil {
+			return err
+		}
+		dataVariant, err := p.daoVariant.Find(ctx, filter)
+		if err == nil && dataVariant[sales_common.FLD_VARIANT_ID] != variantId {
+			err := &utils.AppError{ErrorCode: unique.errorCode, ErrorMsg: unique.errorMsg, ErrorDetail: "Given " + unique.field + " " + value + " is used by other Variant"}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// variantWriteError - Error of the Variant write, a duplicate key of the unique indexes is reported as the
+// duplicate SKU or Barcode
+func variantWriteError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	for _, unique := range variantUniqueFields {
+		if strings.Contains(err.Error(), unique.field) {
+			err := &utils.AppError{ErrorCode: unique.errorCode, ErrorMsg: unique.errorMsg, ErrorDetail: "Given " + unique.field + " is used by other Variant"}
+			return err
+		}
+	}
+	return err
+}
+
+// validateProductVariants - Verify the existing Variants of the Product have one of the values of each of
+// the product_options in indata, so the options can not be changed under the Variants
+func (p *productBaseService) validateProductVariants(ctx context.Context, productId string, indata utils.Map) error {
+	axes, err := productOptions(indata)
+	if err != nil {
+		return err
+	}
+
+	variants, err := p.listProductVariants(ctx, productId)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		options, _ := sales_common.GetMemberDataMap(variant, sales_common.FLD_VARIANT_OPTIONS)
+		if detail := checkVariantOptions(axes, options); len(detail) > 0 {
+			sku, _ := utils.GetMemberDataStr(variant, sales_common.FLD_VARIANT_SKU)
+			err := &utils.AppError{ErrorCode: "S30340260", ErrorMsg: "Variants not in Options", ErrorDetail: "Variant " + sku + " does not fit the product_options, update or delete it first. " + detail}
+			return err
+		}
+	}
+	return nil
+}
+
+// listProductVariants - Variants of the Product
+func (p *productBaseService) listProductVariants(ctx context.Context, productId string) ([]utils.Map, error) {
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRODUCT_ID: productId})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := p.daoVariant.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	variants, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	return variants, nil
+}
+
+// categoryProductsFilter - Filter of the Products in the Category, and in its sub Categories if includeDescendants
+// is true. category_id of the Product could be a single id or an array of ids, $in matches both
+func categoryProductsFilter(ctx context.Context, daoCategory sales_repository.CategoryDao, categoryId string, includeDescendants bool) (string, error) {
+	categoryIds := []string{categoryId}
+	if includeDescendants {
+		filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CATEGORY_ANCESTORS: categoryId})
+		if err != nil {
+			return "", err
+		}
+		listdata, err := daoCategory.List(ctx, filter, "", 0, 0)
+		if err != nil {
+			return "", err
+		}
+		descendants, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+		for _, descendant := range descendants {
+			descendantId, _ := utils.GetMemberDataStr(descendant, sales_common.FLD_CATEGORY_ID)
+			categoryIds = append(categoryIds, descendantId)
+		}
+	}
+
+	return sales_common.BuildFilter(utils.Map{sales_common.FLD_CATEGORY_ID: utils.Map{"$in": categoryIds}})
+}
+
+// attachProductVariants - Set the product_variants in each Product of the list for the Products having options
+func attachProductVariants(ctx context.Context, daoVariant sales_repository.ProductVariantDao, listdata utils.Map) error {
+	products, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	productIds := []string{}
+	for _, product := range products {
+		if _, ok := product[sales_common.FLD_PRODUCT_OPTIONS]; ok {
+			productId, _ := utils.GetMemberDataStr(product, sales_common.FLD_PRODUCT_ID)
+			productIds = append(productIds, productId)
+		}
+	}
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRODUCT_ID: utils.Map{"$in": productIds}})
+	if err != nil {
+		return err
+	}
+	variantdata, err := daoVariant.List(ctx, filter, `{"`+sales_common.FLD_VARIANT_SKU+`": 1}`, 0, 0)
+	if err != nil {
+		return err
+	}
+	variants, _ := sales_common.GetMemberDataMapArray(variantdata, db_common.LIST_RESULT)
+
+	for _, product := range products {
+		if _, ok := product[sales_common.FLD_PRODUCT_OPTIONS]; !ok {
+			continue
+		}
+		productVariants := []utils.Map{}
+		for _, variant := range variants {
+			if variant[sales_common.FLD_PRODUCT_ID] == product[sales_common.FLD_PRODUCT_ID] {
+				productVariants = append(productVariants, variant)
+			}
+		}
+		product[sales_common.FLD_PRODUCT_VARIANTS] = productVariants
+	}
+	return nil
+}
+
+// productOptions - Option axes of the Product as option_name to its option_values, empty if the Product has
+// no options
+func productOptions(dataProduct utils.Map) (map[string][]string, error) {
+	axes := map[string][]string{}
+	if _, ok := dataProduct[sales_common.FLD_PRODUCT_OPTIONS]; !ok {
+		return axes, nil
+	}
+
+	options, err := sales_common.GetMemberDataMapArray(dataProduct, sales_common.FLD_PRODUCT_OPTIONS)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		optionName, _ := utils.GetMemberDataStr(option, sales_common.FLD_OPTION_NAME)
+		optionValues, _ := sales_common.GetMemberDataStrArray(option, sales_common.FLD_OPTION_VALUES)
+		if _, ok := axes[optionName]; ok || len(optionName) == 0 || len(optionValues) == 0 {
+			err := &utils.AppError{ErrorCode: "S30340228", ErrorMsg: "Invalid Product Options", ErrorDetail: "Each of the product_options should have an unique option_name and option_values"}
+			return nil, err
+		}
+		axes[optionName] = optionValues
+	}
+	return axes, nil
+}
+
+// checkVariantOptions - Verify the variant_options has one of the values of each option axis and no other
+// option, returns the reason if not
+func checkVariantOptions(axes map[string][]string, variantOptions utils.Map) string {
+	if len(variantOptions) != len(axes) {
+		return "Variant should have one value for each of the product_options"
+	}
+	for optionName, optionValues := range axes {
+		value, _ := utils.GetMemberDataStr(variantOptions, optionName)
+		valid := false
+		for _, optionValue := range optionValues {
+			valid = valid || optionValue == value
+		}
+		if !valid {
+			return "Value '" + value + "' is not one of the option_values of " + optionName
+		}
+	}
+	return ""
+}
+
+// sameVariantOptions - Whether both the variant_options have the same value for each option
+func sameVariantOptions(options utils.Map, otherOptions utils.Map) bool {
+	if len(options) != len(otherOptions) {
+		return false
+	}
+	for optionName, value := range options {
+		if otherOptions[optionName] != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *productBaseService) errorReturn(err error) (ProductService, error) {
 	// Close the Database Connection
 	p.EndService()
@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type quizBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       QuizService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewQuizService - Construct Quiz
@@ -67,6 +70,7 @@ func NewQuizService(props utils.Map) (QuizService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *quizBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *quizBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *quizBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("quizBaseService::FindAll - Begin")
 
-	listdata, err := p.daoQuiz.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoQuiz.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *quizBaseService) List(filter string, sort string, skip int64, limit int
 }
 
 // Get - Find By Code
-func (p *quizBaseService) Get(quizId string) (utils.Map, error) {
+func (p *quizBaseService) Get(quizId string) (_ utils.Map, err error) {
 	log.Printf("quizBaseService::Get::  Begin %v", quizId)
 
-	data, err := p.daoQuiz.Get(quizId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoQuiz.Get(ctx, quizId)
 
 	log.Println("quizBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *quizBaseService) Find(filter string) (utils.Map, error) {
+func (p *quizBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("QuizService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoQuiz.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoQuiz.Find(ctx, filter)
 	log.Println("QuizService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *quizBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *quizBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("QuizService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var quizId string
 
 	dataval, dataok := indata[sales_common.FLD_QUIZ_ID]
@@ -146,7 +163,7 @@ func (p *quizBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_QUIZ_ID] = quizId
 
-	data, err := p.daoQuiz.Create(indata)
+	data, err := p.daoQuiz.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *quizBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *quizBaseService) Update(quizId string, indata utils.Map) (utils.Map, error) {
+func (p *quizBaseService) Update(quizId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("QuizService::Update - Begin")
 
-	data, err := p.daoQuiz.Update(quizId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoQuiz.Update(ctx, quizId, indata)
 
 	log.Println("QuizService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *quizBaseService) Delete(quizId string, delete_permanent bool) error {
+func (p *quizBaseService) Delete(quizId string, delete_permanent bool) (err error) {
 
 	log.Println("QuizService::Delete - Begin", quizId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "quiz", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoQuiz.Delete(quizId)
+		result, err := p.daoQuiz.Delete(ctx, quizId)
 		if err != nil {
 			return err
 		}
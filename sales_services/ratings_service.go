@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type ratingsBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       RatingsService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewRatingsService - Construct Ratings
@@ -67,6 +70,7 @@ func NewRatingsService(props utils.Map) (RatingsService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *ratingsBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *ratingsBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *ratingsBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("ratingsBaseService::FindAll - Begin")
 
-	listdata, err := p.daoRatings.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoRatings.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *ratingsBaseService) List(filter string, sort string, skip int64, limit
 }
 
 // Get - Find By Code
-func (p *ratingsBaseService) Get(ratingId string) (utils.Map, error) {
+func (p *ratingsBaseService) Get(ratingId string) (_ utils.Map, err error) {
 	log.Printf("ratingsBaseService::Get::  Begin %v", ratingId)
 
-	data, err := p.daoRatings.Get(ratingId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRatings.Get(ctx, ratingId)
 
 	log.Println("ratingsBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *ratingsBaseService) Find(filter string) (utils.Map, error) {
+func (p *ratingsBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("RatingsService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoRatings.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRatings.Find(ctx, filter)
 	log.Println("RatingsService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *ratingsBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *ratingsBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("RatingsService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var ratingId string
 
 	dataval, dataok := indata[sales_common.FLD_RATING_ID]
@@ -146,7 +163,7 @@ func (p *ratingsBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_RATING_ID] = ratingId
 
-	data, err := p.daoRatings.Create(indata)
+	data, err := p.daoRatings.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *ratingsBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *ratingsBaseService) Update(ratingId string, indata utils.Map) (utils.Map, error) {
+func (p *ratingsBaseService) Update(ratingId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("RatingsService::Update - Begin")
 
-	data, err := p.daoRatings.Update(ratingId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRatings.Update(ctx, ratingId, indata)
 
 	log.Println("RatingsService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *ratingsBaseService) Delete(ratingId string, delete_permanent bool) error {
+func (p *ratingsBaseService) Delete(ratingId string, delete_permanent bool) (err error) {
 
 	log.Println("RatingsService::Delete - Begin", ratingId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "ratings", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoRatings.Delete(ratingId)
+		result, err := p.daoRatings.Delete(ctx, ratingId)
 		if err != nil {
 			return err
 		}
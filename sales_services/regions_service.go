@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type regionBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       RegionService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewRegionService - Construct Region
@@ -67,6 +70,7 @@ func NewRegionService(props utils.Map) (RegionService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *regionBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *regionBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *regionBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("regionBaseService::FindAll - Begin")
 
-	listdata, err := p.daoRegion.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoRegion.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *regionBaseService) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Find By Code
-func (p *regionBaseService) Get(regionId string) (utils.Map, error) {
+func (p *regionBaseService) Get(regionId string) (_ utils.Map, err error) {
 	log.Printf("regionBaseService::Get::  Begin %v", regionId)
 
-	data, err := p.daoRegion.Get(regionId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRegion.Get(ctx, regionId)
 
 	log.Println("regionBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *regionBaseService) Find(filter string) (utils.Map, error) {
+func (p *regionBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("RegionService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoRegion.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRegion.Find(ctx, filter)
 	log.Println("RegionService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *regionBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *regionBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("RegionService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var regionId string
 
 	dataval, dataok := indata[sales_common.FLD_REGION_ID]
@@ -146,7 +163,7 @@ func (p *regionBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_REGION_ID] = regionId
 
-	data, err := p.daoRegion.Create(indata)
+	data, err := p.daoRegion.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *regionBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *regionBaseService) Update(regionId string, indata utils.Map) (utils.Map, error) {
+func (p *regionBaseService) Update(regionId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("RegionService::Update - Begin")
 
-	data, err := p.daoRegion.Update(regionId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoRegion.Update(ctx, regionId, indata)
 
 	log.Println("RegionService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *regionBaseService) Delete(regionId string, delete_permanent bool) error {
+func (p *regionBaseService) Delete(regionId string, delete_permanent bool) (err error) {
 
 	log.Println("RegionService::Delete - Begin", regionId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "region", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoRegion.Delete(regionId)
+		result, err := p.daoRegion.Delete(ctx, regionId)
 		if err != nil {
 			return err
 		}
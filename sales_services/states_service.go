@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type statesBaseService struct {
 	daoBusiness platform_repository.BusinessDao
 	child       StatesService
 	businessId  string
+	ctx         context.Context
 }
 
 // NewStatesService - Construct States
@@ -67,6 +70,7 @@ func NewStatesService(props utils.Map) (StatesService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *statesBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *statesBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *statesBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("statesBaseService::FindAll - Begin")
 
-	listdata, err := p.daoStates.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoStates.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *statesBaseService) List(filter string, sort string, skip int64, limit i
 }
 
 // Get - Find By Code
-func (p *statesBaseService) Get(statesId string) (utils.Map, error) {
+func (p *statesBaseService) Get(statesId string) (_ utils.Map, err error) {
 	log.Printf("statesBaseService::Get::  Begin %v", statesId)
 
-	data, err := p.daoStates.Get(statesId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoStates.Get(ctx, statesId)
 
 	log.Println("statesBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *statesBaseService) Find(filter string) (utils.Map, error) {
+func (p *statesBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("StatesService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoStates.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoStates.Find(ctx, filter)
 	log.Println("StatesService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *statesBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *statesBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("StatesService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var statesId string
 
 	dataval, dataok := indata[sales_common.FLD_STATE_ID]
@@ -146,7 +163,7 @@ func (p *statesBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_STATE_ID] = statesId
 
-	data, err := p.daoStates.Create(indata)
+	data, err := p.daoStates.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *statesBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *statesBaseService) Update(statesId string, indata utils.Map) (utils.Map, error) {
+func (p *statesBaseService) Update(statesId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("StatesService::Update - Begin")
 
-	data, err := p.daoStates.Update(statesId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoStates.Update(ctx, statesId, indata)
 
 	log.Println("StatesService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *statesBaseService) Delete(statesId string, delete_permanent bool) error {
+func (p *statesBaseService) Delete(statesId string, delete_permanent bool) (err error) {
 
 	log.Println("StatesService::Delete - Begin", statesId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "states", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoStates.Delete(statesId)
+		result, err := p.daoStates.Delete(ctx, statesId)
 		if err != nil {
 			return err
 		}
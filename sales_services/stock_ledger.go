@@ -0,0 +1,661 @@
+package sales_services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StockLedger - Stock of the Products and Variants held by the Carts and Orders.
+//
+// Each Inventory has the on hand and reserved stock, the available stock is the on hand less the reserved.
+// Carts and Orders reserve the quantity of their Products only if available, with a single atomic update so
+// parallel checkouts can not oversell. Reservations are released when the Cart is removed or the Order failed,
+// or expire after their time, and are committed, taking the quantity from the on hand, when the Order is
+// confirmed. Products without Inventory are not tracked, so are never short of stock.
+//
+// The stock is held at locations, Warehouses and Dealers, each with an Inventory of the Product. Inventory
+// without location is the default stock of the business. The Orders are sourced from the locations by
+// FulfilmentSourcing, the Carts reserve from the location having the most available.
+type StockLedger struct {
+	businessId     string
+	daoInventory   sales_repository.InventoryDao
+	daoReservation sales_repository.StockReservationDao
+	daoProduct     sales_repository.ProductDao
+	daoVariant     sales_repository.ProductVariantDao
+	daoWarehouse   sales_repository.WarehouseDao
+	daoDealer      sales_repository.DealerDao
+	daoPreference  sales_repository.PreferenceDao
+}
+
+// NewStockLedger - Construct StockLedger on the Region database client
+func NewStockLedger(client utils.Map, businessId string) *StockLedger {
+	return &StockLedger{
+		businessId:     businessId,
+		daoInventory:   sales_repository.NewInventoryDao(client, businessId),
+		daoReservation: sales_repository.NewStockReservationDao(client, businessId),
+		daoProduct:     sales_repository.NewProductDao(client, businessId),
+		daoVariant:     sales_repository.NewProductVariantDao(client, businessId),
+		daoWarehouse:   sales_repository.NewWarehouseDao(client, businessId),
+		daoDealer:      sales_repository.NewDealerDao(client, businessId),
+		daoPreference:  sales_repository.NewPreferenceDao(client, businessId),
+	}
+}
+
+// GetStock - Get the stock of the Product, or its Variant, over all the locations, with the Inventory of each
+// location in stock_locations
+func (l *StockLedger) GetStock(ctx context.Context, productId string, variantId string) (_ utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "GetStock", l.businessId)
+	defer span.EndWith(&err)
+
+	inventories, err := l.ListInventories(ctx, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	if len(inventories) == 0 {
+		err := &utils.AppError{ErrorCode: "S30340250", ErrorMsg: "No Inventory", ErrorDetail: "Stock of the Product " + productId + " " + variantId + " is not tracked"}
+		return nil, err
+	}
+
+	onHand, reserved := 0.0, 0.0
+	for _, inventory := range inventories {
+		inventoryOnHand, _ := sales_common.GetMemberDataFloat(inventory, sales_common.FLD_STOCK_ON_HAND)
+		inventoryReserved, _ := sales_common.GetMemberDataFloat(inventory, sales_common.FLD_STOCK_RESERVED)
+		onHand += inventoryOnHand
+		reserved += inventoryReserved
+	}
+
+	data := utils.Map{
+		sales_common.FLD_PRODUCT_ID:      productId,
+		sales_common.FLD_VARIANT_ID:      variantId,
+		sales_common.FLD_STOCK_ON_HAND:   onHand,
+		sales_common.FLD_STOCK_RESERVED:  reserved,
+		sales_common.FLD_STOCK_AVAILABLE: onHand - reserved,
+		sales_common.FLD_STOCK_LOCATIONS: inventories,
+	}
+	return data, nil
+}
+
+// ListInventories - List the Inventory of the Product, or its Variant, at all the locations with the stock_available
+func (l *StockLedger) ListInventories(ctx context.Context, productId string, variantId string) (_ []utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ListInventories", l.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_PRODUCT_ID: productId, sales_common.FLD_VARIANT_ID: variantId})
+	if err != nil {
+		return nil, err
+	}
+	listdata, err := l.daoInventory.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	inventories, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	for _, inventory := range inventories {
+		setStockAvailable(inventory)
+	}
+	return inventories, nil
+}
+
+// AdjustStock - Add the quantity to the on hand stock at the location, negative to remove, creates the Inventory
+// if not exist. The location is a Warehouse or a Dealer, empty for the default stock. The on hand can not go
+// below the reserved stock
+func (l *StockLedger) AdjustStock(ctx context.Context, productId string, variantId string, locationType string, locationId string, quantity float64) (_ utils.Map, err error) {
+
+	log.Println("StockLedger::AdjustStock - Begin ", productId, variantId, locationType, locationId, quantity)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "AdjustStock", l.businessId)
+	defer span.EndWith(&err)
+
+	dataInventory, err := l.findInventory(ctx, productId, variantId, locationType, locationId)
+	if err != nil {
+		err = l.validateProduct(ctx, productId, variantId)
+		if err != nil {
+			return nil, err
+		}
+		err = l.validateLocation(ctx, locationType, locationId)
+		if err != nil {
+			return nil, err
+		}
+		if quantity < 0 {
+			err := &utils.AppError{ErrorCode: "S30340251", ErrorMsg: "Invalid Stock Adjustment", ErrorDetail: "On hand stock can not be negative"}
+			return nil, err
+		}
+
+		indata := utils.Map{
+			sales_common.FLD_BUSINESS_ID:    l.businessId,
+			sales_common.FLD_INVENTORY_ID:   utils.GenerateUniqueId("inv"),
+			sales_common.FLD_PRODUCT_ID:     productId,
+			sales_common.FLD_VARIANT_ID:     variantId,
+			sales_common.FLD_STOCK_ON_HAND:  quantity,
+			sales_common.FLD_STOCK_RESERVED: 0.0,
+		}
+		if len(locationType) > 0 {
+			indata[sales_common.FLD_LOCATION_TYPE] = locationType
+			indata[sales_common.FLD_LOCATION_ID] = locationId
+		}
+		dataInventory, err = l.daoInventory.Create(ctx, indata)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Println("StockLedger::AdjustStock - End Created ", dataInventory[sales_common.FLD_INVENTORY_ID])
+		return setStockAvailable(dataInventory), nil
+	}
+
+	inventoryId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_INVENTORY_ID)
+	dataInventory, err = l.daoInventory.ApplyStock(ctx, inventoryId, quantity, 0)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340251", ErrorMsg: "Invalid Stock Adjustment", ErrorDetail: "On hand stock can not go below the reserved stock"}
+		return nil, err
+	}
+
+	log.Println("StockLedger::AdjustStock - End ", inventoryId)
+	return setStockAvailable(dataInventory), nil
+}
+
+// Reserve - Reserve the quantity of the Product, or its Variant, for the Cart or Order given in ref, e.g.
+// { "customer_id": "..", "cart_id": ".." }, at the location having the most available. Expires after the
+// minutes, never if 0. Returns nil if the stock of the Product is not tracked
+func (l *StockLedger) Reserve(ctx context.Context, productId string, variantId string, quantity float64, ref utils.Map, minutes int) (_ utils.Map, err error) {
+
+	log.Println("StockLedger::Reserve - Begin ", productId, variantId, quantity, ref)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "Reserve", l.businessId)
+	defer span.EndWith(&err)
+
+	if quantity <= 0 {
+		err := &utils.AppError{ErrorCode: "S30340172", ErrorMsg: "Invalid Quantity", ErrorDetail: "Quantity of Product " + productId + " should be greater than 0"}
+		return nil, err
+	}
+	inventories, err := l.ListInventories(ctx, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	if len(inventories) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(inventories, func(i, j int) bool {
+		return inventories[i][sales_common.FLD_STOCK_AVAILABLE].(float64) > inventories[j][sales_common.FLD_STOCK_AVAILABLE].(float64)
+	})
+	for _, dataInventory := range inventories {
+		if dataInventory[sales_common.FLD_STOCK_AVAILABLE].(float64) < quantity {
+			break
+		}
+		data, err := l.ReserveInventory(ctx, dataInventory, quantity, ref, minutes)
+		if err == nil {
+			return data, nil
+		}
+		// Taken meanwhile, try the next location
+	}
+
+	err = &utils.AppError{ErrorCode: "S30340252", ErrorMsg: "Insufficient Stock", ErrorDetail: fmt.Sprintf("Only %v of the Product %s %s is available at a location", inventories[0][sales_common.FLD_STOCK_AVAILABLE], productId, variantId)}
+	return nil, err
+}
+
+// ReserveInventory - Reserve the quantity from the Inventory of a location, for the Cart or Order given in ref.
+// Expires after the minutes, never if 0
+func (l *StockLedger) ReserveInventory(ctx context.Context, dataInventory utils.Map, quantity float64, ref utils.Map, minutes int) (_ utils.Map, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ReserveInventory", l.businessId)
+	defer span.EndWith(&err)
+
+	inventoryId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_INVENTORY_ID)
+	productId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_PRODUCT_ID)
+	variantId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_VARIANT_ID)
+
+	log.Println("StockLedger::ReserveInventory - Begin ", inventoryId, quantity, ref)
+
+	_, err = l.daoInventory.ApplyStock(ctx, inventoryId, 0, quantity)
+	if err != nil {
+		locationType, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_TYPE)
+		locationId, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_ID)
+		err := &utils.AppError{ErrorCode: "S30340252", ErrorMsg: "Insufficient Stock", ErrorDetail: fmt.Sprintf("Product %s %s is not available at the location %s %s", productId, variantId, locationType, locationId)}
+		return nil, err
+	}
+
+	indata := utils.Map{
+		sales_common.FLD_BUSINESS_ID:        l.businessId,
+		sales_common.FLD_RESERVATION_ID:     utils.GenerateUniqueId("rsv"),
+		sales_common.FLD_INVENTORY_ID:       inventoryId,
+		sales_common.FLD_PRODUCT_ID:         productId,
+		sales_common.FLD_VARIANT_ID:         variantId,
+		sales_common.FLD_QUANTITY:           quantity,
+		sales_common.FLD_RESERVATION_STATUS: sales_common.RESERVATION_STATUS_ACTIVE,
+	}
+	if locationType, _ := utils.GetMemberDataStr(dataInventory, sales_common.FLD_LOCATION_TYPE); len(locationType) > 0 {
+		indata[sales_common.FLD_LOCATION_TYPE] = locationType
+		indata[sales_common.FLD_LOCATION_ID] = dataInventory[sales_common.FLD_LOCATION_ID]
+	}
+	for key, value := range ref {
+		indata[key] = value
+	}
+	if minutes > 0 {
+		indata[sales_common.FLD_RESERVATION_EXPIRES_AT] = time.Now().Add(time.Duration(minutes) * time.Minute)
+	}
+	data, err := l.daoReservation.Create(ctx, indata)
+	if err != nil {
+		// Return the stock, the Reservation was not saved
+		_, errUndo := l.daoInventory.ApplyStock(ctx, inventoryId, 0, -quantity)
+		log.Println("StockLedger::ReserveInventory - Failed ", err, errUndo)
+		return nil, err
+	}
+
+	log.Println("StockLedger::ReserveInventory - End ", data[sales_common.FLD_RESERVATION_ID])
+	return data, nil
+}
+
+// Release - Release the stock of the active Reservation, nothing to do if already released, committed or expired
+func (l *StockLedger) Release(ctx context.Context, reservationId string) (err error) {
+
+	log.Println("StockLedger::Release - Begin ", reservationId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "Release", l.businessId)
+	defer span.EndWith(&err)
+
+	dataReservation, err := l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_ACTIVE, sales_common.RESERVATION_STATUS_RELEASED)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Println("StockLedger::Release - End Not active ", reservationId)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = l.applyReservation(ctx, dataReservation, 0, -1)
+	if err != nil {
+		l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_RELEASED, sales_common.RESERVATION_STATUS_ACTIVE)
+	}
+
+	log.Println("StockLedger::Release - End ", err)
+	return err
+}
+
+// Restore - Hold the stock of the released Reservation again, only if the quantity is still available
+func (l *StockLedger) Restore(ctx context.Context, reservationId string) (err error) {
+
+	log.Println("StockLedger::Restore - Begin ", reservationId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "Restore", l.businessId)
+	defer span.EndWith(&err)
+
+	dataReservation, err := l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_RELEASED, sales_common.RESERVATION_STATUS_ACTIVE)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340253", ErrorMsg: "Invalid Reservation", ErrorDetail: "Reservation " + reservationId + " is not exist or not released"}
+		return err
+	}
+
+	err = l.applyReservation(ctx, dataReservation, 0, 1)
+	if err != nil {
+		l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_ACTIVE, sales_common.RESERVATION_STATUS_RELEASED)
+		productId, _ := utils.GetMemberDataStr(dataReservation, sales_common.FLD_PRODUCT_ID)
+		err := &utils.AppError{ErrorCode: "S30340252", ErrorMsg: "Insufficient Stock", ErrorDetail: "Reservation " + reservationId + " released and the Product " + productId + " is not available"}
+		return err
+	}
+
+	log.Println("StockLedger::Restore - End ")
+	return nil
+}
+
+// Restock - Put the quantity of the committed Reservation back on hand, when its Order failed after it was
+// confirmed. Nothing to do if not committed
+func (l *StockLedger) Restock(ctx context.Context, reservationId string) (err error) {
+
+	log.Println("StockLedger::Restock - Begin ", reservationId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "Restock", l.businessId)
+	defer span.EndWith(&err)
+
+	dataReservation, err := l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_COMMITTED, sales_common.RESERVATION_STATUS_RESTOCKED)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Println("StockLedger::Restock - End Not committed ", reservationId)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = l.applyReservation(ctx, dataReservation, 1, 0)
+	if err != nil {
+		l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_RESTOCKED, sales_common.RESERVATION_STATUS_COMMITTED)
+	}
+
+	log.Println("StockLedger::Restock - End ", err)
+	return err
+}
+
+// Commit - Take the quantity of the Reservation from the on hand stock. Expired Reservation is committed only if the
+// quantity is still available
+func (l *StockLedger) Commit(ctx context.Context, reservationId string) (err error) {
+
+	log.Println("StockLedger::Commit - Begin ", reservationId)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "Commit", l.businessId)
+	defer span.EndWith(&err)
+
+	dataReservation, err := l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_ACTIVE, sales_common.RESERVATION_STATUS_COMMITTED)
+	if err == nil {
+		err = l.applyReservation(ctx, dataReservation, -1, -1)
+		if err != nil {
+			// Active again, so the Commit can be retried
+			l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_COMMITTED, sales_common.RESERVATION_STATUS_ACTIVE)
+		}
+		log.Println("StockLedger::Commit - End ", err)
+		return err
+	}
+
+	dataReservation, err = l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_EXPIRED, sales_common.RESERVATION_STATUS_COMMITTED)
+	if err == nil {
+		err = l.applyReservation(ctx, dataReservation, -1, 0)
+		if err != nil {
+			l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_COMMITTED, sales_common.RESERVATION_STATUS_EXPIRED)
+			productId, _ := utils.GetMemberDataStr(dataReservation, sales_common.FLD_PRODUCT_ID)
+			err := &utils.AppError{ErrorCode: "S30340252", ErrorMsg: "Insufficient Stock", ErrorDetail: "Reservation " + reservationId + " expired and the Product " + productId + " is not available"}
+			return err
+		}
+		log.Println("StockLedger::Commit - End Expired ", reservationId)
+		return nil
+	}
+
+	dataReservation, err = l.daoReservation.Get(ctx, reservationId)
+	if err == nil && dataReservation[sales_common.FLD_RESERVATION_STATUS] == sales_common.RESERVATION_STATUS_COMMITTED {
+		log.Println("StockLedger::Commit - End Already committed ", reservationId)
+		return nil
+	}
+
+	err = &utils.AppError{ErrorCode: "S30340253", ErrorMsg: "Invalid Reservation", ErrorDetail: "Reservation " + reservationId + " is not exist or released"}
+	return err
+}
+
+// ReleaseItems - Release the Reservations of the items, or of their allocations, returns the first failure after
+// trying all of them
+func (l *StockLedger) ReleaseItems(ctx context.Context, items []utils.Map) (err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ReleaseItems", l.businessId)
+	defer span.EndWith(&err)
+
+	var errFirst error
+	for _, reservationId := range itemReservationIds(items) {
+		if err := l.Release(ctx, reservationId); err != nil && errFirst == nil {
+			errFirst = err
+		}
+	}
+	return errFirst
+}
+
+// RestockItems - Put the quantity of the committed Reservations of the items, or of their allocations, back on
+// hand, returns the first failure after trying all of them
+func (l *StockLedger) RestockItems(ctx context.Context, items []utils.Map) (err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "RestockItems", l.businessId)
+	defer span.EndWith(&err)
+
+	var errFirst error
+	for _, reservationId := range itemReservationIds(items) {
+		if err := l.Restock(ctx, reservationId); err != nil && errFirst == nil {
+			errFirst = err
+		}
+	}
+	return errFirst
+}
+
+// CommitItems - Commit the Reservations of the items, or of their allocations, returns the first failure after
+// trying all of them
+func (l *StockLedger) CommitItems(ctx context.Context, items []utils.Map) (err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "CommitItems", l.businessId)
+	defer span.EndWith(&err)
+
+	var errFirst error
+	for _, reservationId := range itemReservationIds(items) {
+		if err := l.Commit(ctx, reservationId); err != nil && errFirst == nil {
+			errFirst = err
+		}
+	}
+	return errFirst
+}
+
+// LocationHasStock - Whether any Inventory of the location has stock on hand
+func (l *StockLedger) LocationHasStock(ctx context.Context, locationType string, locationId string) (_ bool, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "LocationHasStock", l.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_LOCATION_TYPE: locationType, sales_common.FLD_LOCATION_ID: locationId,
+		sales_common.FLD_STOCK_ON_HAND: utils.Map{"$gt": 0}})
+	if err != nil {
+		return false, err
+	}
+	listdata, err := l.daoInventory.List(ctx, filter, "", 0, 1)
+	if err != nil {
+		return false, err
+	}
+
+	inventories, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+	return len(inventories) > 0, nil
+}
+
+// ReleaseCartReservations - Release the active Reservations of the Customer's Carts for the Products of the
+// items, so the Order placed from the Carts can reserve the same stock. Returns the Reservations released, also
+// on failure, so they can be restored
+func (l *StockLedger) ReleaseCartReservations(ctx context.Context, customerId string, items []utils.Map) (_ []string, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ReleaseCartReservations", l.businessId)
+	defer span.EndWith(&err)
+
+	released := []string{}
+	if len(customerId) == 0 {
+		return released, nil
+	}
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_CUSTOMER_ID: customerId,
+		sales_common.FLD_RESERVATION_STATUS: sales_common.RESERVATION_STATUS_ACTIVE, sales_common.FLD_CART_ID: utils.Map{"$exists": true}})
+	if err != nil {
+		return released, err
+	}
+	listdata, err := l.daoReservation.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return released, err
+	}
+	reservations, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	for _, reservation := range reservations {
+		for _, item := range items {
+			itemVariantId, _ := utils.GetMemberDataStr(item, sales_common.FLD_VARIANT_ID)
+			if reservation[sales_common.FLD_PRODUCT_ID] == item[sales_common.FLD_PRODUCT_ID] && reservation[sales_common.FLD_VARIANT_ID] == itemVariantId {
+				reservationId, _ := utils.GetMemberDataStr(reservation, sales_common.FLD_RESERVATION_ID)
+				err = l.Release(ctx, reservationId)
+				if err != nil {
+					return released, err
+				}
+				released = append(released, reservationId)
+				break
+			}
+		}
+	}
+	return released, nil
+}
+
+// ReassignCustomer - Move the Reservations of the Customer, e.g. of the Guest carts, to the other Customer
+func (l *StockLedger) ReassignCustomer(ctx context.Context, fromCustomerId string, toCustomerId string) (_ int64, err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ReassignCustomer", l.businessId)
+	defer span.EndWith(&err)
+
+	return l.daoReservation.ReassignCustomer(ctx, fromCustomerId, toCustomerId)
+}
+
+// RestoreReservations - Hold the stock of the released Reservations again, returns the first failure after trying
+// all of them
+func (l *StockLedger) RestoreReservations(ctx context.Context, reservationIds []string) (err error) {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "RestoreReservations", l.businessId)
+	defer span.EndWith(&err)
+
+	var errFirst error
+	for _, reservationId := range reservationIds {
+		if err := l.Restore(ctx, reservationId); err != nil && errFirst == nil {
+			errFirst = err
+		}
+	}
+	return errFirst
+}
+
+// ExpireReservations - Release the stock of the active Reservations expired by the time, returns the number expired
+func (l *StockLedger) ExpireReservations(ctx context.Context, at time.Time) (_ int, err error) {
+
+	log.Println("StockLedger::ExpireReservations - Begin ", at)
+
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ExpireReservations", l.businessId)
+	defer span.EndWith(&err)
+
+	filter, err := sales_common.BuildFilter(utils.Map{sales_common.FLD_RESERVATION_STATUS: sales_common.RESERVATION_STATUS_ACTIVE,
+		sales_common.FLD_RESERVATION_EXPIRES_AT: utils.Map{"$lt": at}})
+	if err != nil {
+		return 0, err
+	}
+	listdata, err := l.daoReservation.List(ctx, filter, "", 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	reservations, _ := sales_common.GetMemberDataMapArray(listdata, db_common.LIST_RESULT)
+
+	expired := 0
+	for _, reservation := range reservations {
+		reservationId, _ := utils.GetMemberDataStr(reservation, sales_common.FLD_RESERVATION_ID)
+		dataReservation, err := l.daoReservation.Transition(ctx, reservationId, sales_common.RESERVATION_STATUS_ACTIVE, sales_common.RESERVATION_STATUS_EXPIRED)
+		if err != nil {
+			// Released or committed meanwhile
+			continue
+		}
+		err = l.applyReservation(ctx, dataReservation, 0, -1)
+		if err != nil {
+			l.undoTransition(ctx, reservationId, sales_common.RESERVATION_STATUS_EXPIRED, sales_common.RESERVATION_STATUS_ACTIVE)
+			return expired, err
+		}
+		expired++
+	}
+
+	log.Println("StockLedger::ExpireReservations - End ", expired)
+	return expired, nil
+}
+
+// ReservationMinutes - Minutes the Reservations are held as per the inventory preference, the default if not set
+func (l *StockLedger) ReservationMinutes(ctx context.Context, field string, defaultMinutes int) int {
+	ctx, span := sales_telemetry.StartService(ctx, "stock_ledger", "ReservationMinutes", l.businessId)
+	defer span.End(nil)
+
+	dataPref, err := l.daoPreference.Get(ctx, sales_common.PREFERENCE_INVENTORY)
+	if err != nil {
+		return defaultMinutes
+	}
+
+	minutes, err := utils.GetMemberDataInt(dataPref, field, true)
+	if err != nil || minutes < 0 {
+		return defaultMinutes
+	}
+	return minutes
+}
+
+// applyReservation - Apply the quantity of the Reservation to the on hand and reserved stock, multiplied by the signs
+func (l *StockLedger) applyReservation(ctx context.Context, dataReservation utils.Map, onHandSign float64, reservedSign float64) error {
+	inventoryId, _ := utils.GetMemberDataStr(dataReservation, sales_common.FLD_INVENTORY_ID)
+	quantity, _ := sales_common.GetMemberDataFloat(dataReservation, sales_common.FLD_QUANTITY)
+
+	_, err := l.daoInventory.ApplyStock(ctx, inventoryId, onHandSign*quantity, reservedSign*quantity)
+	return err
+}
+
+// undoTransition - Move the Reservation back to its status when its stock could not be applied. Failure is only
+// logged
+func (l *StockLedger) undoTransition(ctx context.Context, reservationId string, fromStatus string, toStatus string) {
+	if _, err := l.daoReservation.Transition(ctx, reservationId, fromStatus, toStatus); err != nil {
+		log.Println("StockLedger::undoTransition - Failed ", reservationId, fromStatus, toStatus, err)
+	}
+}
+
+// findInventory - Find the Inventory of the Product, or of its Variant, at the location, the default stock if no
+// location given
+func (l *StockLedger) findInventory(ctx context.Context, productId string, variantId string, locationType string, locationId string) (utils.Map, error) {
+	filterData := utils.Map{sales_common.FLD_PRODUCT_ID: productId, sales_common.FLD_VARIANT_ID: variantId}
+	if len(locationType) > 0 {
+		filterData[sales_common.FLD_LOCATION_TYPE] = locationType
+		filterData[sales_common.FLD_LOCATION_ID] = locationId
+	} else {
+		filterData[sales_common.FLD_LOCATION_TYPE] = utils.Map{"$in": []interface{}{nil, ""}}
+	}
+	filter, err := sales_common.BuildFilter(filterData)
+	if err != nil {
+		return nil, err
+	}
+	dataInventory, err := l.daoInventory.Find(ctx, filter)
+	return dataInventory, err
+}
+
+// validateProduct - Verify the Product exist and the Variant, if given, is of the Product
+func (l *StockLedger) validateProduct(ctx context.Context, productId string, variantId string) error {
+	_, err := l.daoProduct.Get(ctx, productId)
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340170", ErrorMsg: "Invalid ProductId", ErrorDetail: "Given ProductId " + productId + " is not exist"}
+		return err
+	}
+
+	if len(variantId) > 0 {
+		dataVariant, err := l.daoVariant.Get(ctx, variantId)
+		if err != nil || dataVariant[sales_common.FLD_PRODUCT_ID] != productId {
+			err := &utils.AppError{ErrorCode: "S30340222", ErrorMsg: "Invalid VariantId", ErrorDetail: "Given VariantId " + variantId + " is not exist for the Product " + productId}
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLocation - Verify the Warehouse or Dealer of the location exist, no location is the default stock
+func (l *StockLedger) validateLocation(ctx context.Context, locationType string, locationId string) error {
+	var err error
+	switch locationType {
+	case "":
+		if len(locationId) > 0 {
+			err = &utils.AppError{ErrorCode: "S30340254", ErrorMsg: "Invalid Location", ErrorDetail: sales_common.FLD_LOCATION_TYPE + " value should be sent for the location " + locationId}
+		}
+		return err
+	case sales_common.LOCATION_TYPE_WAREHOUSE:
+		_, err = l.daoWarehouse.Get(ctx, locationId)
+	case sales_common.LOCATION_TYPE_DEALER:
+		_, err = l.daoDealer.Get(ctx, locationId)
+	default:
+		err = &utils.AppError{ErrorCode: "S30340254", ErrorMsg: "Invalid Location", ErrorDetail: "Location type should be " + sales_common.LOCATION_TYPE_WAREHOUSE + " or " + sales_common.LOCATION_TYPE_DEALER}
+		return err
+	}
+	if err != nil {
+		err := &utils.AppError{ErrorCode: "S30340254", ErrorMsg: "Invalid Location", ErrorDetail: "Given " + locationType + " " + locationId + " is not exist"}
+		return err
+	}
+	return nil
+}
+
+// itemReservationIds - Reservations of the items, the reservation_id of the item or of each of its allocations
+func itemReservationIds(items []utils.Map) []string {
+	reservationIds := []string{}
+	for _, item := range items {
+		if reservationId, _ := utils.GetMemberDataStr(item, sales_common.FLD_RESERVATION_ID); len(reservationId) > 0 {
+			reservationIds = append(reservationIds, reservationId)
+		}
+		allocations, _ := sales_common.GetMemberDataMapArray(item, sales_common.FLD_ITEM_ALLOCATIONS)
+		for _, allocation := range allocations {
+			if reservationId, _ := utils.GetMemberDataStr(allocation, sales_common.FLD_RESERVATION_ID); len(reservationId) > 0 {
+				reservationIds = append(reservationIds, reservationId)
+			}
+		}
+	}
+	return reservationIds
+}
+
+// setStockAvailable - Set the stock_available of the Inventory
+func setStockAvailable(dataInventory utils.Map) utils.Map {
+	onHand, _ := sales_common.GetMemberDataFloat(dataInventory, sales_common.FLD_STOCK_ON_HAND)
+	reserved, _ := sales_common.GetMemberDataFloat(dataInventory, sales_common.FLD_STOCK_RESERVED)
+	dataInventory[sales_common.FLD_STOCK_AVAILABLE] = onHand - reserved
+	return dataInventory
+}
@@ -0,0 +1,469 @@
+package sales_services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-utils/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// matchFilter - Whether the document matches the filter of equal values, $exists and $lt of time
+func matchFilter(t *testing.T, doc utils.Map, filter string) bool {
+	var filterdoc bson.M
+	if err := bson.UnmarshalExtJSON([]byte(filter), true, &filterdoc); err != nil {
+		t.Fatalf("filter %s: %v", filter, err)
+	}
+	for key, value := range filterdoc {
+		cond, ok := value.(bson.M)
+		if !ok {
+			if doc[key] != value {
+				return false
+			}
+			continue
+		}
+		if exists, ok := cond["$exists"]; ok {
+			if _, found := doc[key]; found != exists {
+				return false
+			}
+		}
+		if lt, ok := cond["$lt"].(primitive.DateTime); ok {
+			at, found := doc[key].(time.Time)
+			if !found || !at.Before(lt.Time()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func copyMap(data utils.Map) utils.Map {
+	result := utils.Map{}
+	for key, value := range data {
+		result[key] = value
+	}
+	return result
+}
+
+// fakeInventoryDao - Inventories in memory, ApplyStock fails while failApply is set
+type fakeInventoryDao struct {
+	sales_repository.InventoryDao
+	t           *testing.T
+	mutex       sync.Mutex
+	inventories map[string]utils.Map
+	failApply   bool
+}
+
+func (f *fakeInventoryDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	result := []utils.Map{}
+	for _, inventory := range f.inventories {
+		if matchFilter(f.t, inventory, filter) {
+			result = append(result, copyMap(inventory))
+		}
+	}
+	return utils.Map{db_common.LIST_RESULT: result}, nil
+}
+
+func (f *fakeInventoryDao) ApplyStock(ctx context.Context, inventoryId string, onHand float64, reserved float64) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	inventory, ok := f.inventories[inventoryId]
+	if !ok || f.failApply {
+		return nil, mongo.ErrNoDocuments
+	}
+	newOnHand := inventory[sales_common.FLD_STOCK_ON_HAND].(float64) + onHand
+	newReserved := inventory[sales_common.FLD_STOCK_RESERVED].(float64) + reserved
+	if newOnHand < 0 || newReserved < 0 || newReserved > newOnHand {
+		return nil, mongo.ErrNoDocuments
+	}
+	inventory[sales_common.FLD_STOCK_ON_HAND] = newOnHand
+	inventory[sales_common.FLD_STOCK_RESERVED] = newReserved
+	return copyMap(inventory), nil
+}
+
+func (f *fakeInventoryDao) stock(inventoryId string) (float64, float64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	inventory := f.inventories[inventoryId]
+	return inventory[sales_common.FLD_STOCK_ON_HAND].(float64), inventory[sales_common.FLD_STOCK_RESERVED].(float64)
+}
+
+// fakeReservationDao - Reservations in memory
+type fakeReservationDao struct {
+	sales_repository.StockReservationDao
+	t            *testing.T
+	mutex        sync.Mutex
+	reservations map[string]utils.Map
+}
+
+func (f *fakeReservationDao) List(ctx context.Context, filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	result := []utils.Map{}
+	for _, reservation := range f.reservations {
+		if matchFilter(f.t, reservation, filter) {
+			result = append(result, copyMap(reservation))
+		}
+	}
+	return utils.Map{db_common.LIST_RESULT: result}, nil
+}
+
+func (f *fakeReservationDao) Get(ctx context.Context, reservationId string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	reservation, ok := f.reservations[reservationId]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return copyMap(reservation), nil
+}
+
+func (f *fakeReservationDao) Create(ctx context.Context, indata utils.Map) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.reservations[indata[sales_common.FLD_RESERVATION_ID].(string)] = copyMap(indata)
+	return indata, nil
+}
+
+func (f *fakeReservationDao) Transition(ctx context.Context, reservationId string, fromStatus string, toStatus string) (utils.Map, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	reservation, ok := f.reservations[reservationId]
+	if !ok || reservation[sales_common.FLD_RESERVATION_STATUS] != fromStatus {
+		return nil, mongo.ErrNoDocuments
+	}
+	reservation[sales_common.FLD_RESERVATION_STATUS] = toStatus
+	return copyMap(reservation), nil
+}
+
+func (f *fakeReservationDao) status(reservationId string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.reservations[reservationId][sales_common.FLD_RESERVATION_STATUS].(string)
+}
+
+func newTestStockLedger(t *testing.T, onHand float64) (*StockLedger, *fakeInventoryDao, *fakeReservationDao) {
+	daoInventory := &fakeInventoryDao{t: t, inventories: map[string]utils.Map{
+		"inv1": {
+			sales_common.FLD_INVENTORY_ID:   "inv1",
+			sales_common.FLD_PRODUCT_ID:     "prod1",
+			sales_common.FLD_VARIANT_ID:     "",
+			sales_common.FLD_STOCK_ON_HAND:  onHand,
+			sales_common.FLD_STOCK_RESERVED: 0.0,
+			sales_common.FLD_LOCATION_TYPE:  sales_common.LOCATION_TYPE_WAREHOUSE,
+			sales_common.FLD_LOCATION_ID:    "wh1",
+		},
+	}}
+	daoReservation := &fakeReservationDao{t: t, reservations: map[string]utils.Map{}}
+	l := &StockLedger{
+		businessId:     "biz1",
+		daoInventory:   daoInventory,
+		daoReservation: daoReservation,
+		daoPreference:  &fakePreferenceDao{},
+	}
+	return l, daoInventory, daoReservation
+}
+
+func TestStockLedgerParallelReserve(t *testing.T) {
+	l, daoInventory, _ := newTestStockLedger(t, 10)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	reserved, short := 0, 0
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ref := utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}
+			_, err := l.Reserve(ctx, "prod1", "", 1, ref, 0)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				reserved++
+			} else if errorCode(err) == "S30340252" {
+				short++
+			} else {
+				t.Errorf("Reserve() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 || short != 30 {
+		t.Errorf("Reserve() reserved %d and short %d, want 10 and 30", reserved, short)
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 10 || stockReserved != 10 {
+		t.Errorf("stock on hand %v reserved %v, want 10 and 10", onHand, stockReserved)
+	}
+}
+
+func TestStockLedgerParallelReleaseAndExpire(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 10)
+	ctx := context.Background()
+
+	reservationIds := []string{}
+	for i := 0; i < 5; i++ {
+		data, err := l.Reserve(ctx, "prod1", "", 2, utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}, 1)
+		if err != nil {
+			t.Fatalf("Reserve() error = %v", err)
+		}
+		reservationIds = append(reservationIds, data[sales_common.FLD_RESERVATION_ID].(string))
+	}
+
+	// Each Reservation is released, expired and committed at the same time, only one of them applies
+	var wg sync.WaitGroup
+	at := time.Now().Add(2 * time.Minute)
+	for _, reservationId := range reservationIds {
+		wg.Add(3)
+		go func(reservationId string) {
+			defer wg.Done()
+			l.Release(ctx, reservationId)
+		}(reservationId)
+		go func(reservationId string) {
+			defer wg.Done()
+			l.Commit(ctx, reservationId)
+		}(reservationId)
+		go func() {
+			defer wg.Done()
+			l.ExpireReservations(ctx, at)
+		}()
+	}
+	wg.Wait()
+
+	committed := 0.0
+	for _, reservationId := range reservationIds {
+		if daoReservation.status(reservationId) == sales_common.RESERVATION_STATUS_COMMITTED {
+			committed += 2
+		}
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 10-committed || stockReserved != 0 {
+		t.Errorf("stock on hand %v reserved %v, want %v and 0", onHand, stockReserved, 10-committed)
+	}
+}
+
+func TestStockLedgerExpireReservations(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 10)
+	ctx := context.Background()
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}
+
+	expiring, err := l.Reserve(ctx, "prod1", "", 3, ref, 10)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	lasting, err := l.Reserve(ctx, "prod1", "", 2, ref, 60)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	unlimited, err := l.Reserve(ctx, "prod1", "", 1, ref, 0)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	expiringId := expiring[sales_common.FLD_RESERVATION_ID].(string)
+
+	at := time.Now().Add(30 * time.Minute)
+	expired, err := l.ExpireReservations(ctx, at)
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireReservations() = %d, %v, want 1", expired, err)
+	}
+	if status := daoReservation.status(expiringId); status != sales_common.RESERVATION_STATUS_EXPIRED {
+		t.Errorf("expired Reservation status = %s", status)
+	}
+	for _, data := range []utils.Map{lasting, unlimited} {
+		if status := daoReservation.status(data[sales_common.FLD_RESERVATION_ID].(string)); status != sales_common.RESERVATION_STATUS_ACTIVE {
+			t.Errorf("Reservation not expired status = %s", status)
+		}
+	}
+	if _, stockReserved := daoInventory.stock("inv1"); stockReserved != 3 {
+		t.Errorf("stock reserved %v after expiry, want 3", stockReserved)
+	}
+
+	// Expired again is nothing to do
+	expired, err = l.ExpireReservations(ctx, at)
+	if err != nil || expired != 0 {
+		t.Errorf("ExpireReservations() again = %d, %v, want 0", expired, err)
+	}
+
+	// The expired Reservation is committed while the quantity is still available
+	err = l.Commit(ctx, expiringId)
+	if err != nil {
+		t.Fatalf("Commit() of expired error = %v", err)
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 7 || stockReserved != 3 {
+		t.Errorf("stock on hand %v reserved %v after commit, want 7 and 3", onHand, stockReserved)
+	}
+}
+
+func TestStockLedgerCommitExpired(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 5)
+	ctx := context.Background()
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}
+
+	data, err := l.Reserve(ctx, "prod1", "", 3, ref, 10)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	reservationId := data[sales_common.FLD_RESERVATION_ID].(string)
+	if _, err := l.ExpireReservations(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The expired quantity is taken by another cart, it can not be committed
+	if _, err := l.Reserve(ctx, "prod1", "", 4, ref, 0); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := l.Commit(ctx, reservationId); errorCode(err) != "S30340252" {
+		t.Errorf("Commit() of expired without stock error = %v, want S30340252", err)
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_EXPIRED {
+		t.Errorf("status after failed Commit = %s, want expired", status)
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 5 || stockReserved != 4 {
+		t.Errorf("stock on hand %v reserved %v, want 5 and 4", onHand, stockReserved)
+	}
+}
+
+func TestStockLedgerRollback(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 10)
+	ctx := context.Background()
+
+	data, err := l.Reserve(ctx, "prod1", "", 4, utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}, 0)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	reservationId := data[sales_common.FLD_RESERVATION_ID].(string)
+
+	// The stock can not be applied, the Reservation stays active to retry
+	daoInventory.failApply = true
+	if err := l.Commit(ctx, reservationId); err == nil {
+		t.Error("Commit() with stock failure, want error")
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_ACTIVE {
+		t.Errorf("status after failed Commit = %s, want active", status)
+	}
+	if err := l.Release(ctx, reservationId); err == nil {
+		t.Error("Release() with stock failure, want error")
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_ACTIVE {
+		t.Errorf("status after failed Release = %s, want active", status)
+	}
+
+	daoInventory.failApply = false
+	if err := l.Commit(ctx, reservationId); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 6 || stockReserved != 0 {
+		t.Errorf("stock on hand %v reserved %v, want 6 and 0", onHand, stockReserved)
+	}
+}
+
+func TestStockLedgerRestore(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 5)
+	ctx := context.Background()
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}
+
+	data, err := l.Reserve(ctx, "prod1", "", 3, ref, 0)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	reservationId := data[sales_common.FLD_RESERVATION_ID].(string)
+
+	if err := l.Release(ctx, reservationId); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := l.Restore(ctx, reservationId); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, stockReserved := daoInventory.stock("inv1"); stockReserved != 3 {
+		t.Errorf("stock reserved %v after restore, want 3", stockReserved)
+	}
+
+	// Taken by another cart meanwhile, the Reservation stays released
+	l.Release(ctx, reservationId)
+	if _, err := l.Reserve(ctx, "prod1", "", 4, ref, 0); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := l.Restore(ctx, reservationId); errorCode(err) != "S30340252" {
+		t.Errorf("Restore() without stock error = %v, want S30340252", err)
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_RELEASED {
+		t.Errorf("status after failed Restore = %s, want released", status)
+	}
+	if _, stockReserved := daoInventory.stock("inv1"); stockReserved != 4 {
+		t.Errorf("stock reserved %v, want 4", stockReserved)
+	}
+}
+
+func TestStockLedgerRestock(t *testing.T) {
+	l, daoInventory, daoReservation := newTestStockLedger(t, 10)
+	ctx := context.Background()
+	ref := utils.Map{sales_common.FLD_CUSTOMER_ID: "cust1"}
+
+	data, err := l.Reserve(ctx, "prod1", "", 4, ref, 0)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	reservationId := data[sales_common.FLD_RESERVATION_ID].(string)
+
+	// Active Reservation is not restocked
+	if err := l.Restock(ctx, reservationId); err != nil {
+		t.Fatalf("Restock() of active error = %v", err)
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_ACTIVE {
+		t.Errorf("status after Restock of active = %s, want active", status)
+	}
+
+	if err := l.Commit(ctx, reservationId); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	// Committed Reservation is not released, the failed Order restocks it
+	if err := l.Release(ctx, reservationId); err != nil {
+		t.Fatalf("Release() of committed error = %v", err)
+	}
+	if err := l.Restock(ctx, reservationId); err != nil {
+		t.Fatalf("Restock() error = %v", err)
+	}
+	if onHand, stockReserved := daoInventory.stock("inv1"); onHand != 10 || stockReserved != 0 {
+		t.Errorf("stock on hand %v reserved %v after restock, want 10 and 0", onHand, stockReserved)
+	}
+
+	// Only once
+	if err := l.Restock(ctx, reservationId); err != nil {
+		t.Fatalf("Restock() again error = %v", err)
+	}
+	if onHand, _ := daoInventory.stock("inv1"); onHand != 10 {
+		t.Errorf("stock on hand %v after restock again, want 10", onHand)
+	}
+	if status := daoReservation.status(reservationId); status != sales_common.RESERVATION_STATUS_RESTOCKED {
+		t.Errorf("status after Restock = %s, want restocked", status)
+	}
+}
+
+// failTransitionDao - Reservations whose status can not be changed
+type failTransitionDao struct {
+	*fakeReservationDao
+}
+
+func (f *failTransitionDao) Transition(ctx context.Context, reservationId string, fromStatus string, toStatus string) (utils.Map, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestStockLedgerReleaseFailure(t *testing.T) {
+	l, _, daoReservation := newTestStockLedger(t, 10)
+	l.daoReservation = &failTransitionDao{daoReservation}
+
+	if err := l.Release(context.Background(), "res1"); err == nil {
+		t.Error("Release() with database failure, want error")
+	}
+}
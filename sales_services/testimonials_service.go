@@ -1,6 +1,7 @@
 package sales_services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/zapscloud/golib-platform/platform_services"
 	"github.com/zapscloud/golib-sales/sales_common"
 	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
 	"github.com/zapscloud/golib-utils/utils"
 )
 
@@ -38,6 +40,7 @@ type testimonialBaseService struct {
 	daoBusiness    platform_repository.BusinessDao
 	child          TestimonialService
 	businessId     string
+	ctx            context.Context
 }
 
 // NewTestimonialService - Construct Testimonail
@@ -67,6 +70,7 @@ func NewTestimonialService(props utils.Map) (TestimonialService, error) {
 
 	// Assign the BusinessId
 	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
 	p.initializeService()
 
 	_, err = p.daoBusiness.Get(businessId)
@@ -97,11 +101,14 @@ func (p *testimonialBaseService) initializeService() {
 }
 
 // List - List All records
-func (p *testimonialBaseService) List(filter string, sort string, skip int64, limit int64) (utils.Map, error) {
+func (p *testimonialBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
 
 	log.Println("testimonialBaseService::FindAll - Begin")
 
-	listdata, err := p.daoTestimonial.List(filter, sort, skip, limit)
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoTestimonial.List(ctx, filter, sort, skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +118,37 @@ func (p *testimonialBaseService) List(filter string, sort string, skip int64, li
 }
 
 // Get - Find By Code
-func (p *testimonialBaseService) Get(testimonialId string) (utils.Map, error) {
+func (p *testimonialBaseService) Get(testimonialId string) (_ utils.Map, err error) {
 	log.Printf("testimonialBaseService::Get::  Begin %v", testimonialId)
 
-	data, err := p.daoTestimonial.Get(testimonialId)
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoTestimonial.Get(ctx, testimonialId)
 
 	log.Println("testimonialBaseService::Get:: End ", err)
 	return data, err
 }
 
-func (p *testimonialBaseService) Find(filter string) (utils.Map, error) {
+func (p *testimonialBaseService) Find(filter string) (_ utils.Map, err error) {
 	fmt.Println("TestimonialService::FindByCode::  Begin ", filter)
 
-	data, err := p.daoTestimonial.Find(filter)
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoTestimonial.Find(ctx, filter)
 	log.Println("TestimonialService::FindByCode:: End ", err)
 	return data, err
 }
 
 // Create - Create Service
-func (p *testimonialBaseService) Create(indata utils.Map) (utils.Map, error) {
+func (p *testimonialBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("TestimonialService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "Create", p.businessId)
+	defer span.EndWith(&err)
+
 	var testimonialId string
 
 	dataval, dataok := indata[sales_common.FLD_TESTIMONIAL_ID]
@@ -146,7 +163,7 @@ func (p *testimonialBaseService) Create(indata utils.Map) (utils.Map, error) {
 	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
 	indata[sales_common.FLD_TESTIMONIAL_ID] = testimonialId
 
-	data, err := p.daoTestimonial.Create(indata)
+	data, err := p.daoTestimonial.Create(ctx, indata)
 	if err != nil {
 		return utils.Map{}, err
 	}
@@ -156,23 +173,29 @@ func (p *testimonialBaseService) Create(indata utils.Map) (utils.Map, error) {
 }
 
 // Update - Update Service
-func (p *testimonialBaseService) Update(testimonialId string, indata utils.Map) (utils.Map, error) {
+func (p *testimonialBaseService) Update(testimonialId string, indata utils.Map) (_ utils.Map, err error) {
 
 	log.Println("TestimonialService::Update - Begin")
 
-	data, err := p.daoTestimonial.Update(testimonialId, indata)
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoTestimonial.Update(ctx, testimonialId, indata)
 
 	log.Println("TestimonialService::Update - End ")
 	return data, err
 }
 
 // Delete - Delete Service
-func (p *testimonialBaseService) Delete(testimonialId string, delete_permanent bool) error {
+func (p *testimonialBaseService) Delete(testimonialId string, delete_permanent bool) (err error) {
 
 	log.Println("TestimonialService::Delete - Begin", testimonialId)
 
+	ctx, span := sales_telemetry.StartService(p.ctx, "testimonial", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
 	if delete_permanent {
-		result, err := p.daoTestimonial.Delete(testimonialId)
+		result, err := p.daoTestimonial.Delete(ctx, testimonialId)
 		if err != nil {
 			return err
 		}
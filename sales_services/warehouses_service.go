@@ -0,0 +1,241 @@
+package sales_services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/zapscloud/golib-dbutils/db_common"
+	"github.com/zapscloud/golib-dbutils/db_utils"
+	"github.com/zapscloud/golib-platform/platform_repository"
+	"github.com/zapscloud/golib-platform/platform_services"
+	"github.com/zapscloud/golib-sales/sales_common"
+	"github.com/zapscloud/golib-sales/sales_repository"
+	"github.com/zapscloud/golib-sales/sales_telemetry"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// WarehouseService - Warehouses of the business, the stock locations besides the Dealers
+type WarehouseService interface {
+	// List - List All records
+	List(filter string, sort string, skip int64, limit int64) (utils.Map, error)
+	// Get - Find By Code
+	Get(warehouseId string) (utils.Map, error)
+	// Find - Find the item
+	Find(filter string) (utils.Map, error)
+	// Create - Create Service
+	Create(indata utils.Map) (utils.Map, error)
+	// Update - Update Service
+	Update(warehouseId string, indata utils.Map) (utils.Map, error)
+	// Delete - Delete Service
+	Delete(warehouseId string, delete_permanent bool) error
+
+	EndService()
+}
+
+type warehouseBaseService struct {
+	db_utils.DatabaseService
+	dbRegion     db_utils.DatabaseService
+	daoWarehouse sales_repository.WarehouseDao
+	daoRegion    sales_repository.RegionDao
+	ledger       *StockLedger
+	daoBusiness  platform_repository.BusinessDao
+	child        WarehouseService
+	businessId   string
+	ctx          context.Context
+}
+
+// NewWarehouseService - Construct Warehouse
+func NewWarehouseService(props utils.Map) (WarehouseService, error) {
+	funcode := sales_common.GetServiceModuleCode() + "M" + "01"
+
+	log.Printf("WarehouseService::Start ")
+	// Verify whether the business id data passed
+	businessId, err := utils.GetMemberDataStr(props, sales_common.FLD_BUSINESS_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := warehouseBaseService{}
+	// Open Database Service
+	err = p.OpenDatabaseService(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open RegionDB Service
+	p.dbRegion, err = platform_services.OpenRegionDatabaseService(props)
+	if err != nil {
+		p.CloseDatabaseService()
+		return nil, err
+	}
+
+	// Assign the BusinessId
+	p.businessId = businessId
+	p.ctx = sales_telemetry.ContextOf(props)
+	p.initializeService()
+
+	_, err = p.daoBusiness.Get(businessId)
+	if err != nil {
+		err := &utils.AppError{
+			ErrorCode:   funcode + "01",
+			ErrorMsg:    "Invalid BusinessId",
+			ErrorDetail: "Given BusinessId is not exist"}
+		return p.errorReturn(err)
+	}
+
+	p.child = &p
+
+	return &p, err
+}
+
+// warehouseBaseService - Close all the services
+func (p *warehouseBaseService) EndService() {
+	log.Printf("EndService ")
+	p.CloseDatabaseService()
+	p.dbRegion.CloseDatabaseService()
+}
+
+func (p *warehouseBaseService) initializeService() {
+	log.Printf("WarehouseService:: GetBusinessDao ")
+	p.daoBusiness = platform_repository.NewBusinessDao(p.GetClient())
+	p.daoWarehouse = sales_repository.NewWarehouseDao(p.dbRegion.GetClient(), p.businessId)
+	p.daoRegion = sales_repository.NewRegionDao(p.dbRegion.GetClient(), p.businessId)
+	p.ledger = NewStockLedger(p.dbRegion.GetClient(), p.businessId)
+}
+
+// List - List All records
+func (p *warehouseBaseService) List(filter string, sort string, skip int64, limit int64) (_ utils.Map, err error) {
+
+	log.Println("warehouseBaseService::FindAll - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "List", p.businessId)
+	defer span.EndWith(&err)
+
+	listdata, err := p.daoWarehouse.List(ctx, filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("warehouseBaseService::FindAll - End ")
+	return listdata, nil
+}
+
+// Get - Find By Code
+func (p *warehouseBaseService) Get(warehouseId string) (_ utils.Map, err error) {
+	log.Printf("warehouseBaseService::Get::  Begin %v", warehouseId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "Get", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoWarehouse.Get(ctx, warehouseId)
+
+	log.Println("warehouseBaseService::Get:: End ", err)
+	return data, err
+}
+
+func (p *warehouseBaseService) Find(filter string) (_ utils.Map, err error) {
+	fmt.Println("warehouseService::FindByCode::  Begin ", filter)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "Find", p.businessId)
+	defer span.EndWith(&err)
+
+	data, err := p.daoWarehouse.Find(ctx, filter)
+	log.Println("warehouseService::FindByCode:: End ", err)
+	return data, err
+}
+
+// Create - Create Service
+func (p *warehouseBaseService) Create(indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("WarehouseService::Create - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "Create", p.businessId)
+	defer span.EndWith(&err)
+
+	var warehouseId string
+
+	dataval, dataok := indata[sales_common.FLD_WAREHOUSE_ID]
+	if dataok {
+		warehouseId = strings.ToLower(dataval.(string))
+	} else {
+		warehouseId = utils.GenerateUniqueId("whs")
+		log.Println("Unique Warehouse ID", warehouseId)
+	}
+
+	// Assign BusinessId
+	indata[sales_common.FLD_BUSINESS_ID] = p.businessId
+	indata[sales_common.FLD_WAREHOUSE_ID] = warehouseId
+
+	// Region of the location for sourcing the Orders
+	setLocationRegion(ctx, p.daoRegion, p.businessId, indata)
+
+	data, err := p.daoWarehouse.Create(ctx, indata)
+	if err != nil {
+		return utils.Map{}, err
+	}
+
+	log.Println("WarehouseService::Create - End ")
+	return data, nil
+}
+
+// Update - Update Service
+func (p *warehouseBaseService) Update(warehouseId string, indata utils.Map) (_ utils.Map, err error) {
+
+	log.Println("WarehouseService::Update - Begin")
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "Update", p.businessId)
+	defer span.EndWith(&err)
+
+	setLocationRegion(ctx, p.daoRegion, p.businessId, indata)
+
+	data, err := p.daoWarehouse.Update(ctx, warehouseId, indata)
+
+	log.Println("WarehouseService::Update - End ")
+	return data, err
+}
+
+// Delete - Delete Service
+func (p *warehouseBaseService) Delete(warehouseId string, delete_permanent bool) (err error) {
+
+	log.Println("WarehouseService::Delete - Begin", warehouseId)
+
+	ctx, span := sales_telemetry.StartService(p.ctx, "warehouse", "Delete", p.businessId)
+	defer span.EndWith(&err)
+
+	// Stock at the location should be moved before
+	hasStock, err := p.ledger.LocationHasStock(ctx, sales_common.LOCATION_TYPE_WAREHOUSE, warehouseId)
+	if err != nil {
+		return err
+	}
+	if hasStock {
+		err := &utils.AppError{ErrorCode: "S30340256", ErrorMsg: "Location has Stock", ErrorDetail: "Warehouse " + warehouseId + " has stock on hand, adjust it to other locations before delete"}
+		return err
+	}
+
+	if delete_permanent {
+		result, err := p.daoWarehouse.Delete(ctx, warehouseId)
+		if err != nil {
+			return err
+		}
+		log.Printf("Delete %v", result)
+	} else {
+		indata := utils.Map{db_common.FLD_IS_DELETED: true}
+		data, err := p.Update(warehouseId, indata)
+		if err != nil {
+			return err
+		}
+		log.Println("Update for Delete Flag", data)
+	}
+
+	log.Printf("WarehouseService::Delete - End")
+	return nil
+}
+
+func (p *warehouseBaseService) errorReturn(err error) (WarehouseService, error) {
+	// Close the Database Connection
+	p.EndService()
+	return nil, err
+}
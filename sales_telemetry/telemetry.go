@@ -0,0 +1,190 @@
+package sales_telemetry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+// Instrumentation scope and attribute names
+const (
+	InstrumentationName = "github.com/zapscloud/golib-sales"
+
+	ATTR_LAYER       = "sales.layer"
+	ATTR_ENTITY      = "sales.entity"
+	ATTR_OPERATION   = "sales.operation"
+	ATTR_BUSINESS_ID = "sales.business_id"
+
+	LAYER_SERVICE = "service"
+	LAYER_DB      = "db"
+
+	METRIC_OPERATIONS = "sales.operations"
+	METRIC_ERRORS     = "sales.errors"
+	METRIC_DURATION   = "sales.duration"
+
+	// Key of the context.Context of the request in the props of the services, their spans are parented to
+	// the span in it
+	PROP_CONTEXT = "trace_context"
+)
+
+type instruments struct {
+	operations metric.Int64Counter
+	errors     metric.Int64Counter
+	duration   metric.Float64Histogram
+}
+
+var (
+	mutex          sync.RWMutex
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	cachedMetrics  *instruments
+)
+
+// SetTracerProvider - Use the given TracerProvider instead of the global one,
+// pass nil to fall back to otel.GetTracerProvider()
+func SetTracerProvider(tp trace.TracerProvider) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	tracerProvider = tp
+}
+
+// SetMeterProvider - Use the given MeterProvider instead of the global one,
+// pass nil to fall back to otel.GetMeterProvider()
+func SetMeterProvider(mp metric.MeterProvider) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	meterProvider = mp
+	cachedMetrics = nil
+}
+
+// Span - An in-flight operation which records a trace span and metrics on End
+type Span struct {
+	span      trace.Span
+	attrs     []attribute.KeyValue
+	startTime time.Time
+}
+
+// ContextOf - Get the context of the request from the props of the service, background if not given
+func ContextOf(props utils.Map) context.Context {
+	if ctx, ok := props[PROP_CONTEXT].(context.Context); ok && ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// StartService - Start a span for the operation of a service, a child of the span in ctx. The returned context
+// has the new span, pass it to the DAO calls of the operation
+func StartService(ctx context.Context, entity string, operation string, businessId string) (context.Context, *Span) {
+	return start(ctx, LAYER_SERVICE, entity, operation, businessId, trace.SpanKindInternal)
+}
+
+// StartDB - Start a span for a database call on the given collection, a child of the span in ctx
+func StartDB(ctx context.Context, collection string, operation string, businessId string) (context.Context, *Span) {
+	return start(ctx, LAYER_DB, collection, operation, businessId, trace.SpanKindClient)
+}
+
+// End - Finish the span and record the duration, marking it failed when err is not nil
+func (s *Span) End(err error) {
+	elapsed := float64(time.Since(s.startTime)) / float64(time.Millisecond)
+
+	// Record not found is a valid result, not a failure
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = nil
+	}
+
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+
+	ctx := context.Background()
+	opts := metric.WithAttributes(s.attrs...)
+	m := getInstruments()
+	m.operations.Add(ctx, 1, opts)
+	m.duration.Record(ctx, elapsed, opts)
+	if err != nil {
+		m.errors.Add(ctx, 1, opts)
+	}
+}
+
+// EndWith - End the span with the error at err, for deferring on the named error result of the operation
+func (s *Span) EndWith(err *error) {
+	s.End(*err)
+}
+
+func start(ctx context.Context, layer string, entity string, operation string, businessId string, kind trace.SpanKind) (context.Context, *Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String(ATTR_LAYER, layer),
+		attribute.String(ATTR_ENTITY, entity),
+		attribute.String(ATTR_OPERATION, operation),
+		attribute.String(ATTR_BUSINESS_ID, businessId),
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := getTracer().Start(ctx, layer+" "+entity+"."+operation,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attrs...))
+
+	return ctx, &Span{span: span, attrs: attrs, startTime: time.Now()}
+}
+
+func getTracer() trace.Tracer {
+	mutex.RLock()
+	tp := tracerProvider
+	mutex.RUnlock()
+
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
+
+func getInstruments() *instruments {
+	mutex.RLock()
+	m := cachedMetrics
+	mutex.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if cachedMetrics != nil {
+		return cachedMetrics
+	}
+
+	mp := meterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(InstrumentationName)
+
+	// Instrument creation only fails on invalid names, the returned
+	// instrument is still usable (no-op) in that case
+	m = &instruments{}
+	m.operations, _ = meter.Int64Counter(METRIC_OPERATIONS,
+		metric.WithDescription("Number of sales operations"))
+	m.errors, _ = meter.Int64Counter(METRIC_ERRORS,
+		metric.WithDescription("Number of failed sales operations"))
+	m.duration, _ = meter.Float64Histogram(METRIC_DURATION,
+		metric.WithDescription("Duration of sales operations"),
+		metric.WithUnit("ms"))
+
+	// Instruments from the global provider delegate to whatever provider
+	// the application installs later, so they are safe to cache as well
+	cachedMetrics = m
+	return m
+}
@@ -0,0 +1,109 @@
+package sales_telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/zapscloud/golib-utils/utils"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { SetTracerProvider(nil) })
+	return recorder
+}
+
+func TestSpansAreParented(t *testing.T) {
+	recorder := newRecorder(t)
+
+	reqCtx, reqSpan := getTracer().Start(context.Background(), "request")
+	props := utils.Map{PROP_CONTEXT: reqCtx}
+
+	ctx, svcSpan := StartService(ContextOf(props), "customer", "ChangePassword", "biz1")
+	_, dbSpan := StartDB(ctx, "sales_customers", "Update", "biz1")
+	dbSpan.End(nil)
+	svcSpan.End(nil)
+	reqSpan.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("ended spans = %d, want 3", len(spans))
+	}
+	db, svc, req := spans[0], spans[1], spans[2]
+
+	tests := []struct {
+		name   string
+		span   sdktrace.ReadOnlySpan
+		want   string
+		parent sdktrace.ReadOnlySpan
+	}{
+		{"service", svc, "service customer.ChangePassword", req},
+		{"db", db, "db sales_customers.Update", svc},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.span.Name() != tt.want {
+				t.Errorf("name = %q, want %q", tt.span.Name(), tt.want)
+			}
+			if tt.span.Parent().SpanID() != tt.parent.SpanContext().SpanID() {
+				t.Errorf("parent of %q = %v, want %q", tt.span.Name(), tt.span.Parent().SpanID(), tt.parent.Name())
+			}
+			if tt.span.SpanContext().TraceID() != req.SpanContext().TraceID() {
+				t.Errorf("%q is not in the trace of the request", tt.span.Name())
+			}
+		})
+	}
+}
+
+func TestContextOfWithoutContext(t *testing.T) {
+	recorder := newRecorder(t)
+
+	_, span := StartService(ContextOf(utils.Map{}), "region", "List", "biz1")
+	span.End(nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	if spans[0].Parent().IsValid() {
+		t.Errorf("span without request context has parent %v", spans[0].Parent().SpanID())
+	}
+}
+
+func TestEndWithStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"ok", nil, codes.Unset},
+		{"failed", errors.New("failed"), codes.Error},
+		{"not found", mongo.ErrNoDocuments, codes.Unset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t)
+
+			func() (err error) {
+				_, span := StartService(context.Background(), "region", "Get", "biz1")
+				defer span.EndWith(&err)
+				return tt.err
+			}()
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("ended spans = %d, want 1", len(spans))
+			}
+			if got := spans[0].Status().Code; got != tt.want {
+				t.Errorf("status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}